@@ -1,12 +1,15 @@
 package auth
 
 import (
+	"auth/internal/errcodes"
 	"auth/internal/jwt"
 	"auth/internal/user"
 	"auth/proto/gen"
 	"context"
+	"fmt"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -32,6 +35,10 @@ func NewAuthService(jwtUtil jwt.JWTUtil, userRepo user.UserRepository, log *logr
 }
 
 func (s *AuthServiceImpl) RegisterUser(ctx context.Context, req *gen.RegisterUserRequest) (*gen.RegisterUserResponse, error) {
+	if err := validateCredentials(req.Username, req.Password); err != nil {
+		return nil, err
+	}
+
 	user := user.User{Username: req.Username, Password: req.Password}
 
 	// Check for existing user
@@ -65,7 +72,7 @@ func (s *AuthServiceImpl) handleExistingUser(ctx context.Context, username strin
 	existingUser, err := s.userRepo.GetByUsername(ctx, username)
 
 	if err == nil && existingUser.ID != 0 {
-		return status.Errorf(codes.AlreadyExists, "user %s already exists", username)
+		return errcodes.Error(codes.AlreadyExists, errcodes.UserExists, fmt.Sprintf("user %s already exists", username))
 	} else if err != nil {
 		s.log.WithError(err).Error("failed to check user existence")
 		return status.Errorf(codes.Internal, "failed to check user existence: %v", err)
@@ -75,6 +82,10 @@ func (s *AuthServiceImpl) handleExistingUser(ctx context.Context, username strin
 }
 
 func (s *AuthServiceImpl) Authenticate(ctx context.Context, req *gen.AuthenticateRequest) (*gen.AuthenticateResponse, error) {
+	if err := validateCredentials(req.Username, req.Password); err != nil {
+		return nil, err
+	}
+
 	existingUser, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
 		s.log.WithError(err).Error("failed to find user")
@@ -83,7 +94,7 @@ func (s *AuthServiceImpl) Authenticate(ctx context.Context, req *gen.Authenticat
 
 	if err := bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(req.Password)); err != nil {
 		s.log.WithError(err).Warn("password mismatch")
-		return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		return nil, errcodes.Error(codes.Unauthenticated, errcodes.InvalidCredentials, "invalid credentials")
 	}
 
 	token, err := s.jwtUtil.GenerateToken(existingUser.ID)
@@ -97,3 +108,18 @@ func (s *AuthServiceImpl) Authenticate(ctx context.Context, req *gen.Authenticat
 		Token: token,
 	}, nil
 }
+
+func validateCredentials(username, password string) error {
+	var violations []*errdetails.BadRequest_FieldViolation
+	if username == "" {
+		violations = append(violations, errcodes.Violation("username", "must not be empty"))
+	}
+	if password == "" {
+		violations = append(violations, errcodes.Violation("password", "must not be empty"))
+	}
+
+	if len(violations) > 0 {
+		return errcodes.Invalid(errcodes.InvalidArgument, "username and password are required", violations...)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"auth/proto/gen"
 	"context"
 	"errors"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
 	"testing"
@@ -22,48 +23,48 @@ func NewInMemoryUserRepository() *InMemoryUserRepository {
 	}
 }
 
-func (r *InMemoryUserRepository) GetByUsername(username string) (*user.User, error) {
-	user, exists := r.users[username]
+func (r *InMemoryUserRepository) GetByUsername(_ context.Context, username string) (*user.User, error) {
+	u, exists := r.users[username]
 	if !exists {
 		return &user.User{}, nil
 	}
-	return &user, nil
+	return &u, nil
 }
 
-func (r *InMemoryUserRepository) Insert(user user.User) (int, error) {
-	if _, exists := r.users[user.Username]; exists {
+func (r *InMemoryUserRepository) Insert(_ context.Context, u user.User) (int, error) {
+	if _, exists := r.users[u.Username]; exists {
 		return 0, errors.New("user already exists")
 	}
 
-	user.ID = len(r.users) + 1
-	user.CreatedAt = time.Now()
-	user.UpdatedAt = time.Now()
+	u.ID = len(r.users) + 1
+	u.CreatedAt = time.Now()
+	u.UpdatedAt = time.Now()
 
-	r.users[user.Username] = user
-	return user.ID, nil
+	r.users[u.Username] = u
+	return u.ID, nil
 }
 
-func (r *InMemoryUserRepository) GetAll() ([]*user.User, error) {
+func (r *InMemoryUserRepository) GetAll(_ context.Context) ([]*user.User, error) {
 	var users []*user.User
-	for _, user := range r.users {
-		userCopy := user
+	for _, u := range r.users {
+		userCopy := u
 		users = append(users, &userCopy)
 	}
 	return users, nil
 }
 
-func (r *InMemoryUserRepository) GetOne(id int) (*user.User, error) {
-	for _, user := range r.users {
-		if user.ID == id {
-			return &user, nil
+func (r *InMemoryUserRepository) GetOne(_ context.Context, id int) (*user.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return &u, nil
 		}
 	}
 	return nil, errors.New("user not found")
 }
 
-func (r *InMemoryUserRepository) DeleteByID(id int) error {
-	for username, user := range r.users {
-		if user.ID == id {
+func (r *InMemoryUserRepository) DeleteByID(_ context.Context, id int) error {
+	for username, u := range r.users {
+		if u.ID == id {
 			delete(r.users, username)
 			return nil
 		}
@@ -123,7 +124,7 @@ func TestRegisterUser(t *testing.T) {
 
 		// Setup service
 		jwtUtil := &SimpleJWTUtil{}
-		service := NewAuthService(jwtUtil, repo)
+		service := NewAuthService(jwtUtil, repo, logrus.New())
 
 		// Execute
 		resp, err := service.RegisterUser(context.Background(), tc.request)
@@ -207,7 +208,7 @@ func TestAuthenticate(t *testing.T) {
 
 		// Setup service
 		jwtUtil := &SimpleJWTUtil{}
-		service := NewAuthService(jwtUtil, repo)
+		service := NewAuthService(jwtUtil, repo, logrus.New())
 
 		// Execute
 		resp, err := service.Authenticate(context.Background(), tc.request)
@@ -4,6 +4,7 @@ import (
 	"auth/internal/auth"
 	"auth/internal/config"
 	"auth/internal/jwt"
+	"auth/internal/mtls"
 	"auth/internal/user"
 	pb "auth/proto/gen"
 	"context"
@@ -13,6 +14,8 @@ import (
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 	"net"
+	"os/signal"
+	"syscall"
 )
 
 // NewServeCmd creates the serve command
@@ -21,7 +24,9 @@ func NewServeCmd() *cobra.Command {
 		Use:   "serve",
 		Short: "Start the auth-service",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			// Cancelled on SIGTERM so that Kubernetes rolling deploys drain the pod.
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
 
 			// Load .env file
 			if err := godotenv.Load(); err != nil {
@@ -39,6 +44,7 @@ func NewServeCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to create postgres pool: %w", err)
 			}
+			defer pgPool.Close()
 
 			log.Info("Successfully connected to postgres")
 
@@ -51,13 +57,33 @@ func NewServeCmd() *cobra.Command {
 				return fmt.Errorf("failed to listen: %w", err)
 			}
 
-			log.Infof("gRPC server listening on port %s", cfg.ListenPort)
-			s := grpc.NewServer()
+			serverOpts, err := mtls.ServerOptions(ctx, cfg.TLS, log)
+			if err != nil {
+				return fmt.Errorf("failed to configure TLS: %w", err)
+			}
+
+			s := grpc.NewServer(serverOpts...)
 			pb.RegisterAuthServiceServer(s, authSvc)
-			if err := s.Serve(lis); err != nil {
+
+			serveErr := make(chan error, 1)
+			go func() {
+				log.Infof("gRPC server listening on port %s", cfg.ListenPort)
+				serveErr <- s.Serve(lis)
+			}()
+
+			select {
+			case err := <-serveErr:
 				return fmt.Errorf("failed to serve: %w", err)
+			case <-ctx.Done():
 			}
 
+			log.Infof("Shutting down, draining for up to %s", cfg.ShutdownTimeout)
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+			defer cancel()
+
+			gracefulStop(shutdownCtx, s)
+
+			log.Info("Auth service stopped")
 			return nil
 		},
 	}
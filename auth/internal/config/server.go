@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"github.com/kelseyhightower/envconfig"
+	"time"
 )
 
 type ServerCfg struct {
@@ -11,8 +12,12 @@ type ServerCfg struct {
 
 	JWTSecret string `default:"change-me-in-prod" envconfig:"JWT_SECRET"`
 
+	// ShutdownTimeout bounds draining on SIGTERM, keep it below the pod's termination grace period.
+	ShutdownTimeout time.Duration `default:"25s" envconfig:"SHUTDOWN_TIMEOUT"`
+
 	Postgres Postgres
 	Log      Log
+	TLS      TLS
 }
 
 func NewServerConfig() (*ServerCfg, error) {
@@ -0,0 +1,47 @@
+// Package errcodes attaches stable error codes to gRPC statuses. The broker reads
+// them from errdetails.ErrorInfo and returns them to clients, so codes must not
+// change once released.
+package errcodes
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+const domain = "auth.wall-e"
+
+const (
+	InvalidArgument    = "invalid_argument"
+	InvalidCredentials = "auth.invalid_credentials"
+	UserExists         = "auth.user_exists"
+)
+
+// Error returns a status error with code and reason attached as ErrorInfo.
+func Error(code codes.Code, reason string, message string) error {
+	return withDetails(status.New(code, message), &errdetails.ErrorInfo{Reason: reason, Domain: domain})
+}
+
+// Invalid returns an InvalidArgument status error listing the invalid fields.
+func Invalid(reason string, message string, violations ...*errdetails.BadRequest_FieldViolation) error {
+	return withDetails(
+		status.New(codes.InvalidArgument, message),
+		&errdetails.ErrorInfo{Reason: reason, Domain: domain},
+		&errdetails.BadRequest{FieldViolations: violations},
+	)
+}
+
+// Violation describes a single invalid request field.
+func Violation(field string, description string) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{Field: field, Description: description}
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		// Details only fail to marshal on programming errors, keep the plain status.
+		return st.Err()
+	}
+	return detailed.Err()
+}
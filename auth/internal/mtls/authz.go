@@ -0,0 +1,70 @@
+package mtls
+
+import (
+	"context"
+	"crypto/x509"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizeSANs rejects calls whose verified client certificate carries none of the allowed SANs.
+// An empty allow list lets every verified client through.
+func AuthorizeSANs(allowed []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, allowed); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthorizeSANsStream is the streaming counterpart of AuthorizeSANs.
+func AuthorizeSANsStream(allowed []string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), allowed); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func authorize(ctx context.Context, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "no peer information")
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return status.Error(codes.Unauthenticated, "client certificate required")
+	}
+
+	if !hasAllowedSAN(tlsInfo.State.VerifiedChains[0][0], allowed) {
+		return status.Error(codes.PermissionDenied, "caller is not allowed to access this service")
+	}
+
+	return nil
+}
+
+func hasAllowedSAN(cert *x509.Certificate, allowed []string) bool {
+	for _, name := range cert.DNSNames {
+		if slices.Contains(allowed, name) {
+			return true
+		}
+	}
+	for _, uri := range cert.URIs {
+		if slices.Contains(allowed, uri.String()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Reloader keeps the certificate, key and CA bundle in memory and swaps them
+// whenever the files on disk change, so rotated certificates are picked up
+// without restarting the service.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	log      *logrus.Logger
+
+	cert    atomic.Pointer[tls.Certificate]
+	pool    atomic.Pointer[x509.CertPool]
+	modTime time.Time
+}
+
+func NewReloader(certFile, keyFile, caFile string, log *logrus.Logger) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		log:      log,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Watch polls the certificate files every interval and reloads them on change.
+// It blocks until ctx is cancelled.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modTime, err := r.latestModTime()
+			if err != nil {
+				r.log.WithError(err).Warn("failed to stat certificate files")
+				continue
+			}
+			if !modTime.After(r.modTime) {
+				continue
+			}
+
+			if err := r.load(); err != nil {
+				// Keep serving with the previous material, the files may be mid-rotation.
+				r.log.WithError(err).Error("failed to reload certificates")
+				continue
+			}
+			r.log.Info("certificates reloaded")
+		}
+	}
+}
+
+// Certificate returns the current key pair.
+func (r *Reloader) Certificate() *tls.Certificate {
+	return r.cert.Load()
+}
+
+// CAPool returns the current CA pool, or nil when no CA bundle is configured.
+func (r *Reloader) CAPool() *x509.CertPool {
+	return r.pool.Load()
+}
+
+func (r *Reloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	if r.certFile != "" || r.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load key pair: %w", err)
+		}
+		r.cert.Store(&cert)
+	}
+
+	if r.caFile != "" {
+		caPEM, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return errors.New("no certificates found in CA file")
+		}
+		r.pool.Store(pool)
+	}
+
+	r.modTime = modTime
+	return nil
+}
+
+func (r *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
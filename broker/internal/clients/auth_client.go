@@ -13,8 +13,8 @@ type AuthClient struct {
 	log    *logrus.Logger
 }
 
-func NewAuthClient(addr string, log *logrus.Logger) (*AuthClient, error) {
-	conn, err := grpc.Dial(addr, grpc.WithInsecure()) // Use TLS in production
+func NewAuthClient(addr string, creds grpc.DialOption, log *logrus.Logger) (*AuthClient, error) {
+	conn, err := grpc.NewClient(addr, creds)
 	if err != nil {
 		log.WithError(err).Error("Failed to connect to auth service")
 		return nil, err
@@ -2,6 +2,7 @@ package clients
 
 import (
 	"broker/internal/models"
+	"broker/internal/money"
 	"broker/proto/gen"
 	"context"
 	"fmt"
@@ -15,8 +16,8 @@ type TransactionClient struct {
 	log    *logrus.Logger
 }
 
-func NewTransactionClient(addr string, log *logrus.Logger) (*TransactionClient, error) {
-	conn, err := grpc.Dial(addr, grpc.WithInsecure()) // Use TLS in production
+func NewTransactionClient(addr string, creds grpc.DialOption, log *logrus.Logger) (*TransactionClient, error) {
+	conn, err := grpc.NewClient(addr, creds)
 	if err != nil {
 		log.WithError(err).Error("Failed to connect to transaction service")
 		return nil, fmt.Errorf("failed to connect to transaction service: %w", err)
@@ -31,7 +32,8 @@ func (c *TransactionClient) Deposit(ctx context.Context, req models.TransactionR
 	c.log.Debug("Depositing money")
 	resp, err := c.client.Deposit(ctx, &gen.TransactionRequest{
 		WalletId:       req.WalletID,
-		Amount:         req.Amount,
+		Amount:         req.Amount.Float64(),
+		AmountMoney:    req.Amount.ProtoIn(req.Currency),
 		IdempotencyKey: req.IdempotencyKey,
 	})
 	if err != nil {
@@ -40,3 +42,90 @@ func (c *TransactionClient) Deposit(ctx context.Context, req models.TransactionR
 
 	return resp.GetTransactionId(), nil
 }
+
+func (c *TransactionClient) Withdraw(ctx context.Context, req models.TransactionRequest) (string, error) {
+	c.log.Debug("Withdrawing money")
+	resp, err := c.client.Withdraw(ctx, &gen.TransactionRequest{
+		WalletId:       req.WalletID,
+		Amount:         req.Amount.Float64(),
+		AmountMoney:    req.Amount.ProtoIn(req.Currency),
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to withdraw: %w", err)
+	}
+
+	return resp.GetTransactionId(), nil
+}
+
+func (c *TransactionClient) Transfer(ctx context.Context, req models.TransferRequest) (*models.TransferResponse, error) {
+	c.log.Debug("Transferring money")
+	resp, err := c.client.Transfer(ctx, &gen.TransferRequest{
+		SourceWalletId:      req.SourceWalletID,
+		DestinationWalletId: req.DestinationWalletID,
+		Amount:              req.Amount.ProtoIn(req.Currency),
+		IdempotencyKey:      req.IdempotencyKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to transfer: %w", err)
+	}
+
+	return &models.TransferResponse{
+		TransferID:          resp.GetTransferId(),
+		DebitTransactionID:  resp.GetDebitTransactionId(),
+		CreditTransactionID: resp.GetCreditTransactionId(),
+	}, nil
+}
+
+func (c *TransactionClient) GetTransaction(ctx context.Context, transactionID string) (*models.Transaction, error) {
+	c.log.Debug("Getting transaction")
+	resp, err := c.client.GetTransaction(ctx, &gen.GetTransactionRequest{
+		TransactionId: transactionID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	return toTransaction(resp), nil
+}
+
+func (c *TransactionClient) ListTransactions(ctx context.Context, req models.ListTransactionsRequest) (*models.TransactionPage, error) {
+	c.log.Debug("Listing transactions")
+	resp, err := c.client.ListTransactions(ctx, &gen.ListTransactionsRequest{
+		WalletId: req.WalletID,
+		Limit:    int32(req.Limit),
+		Cursor:   req.Cursor,
+		Type:     req.Type,
+		Status:   req.Status,
+		From:     req.From,
+		To:       req.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	page := &models.TransactionPage{
+		Transactions: make([]models.Transaction, 0, len(resp.GetTransactions())),
+		NextCursor:   resp.GetNextCursor(),
+	}
+	for _, t := range resp.GetTransactions() {
+		page.Transactions = append(page.Transactions, *toTransaction(t))
+	}
+	return page, nil
+}
+
+func toTransaction(t *gen.Transaction) *models.Transaction {
+	return &models.Transaction{
+		ID:                    t.GetId(),
+		WalletID:              t.GetWalletId(),
+		Amount:                money.FromProto(t.GetAmountMoney(), t.GetAmount()),
+		Currency:              money.CurrencyOf(t.GetAmountMoney()),
+		Type:                  t.GetType(),
+		Status:                t.GetStatus(),
+		CreatedAt:             t.GetCreatedAt(),
+		UpdatedAt:             t.GetUpdatedAt(),
+		TransferID:            t.GetTransferId(),
+		FailureReason:         t.GetFailureReason(),
+		OriginalTransactionID: t.GetOriginalTransactionId(),
+	}
+}
@@ -2,6 +2,7 @@ package clients
 
 import (
 	"broker/internal/models"
+	"broker/internal/money"
 	"broker/proto/gen"
 	"context"
 	"fmt"
@@ -16,8 +17,8 @@ type WalletClient struct {
 	log    *logrus.Logger
 }
 
-func NewWalletClient(addr string, log *logrus.Logger) (*WalletClient, error) {
-	conn, err := grpc.Dial(addr, grpc.WithInsecure()) // Use TLS in production
+func NewWalletClient(addr string, creds grpc.DialOption, log *logrus.Logger) (*WalletClient, error) {
+	conn, err := grpc.NewClient(addr, creds)
 	if err != nil {
 		log.WithError(err).Error("Failed to connect to auth service")
 		return nil, fmt.Errorf("failed to connect to auth service: %w", err)
@@ -28,10 +29,11 @@ func NewWalletClient(addr string, log *logrus.Logger) (*WalletClient, error) {
 	}, nil
 }
 
-func (c *WalletClient) CreateWallet(ctx context.Context, walletName string) (string, error) {
+func (c *WalletClient) CreateWallet(ctx context.Context, req models.CreateWalletRequest) (string, error) {
 	c.log.Debug("Creating wallet")
 	resp, err := c.client.CreateWallet(ctx, &gen.CreateWalletRequest{
-		Name: walletName,
+		Name:     req.Name,
+		Currency: string(req.Currency),
 	})
 	if err != nil {
 		c.log.WithError(err).Error("Failed to create wallet")
@@ -51,13 +53,29 @@ func (c *WalletClient) ViewBalance(ctx context.Context, walletID string) (*model
 		return nil, fmt.Errorf("failed to view balance: %w", err)
 	}
 
+	balance := money.FromProto(resp.GetBalanceMoney(), resp.GetBalance())
+	held := money.FromProto(resp.GetHeldMoney(), 0)
+	available := balance - held
+	if resp.GetAvailableMoney() != nil {
+		available = money.FromProto(resp.GetAvailableMoney(), 0)
+	}
+
 	return &models.ViewBalanceResponse{
-		Name:    resp.GetName(),
-		Balance: resp.GetBalance(),
+		Name:      resp.GetName(),
+		Balance:   balance,
+		Available: available,
+		Held:      held,
+		Currency:  money.CurrencyOf(resp.GetBalanceMoney()),
 	}, nil
 }
 
 func (c *WalletClient) IsWalletOwner(ctx context.Context, userID int64, walletID string) (bool, error) {
+	isOwner, _, err := c.WalletAccess(ctx, userID, walletID)
+	return isOwner, err
+}
+
+// WalletAccess reports whether the user owns the wallet and whether it is closed.
+func (c *WalletClient) WalletAccess(ctx context.Context, userID int64, walletID string) (isOwner bool, closed bool, err error) {
 	c.log.Debug("Checking wallet ownership")
 	resp, err := c.client.IsWalletOwner(ctx, &gen.IsOwnerRequest{
 		WalletId: walletID,
@@ -65,10 +83,152 @@ func (c *WalletClient) IsWalletOwner(ctx context.Context, userID int64, walletID
 	})
 	if err != nil {
 		c.log.WithError(err).Error("Failed to check wallet ownership")
-		return false, fmt.Errorf("failed to check wallet ownership: %w", err)
+		return false, false, fmt.Errorf("failed to check wallet ownership: %w", err)
+	}
+
+	return resp.GetValid(), resp.GetClosed(), nil
+}
+
+func (c *WalletClient) ListWallets(ctx context.Context) ([]models.Wallet, error) {
+	c.log.Debug("Listing wallets")
+	resp, err := c.client.ListWallets(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallets: %w", err)
 	}
 
-	return resp.GetValid(), nil
+	wallets := make([]models.Wallet, 0, len(resp.GetWallets()))
+	for _, wallet := range resp.GetWallets() {
+		wallets = append(wallets, *toWallet(wallet))
+	}
+	return wallets, nil
+}
+
+func (c *WalletClient) GetWallet(ctx context.Context, walletID string) (*models.Wallet, error) {
+	c.log.Debug("Getting wallet")
+	resp, err := c.client.GetWallet(ctx, &gen.WalletRequest{WalletId: walletID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+
+	return toWallet(resp.GetWallet()), nil
+}
+
+func (c *WalletClient) RenameWallet(ctx context.Context, walletID string, name string) (*models.Wallet, error) {
+	c.log.Debug("Renaming wallet")
+	resp, err := c.client.RenameWallet(ctx, &gen.RenameWalletRequest{
+		WalletId: walletID,
+		Name:     name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename wallet: %w", err)
+	}
+
+	return toWallet(resp.GetWallet()), nil
+}
+
+func (c *WalletClient) CloseWallet(ctx context.Context, walletID string) (*models.Wallet, error) {
+	c.log.Debug("Closing wallet")
+	resp, err := c.client.CloseWallet(ctx, &gen.WalletRequest{WalletId: walletID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to close wallet: %w", err)
+	}
+
+	return toWallet(resp.GetWallet()), nil
+}
+
+func (c *WalletClient) GetLedger(ctx context.Context, req models.LedgerRequest) (*models.LedgerPage, error) {
+	c.log.Debug("Getting wallet ledger")
+	resp, err := c.client.GetLedger(ctx, &gen.GetLedgerRequest{
+		WalletId: req.WalletID,
+		Limit:    int32(req.Limit),
+		Cursor:   req.Cursor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet ledger: %w", err)
+	}
+
+	page := &models.LedgerPage{
+		Entries:    make([]models.LedgerEntry, 0, len(resp.GetEntries())),
+		NextCursor: resp.GetNextCursor(),
+	}
+	for _, entry := range resp.GetEntries() {
+		page.Entries = append(page.Entries, models.LedgerEntry{
+			ID:              entry.GetId(),
+			JournalID:       entry.GetJournalId(),
+			Kind:            entry.GetKind(),
+			TransactionID:   entry.GetTransactionId(),
+			Direction:       entry.GetDirection(),
+			Amount:          money.FromProto(entry.GetAmount(), 0),
+			BalanceAfter:    money.FromProto(entry.GetBalanceAfter(), 0),
+			CreatedAt:       entry.GetCreatedAt(),
+			CounterAccount:  entry.GetCounterAccount(),
+			CounterWalletID: entry.GetCounterWalletId(),
+		})
+	}
+	return page, nil
+}
+
+func (c *WalletClient) PlaceHold(ctx context.Context, walletID string, req models.PlaceHoldRequest) (*models.Hold, error) {
+	c.log.Debug("Placing hold")
+	resp, err := c.client.PlaceHold(ctx, &gen.PlaceHoldRequest{
+		WalletId:   walletID,
+		Amount:     req.Amount.ProtoIn(req.Currency),
+		TtlSeconds: req.TTLSeconds,
+		Reference:  req.Reference,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to place hold: %w", err)
+	}
+
+	return toHold(resp.GetHold()), nil
+}
+
+func (c *WalletClient) CaptureHold(ctx context.Context, holdID string, req models.CaptureHoldRequest) (*models.Hold, error) {
+	c.log.Debug("Capturing hold")
+	capture := &gen.CaptureHoldRequest{HoldId: holdID}
+	if req.Amount != nil {
+		capture.Amount = req.Amount.ProtoIn(req.Currency)
+	}
+	resp, err := c.client.CaptureHold(ctx, capture)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture hold: %w", err)
+	}
+
+	return toHold(resp.GetHold()), nil
+}
+
+func (c *WalletClient) VoidHold(ctx context.Context, holdID string) (*models.Hold, error) {
+	c.log.Debug("Voiding hold")
+	resp, err := c.client.VoidHold(ctx, &gen.HoldRequest{HoldId: holdID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to void hold: %w", err)
+	}
+
+	return toHold(resp.GetHold()), nil
+}
+
+func (c *WalletClient) CreateQuote(ctx context.Context, req models.CreateQuoteRequest) (*models.Quote, error) {
+	c.log.Debug("Creating FX quote")
+	resp, err := c.client.CreateQuote(ctx, &gen.CreateQuoteRequest{
+		SourceWalletId:      req.SourceWalletID,
+		DestinationWalletId: req.DestinationWalletID,
+		Amount:              req.Amount.ProtoIn(req.Currency),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create quote: %w", err)
+	}
+
+	return toQuote(resp.GetQuote()), nil
+}
+
+func (c *WalletClient) ExecuteConversion(ctx context.Context, quoteID string) (*models.Quote, error) {
+	c.log.Debug("Executing FX conversion")
+	resp, err := c.client.ExecuteConversion(ctx, &gen.ExecuteConversionRequest{QuoteId: quoteID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute conversion: %w", err)
+	}
+
+	return toQuote(resp.GetQuote()), nil
 }
 
 func (c *WalletClient) HealthCheck(ctx context.Context, empty *emptypb.Empty) error {
@@ -80,3 +240,56 @@ func (c *WalletClient) HealthCheck(ctx context.Context, empty *emptypb.Empty) er
 	}
 	return nil
 }
+
+func toWallet(wallet *gen.Wallet) *models.Wallet {
+	return &models.Wallet{
+		ID:        wallet.GetId(),
+		Name:      wallet.GetName(),
+		Balance:   money.FromProto(wallet.GetBalanceMoney(), wallet.GetBalance()),
+		Currency:  money.CurrencyOf(wallet.GetBalanceMoney()),
+		Status:    wallet.GetStatus(),
+		CreatedAt: wallet.GetCreatedAt(),
+		UpdatedAt: wallet.GetUpdatedAt(),
+		ClosedAt:  wallet.GetClosedAt(),
+	}
+}
+
+func toHold(hold *gen.Hold) *models.Hold {
+	h := &models.Hold{
+		ID:        hold.GetId(),
+		WalletID:  hold.GetWalletId(),
+		Amount:    money.FromProto(hold.GetAmount(), 0),
+		Currency:  money.CurrencyOf(hold.GetAmount()),
+		Status:    hold.GetStatus(),
+		Reference: hold.GetReference(),
+		ExpiresAt: hold.GetExpiresAt(),
+		CreatedAt: hold.GetCreatedAt(),
+		UpdatedAt: hold.GetUpdatedAt(),
+	}
+	if hold.GetCapturedAmount() != nil {
+		captured := money.FromProto(hold.GetCapturedAmount(), 0)
+		h.CapturedAmount = &captured
+	}
+	return h
+}
+
+func toQuote(quote *gen.Quote) *models.Quote {
+	return &models.Quote{
+		ID:                  quote.GetId(),
+		SourceWalletID:      quote.GetSourceWalletId(),
+		DestinationWalletID: quote.GetDestinationWalletId(),
+		SourceAmount:        money.FromProto(quote.GetSourceAmount(), 0),
+		SourceCurrency:      money.CurrencyOf(quote.GetSourceAmount()),
+		Fee:                 money.FromProto(quote.GetFee(), 0),
+		TargetAmount:        money.FromProto(quote.GetTargetAmount(), 0),
+		TargetCurrency:      money.CurrencyOf(quote.GetTargetAmount()),
+		MidRate:             quote.GetMidRate(),
+		Rate:                quote.GetRate(),
+		SpreadBps:           quote.GetSpreadBps(),
+		FeeBps:              quote.GetFeeBps(),
+		Status:              quote.GetStatus(),
+		ExpiresAt:           quote.GetExpiresAt(),
+		CreatedAt:           quote.GetCreatedAt(),
+		ExecutedAt:          quote.GetExecutedAt(),
+	}
+}
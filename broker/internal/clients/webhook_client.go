@@ -0,0 +1,154 @@
+package clients
+
+import (
+	"broker/internal/models"
+	"broker/proto/gen"
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"google.golang.org/grpc"
+)
+
+type WebhookClient struct {
+	client gen.WebhookServiceClient
+	log    *logrus.Logger
+}
+
+func NewWebhookClient(addr string, creds grpc.DialOption, log *logrus.Logger) (*WebhookClient, error) {
+	conn, err := grpc.NewClient(addr, creds)
+	if err != nil {
+		log.WithError(err).Error("Failed to connect to webhook service")
+		return nil, fmt.Errorf("failed to connect to webhook service: %w", err)
+	}
+	return &WebhookClient{
+		client: gen.NewWebhookServiceClient(conn),
+		log:    log,
+	}, nil
+}
+
+func (c *WebhookClient) CreateEndpoint(ctx context.Context, req models.CreateWebhookRequest) (*models.WebhookEndpoint, error) {
+	c.log.Debug("Creating webhook endpoint")
+	resp, err := c.client.CreateEndpoint(ctx, &gen.CreateEndpointRequest{
+		Url:        req.URL,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	return toEndpoint(resp.GetEndpoint()), nil
+}
+
+func (c *WebhookClient) ListEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	c.log.Debug("Listing webhook endpoints")
+	resp, err := c.client.ListEndpoints(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+
+	endpoints := make([]models.WebhookEndpoint, 0, len(resp.GetEndpoints()))
+	for _, endpoint := range resp.GetEndpoints() {
+		endpoints = append(endpoints, *toEndpoint(endpoint))
+	}
+	return endpoints, nil
+}
+
+func (c *WebhookClient) DeleteEndpoint(ctx context.Context, endpointID string) error {
+	c.log.Debug("Deleting webhook endpoint")
+	if _, err := c.client.DeleteEndpoint(ctx, &gen.EndpointRequest{EndpointId: endpointID}); err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+func (c *WebhookClient) RotateSecret(ctx context.Context, endpointID string) (*models.WebhookEndpoint, error) {
+	c.log.Debug("Rotating webhook secret")
+	resp, err := c.client.RotateSecret(ctx, &gen.EndpointRequest{EndpointId: endpointID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+
+	return toEndpoint(resp.GetEndpoint()), nil
+}
+
+func (c *WebhookClient) EnableEndpoint(ctx context.Context, endpointID string) (*models.WebhookEndpoint, error) {
+	c.log.Debug("Enabling webhook endpoint")
+	resp, err := c.client.EnableEndpoint(ctx, &gen.EndpointRequest{EndpointId: endpointID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable webhook endpoint: %w", err)
+	}
+
+	return toEndpoint(resp.GetEndpoint()), nil
+}
+
+func (c *WebhookClient) ListDeliveries(ctx context.Context, endpointID string, limit int) ([]models.WebhookDelivery, error) {
+	c.log.Debug("Listing webhook deliveries")
+	resp, err := c.client.ListDeliveries(ctx, &gen.ListDeliveriesRequest{
+		EndpointId: endpointID,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(resp.GetDeliveries()))
+	for _, delivery := range resp.GetDeliveries() {
+		deliveries = append(deliveries, *toDelivery(delivery))
+	}
+	return deliveries, nil
+}
+
+func (c *WebhookClient) Redeliver(ctx context.Context, endpointID, deliveryID string) (*models.WebhookDelivery, error) {
+	c.log.Debug("Redelivering webhook")
+	resp, err := c.client.Redeliver(ctx, &gen.RedeliverRequest{
+		EndpointId: endpointID,
+		DeliveryId: deliveryID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+
+	return toDelivery(resp.GetDelivery()), nil
+}
+
+func toEndpoint(endpoint *gen.Endpoint) *models.WebhookEndpoint {
+	return &models.WebhookEndpoint{
+		ID:                  endpoint.GetId(),
+		URL:                 endpoint.GetUrl(),
+		EventTypes:          append([]string{}, endpoint.GetEventTypes()...),
+		Enabled:             endpoint.GetEnabled(),
+		Secret:              endpoint.GetSecret(),
+		ConsecutiveFailures: int(endpoint.GetConsecutiveFailures()),
+		DisabledAt:          endpoint.GetDisabledAt(),
+		CreatedAt:           endpoint.GetCreatedAt(),
+	}
+}
+
+func toDelivery(delivery *gen.Delivery) *models.WebhookDelivery {
+	attempts := make([]models.WebhookDeliveryAttempt, 0, len(delivery.GetAttemptLog()))
+	for _, attempt := range delivery.GetAttemptLog() {
+		attempts = append(attempts, models.WebhookDeliveryAttempt{
+			Attempt:    int(attempt.GetAttempt()),
+			StatusCode: int(attempt.GetStatusCode()),
+			Error:      attempt.GetError(),
+			DurationMs: attempt.GetDurationMs(),
+			CreatedAt:  attempt.GetCreatedAt(),
+		})
+	}
+
+	return &models.WebhookDelivery{
+		ID:             delivery.GetId(),
+		EndpointID:     delivery.GetEndpointId(),
+		EventID:        delivery.GetEventId(),
+		EventType:      delivery.GetEventType(),
+		Status:         delivery.GetStatus(),
+		Attempts:       int(delivery.GetAttempts()),
+		LastStatusCode: int(delivery.GetLastStatusCode()),
+		LastError:      delivery.GetLastError(),
+		NextAttemptAt:  delivery.GetNextAttemptAt(),
+		CreatedAt:      delivery.GetCreatedAt(),
+		AttemptLog:     attempts,
+	}
+}
@@ -13,6 +13,9 @@ type AppHandlers struct {
 	Auth        handlers.AuthHandler
 	Wallet      handlers.WalletHandler
 	Transaction handlers.TransactionHandler
+	Stream      handlers.StreamHandler
+	Docs        handlers.DocsHandler
+	Webhook     handlers.WebhookHandler
 }
 
 func newLogger(cfg config.Log) *logrus.Logger {
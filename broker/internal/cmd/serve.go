@@ -4,12 +4,21 @@ import (
 	"broker/internal/clients"
 	"broker/internal/config"
 	"broker/internal/handlers"
+	"broker/internal/idempotency"
 	"broker/internal/middlewares"
+	"broker/internal/mtls"
+	"broker/internal/openapi"
+	"broker/internal/stream"
+	"context"
 	"fmt"
 	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
+	"github.com/redis/go-redis/v9"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +28,9 @@ func NewServeCmd() *cobra.Command {
 		Use:   "serve",
 		Short: "Start the API gateway server",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			//ctx := context.Background()
+			// Cancelled on SIGTERM so that Kubernetes rolling deploys drain the pod.
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
 
 			cfg, err := config.NewServerConfig()
 			if err != nil {
@@ -28,29 +39,78 @@ func NewServeCmd() *cobra.Command {
 
 			log := newLogger(cfg.Log)
 
+			creds, err := mtls.DialOption(ctx, cfg.TLS, log)
+			if err != nil {
+				log.WithError(err).Error("Failed to configure TLS")
+				return fmt.Errorf("failed to configure TLS: %w", err)
+			}
+
 			// Initialize clients
-			authClient, err := clients.NewAuthClient(cfg.AuthHost, log)
+			authClient, err := clients.NewAuthClient(cfg.AuthHost, creds, log)
 			if err != nil {
 				log.WithError(err).Error("Failed to create auth client")
 				return fmt.Errorf("failed to create auth client: %w", err)
 			}
 
-			walletClient, err := clients.NewWalletClient(cfg.WalletHost, log)
+			walletClient, err := clients.NewWalletClient(cfg.WalletHost, creds, log)
 			if err != nil {
 				log.WithError(err).Error("Failed to create wallet client")
 				return fmt.Errorf("failed to create wallet client: %w", err)
 			}
 
-			//transactionClient, err := clients.NewTransactionClient(cfg.TransactionHost, log)
-			//if err != nil {
-			//	log.WithError(err).Error("Failed to create transaction client")
-			//	return fmt.Errorf("failed to create transaction client: %w", err)
-			//}
+			transactionClient, err := clients.NewTransactionClient(cfg.TransactionHost, creds, log)
+			if err != nil {
+				log.WithError(err).Error("Failed to create transaction client")
+				return fmt.Errorf("failed to create transaction client: %w", err)
+			}
+
+			webhookClient, err := clients.NewWebhookClient(cfg.WebhookHost, creds, log)
+			if err != nil {
+				log.WithError(err).Error("Failed to create webhook client")
+				return fmt.Errorf("failed to create webhook client: %w", err)
+			}
+
+			var idempotencyStore idempotency.Store
+			if cfg.IdempotencyRedisAddr != "" {
+				redisClient := redis.NewClient(&redis.Options{Addr: cfg.IdempotencyRedisAddr})
+				defer redisClient.Close()
+				if err := redisClient.Ping(ctx).Err(); err != nil {
+					log.WithError(err).Error("Failed to connect to Redis")
+					return fmt.Errorf("failed to connect to Redis: %w", err)
+				}
+				idempotencyStore = idempotency.NewRedisStore(redisClient, cfg.IdempotencyTTL, cfg.IdempotencyLockTTL)
+			} else {
+				log.Warn("No Redis configured, idempotent responses are only replayed by this instance")
+				memoryStore := idempotency.NewMemoryStore(cfg.IdempotencyTTL, cfg.IdempotencyLockTTL)
+				go memoryStore.Cleanup(ctx, time.Minute)
+				idempotencyStore = memoryStore
+			}
+
+			// Initialize the event stream. Each instance reads every partition to see every event.
+			hub := stream.NewHub(cfg.Stream.HistorySize, cfg.Stream.BufferSize, log)
+			go hub.Cleanup(ctx, time.Minute, cfg.Stream.HistoryIdle)
+			streamConsumer := stream.NewConsumer(
+				cfg.Stream.KafkaBrokers,
+				cfg.Stream.Topics,
+				hub,
+				log,
+			)
+			defer streamConsumer.Close()
+			go streamConsumer.Consume(ctx)
+
+			_, specRouter, err := openapi.Load(ctx)
+			if err != nil {
+				log.WithError(err).Error("Failed to load API spec")
+				return fmt.Errorf("failed to load API spec: %w", err)
+			}
 
 			// Initialize handlers
 			authHandler := handlers.NewAuthHandler(authClient)
-			walletHandler := handlers.NewWalletHandler(walletClient)
-			//transactionHandler := handlers.NewTransactionHandler(transactionClient)
+			walletHandler := handlers.NewWalletHandler(walletClient, transactionClient)
+			transactionHandler := handlers.NewTransactionHandler(transactionClient, walletClient)
+			streamHandler := handlers.NewStreamHandler(hub, cfg.Stream.HeartbeatInterval, cfg.JWTSecret, cfg.Stream.TokenTTL, cfg.AllowedOrigins, log)
+			docsHandler := handlers.NewDocsHandler(openapi.Spec)
+			webhookHandler := handlers.NewWebhookHandler(webhookClient)
 
 			// Initialize router
 			router := chi.NewRouter()
@@ -61,35 +121,100 @@ func NewServeCmd() *cobra.Command {
 				v1.Use(middlewares.Logger(log))
 
 				v1.Use(cors.Handler(cors.Options{
-					AllowedOrigins: []string{"*"},
-					AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+					AllowedOrigins: cfg.AllowedOrigins,
+					AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 				}))
 
 				// Public routes
+				v1.Get("/openapi.yaml", docsHandler.Spec)
+				v1.Get("/docs", docsHandler.UI)
+
 				v1.Route("/auth", func(auth chi.Router) {
+					auth.Use(middlewares.ValidateRequest(specRouter, log))
+
+					// A login response is a fresh token, there's nothing to replay.
 					auth.Post("/login", authHandler.Authenticate)
-					auth.Post("/register", authHandler.Register)
+					auth.With(middlewares.Idempotency(idempotencyStore, log)).Post("/register", authHandler.Register)
 				})
 				v1.Route("/health", func(health chi.Router) {
 					health.Get("/wallet", walletHandler.HealthCheck)
 				})
 
+				// Browser EventSource and WebSocket clients can't send an Authorization
+				// header, so the stream also takes a stream token from /stream/token.
+				v1.With(
+					middlewares.AuthenticateStream(cfg.JWTSecret, log),
+					middlewares.ValidateRequest(specRouter, log),
+				).Get("/stream", streamHandler.Stream)
+
 				v1.Route("/", func(protected chi.Router) {
 					protected.Use(middlewares.Authenticate(cfg.JWTSecret, log))
+					protected.Use(middlewares.ValidateRequest(specRouter, log))
+					protected.Use(middlewares.Idempotency(idempotencyStore, log))
 
 					protected.Post("/wallet", walletHandler.CreateWallet)
 					protected.Get("/wallet", walletHandler.ViewBalance)
+					protected.Get("/wallets", walletHandler.ListWallets)
+					protected.Get("/wallets/{walletID}", walletHandler.GetWallet)
+					protected.Patch("/wallets/{walletID}", walletHandler.RenameWallet)
+					protected.Delete("/wallets/{walletID}", walletHandler.CloseWallet)
+					protected.Get("/wallets/{walletID}/ledger", walletHandler.GetLedger)
+					protected.Post("/wallets/{walletID}/holds", walletHandler.PlaceHold)
+					protected.Post("/holds/{holdID}/capture", walletHandler.CaptureHold)
+					protected.Post("/holds/{holdID}/void", walletHandler.VoidHold)
+					protected.Post("/fx/quotes", walletHandler.CreateQuote)
+					protected.Post("/fx/quotes/{quoteID}/execute", walletHandler.ExecuteConversion)
+					protected.Post("/stream/token", streamHandler.Token)
+
+					protected.Post("/transactions/deposit", transactionHandler.Deposit)
+					protected.Post("/transactions/withdraw", transactionHandler.Withdraw)
+					protected.Post("/transactions/transfer", transactionHandler.Transfer)
+					protected.Get("/transactions/{transactionID}", transactionHandler.GetTransaction)
+					protected.Get("/wallets/{walletID}/transactions", transactionHandler.ListTransactions)
+
+					protected.Route("/webhooks", func(webhooks chi.Router) {
+						webhooks.Post("/", webhookHandler.Create)
+						webhooks.Get("/", webhookHandler.List)
+						webhooks.Delete("/{webhookID}", webhookHandler.Delete)
+						webhooks.Post("/{webhookID}/rotate-secret", webhookHandler.RotateSecret)
+						webhooks.Post("/{webhookID}/enable", webhookHandler.Enable)
+						webhooks.Get("/{webhookID}/deliveries", webhookHandler.ListDeliveries)
+						webhooks.Post("/{webhookID}/deliveries/{deliveryID}/redeliver", webhookHandler.Redeliver)
+					})
 				})
 			})
 
-			host := fmt.Sprintf(cfg.ListenHost + ":" + cfg.ListenPort)
-			log.Info("Starting server on addr: ", host)
-			err = http.ListenAndServe(host, router)
-			if err != nil {
+			server := &http.Server{
+				Addr:    cfg.ListenHost + ":" + cfg.ListenPort,
+				Handler: router,
+			}
+			// Shutdown doesn't wait for hijacked WebSockets and would wait out the
+			// timeout on SSE, so streams are ended up front and clients reconnect.
+			server.RegisterOnShutdown(hub.Close)
+
+			serveErr := make(chan error, 1)
+			go func() {
+				log.Info("Starting server on addr: ", server.Addr)
+				serveErr <- server.ListenAndServe()
+			}()
+
+			select {
+			case err := <-serveErr:
 				log.WithError(err).Error("Failed to start server")
 				return fmt.Errorf("failed to start server: %w", err)
+			case <-ctx.Done():
+			}
+
+			log.Infof("Shutting down, draining for up to %s", cfg.ShutdownTimeout)
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+			defer cancel()
+
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				log.WithError(err).Error("Failed to drain HTTP connections")
+				return fmt.Errorf("failed to shut down server: %w", err)
 			}
 
+			log.Info("Broker stopped")
 			return nil
 		},
 	}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"github.com/kelseyhightower/envconfig"
+	"time"
 )
 
 type ServerCfg struct {
@@ -13,11 +14,29 @@ type ServerCfg struct {
 	// JWTSecret is the secret key used for signing JWT tokens.
 	JWTSecret string `default:"change-me-in-prod" envconfig:"JWT_SECRET"`
 
+	// AllowedOrigins are the browser origins allowed to call the API and open the stream, "*" allowing any.
+	AllowedOrigins []string `default:"*" envconfig:"ALLOWED_ORIGINS"`
+
 	AuthHost        string `default:"localhost:50051" envconfig:"AUTH_HOST"`
 	WalletHost      string `default:"localhost:50052" envconfig:"WALLET_HOST"`
 	TransactionHost string `default:"localhost:50053" envconfig:"TRANSACTION_HOST"`
+	WebhookHost     string `default:"localhost:50054" envconfig:"WEBHOOK_HOST"`
+
+	// IdempotencyTTL is how long responses are kept for replay of requests with an Idempotency-Key.
+	IdempotencyTTL time.Duration `default:"24h" envconfig:"IDEMPOTENCY_TTL"`
+	// IdempotencyLockTTL is how long a request in flight holds its key, should the broker fail to complete or release it.
+	IdempotencyLockTTL time.Duration `default:"1m" envconfig:"IDEMPOTENCY_LOCK_TTL"`
+	// IdempotencyRedisAddr is the Redis the responses are kept in, shared by every broker instance.
+	// Empty keeps them in process memory, which only suits a single instance.
+	IdempotencyRedisAddr string `default:"localhost:6379" envconfig:"IDEMPOTENCY_REDIS_ADDR"`
+
+	// ShutdownTimeout bounds draining on SIGTERM, keep it below the pod's termination grace period.
+	ShutdownTimeout time.Duration `default:"25s" envconfig:"SHUTDOWN_TIMEOUT"`
+
+	Stream Stream
 
 	Log Log
+	TLS TLS
 }
 
 func NewServerConfig() (*ServerCfg, error) {
@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+type Stream struct {
+	// KafkaBrokers is the list of Kafka brokers the event stream is consumed from.
+	KafkaBrokers []string `default:"localhost:9092" envconfig:"STREAM_KAFKA_BROKERS"`
+
+	// Topics is the list of domain topics pushed to clients. Events must carry a `user_id`.
+	Topics []string `default:"deposit_completed,deposit_failed,withdraw_completed,withdraw_failed,transfer_completed,transfer_failed,reversal_completed,reversal_failed" envconfig:"STREAM_TOPICS"`
+
+	// HeartbeatInterval is how often idle connections are pinged to keep proxies from closing them.
+	HeartbeatInterval time.Duration `default:"15s" envconfig:"STREAM_HEARTBEAT_INTERVAL"`
+
+	// HistorySize is the number of recent events kept per user for Last-Event-ID resume.
+	HistorySize int `default:"100" envconfig:"STREAM_HISTORY_SIZE"`
+
+	// HistoryIdle is how long the history of a user without connections is kept after their last event.
+	HistoryIdle time.Duration `default:"1h" envconfig:"STREAM_HISTORY_IDLE"`
+
+	// BufferSize is the number of undelivered events a connection may lag behind before it is dropped.
+	BufferSize int `default:"64" envconfig:"STREAM_BUFFER_SIZE"`
+
+	// TokenTTL is how long the tokens browser clients open the stream with are valid.
+	TokenTTL time.Duration `default:"1m" envconfig:"STREAM_TOKEN_TTL"`
+}
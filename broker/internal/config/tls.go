@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+type TLS struct {
+	// Enabled is a toggle whether connections to the internal gRPC services use TLS or plaintext.
+	Enabled bool `default:"false" envconfig:"TLS_ENABLED"`
+
+	// CertFile and KeyFile are the PEM encoded client certificate and private key presented to the services (mTLS).
+	CertFile string `envconfig:"TLS_CERT_FILE"`
+	KeyFile  string `envconfig:"TLS_KEY_FILE"`
+
+	// CAFile is the PEM encoded CA bundle used to verify the services. Empty uses the system pool.
+	CAFile string `envconfig:"TLS_CA_FILE"`
+
+	// ReloadInterval is how often the certificate files are checked for changes.
+	ReloadInterval time.Duration `default:"30s" envconfig:"TLS_RELOAD_INTERVAL"`
+}
@@ -29,7 +29,7 @@ func (h *AuthHandlerImpl) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.Respond(w, http.StatusBadRequest, "invalid request", nil, err)
+		utils.RespondProblem(w, utils.CodeRequestMalformed, err.Error())
 		return
 	}
 
@@ -58,7 +58,7 @@ func (h *AuthHandlerImpl) Authenticate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.Respond(w, http.StatusBadRequest, "invalid request", nil, err)
+		utils.RespondProblem(w, utils.CodeRequestMalformed, err.Error())
 		return
 	}
 
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+type DocsHandler interface {
+	Spec(w http.ResponseWriter, r *http.Request)
+	UI(w http.ResponseWriter, r *http.Request)
+}
+
+type DocsHandlerImpl struct {
+	spec []byte
+}
+
+func NewDocsHandler(spec []byte) *DocsHandlerImpl {
+	return &DocsHandlerImpl{
+		spec: spec,
+	}
+}
+
+// Spec serves the raw OpenAPI document, e.g. for client generators.
+func (h *DocsHandlerImpl) Spec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(h.spec)
+}
+
+// UI serves a Swagger UI page for the spec served next to it.
+func (h *DocsHandlerImpl) UI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(swaggerUI))
+}
+
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Wall-E API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "openapi.yaml", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"broker/internal/middlewares"
+	"broker/internal/stream"
+	"broker/internal/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+type StreamHandler interface {
+	Stream(w http.ResponseWriter, r *http.Request)
+	Token(w http.ResponseWriter, r *http.Request)
+}
+
+type StreamHandlerImpl struct {
+	hub       *stream.Hub
+	heartbeat time.Duration
+	// secret signs the stream tokens, which expire after tokenTTL.
+	secret   string
+	tokenTTL time.Duration
+	upgrader websocket.Upgrader
+	log      *logrus.Logger
+}
+
+func NewStreamHandler(hub *stream.Hub, heartbeat time.Duration, secret string, tokenTTL time.Duration, allowedOrigins []string, log *logrus.Logger) *StreamHandlerImpl {
+	return &StreamHandlerImpl{
+		hub:       hub,
+		heartbeat: heartbeat,
+		secret:    secret,
+		tokenTTL:  tokenTTL,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: checkOrigin(allowedOrigins),
+		},
+		log: log,
+	}
+}
+
+// Stream pushes the authenticated user's events over WebSocket when the client asks
+// for an upgrade, and over Server-Sent Events otherwise.
+func (h *StreamHandlerImpl) Stream(w http.ResponseWriter, r *http.Request) {
+	userID := middlewares.GetUserID(r.Context())
+
+	// Browsers can't set headers on EventSource reconnects to other hosts or on
+	// WebSocket handshakes, so the resume point is also accepted as a query param.
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventID")
+	}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		h.streamWebSocket(w, r, userID, lastEventID)
+		return
+	}
+	h.streamSSE(w, r, userID, lastEventID)
+}
+
+// Token issues the authenticated user a short-lived token for browser clients,
+// which can't send an Authorization header, to open the stream with. It is
+// returned to pass as a query parameter and set as a cookie scoped to the
+// stream.
+func (h *StreamHandlerImpl) Token(w http.ResponseWriter, r *http.Request) {
+	userID := middlewares.GetUserID(r.Context())
+
+	token, expiresAt, err := middlewares.IssueStreamToken(h.secret, userID, h.tokenTTL)
+	if err != nil {
+		h.log.WithError(err).Error("Failed to issue stream token")
+		utils.RespondProblem(w, utils.CodeInternal, "failed to issue stream token")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     middlewares.StreamTokenCookie,
+		Value:    token,
+		Path:     strings.TrimSuffix(r.URL.Path, "/token"),
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteStrictMode,
+	})
+	utils.Respond(
+		w,
+		http.StatusOK,
+		"stream token issued successfully",
+		map[string]any{
+			"token":      token,
+			"expires_at": expiresAt.UTC().Format(time.RFC3339),
+		},
+		nil,
+	)
+}
+
+// checkOrigin admits WebSocket handshakes from the stream's own origin and the
+// allowed ones. Browsers don't apply CORS to handshakes, so any site could
+// otherwise open the stream with the user's stream cookie. "*" admits no other
+// site, as CORS doesn't let any site send credentials either: list the origins
+// of browser clients served elsewhere. Clients other than browsers send no
+// Origin and are admitted.
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		if strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		for _, allowed := range allowedOrigins {
+			if allowed != "*" && strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (h *StreamHandlerImpl) streamSSE(w http.ResponseWriter, r *http.Request, userID int, lastEventID string) {
+	rc := http.NewResponseController(w)
+
+	sub, backlog := h.hub.Subscribe(userID, lastEventID)
+	defer h.hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range backlog {
+		if err := writeSSE(w, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		h.log.WithError(err).Error("Response writer does not support streaming")
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event := <-sub.Events():
+			if err := writeSSE(w, event); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, event stream.Event) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
+
+func (h *StreamHandlerImpl) streamWebSocket(w http.ResponseWriter, r *http.Request, userID int, lastEventID string) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written an error response.
+		h.log.WithError(err).Warn("Failed to upgrade stream connection")
+		return
+	}
+	defer conn.Close()
+
+	sub, backlog := h.hub.Subscribe(userID, lastEventID)
+	defer h.hub.Unsubscribe(sub)
+
+	// Clients don't send anything, but reading is required to process pongs and close frames.
+	closed := make(chan struct{})
+	conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, event := range backlog {
+		if err := writeWebSocket(conn, event); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-closed:
+			return
+		case <-sub.Done():
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "reconnect"), time.Now().Add(time.Second))
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.heartbeat)); err != nil {
+				return
+			}
+		case event := <-sub.Events():
+			if err := writeWebSocket(conn, event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeWebSocket(conn *websocket.Conn, event stream.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, payload)
+}
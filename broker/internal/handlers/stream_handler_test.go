@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		allowedOrigins []string
+		origin         string
+		expected       bool
+	}{
+		{
+			name:           "when the handshake comes from an allowed origin, it should admit it",
+			allowedOrigins: []string{"https://app.example.com"},
+			origin:         "https://app.example.com",
+			expected:       true,
+		},
+		{
+			name:           "when the handshake comes from another origin, it should refuse it",
+			allowedOrigins: []string{"https://app.example.com"},
+			origin:         "https://evil.example.net",
+		},
+		{
+			name:           "when any origin is allowed, it should still refuse other sites",
+			allowedOrigins: []string{"*"},
+			origin:         "https://evil.example.net",
+		},
+		{
+			name:           "when the handshake comes from the stream's own origin, it should admit it",
+			allowedOrigins: []string{"*"},
+			origin:         "https://api.example.com",
+			expected:       true,
+		},
+		{
+			name:           "when the handshake has no origin, it should admit it",
+			allowedOrigins: []string{"https://app.example.com"},
+			expected:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "https://api.example.com/api/v1/stream", nil)
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+
+			assert.Equal(t, tc.expected, checkOrigin(tc.allowedOrigins)(r))
+		})
+	}
+}
@@ -2,46 +2,285 @@ package handlers
 
 import (
 	"broker/internal/clients"
+	"broker/internal/middlewares"
 	"broker/internal/models"
+	"broker/internal/money"
+	"broker/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
-	"github.com/gin-gonic/gin"
+	"github.com/go-chi/chi/v5"
 )
 
 type TransactionHandler interface {
-	Deposit(*gin.Context)
+	Deposit(w http.ResponseWriter, r *http.Request)
+	Withdraw(w http.ResponseWriter, r *http.Request)
+	Transfer(w http.ResponseWriter, r *http.Request)
+	GetTransaction(w http.ResponseWriter, r *http.Request)
+	ListTransactions(w http.ResponseWriter, r *http.Request)
 }
 
 type TransactionHandlerImpl struct {
 	transactionClient *clients.TransactionClient
+	walletClient      *clients.WalletClient
 }
 
-func NewTransactionHandler(transactionClient *clients.TransactionClient) *TransactionHandlerImpl {
+func NewTransactionHandler(transactionClient *clients.TransactionClient, walletClient *clients.WalletClient) *TransactionHandlerImpl {
 	return &TransactionHandlerImpl{
 		transactionClient: transactionClient,
+		walletClient:      walletClient,
 	}
 }
 
-func (h *TransactionHandlerImpl) Deposit(c *gin.Context) {
-	reqValue, exists := c.Get("transactionRequest")
-	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction request not found"})
+func (h *TransactionHandlerImpl) Deposit(w http.ResponseWriter, r *http.Request) {
+	h.initiate(w, r, models.Deposit)
+}
+
+func (h *TransactionHandlerImpl) Withdraw(w http.ResponseWriter, r *http.Request) {
+	h.initiate(w, r, models.Withdraw)
+}
+
+// initiate validates a deposit or withdrawal request against the caller's wallet
+// and hands it to the transaction service.
+func (h *TransactionHandlerImpl) initiate(w http.ResponseWriter, r *http.Request, txnType models.TransactionType) {
+	var req models.TransactionRequest
+	if !decodeMoneyRequest(w, r, &req) {
+		return
+	}
+
+	// The Idempotency-Key header doubles as the transaction idempotency key.
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get(middlewares.IdempotencyKeyHeader)
+	}
+	if req.WalletID == "" || req.IdempotencyKey == "" {
+		utils.RespondProblem(w, utils.CodeRequestInvalid, "missing wallet_id or idempotency_key")
+		return
+	}
+	// Transaction keys are unique across users.
+	req.IdempotencyKey = middlewares.ScopedIdempotencyKey(r.Context(), req.IdempotencyKey)
+
+	ctx := r.Context()
+
+	isOwner, closed, err := h.walletClient.WalletAccess(ctx, int64(middlewares.GetUserID(ctx)), req.WalletID)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+	if !isOwner {
+		utils.RespondProblem(w, utils.CodeWalletForbidden, "wallet does not belong to user")
+		return
+	}
+	if closed {
+		utils.RespondProblem(w, utils.CodeWalletClosed, "wallet is closed")
 		return
 	}
 
-	req, ok := reqValue.(models.TransactionRequest)
+	balance, ok := h.walletBalance(w, r, req.WalletID, &req.Currency, req.Amount)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid transaction request type"})
 		return
 	}
 
-	ctx := c.Request.Context()
+	var txID string
+	if txnType == models.Withdraw {
+		if !coversAmount(w, balance, req.Amount) {
+			return
+		}
+		txID, err = h.transactionClient.Withdraw(ctx, req)
+	} else {
+		txID, err = h.transactionClient.Deposit(ctx, req)
+	}
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(
+		w,
+		http.StatusOK,
+		"transaction initiated successfully",
+		map[string]string{
+			"transaction_id": txID,
+		},
+		nil,
+	)
+}
+
+// Transfer moves money from one of the caller's wallets to any active wallet,
+// including other users' ones.
+func (h *TransactionHandlerImpl) Transfer(w http.ResponseWriter, r *http.Request) {
+	var req models.TransferRequest
+	if !decodeMoneyRequest(w, r, &req) {
+		return
+	}
+
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get(middlewares.IdempotencyKeyHeader)
+	}
+	if req.SourceWalletID == "" || req.DestinationWalletID == "" || req.IdempotencyKey == "" {
+		utils.RespondProblem(w, utils.CodeRequestInvalid, "missing source_wallet_id, destination_wallet_id or idempotency_key")
+		return
+	}
+	req.IdempotencyKey = middlewares.ScopedIdempotencyKey(r.Context(), req.IdempotencyKey)
+
+	ctx := r.Context()
+
+	// Only the source has to be the caller's. The wallet service fails the
+	// transfer if the destination doesn't exist or is closed.
+	isOwner, closed, err := h.walletClient.WalletAccess(ctx, int64(middlewares.GetUserID(ctx)), req.SourceWalletID)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+	if !isOwner {
+		utils.RespondProblem(w, utils.CodeWalletForbidden, "source wallet does not belong to user")
+		return
+	}
+	if closed {
+		utils.RespondProblem(w, utils.CodeWalletClosed, "source wallet is closed")
+		return
+	}
+	balance, ok := h.walletBalance(w, r, req.SourceWalletID, &req.Currency, req.Amount)
+	if !ok || !coversAmount(w, balance, req.Amount) {
+		return
+	}
+
+	transfer, err := h.transactionClient.Transfer(ctx, req)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "transfer initiated successfully", transfer, nil)
+}
+
+// walletBalance returns the wallet's balance and settles the currency of the
+// request against it. A request naming no currency is in the wallet's, one in
+// another currency or finer than its minor unit is refused.
+func (h *TransactionHandlerImpl) walletBalance(w http.ResponseWriter, r *http.Request, walletID string, currency *money.Currency, amount money.Amount) (*models.ViewBalanceResponse, bool) {
+	balance, err := h.walletClient.ViewBalance(r.Context(), walletID)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return nil, false
+	}
+	if *currency == "" {
+		*currency = balance.Currency
+	}
+	if *currency != balance.Currency {
+		utils.RespondProblem(w, utils.CodeCurrencyMismatch, fmt.Sprintf("wallet is in %s, not %s", balance.Currency, *currency))
+		return nil, false
+	}
+	if !fitsCurrency(w, *currency, amount) {
+		return nil, false
+	}
+	return balance, true
+}
+
+// fitsCurrency refuses amounts finer than the currency's minor unit, such as
+// 10.50 JPY. Requests naming no currency are checked by the wallet service.
+func fitsCurrency(w http.ResponseWriter, currency money.Currency, amount money.Amount) bool {
+	if currency == "" {
+		return true
+	}
+	if err := currency.Check(amount); err != nil {
+		utils.RespondProblem(w, utils.CodeAmountInvalid, err.Error(), utils.Violation{Field: "body.amount", Message: err.Error()})
+		return false
+	}
+	return true
+}
+
+// coversAmount refuses what the available balance clearly can't cover. The
+// wallet service checks again when it debits, concurrent debits and holds may
+// get there first.
+func coversAmount(w http.ResponseWriter, balance *models.ViewBalanceResponse, amount money.Amount) bool {
+	if balance.Available < amount {
+		utils.RespondProblem(w, utils.CodeInsufficientFunds, fmt.Sprintf("wallet available balance is %s", balance.Available))
+		return false
+	}
+	return true
+}
+
+// decodeMoneyRequest decodes a request with an amount or a currency, reporting
+// amounts that aren't a whole number of cents as transaction.amount_invalid and
+// unsupported currencies as currency.invalid.
+func decodeMoneyRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		if errors.Is(err, money.ErrInvalid) || errors.Is(err, money.ErrPrecision) {
+			utils.RespondProblem(w, utils.CodeAmountInvalid, err.Error(), utils.Violation{Field: "body.amount", Message: err.Error()})
+			return false
+		}
+		if errors.Is(err, money.ErrCurrency) {
+			utils.RespondProblem(w, utils.CodeCurrencyInvalid, err.Error(), utils.Violation{Field: "body.currency", Message: err.Error()})
+			return false
+		}
+		utils.RespondProblem(w, utils.CodeRequestMalformed, err.Error())
+		return false
+	}
+	return true
+}
+
+func (h *TransactionHandlerImpl) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	transaction, err := h.transactionClient.GetTransaction(ctx, chi.URLParam(r, "transactionID"))
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	// Transactions of other users' wallets are reported as missing, not forbidden,
+	// so that transaction IDs can't be probed.
+	isOwner, err := h.walletClient.IsWalletOwner(ctx, int64(middlewares.GetUserID(ctx)), transaction.WalletID)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+	if !isOwner {
+		utils.RespondProblem(w, utils.CodeTransactionNotFound, "transaction not found")
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "transaction retrieved successfully", transaction, nil)
+}
+
+func (h *TransactionHandlerImpl) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := models.ListTransactionsRequest{
+		WalletID: chi.URLParam(r, "walletID"),
+		Cursor:   query.Get("cursor"),
+		Type:     query.Get("type"),
+		Status:   query.Get("status"),
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			utils.RespondProblem(w, utils.CodeRequestInvalid, "invalid limit", utils.Violation{Field: "query.limit", Message: "must be an integer"})
+			return
+		}
+		req.Limit = limit
+	}
+
+	ctx := r.Context()
+
+	isOwner, err := h.walletClient.IsWalletOwner(ctx, int64(middlewares.GetUserID(ctx)), req.WalletID)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+	if !isOwner {
+		utils.RespondProblem(w, utils.CodeWalletForbidden, "wallet does not belong to user")
+		return
+	}
 
-	txID, err := h.transactionClient.Deposit(ctx, req)
+	page, err := h.transactionClient.ListTransactions(ctx, req)
 	if err != nil {
-		//utils.HandleGRPCError(c, err)
+		utils.HandleGRPCError(w, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"transaction_id": txID, "status": "transaction initiated successfully"})
+	utils.Respond(w, http.StatusOK, "transactions retrieved successfully", page, nil)
 }
@@ -2,41 +2,52 @@ package handlers
 
 import (
 	"broker/internal/clients"
+	"broker/internal/middlewares"
 	"broker/internal/models"
 	"broker/internal/utils"
 	"encoding/json"
-	"errors"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
 )
 
 type WalletHandler interface {
 	CreateWallet(w http.ResponseWriter, r *http.Request)
 	ViewBalance(w http.ResponseWriter, r *http.Request)
+	ListWallets(w http.ResponseWriter, r *http.Request)
+	GetWallet(w http.ResponseWriter, r *http.Request)
+	RenameWallet(w http.ResponseWriter, r *http.Request)
+	CloseWallet(w http.ResponseWriter, r *http.Request)
+	GetLedger(w http.ResponseWriter, r *http.Request)
+	PlaceHold(w http.ResponseWriter, r *http.Request)
+	CaptureHold(w http.ResponseWriter, r *http.Request)
+	VoidHold(w http.ResponseWriter, r *http.Request)
+	CreateQuote(w http.ResponseWriter, r *http.Request)
+	ExecuteConversion(w http.ResponseWriter, r *http.Request)
 	HealthCheck(w http.ResponseWriter, r *http.Request)
 }
 
 type WalletHandlerImpl struct {
-	walletClient *clients.WalletClient
+	walletClient      *clients.WalletClient
+	transactionClient *clients.TransactionClient
 }
 
-func NewWalletHandler(walletClient *clients.WalletClient) *WalletHandlerImpl {
+func NewWalletHandler(walletClient *clients.WalletClient, transactionClient *clients.TransactionClient) *WalletHandlerImpl {
 	return &WalletHandlerImpl{
-		walletClient: walletClient,
+		walletClient:      walletClient,
+		transactionClient: transactionClient,
 	}
 }
 
 func (h *WalletHandlerImpl) CreateWallet(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Name string `json:"name"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.Respond(w, http.StatusBadRequest, "invalid request", nil, err)
+	var req models.CreateWalletRequest
+	if !decodeMoneyRequest(w, r, &req) {
 		return
 	}
 
-	walletID, err := h.walletClient.CreateWallet(r.Context(), req.Name)
+	walletID, err := h.walletClient.CreateWallet(r.Context(), req)
 	if err != nil {
 		utils.HandleGRPCError(w, err)
 		return
@@ -57,7 +68,7 @@ func (h *WalletHandlerImpl) CreateWallet(w http.ResponseWriter, r *http.Request)
 func (h *WalletHandlerImpl) ViewBalance(w http.ResponseWriter, r *http.Request) {
 	walletID := r.URL.Query().Get("walletID")
 	if walletID == "" {
-		utils.Respond(w, http.StatusBadRequest, "missing walletID", nil, errors.New("missing walletID"))
+		utils.RespondProblem(w, utils.CodeRequestInvalid, "missing walletID", utils.Violation{Field: "query.walletID", Message: "parameter is required"})
 		return
 	}
 
@@ -67,19 +78,195 @@ func (h *WalletHandlerImpl) ViewBalance(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	utils.Respond(
-		w,
-		http.StatusOK,
-		"wallet balance retrieved successfully",
-		models.ViewBalanceResponse{
-			Name:    response.Name,
-			Balance: response.Balance,
-		},
-		nil,
-	)
+	utils.Respond(w, http.StatusOK, "wallet balance retrieved successfully", response, nil)
 	return
 }
 
+func (h *WalletHandlerImpl) ListWallets(w http.ResponseWriter, r *http.Request) {
+	wallets, err := h.walletClient.ListWallets(r.Context())
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "wallets retrieved successfully", wallets, nil)
+}
+
+func (h *WalletHandlerImpl) GetWallet(w http.ResponseWriter, r *http.Request) {
+	wallet, err := h.walletClient.GetWallet(r.Context(), chi.URLParam(r, "walletID"))
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "wallet retrieved successfully", wallet, nil)
+}
+
+func (h *WalletHandlerImpl) RenameWallet(w http.ResponseWriter, r *http.Request) {
+	var req models.RenameWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondProblem(w, utils.CodeRequestMalformed, err.Error())
+		return
+	}
+
+	wallet, err := h.walletClient.RenameWallet(r.Context(), chi.URLParam(r, "walletID"), req.Name)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "wallet renamed successfully", wallet, nil)
+}
+
+// CloseWallet closes an empty wallet. Pending transactions are checked here
+// since only the transaction service knows about them; the wallet service
+// refuses a non-zero balance.
+func (h *WalletHandlerImpl) CloseWallet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	walletID := chi.URLParam(r, "walletID")
+
+	// Scoped to the caller, so other users' wallets are not found.
+	if _, err := h.walletClient.GetWallet(ctx, walletID); err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	pending, err := h.transactionClient.ListTransactions(ctx, models.ListTransactionsRequest{
+		WalletID: walletID,
+		Status:   "PENDING",
+		Limit:    1,
+	})
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+	if len(pending.Transactions) > 0 {
+		utils.RespondProblem(w, utils.CodeWalletPending, "wait for pending transactions to complete before closing the wallet")
+		return
+	}
+
+	wallet, err := h.walletClient.CloseWallet(ctx, walletID)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "wallet closed successfully", wallet, nil)
+}
+
+// GetLedger lists the ledger entries of one of the caller's wallets, oldest first.
+func (h *WalletHandlerImpl) GetLedger(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := models.LedgerRequest{
+		WalletID: chi.URLParam(r, "walletID"),
+		Cursor:   query.Get("cursor"),
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			utils.RespondProblem(w, utils.CodeRequestInvalid, "invalid limit", utils.Violation{Field: "query.limit", Message: "must be an integer"})
+			return
+		}
+		req.Limit = limit
+	}
+
+	ctx := r.Context()
+
+	// The wallet service doesn't scope the ledger to the caller.
+	isOwner, err := h.walletClient.IsWalletOwner(ctx, int64(middlewares.GetUserID(ctx)), req.WalletID)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+	if !isOwner {
+		utils.RespondProblem(w, utils.CodeWalletForbidden, "wallet does not belong to user")
+		return
+	}
+
+	page, err := h.walletClient.GetLedger(ctx, req)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "ledger retrieved successfully", page, nil)
+}
+
+// PlaceHold sets part of the wallet's available balance aside for a later
+// capture.
+func (h *WalletHandlerImpl) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	var req models.PlaceHoldRequest
+	if !decodeMoneyRequest(w, r, &req) || !fitsCurrency(w, req.Currency, req.Amount) {
+		return
+	}
+
+	hold, err := h.walletClient.PlaceHold(r.Context(), chi.URLParam(r, "walletID"), req)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "hold placed successfully", hold, nil)
+}
+
+// CaptureHold debits all or part of a hold and releases the rest.
+func (h *WalletHandlerImpl) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	// Without a body the whole hold is captured.
+	var req models.CaptureHoldRequest
+	if r.ContentLength != 0 && !decodeMoneyRequest(w, r, &req) {
+		return
+	}
+	if req.Amount != nil && !fitsCurrency(w, req.Currency, *req.Amount) {
+		return
+	}
+
+	hold, err := h.walletClient.CaptureHold(r.Context(), chi.URLParam(r, "holdID"), req)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "hold captured successfully", hold, nil)
+}
+
+func (h *WalletHandlerImpl) VoidHold(w http.ResponseWriter, r *http.Request) {
+	hold, err := h.walletClient.VoidHold(r.Context(), chi.URLParam(r, "holdID"))
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "hold voided successfully", hold, nil)
+}
+
+// CreateQuote locks an exchange rate for converting an amount from one wallet
+// into another wallet in a different currency.
+func (h *WalletHandlerImpl) CreateQuote(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateQuoteRequest
+	if !decodeMoneyRequest(w, r, &req) || !fitsCurrency(w, req.Currency, req.Amount) {
+		return
+	}
+
+	quote, err := h.walletClient.CreateQuote(r.Context(), req)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "quote created successfully", quote, nil)
+}
+
+// ExecuteConversion converts at the quote's rate, before it expires.
+func (h *WalletHandlerImpl) ExecuteConversion(w http.ResponseWriter, r *http.Request) {
+	quote, err := h.walletClient.ExecuteConversion(r.Context(), chi.URLParam(r, "quoteID"))
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "conversion executed successfully", quote, nil)
+}
+
 func (h *WalletHandlerImpl) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"broker/internal/clients"
+	"broker/internal/models"
+	"broker/internal/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type WebhookHandler interface {
+	Create(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	RotateSecret(w http.ResponseWriter, r *http.Request)
+	Enable(w http.ResponseWriter, r *http.Request)
+	ListDeliveries(w http.ResponseWriter, r *http.Request)
+	Redeliver(w http.ResponseWriter, r *http.Request)
+}
+
+type WebhookHandlerImpl struct {
+	webhookClient *clients.WebhookClient
+}
+
+func NewWebhookHandler(webhookClient *clients.WebhookClient) *WebhookHandlerImpl {
+	return &WebhookHandlerImpl{
+		webhookClient: webhookClient,
+	}
+}
+
+func (h *WebhookHandlerImpl) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondProblem(w, utils.CodeRequestMalformed, err.Error())
+		return
+	}
+
+	endpoint, err := h.webhookClient.CreateEndpoint(r.Context(), req)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusCreated, "webhook endpoint created successfully", endpoint, nil)
+}
+
+func (h *WebhookHandlerImpl) List(w http.ResponseWriter, r *http.Request) {
+	endpoints, err := h.webhookClient.ListEndpoints(r.Context())
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "webhook endpoints retrieved successfully", endpoints, nil)
+}
+
+func (h *WebhookHandlerImpl) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.webhookClient.DeleteEndpoint(r.Context(), chi.URLParam(r, "webhookID")); err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "webhook endpoint deleted successfully", nil, nil)
+}
+
+func (h *WebhookHandlerImpl) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	endpoint, err := h.webhookClient.RotateSecret(r.Context(), chi.URLParam(r, "webhookID"))
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "webhook secret rotated successfully", endpoint, nil)
+}
+
+func (h *WebhookHandlerImpl) Enable(w http.ResponseWriter, r *http.Request) {
+	endpoint, err := h.webhookClient.EnableEndpoint(r.Context(), chi.URLParam(r, "webhookID"))
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "webhook endpoint enabled successfully", endpoint, nil)
+}
+
+func (h *WebhookHandlerImpl) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			utils.RespondProblem(w, utils.CodeRequestInvalid, "invalid limit", utils.Violation{Field: "query.limit", Message: "must be an integer"})
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.webhookClient.ListDeliveries(r.Context(), chi.URLParam(r, "webhookID"), limit)
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusOK, "webhook deliveries retrieved successfully", deliveries, nil)
+}
+
+func (h *WebhookHandlerImpl) Redeliver(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.webhookClient.Redeliver(r.Context(), chi.URLParam(r, "webhookID"), chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		utils.HandleGRPCError(w, err)
+		return
+	}
+
+	utils.Respond(w, http.StatusAccepted, "webhook delivery queued for redelivery", delivery, nil)
+}
@@ -0,0 +1,92 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces the broker's keys in a shared Redis.
+const keyPrefix = "broker:idempotency:"
+
+// RedisStore keeps records in Redis, shared by every broker instance, so a
+// retry is replayed whichever instance it reaches. Records expire with their
+// key, completed ones after ttl and reservations after lockTTL.
+type RedisStore struct {
+	client  redis.UniversalClient
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+func NewRedisStore(client redis.UniversalClient, ttl, lockTTL time.Duration) *RedisStore {
+	return &RedisStore{
+		client:  client,
+		ttl:     ttl,
+		lockTTL: lockTTL,
+	}
+}
+
+func (s *RedisStore) Begin(ctx context.Context, key, fingerprint string) (*Record, error) {
+	reservation, err := json.Marshal(Record{
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(s.lockTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The key may expire between the two calls, try again then.
+	for {
+		reserved, err := s.client.SetNX(ctx, keyPrefix+key, reservation, s.lockTTL).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		stored, err := s.client.Get(ctx, keyPrefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+		}
+
+		var record Record
+		if err := json.Unmarshal(stored, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode idempotency record: %w", err)
+		}
+		if record.Fingerprint != fingerprint {
+			return nil, ErrMismatch
+		}
+		if !record.Completed {
+			return nil, ErrInFlight
+		}
+		return &record, nil
+	}
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key string, record Record) error {
+	record.Completed = true
+	record.ExpiresAt = time.Now().Add(s.ttl)
+	stored, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Set(ctx, keyPrefix+key, stored, s.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, keyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
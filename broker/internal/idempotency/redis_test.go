@@ -0,0 +1,117 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisStore(t *testing.T) {
+	t.Parallel()
+
+	completed := Record{Fingerprint: "f1", StatusCode: http.StatusCreated, ContentType: "application/json", Body: []byte(`{"id":"tx-1"}`)}
+
+	testCases := []struct {
+		name        string
+		prepare     func(ctx context.Context, other *RedisStore, server *miniredis.Miniredis)
+		fingerprint string
+		expected    *Record
+		expectedErr error
+	}{
+		{
+			name:        "when the key is new, it should reserve it",
+			prepare:     func(ctx context.Context, other *RedisStore, server *miniredis.Miniredis) {},
+			fingerprint: "f1",
+		},
+		{
+			name: "when another instance completed the request, it should replay its response",
+			prepare: func(ctx context.Context, other *RedisStore, server *miniredis.Miniredis) {
+				other.Begin(ctx, "1:k1", "f1")
+				other.Complete(ctx, "1:k1", completed)
+			},
+			fingerprint: "f1",
+			expected:    &completed,
+		},
+		{
+			name: "when another instance is still processing the request, it should return in flight",
+			prepare: func(ctx context.Context, other *RedisStore, server *miniredis.Miniredis) {
+				other.Begin(ctx, "1:k1", "f1")
+			},
+			fingerprint: "f1",
+			expectedErr: ErrInFlight,
+		},
+		{
+			name: "when the key was used for a different request, it should return a mismatch",
+			prepare: func(ctx context.Context, other *RedisStore, server *miniredis.Miniredis) {
+				other.Begin(ctx, "1:k1", "f2")
+			},
+			fingerprint: "f1",
+			expectedErr: ErrMismatch,
+		},
+		{
+			name: "when the record of a different request expired, it should reserve the key",
+			prepare: func(ctx context.Context, other *RedisStore, server *miniredis.Miniredis) {
+				other.Begin(ctx, "1:k1", "f2")
+				server.FastForward(2 * time.Hour)
+			},
+			fingerprint: "f1",
+		},
+		{
+			name: "when a request was never completed, it should reserve the key once the lock expires",
+			prepare: func(ctx context.Context, other *RedisStore, server *miniredis.Miniredis) {
+				other.Begin(ctx, "1:k1", "f1")
+				server.FastForward(2 * time.Minute)
+			},
+			fingerprint: "f1",
+		},
+		{
+			name: "when a completed request is retried after the lock would have expired, it should replay its response",
+			prepare: func(ctx context.Context, other *RedisStore, server *miniredis.Miniredis) {
+				other.Begin(ctx, "1:k1", "f1")
+				other.Complete(ctx, "1:k1", completed)
+				server.FastForward(2 * time.Minute)
+			},
+			fingerprint: "f1",
+			expected:    &completed,
+		},
+		{
+			name: "when another instance released the key, it should reserve it again",
+			prepare: func(ctx context.Context, other *RedisStore, server *miniredis.Miniredis) {
+				other.Begin(ctx, "1:k1", "f1")
+				other.Release(ctx, "1:k1")
+			},
+			fingerprint: "f1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := miniredis.RunT(t)
+			newStore := func() *RedisStore {
+				client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+				t.Cleanup(func() { client.Close() })
+				return NewRedisStore(client, time.Hour, time.Minute)
+			}
+			ctx := context.Background()
+			tc.prepare(ctx, newStore(), server)
+
+			record, err := newStore().Begin(ctx, "1:k1", tc.fingerprint)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expected == nil {
+				assert.Nil(t, record)
+				return
+			}
+			assert.Equal(t, tc.expected.StatusCode, record.StatusCode)
+			assert.Equal(t, tc.expected.ContentType, record.ContentType)
+			assert.Equal(t, tc.expected.Body, record.Body)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInFlight is returned when a request with the same key is still being processed.
+	ErrInFlight = errors.New("request with this idempotency key is in progress")
+	// ErrMismatch is returned when a key is reused for a different request.
+	ErrMismatch = errors.New("idempotency key was used for a different request")
+)
+
+// Record is the stored outcome of a request.
+type Record struct {
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+type Store interface {
+	// Begin reserves key for a request with fingerprint. It returns the completed
+	// record to replay if there is one, ErrInFlight or ErrMismatch.
+	Begin(ctx context.Context, key, fingerprint string) (*Record, error)
+	// Complete stores the response for key.
+	Complete(ctx context.Context, key string, record Record) error
+	// Release drops the reservation so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryStore keeps records in process memory. Replays are only guaranteed when
+// retries reach the same broker instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+	// ttl is how long completed records are replayed, lockTTL how long a
+	// reservation holds its key when it is never completed or released.
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+func NewMemoryStore(ttl, lockTTL time.Duration) *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]*Record),
+		ttl:     ttl,
+		lockTTL: lockTTL,
+	}
+}
+
+func (s *MemoryStore) Begin(_ context.Context, key, fingerprint string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if ok && time.Now().After(record.ExpiresAt) {
+		delete(s.records, key)
+		ok = false
+	}
+
+	if !ok {
+		s.records[key] = &Record{
+			Fingerprint: fingerprint,
+			ExpiresAt:   time.Now().Add(s.lockTTL),
+		}
+		return nil, nil
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, ErrMismatch
+	}
+	if !record.Completed {
+		return nil, ErrInFlight
+	}
+
+	replay := *record
+	return &replay, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key string, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record.Completed = true
+	record.ExpiresAt = time.Now().Add(s.ttl)
+	s.records[key] = &record
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// Cleanup removes expired records every interval until ctx is cancelled.
+func (s *MemoryStore) Cleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			now := time.Now()
+			for key, record := range s.records {
+				if now.After(record.ExpiresAt) {
+					delete(s.records, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -2,12 +2,13 @@ package middlewares
 
 import (
 	"broker/internal/utils"
-	"errors"
+	"context"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc/metadata"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 )
@@ -16,42 +17,126 @@ type customClaims struct {
 	jwt.RegisteredClaims
 }
 
+const userIDKey ctxKey = ctxKey(1)
+
+const (
+	// streamAudience marks the short-lived tokens that only open the event
+	// stream, and that no other route accepts.
+	streamAudience = "stream"
+	// StreamTokenCookie and StreamTokenParam carry a stream token for browser
+	// EventSource and WebSocket clients, which can't send an Authorization
+	// header.
+	StreamTokenCookie = "stream_token"
+	StreamTokenParam  = "access_token"
+)
+
+// GetUserID returns the ID of the authenticated user, or 0 outside of Authenticate.
+func GetUserID(ctx context.Context) int {
+	userID, _ := ctx.Value(userIDKey).(int)
+	return userID
+}
+
 // Authenticate middleware for validating JWT tokens and appending user ID to the context
 func Authenticate(secret string, log *logrus.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			bearerToken := r.Header.Get("Authorization")
-			if bearerToken == "" {
-				utils.Respond(w, http.StatusUnauthorized, "missing token", nil, errors.New("unauthorized access"))
+			authenticateBearer(w, r, next, secret, log)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// AuthenticateStream is Authenticate for the event stream, which also accepts a
+// stream token from IssueStreamToken in the StreamTokenParam query parameter or
+// the StreamTokenCookie cookie when the request has no Authorization header.
+func AuthenticateStream(secret string, log *logrus.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "" {
+				authenticateBearer(w, r, next, secret, log)
 				return
 			}
 
-			var token string
-			if len(bearerToken) > 7 && bearerToken[:7] == "Bearer " {
-				token = bearerToken[7:]
-			} else {
-				utils.Respond(w, http.StatusUnauthorized, "invalid token format", nil, errors.New("unauthorized access"))
+			token := r.URL.Query().Get(StreamTokenParam)
+			if token == "" {
+				if cookie, err := r.Cookie(StreamTokenCookie); err == nil {
+					token = cookie.Value
+				}
+			}
+			if token == "" {
+				utils.RespondProblem(w, utils.CodeUnauthenticated, "missing token")
 				return
 			}
 
-			userID, err := validateToken(token, secret, log)
+			userID, err := validateToken(token, secret, streamAudience, log)
 			if err != nil {
-				utils.Respond(w, http.StatusUnauthorized, "invalid token", nil, err)
+				log.WithError(err).Debug("Rejected stream token")
+				utils.RespondProblem(w, utils.CodeTokenInvalid, "invalid token")
 				return
 			}
-
-			md := metadata.New(map[string]string{
-				"userID": strconv.Itoa(userID),
-			})
-			grpcCtx := metadata.NewOutgoingContext(r.Context(), md)
-
-			next.ServeHTTP(w, r.WithContext(grpcCtx))
+			next.ServeHTTP(w, r.WithContext(withUserID(r.Context(), userID)))
 		}
 		return http.HandlerFunc(fn)
 	}
 }
 
-func validateToken(token string, secret string, log *logrus.Logger) (int, error) {
+// IssueStreamToken returns a token for userID that only opens the event stream,
+// and when it expires.
+func IssueStreamToken(secret string, userID int, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, customClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			Audience:  jwt.ClaimStrings{streamAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign stream token: %w", err)
+	}
+	return signed, expiresAt, nil
+}
+
+func authenticateBearer(w http.ResponseWriter, r *http.Request, next http.Handler, secret string, log *logrus.Logger) {
+	bearerToken := r.Header.Get("Authorization")
+	if bearerToken == "" {
+		utils.RespondProblem(w, utils.CodeUnauthenticated, "missing token")
+		return
+	}
+
+	var token string
+	if len(bearerToken) > 7 && bearerToken[:7] == "Bearer " {
+		token = bearerToken[7:]
+	} else {
+		utils.RespondProblem(w, utils.CodeTokenInvalid, "invalid token format")
+		return
+	}
+
+	userID, err := validateToken(token, secret, "", log)
+	if err != nil {
+		log.WithError(err).Debug("Rejected token")
+		utils.RespondProblem(w, utils.CodeTokenInvalid, "invalid token")
+		return
+	}
+
+	next.ServeHTTP(w, r.WithContext(withUserID(r.Context(), userID)))
+}
+
+// withUserID adds the user to ctx and to the metadata of outgoing gRPC calls.
+func withUserID(ctx context.Context, userID int) context.Context {
+	md := metadata.New(map[string]string{
+		"userID": strconv.Itoa(userID),
+	})
+	grpcCtx := metadata.NewOutgoingContext(ctx, md)
+	return context.WithValue(grpcCtx, userIDKey, userID)
+}
+
+// validateToken returns the user of a token meant for audience. Access tokens
+// have none, and stream tokens are refused where they are expected.
+func validateToken(token string, secret string, audience string, log *logrus.Logger) (int, error) {
 	parsedToken, err := jwt.ParseWithClaims(token, &customClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Method.Alg())
@@ -72,6 +157,13 @@ func validateToken(token string, secret string, log *logrus.Logger) (int, error)
 	}
 	log.Info("Claims: ", claims, "subject: ", claims.Subject)
 
+	if audience == "" && slices.Contains(claims.Audience, streamAudience) {
+		return 0, fmt.Errorf("stream tokens are only accepted by the stream")
+	}
+	if audience != "" && !slices.Contains(claims.Audience, audience) {
+		return 0, fmt.Errorf("token is not meant for %s", audience)
+	}
+
 	userID, err := strconv.Atoi(claims.Subject)
 	if err != nil {
 		return 0, fmt.Errorf("failed to convert user ID to int: %v", err)
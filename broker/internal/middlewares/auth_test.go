@@ -0,0 +1,155 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "test-secret"
+
+// accessToken signs a token like the ones the auth service issues.
+func accessToken(t *testing.T, userID int) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, customClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString([]byte(testSecret))
+	assert.NoError(t, err)
+	return token
+}
+
+func streamToken(t *testing.T, userID int, ttl time.Duration) string {
+	token, _, err := IssueStreamToken(testSecret, userID, ttl)
+	assert.NoError(t, err)
+	return token
+}
+
+func TestAuthenticateStream(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		request        func(r *http.Request)
+		expectedStatus int
+		expectedUserID int
+	}{
+		{
+			name:           "when a stream token is in the query, it should authenticate its user",
+			request:        func(r *http.Request) { r.URL.RawQuery = StreamTokenParam + "=" + streamToken(t, 7, time.Minute) },
+			expectedStatus: http.StatusOK,
+			expectedUserID: 7,
+		},
+		{
+			name: "when a stream token is in the cookie, it should authenticate its user",
+			request: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: StreamTokenCookie, Value: streamToken(t, 7, time.Minute)})
+			},
+			expectedStatus: http.StatusOK,
+			expectedUserID: 7,
+		},
+		{
+			name:           "when an access token is in the Authorization header, it should authenticate its user",
+			request:        func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+accessToken(t, 7)) },
+			expectedStatus: http.StatusOK,
+			expectedUserID: 7,
+		},
+		{
+			name:           "when an access token is in the query, it should refuse it",
+			request:        func(r *http.Request) { r.URL.RawQuery = StreamTokenParam + "=" + accessToken(t, 7) },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "when the stream token has expired, it should refuse it",
+			request:        func(r *http.Request) { r.URL.RawQuery = StreamTokenParam + "=" + streamToken(t, 7, -time.Minute) },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "when there is no token, it should refuse the request",
+			request:        func(r *http.Request) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var userID int
+			handler := AuthenticateStream(testSecret, logrus.New())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID = GetUserID(r.Context())
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/stream", nil)
+			tc.request(r)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedUserID, userID)
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{
+			name:           "when the token is an access token, it should pass the request",
+			token:          accessToken(t, 7),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "when the token is a stream token, it should refuse it",
+			token:          streamToken(t, 7, time.Minute),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := Authenticate(testSecret, logrus.New())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/wallets", nil)
+			r.Header.Set("Authorization", "Bearer "+tc.token)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRedactedQuery(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		rawQuery string
+		expected string
+	}{
+		{name: "when the query has no stream token, it should keep it as is", rawQuery: "lastEventID=e1", expected: "lastEventID=e1"},
+		{name: "when the query has a stream token, it should redact it", rawQuery: "access_token=secret&lastEventID=e1", expected: "access_token=REDACTED&lastEventID=e1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, redactedQuery(&url.URL{RawQuery: tc.rawQuery}))
+		})
+	}
+}
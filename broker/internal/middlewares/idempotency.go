@@ -0,0 +1,146 @@
+package middlewares
+
+import (
+	"broker/internal/idempotency"
+	"broker/internal/utils"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Idempotency replays the stored response when a mutating request is retried with
+// the same Idempotency-Key. Keys are scoped per user; reusing one with a different
+// request is rejected with 422 and a duplicate that is still in flight with 409.
+// Without an authenticated user, as on /auth/register, keys are scoped by the
+// request instead, so only identical requests share one. Requests without the
+// header are passed through.
+func Idempotency(store idempotency.Store, log *logrus.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > 255 {
+				utils.RespondProblem(w, utils.CodeIdempotencyInvalid, "idempotency key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				utils.RespondProblem(w, utils.CodeRequestMalformed, "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			fingerprint := requestFingerprint(r, body)
+			scopedKey := ScopedIdempotencyKey(r.Context(), key)
+			if GetUserID(r.Context()) == 0 {
+				scopedKey = anonymousKey(key, fingerprint)
+			}
+
+			record, err := store.Begin(r.Context(), scopedKey, fingerprint)
+			switch {
+			case errors.Is(err, idempotency.ErrMismatch):
+				utils.RespondProblem(w, utils.CodeIdempotencyReused, err.Error())
+				return
+			case errors.Is(err, idempotency.ErrInFlight):
+				utils.RespondProblem(w, utils.CodeIdempotencyPending, err.Error())
+				return
+			case err != nil:
+				log.WithError(err).Error("Failed to check idempotency key")
+				utils.RespondProblem(w, utils.CodeInternal, "failed to check idempotency key")
+				return
+			case record != nil:
+				w.Header().Set("Content-Type", record.ContentType)
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(record.StatusCode)
+				w.Write(record.Body)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// The outcome is stored even if the client has gone, or its retries would
+			// find the key in flight until the reservation expires.
+			ctx := context.WithoutCancel(r.Context())
+
+			// Server errors are not final, let the client retry them with the same key.
+			if recorder.statusCode >= http.StatusInternalServerError {
+				if err := store.Release(ctx, scopedKey); err != nil {
+					log.WithError(err).Error("Failed to release idempotency key")
+				}
+				return
+			}
+
+			err = store.Complete(ctx, scopedKey, idempotency.Record{
+				Fingerprint: fingerprint,
+				StatusCode:  recorder.statusCode,
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			})
+			if err != nil {
+				log.WithError(err).Error("Failed to store idempotent response")
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// ScopedIdempotencyKey scopes a client's key to the authenticated user, so that
+// users picking the same key, such as a counter, don't share it. Keys passed on
+// to the services are scoped too.
+func ScopedIdempotencyKey(ctx context.Context, key string) string {
+	return fmt.Sprintf("%d:%s", GetUserID(ctx), key)
+}
+
+// anonymousKey scopes the key of an unauthenticated request by the request
+// itself, as there is no user to scope it to.
+func anonymousKey(key, fingerprint string) string {
+	h := sha256.Sum256([]byte(key + "\n" + fingerprint))
+	return "anonymous:" + hex.EncodeToString(h[:])
+}
+
+func isMutating(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder writes through to the client while keeping a copy of the response.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
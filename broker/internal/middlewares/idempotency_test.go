@@ -0,0 +1,164 @@
+package middlewares
+
+import (
+	"broker/internal/idempotency"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		key  string
+		body string
+		// disconnect cancels the request context once the handler has run.
+		disconnect bool
+	}
+
+	testCases := []struct {
+		name             string
+		handlerStatus    int
+		anonymous        bool
+		requests         []request
+		expectedStatuses []int
+		expectedCalls    int
+	}{
+		{
+			name:             "when a request is retried with the same key, it should replay the response",
+			handlerStatus:    http.StatusOK,
+			requests:         []request{{key: "k1", body: `{"amount":10}`}, {key: "k1", body: `{"amount":10}`}},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK},
+			expectedCalls:    1,
+		},
+		{
+			name:             "when a key is reused with a different body, it should return 422",
+			handlerStatus:    http.StatusOK,
+			requests:         []request{{key: "k1", body: `{"amount":10}`}, {key: "k1", body: `{"amount":20}`}},
+			expectedStatuses: []int{http.StatusOK, http.StatusUnprocessableEntity},
+			expectedCalls:    1,
+		},
+		{
+			name:             "when the handler fails with a server error, it should allow a retry",
+			handlerStatus:    http.StatusInternalServerError,
+			requests:         []request{{key: "k1", body: `{}`}, {key: "k1", body: `{}`}},
+			expectedStatuses: []int{http.StatusInternalServerError, http.StatusInternalServerError},
+			expectedCalls:    2,
+		},
+		{
+			name:             "when no key is sent, it should pass every request through",
+			handlerStatus:    http.StatusOK,
+			requests:         []request{{body: `{}`}, {body: `{}`}},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK},
+			expectedCalls:    2,
+		},
+		{
+			name:             "when the client disconnects once the request has run, it should still replay the response",
+			handlerStatus:    http.StatusOK,
+			requests:         []request{{key: "k1", body: `{}`, disconnect: true}, {key: "k1", body: `{}`}},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK},
+			expectedCalls:    1,
+		},
+		{
+			name:             "when no user is authenticated, it should replay an identical retry",
+			handlerStatus:    http.StatusOK,
+			anonymous:        true,
+			requests:         []request{{key: "k1", body: `{}`}, {key: "k1", body: `{}`}},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK},
+			expectedCalls:    1,
+		},
+		{
+			name:             "when unauthenticated callers send the same key with different requests, it should pass both through",
+			handlerStatus:    http.StatusOK,
+			anonymous:        true,
+			requests:         []request{{key: "k1", body: `{"username":"a"}`}, {key: "k1", body: `{"username":"b"}`}},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK},
+			expectedCalls:    2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			var disconnect context.CancelFunc
+			store := contextStore{idempotency.NewMemoryStore(time.Hour, time.Minute)}
+			handler := Idempotency(store, logrus.New())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(tc.handlerStatus)
+				if disconnect != nil {
+					disconnect()
+				}
+			}))
+
+			for i, req := range tc.requests {
+				r := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/deposit", strings.NewReader(req.body))
+				if req.key != "" {
+					r.Header.Set(IdempotencyKeyHeader, req.key)
+				}
+				if !tc.anonymous {
+					r = r.WithContext(context.WithValue(r.Context(), userIDKey, 1))
+				}
+				ctx, cancel := context.WithCancel(r.Context())
+				r = r.WithContext(ctx)
+				disconnect = nil
+				if req.disconnect {
+					disconnect = cancel
+				}
+				w := httptest.NewRecorder()
+
+				handler.ServeHTTP(w, r)
+				cancel()
+
+				assert.Equal(t, tc.expectedStatuses[i], w.Code)
+			}
+			assert.Equal(t, tc.expectedCalls, calls)
+		})
+	}
+}
+
+// contextStore fails like a networked store once the context is cancelled.
+type contextStore struct {
+	*idempotency.MemoryStore
+}
+
+func (s contextStore) Complete(ctx context.Context, key string, record idempotency.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Complete(ctx, key, record)
+}
+
+func (s contextStore) Release(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Release(ctx, key)
+}
+
+func TestScopedIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		userID   int
+		key      string
+		expected string
+	}{
+		{name: "when a user sends a key, it should scope it to them", userID: 1, key: "1", expected: "1:1"},
+		{name: "when another user sends the same key, it should scope it to them", userID: 2, key: "1", expected: "2:1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), userIDKey, tc.userID)
+			assert.Equal(t, tc.expected, ScopedIdempotencyKey(ctx, tc.key))
+		})
+	}
+}
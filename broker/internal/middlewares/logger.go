@@ -5,6 +5,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/trace"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -19,7 +20,7 @@ func Logger(log *logrus.Logger) func(http.Handler) http.Handler {
 					"status":     wrappedWriter.Status(),
 					"method":     r.Method,
 					"path":       r.URL.Path,
-					"query":      r.URL.RawQuery,
+					"query":      redactedQuery(r.URL),
 					"ip":         r.RemoteAddr,
 					"trace-id":   trace.SpanFromContext(r.Context()).SpanContext().TraceID().String(),
 					"latency":    time.Since(start).String(),
@@ -31,3 +32,13 @@ func Logger(log *logrus.Logger) func(http.Handler) http.Handler {
 		return http.HandlerFunc(fn)
 	}
 }
+
+// redactedQuery is the query of u without the value of a stream token.
+func redactedQuery(u *url.URL) string {
+	query := u.Query()
+	if !query.Has(StreamTokenParam) {
+		return u.RawQuery
+	}
+	query.Set(StreamTokenParam, "REDACTED")
+	return query.Encode()
+}
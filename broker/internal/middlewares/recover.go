@@ -8,7 +8,7 @@ import (
 	"net/http"
 )
 
-func Recover(log logrus.Logger) func(http.Handler) http.Handler {
+func Recover(log *logrus.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"broker/internal/utils"
 	"context"
 	"github.com/google/uuid"
 	"net/http"
@@ -10,25 +11,30 @@ type ctxKey int
 
 const ridKey ctxKey = ctxKey(0)
 
+// GetRequestID returns the ID assigned by RequestID, or "" outside of it.
 func GetRequestID(ctx context.Context) string {
-	return ctx.Value(ridKey).(string)
+	rid, _ := ctx.Value(ridKey).(string)
+	return rid
 }
 
+// RequestID reuses the X-Request-ID header of the request or generates one, and
+// echoes it on the response.
 func RequestID(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
-		rid := r.Header.Get("X-Request-ID")
+		rid := r.Header.Get(utils.RequestIDHeader)
 		if rid == "" {
-			uuid, err := uuid.NewV6()
-			rid = uuid.String()
+			id, err := uuid.NewV6()
 			if err != nil {
-				http.Error(w, "failed to generate request ID", http.StatusInternalServerError)
+				utils.RespondProblem(w, utils.CodeInternal, "failed to generate request ID")
 				return
 			}
-			ctx := context.WithValue(r.Context(), ridKey, rid)
-			w.Header().Set("X-Request-ID", rid)
-
-			next.ServeHTTP(w, r.WithContext(ctx))
+			rid = id.String()
 		}
+
+		ctx := context.WithValue(r.Context(), ridKey, rid)
+		w.Header().Set(utils.RequestIDHeader, rid)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
 }
@@ -0,0 +1,104 @@
+package middlewares
+
+import (
+	"broker/internal/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/sirupsen/logrus"
+)
+
+// ValidateRequest checks requests against the OpenAPI spec and rejects the ones that
+// don't match with a problem listing every invalid field. Routes missing from the spec are passed through.
+func ValidateRequest(router routers.Router, log *logrus.Logger) func(http.Handler) http.Handler {
+	options := &openapi3filter.Options{
+		MultiError: true,
+		// Tokens are verified by the Authenticate middleware.
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			})
+			if err != nil {
+				fieldErrors := toFieldErrors(err)
+				log.WithField("errors", fieldErrors).Debug("Request failed validation")
+				utils.RespondProblem(w, utils.CodeRequestInvalid, "one or more fields are invalid", fieldErrors...)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func toFieldErrors(err error) []utils.Violation {
+	var multi openapi3.MultiError
+	if !errors.As(err, &multi) {
+		multi = openapi3.MultiError{err}
+	}
+
+	var fieldErrors []utils.Violation
+	seen := make(map[string]bool)
+	add := func(field, message string) {
+		// A value can break several rules of the same schema, e.g. minimum and
+		// exclusiveMinimum; the first one is enough to fix it.
+		if seen[field] {
+			return
+		}
+		seen[field] = true
+		fieldErrors = append(fieldErrors, utils.Violation{Field: field, Message: message})
+	}
+
+	for _, err := range multi {
+		var requestErr *openapi3filter.RequestError
+		if !errors.As(err, &requestErr) {
+			add("request", err.Error())
+			continue
+		}
+
+		prefix := "body"
+		if requestErr.Parameter != nil {
+			prefix = requestErr.Parameter.In + "." + requestErr.Parameter.Name
+		}
+
+		// Body errors are usually several schema errors wrapped together.
+		var schemaErrors openapi3.MultiError
+		if !errors.As(requestErr.Err, &schemaErrors) {
+			schemaErrors = openapi3.MultiError{requestErr.Err}
+		}
+
+		for _, err := range schemaErrors {
+			var schemaErr *openapi3.SchemaError
+			switch {
+			case errors.As(err, &schemaErr):
+				field := prefix
+				if path := schemaErr.JSONPointer(); len(path) > 0 {
+					field += "." + strings.Join(path, ".")
+				}
+				add(field, schemaErr.Reason)
+			case err != nil:
+				add(prefix, err.Error())
+			default:
+				add(prefix, requestErr.Reason)
+			}
+		}
+	}
+	return fieldErrors
+}
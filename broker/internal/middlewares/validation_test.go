@@ -0,0 +1,129 @@
+package middlewares
+
+import (
+	"broker/internal/openapi"
+	"broker/internal/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRequest(t *testing.T) {
+	t.Parallel()
+
+	_, router, err := openapi.Load(context.Background())
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+
+	testCases := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+		expectedFields []string
+	}{
+		{
+			name:           "when the request matches the spec, it should pass it through",
+			method:         http.MethodPost,
+			target:         "/api/v1/transactions/deposit",
+			body:           `{"wallet_id":"7f1c1e2a-2b3c-4d5e-8f90-1a2b3c4d5e6f","amount":10}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "when the amount is a decimal string, it should pass it through",
+			method:         http.MethodPost,
+			target:         "/api/v1/transactions/deposit",
+			body:           `{"wallet_id":"7f1c1e2a-2b3c-4d5e-8f90-1a2b3c4d5e6f","amount":"10.50"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "when the amount string has sub-cent precision, it should reject it",
+			method:         http.MethodPost,
+			target:         "/api/v1/transactions/deposit",
+			body:           `{"wallet_id":"7f1c1e2a-2b3c-4d5e-8f90-1a2b3c4d5e6f","amount":"10.505"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"body.amount"},
+		},
+		{
+			name:           "when the currency isn't a three letter code, it should reject it",
+			method:         http.MethodPost,
+			target:         "/api/v1/transactions/deposit",
+			body:           `{"wallet_id":"7f1c1e2a-2b3c-4d5e-8f90-1a2b3c4d5e6f","amount":"10.50","currency":"EURO"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"body.currency"},
+		},
+		{
+			name:           "when body fields are missing or invalid, it should list each of them",
+			method:         http.MethodPost,
+			target:         "/api/v1/transactions/deposit",
+			body:           `{"wallet_id":"not-a-uuid","amount":-5}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"body.amount", "body.wallet_id"},
+		},
+		{
+			name:           "when a hold is captured without a body, it should pass it through",
+			method:         http.MethodPost,
+			target:         "/api/v1/holds/7f1c1e2a-2b3c-4d5e-8f90-1a2b3c4d5e6f/capture",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "when a quote has no destination wallet, it should name the field",
+			method:         http.MethodPost,
+			target:         "/api/v1/fx/quotes",
+			body:           `{"source_wallet_id":"7f1c1e2a-2b3c-4d5e-8f90-1a2b3c4d5e6f","amount":"100.00"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"body.destination_wallet_id"},
+		},
+		{
+			name:           "when a required query parameter is missing, it should name the parameter",
+			method:         http.MethodGet,
+			target:         "/api/v1/wallet",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"query.walletID"},
+		},
+		{
+			name:           "when the route is not in the spec, it should pass it through",
+			method:         http.MethodGet,
+			target:         "/api/v1/unknown",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := ValidateRequest(router, logrus.New())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedFields == nil {
+				return
+			}
+
+			var problem utils.Problem
+			assert.Equal(t, utils.ProblemContentType, w.Header().Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+			assert.Equal(t, utils.CodeRequestInvalid, problem.Code)
+
+			var fields []string
+			for _, fieldErr := range problem.Violations {
+				fields = append(fields, fieldErr.Field)
+			}
+			assert.ElementsMatch(t, tc.expectedFields, fields)
+		})
+	}
+}
@@ -1,7 +1,74 @@
 package models
 
+import "broker/internal/money"
+
 type TransactionRequest struct {
-	WalletID       string  `json:"wallet_id" binding:"required"`
-	Amount         float64 `json:"amount" binding:"required"`
-	IdempotencyKey string  `json:"idempotency_key" binding:"required"`
+	WalletID string `json:"wallet_id"`
+	// Amount accepts a decimal string or, from older clients, a JSON number.
+	Amount money.Amount `json:"amount"`
+	// Currency is the wallet's when the request names none.
+	Currency       money.Currency `json:"currency"`
+	IdempotencyKey string         `json:"idempotency_key"`
+}
+
+type TransferRequest struct {
+	SourceWalletID      string         `json:"source_wallet_id"`
+	DestinationWalletID string         `json:"destination_wallet_id"`
+	Amount              money.Amount   `json:"amount"`
+	Currency            money.Currency `json:"currency"`
+	IdempotencyKey      string         `json:"idempotency_key"`
+}
+
+type PlaceHoldRequest struct {
+	Amount     money.Amount   `json:"amount"`
+	Currency   money.Currency `json:"currency"`
+	TTLSeconds int64          `json:"ttl_seconds"`
+	Reference  string         `json:"reference"`
+}
+
+type CaptureHoldRequest struct {
+	// Amount is nil to capture the whole hold.
+	Amount   *money.Amount  `json:"amount"`
+	Currency money.Currency `json:"currency"`
+}
+
+type CreateQuoteRequest struct {
+	SourceWalletID      string       `json:"source_wallet_id"`
+	DestinationWalletID string       `json:"destination_wallet_id"`
+	Amount              money.Amount `json:"amount"`
+	// Currency is the source wallet's when the request names none.
+	Currency money.Currency `json:"currency"`
+}
+
+type CreateWalletRequest struct {
+	Name string `json:"name"`
+	// Currency is money.DefaultCurrency when empty.
+	Currency money.Currency `json:"currency"`
+}
+
+type RenameWalletRequest struct {
+	Name string `json:"name"`
+}
+
+// ListTransactionsRequest holds the query parameters of a wallet's transaction history.
+type ListTransactionsRequest struct {
+	WalletID string
+	Limit    int
+	Cursor   string
+	Type     string
+	Status   string
+	From     string
+	To       string
+}
+
+// LedgerRequest holds the query parameters of a wallet's ledger.
+type LedgerRequest struct {
+	WalletID string
+	Limit    int
+	Cursor   string
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
 }
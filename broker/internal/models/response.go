@@ -1,5 +1,7 @@
 package models
 
+import "broker/internal/money"
+
 type TransactionType string
 
 const (
@@ -8,14 +10,143 @@ const (
 )
 
 type ViewBalanceResponse struct {
-	Name    string  `json:"name"`
-	Balance float64 `json:"balance"`
+	Name    string       `json:"name"`
+	Balance money.Amount `json:"balance"`
+	// Available is Balance less Held, what can be spent.
+	Available money.Amount   `json:"available"`
+	Held      money.Amount   `json:"held"`
+	Currency  money.Currency `json:"currency"`
+}
+
+type Hold struct {
+	ID             string         `json:"id"`
+	WalletID       string         `json:"wallet_id"`
+	Amount         money.Amount   `json:"amount"`
+	CapturedAmount *money.Amount  `json:"captured_amount,omitempty"`
+	Currency       money.Currency `json:"currency"`
+	Status         string         `json:"status"`
+	Reference      string         `json:"reference,omitempty"`
+	ExpiresAt      string         `json:"expires_at"`
+	CreatedAt      string         `json:"created_at"`
+	UpdatedAt      string         `json:"updated_at"`
+}
+
+// Quote is a locked exchange rate for a conversion, and once executed its
+// record. Fee is in the source currency.
+type Quote struct {
+	ID                  string         `json:"id"`
+	SourceWalletID      string         `json:"source_wallet_id"`
+	DestinationWalletID string         `json:"destination_wallet_id"`
+	SourceAmount        money.Amount   `json:"source_amount"`
+	SourceCurrency      money.Currency `json:"source_currency"`
+	Fee                 money.Amount   `json:"fee"`
+	TargetAmount        money.Amount   `json:"target_amount"`
+	TargetCurrency      money.Currency `json:"target_currency"`
+	MidRate             string         `json:"mid_rate"`
+	Rate                string         `json:"rate"`
+	SpreadBps           int64          `json:"spread_bps"`
+	FeeBps              int64          `json:"fee_bps"`
+	Status              string         `json:"status"`
+	ExpiresAt           string         `json:"expires_at"`
+	CreatedAt           string         `json:"created_at"`
+	ExecutedAt          string         `json:"executed_at,omitempty"`
+}
+
+type Wallet struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Balance   money.Amount   `json:"balance"`
+	Currency  money.Currency `json:"currency"`
+	Status    string         `json:"status"`
+	CreatedAt string         `json:"created_at"`
+	UpdatedAt string         `json:"updated_at"`
+	ClosedAt  string         `json:"closed_at,omitempty"`
 }
 
 type TransactionResponse struct {
 	Message       string             `json:"message,omitempty"`
 	TransactionID string             `json:"transaction_id" validate:"required"`
 	WalletID      string             `json:"wallet_id" validate:"required"`
-	Amount        float64            `json:"amount" validate:"required"`
+	Amount        money.Amount       `json:"amount" validate:"required"`
 	Type          TransactionRequest `json:"transaction_type" validate:"required"`
 }
+
+type TransferResponse struct {
+	TransferID          string `json:"transfer_id"`
+	DebitTransactionID  string `json:"debit_transaction_id"`
+	CreditTransactionID string `json:"credit_transaction_id"`
+}
+
+type Transaction struct {
+	ID            string         `json:"id"`
+	WalletID      string         `json:"wallet_id"`
+	Amount        money.Amount   `json:"amount"`
+	Currency      money.Currency `json:"currency"`
+	Type          string         `json:"type"`
+	Status        string         `json:"status"`
+	CreatedAt     string         `json:"created_at"`
+	UpdatedAt     string         `json:"updated_at"`
+	TransferID    string         `json:"transfer_id,omitempty"`
+	FailureReason string         `json:"failure_reason,omitempty"`
+	// OriginalTransactionID is set on reversals and refunds.
+	OriginalTransactionID string `json:"original_transaction_id,omitempty"`
+}
+
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
+// LedgerEntry is a posting to a wallet's ledger account.
+type LedgerEntry struct {
+	ID            int64        `json:"id"`
+	JournalID     string       `json:"journal_id"`
+	Kind          string       `json:"kind"`
+	TransactionID string       `json:"transaction_id"`
+	Direction     string       `json:"direction"`
+	Amount        money.Amount `json:"amount"`
+	BalanceAfter  money.Amount `json:"balance_after"`
+	CreatedAt     string       `json:"created_at"`
+	// CounterAccount is the other side of the journal, with its wallet ID when
+	// it is another wallet.
+	CounterAccount  string `json:"counter_account"`
+	CounterWalletID string `json:"counter_wallet_id,omitempty"`
+}
+
+type LedgerPage struct {
+	Entries    []LedgerEntry `json:"entries"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type WebhookEndpoint struct {
+	ID                  string   `json:"id"`
+	URL                 string   `json:"url"`
+	EventTypes          []string `json:"event_types"`
+	Enabled             bool     `json:"enabled"`
+	Secret              string   `json:"secret,omitempty"`
+	ConsecutiveFailures int      `json:"consecutive_failures"`
+	DisabledAt          string   `json:"disabled_at,omitempty"`
+	CreatedAt           string   `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             string                   `json:"id"`
+	EndpointID     string                   `json:"endpoint_id"`
+	EventID        string                   `json:"event_id"`
+	EventType      string                   `json:"event_type"`
+	Status         string                   `json:"status"`
+	Attempts       int                      `json:"attempts"`
+	LastStatusCode int                      `json:"last_status_code,omitempty"`
+	LastError      string                   `json:"last_error,omitempty"`
+	NextAttemptAt  string                   `json:"next_attempt_at,omitempty"`
+	CreatedAt      string                   `json:"created_at"`
+	AttemptLog     []WebhookDeliveryAttempt `json:"attempt_log"`
+}
+
+type WebhookDeliveryAttempt struct {
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	CreatedAt  string `json:"created_at"`
+}
@@ -0,0 +1,80 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCurrency(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		input       string
+		expected    Currency
+		expectedErr error
+	}{
+		{name: "when the code is known, it should return it", input: "GBP", expected: "GBP"},
+		{name: "when the code is lower case, it should upper case it", input: "eur", expected: "EUR"},
+		{name: "when the code is empty, it should return the default currency", input: "", expected: DefaultCurrency},
+		{name: "when the currency has three decimals, it should return an error", input: "BHD", expectedErr: ErrCurrency},
+		{name: "when the code isn't a currency, it should return an error", input: "EURO", expectedErr: ErrCurrency},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			currency, err := ParseCurrency(tc.input)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedErr == nil {
+				assert.Equal(t, tc.expected, currency)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		currency    Currency
+		amount      Amount
+		expectedErr error
+	}{
+		{name: "when the amount has cents in a two decimal currency, it should accept it", currency: "EUR", amount: 1050},
+		{name: "when the amount is whole yen, it should accept it", currency: "JPY", amount: 100000},
+		{name: "when the amount has fractions of a yen, it should return an error", currency: "JPY", amount: 1050, expectedErr: ErrCurrencyPrecision},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, tc.currency.Check(tc.amount), tc.expectedErr)
+		})
+	}
+}
+
+func TestCurrencyUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		input       string
+		expected    Currency
+		expectedErr error
+	}{
+		{name: "when the code is lower case, it should upper case it", input: `"gbp"`, expected: "GBP"},
+		{name: "when the code is empty, it should leave the currency unset", input: `""`, expected: ""},
+		{name: "when the currency isn't supported, it should return an error", input: `"XYZ"`, expectedErr: ErrCurrency},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var currency Currency
+			err := json.Unmarshal([]byte(tc.input), &currency)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Equal(t, tc.expected, currency)
+		})
+	}
+}
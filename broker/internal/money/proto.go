@@ -0,0 +1,38 @@
+package money
+
+import (
+	"broker/proto/gen"
+	"math"
+)
+
+// Proto returns the amount as a Money message in the default currency.
+func (a Amount) Proto() *gen.Money {
+	return &gen.Money{MinorUnits: int64(a)}
+}
+
+// ProtoIn returns the amount as a Money message in minor units of c. The amount
+// must pass c.Check.
+func (a Amount) ProtoIn(c Currency) *gen.Money {
+	return &gen.Money{MinorUnits: int64(a / c.unit()), Currency: string(c)}
+}
+
+// FromProto reads an amount from a service response. Services that predate
+// Money only send the legacy double, which holds a DECIMAL(15,2) value and is
+// rounded back to it.
+func FromProto(m *gen.Money, legacy float64) Amount {
+	if m != nil {
+		// Unsupported currencies are taken to have Scale decimal places.
+		c, _ := ParseCurrency(m.GetCurrency())
+		return Amount(m.GetMinorUnits()) * c.unit()
+	}
+	return Amount(math.Round(legacy * minorPerMajor))
+}
+
+// CurrencyOf returns the currency of m, DefaultCurrency when it names none.
+func CurrencyOf(m *gen.Money) Currency {
+	c, err := ParseCurrency(m.GetCurrency())
+	if err != nil {
+		return Currency(m.GetCurrency())
+	}
+	return c
+}
@@ -0,0 +1,64 @@
+package mtls
+
+import (
+	"broker/internal/config"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// DialOption returns the transport credentials used to call the internal services.
+// When TLS is disabled the connection stays plaintext.
+func DialOption(ctx context.Context, cfg config.TLS, log *logrus.Logger) (grpc.DialOption, error) {
+	if !cfg.Enabled {
+		log.Warn("TLS is disabled, dialing plaintext gRPC")
+		return grpc.WithTransportCredentials(insecure.NewCredentials()), nil
+	}
+
+	reloader, err := NewReloader(cfg.CertFile, cfg.KeyFile, cfg.CAFile, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificates: %w", err)
+	}
+	go reloader.Watch(ctx, cfg.ReloadInterval)
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(clientTLSConfig(reloader))), nil
+}
+
+func clientTLSConfig(reloader *Reloader) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := reloader.Certificate(); cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+		// Verification is done in VerifyConnection against the current CA pool,
+		// since RootCAs cannot be swapped on an existing config.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+
+			opts := x509.VerifyOptions{
+				DNSName:       cs.ServerName,
+				Roots:         reloader.CAPool(),
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// Spec is the OpenAPI document of the /api/v1 REST API.
+//
+//go:embed openapi.yaml
+var Spec []byte
+
+func init() {
+	// String formats other than date and date-time are opt-in.
+	openapi3.DefineStringFormatValidator("uuid", openapi3.NewRegexpFormatValidator(openapi3.FormatOfStringForUUIDOfRFC4122))
+}
+
+// Load parses and validates the embedded spec and returns a router that matches
+// incoming requests to its operations.
+func Load(ctx context.Context) (*openapi3.T, routers.Router, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(Spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load openapi spec: %w", err)
+	}
+
+	if err := doc.Validate(ctx); err != nil {
+		return nil, nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create openapi router: %w", err)
+	}
+
+	return doc, router, nil
+}
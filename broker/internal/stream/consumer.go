@@ -0,0 +1,156 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Consumer reads domain events from Kafka and publishes them to the hub.
+type Consumer struct {
+	brokers []string
+	topics  []string
+	hub     *Hub
+	log     *logrus.Logger
+
+	mu      sync.Mutex
+	readers []*kafka.Reader
+}
+
+// NewConsumer creates a consumer for topics. Every broker instance must see every
+// event to serve its own connections, so each reads every partition itself from
+// the latest offset, outside of any consumer group: there are no offsets to
+// commit, nor groups left behind by the instances that are gone.
+func NewConsumer(brokers, topics []string, hub *Hub, log *logrus.Logger) *Consumer {
+	return &Consumer{
+		brokers: brokers,
+		topics:  topics,
+		hub:     hub,
+		log:     log,
+	}
+}
+
+// Consume reads events until ctx is cancelled. Partitions added to the topics
+// afterwards are read once the broker restarts.
+func (c *Consumer) Consume(ctx context.Context) {
+	c.log.WithField("topics", c.topics).Info("Starting stream consumer")
+
+	partitions, err := c.partitions(ctx)
+	if err != nil {
+		c.log.Info("Stream consumer stopped")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, partition := range partitions {
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:   c.brokers,
+			Topic:     partition.Topic,
+			Partition: partition.ID,
+			MinBytes:  1,
+			MaxBytes:  10e6, // 10MB
+			MaxWait:   500 * time.Millisecond,
+		})
+		if err := reader.SetOffset(kafka.LastOffset); err != nil {
+			c.log.WithError(err).WithField("topic", partition.Topic).Error("Failed to start stream reader")
+			reader.Close()
+			continue
+		}
+		c.mu.Lock()
+		c.readers = append(c.readers, reader)
+		c.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.read(ctx, reader)
+		}()
+	}
+	wg.Wait()
+	c.log.Info("Stream consumer stopped")
+}
+
+// partitions looks up the partitions of the topics, retrying until Kafka
+// answers or ctx is cancelled.
+func (c *Consumer) partitions(ctx context.Context) ([]kafka.Partition, error) {
+	for {
+		for _, broker := range c.brokers {
+			conn, err := kafka.DialContext(ctx, "tcp", broker)
+			if err != nil {
+				c.log.WithError(err).WithField("broker", broker).Error("Failed to connect to Kafka")
+				continue
+			}
+			partitions, err := conn.ReadPartitions(c.topics...)
+			conn.Close()
+			if err != nil {
+				c.log.WithError(err).WithField("broker", broker).Error("Failed to look up stream partitions")
+				continue
+			}
+			return partitions, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (c *Consumer) read(ctx context.Context, reader *kafka.Reader) {
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.log.WithError(err).Error("Failed to read stream event")
+			time.Sleep(time.Second)
+			continue
+		}
+		c.publish(msg)
+	}
+}
+
+// publish hands the event to the hub for its owner and, for transfers, its
+// recipient.
+func (c *Consumer) publish(msg kafka.Message) {
+	var owner struct {
+		UserID int `json:"user_id"`
+		// DestinationUserID is set on transfers, their recipient sees them too.
+		DestinationUserID int `json:"destination_user_id"`
+	}
+	if err := json.Unmarshal(msg.Value, &owner); err != nil || owner.UserID == 0 {
+		c.log.WithField("topic", msg.Topic).Debug("Skipping stream event without an owner")
+		return
+	}
+
+	userIDs := []int{owner.UserID}
+	if owner.DestinationUserID != 0 && owner.DestinationUserID != owner.UserID {
+		userIDs = append(userIDs, owner.DestinationUserID)
+	}
+	for _, userID := range userIDs {
+		c.hub.Publish(Event{
+			ID:     fmt.Sprintf("%s-%d-%d", msg.Topic, msg.Partition, msg.Offset),
+			Type:   msg.Topic,
+			UserID: userID,
+			Data:   msg.Value,
+			Time:   msg.Time,
+		})
+	}
+}
+
+func (c *Consumer) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, reader := range c.readers {
+		if err := reader.Close(); err != nil {
+			c.log.WithError(err).Error("Failed to close stream reader")
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Event is a domain event addressed to a single user.
+type Event struct {
+	// ID is stable across broker instances (topic-partition-offset), so a client can resume on any of them.
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	UserID int             `json:"-"`
+	Data   json.RawMessage `json:"data"`
+	Time   time.Time       `json:"time"`
+}
+
+// Subscriber receives the events of one user over one connection.
+type Subscriber struct {
+	userID int
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Events returns the channel the subscriber's events are delivered on.
+func (s *Subscriber) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed when the hub drops the subscriber because it can't keep up or is closing.
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscriber) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Hub fans events out to every connection of their owner and keeps a short
+// per-user history so reconnecting clients can resume from Last-Event-ID.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[int]map[*Subscriber]struct{}
+	history     map[int][]Event
+	// published is when each user in history was last published an event.
+	published   map[int]time.Time
+	historySize int
+	bufferSize  int
+	log         *logrus.Logger
+}
+
+func NewHub(historySize, bufferSize int, log *logrus.Logger) *Hub {
+	return &Hub{
+		subscribers: make(map[int]map[*Subscriber]struct{}),
+		history:     make(map[int][]Event),
+		published:   make(map[int]time.Time),
+		historySize: historySize,
+		bufferSize:  bufferSize,
+		log:         log,
+	}
+}
+
+// Subscribe registers a connection for userID. If lastEventID is found in the
+// user's history, the events that followed it are returned as backlog.
+func (h *Hub) Subscribe(userID int, lastEventID string) (*Subscriber, []Event) {
+	sub := &Subscriber{
+		userID: userID,
+		events: make(chan Event, h.bufferSize),
+		done:   make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*Subscriber]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+
+	var backlog []Event
+	if lastEventID != "" {
+		history := h.history[userID]
+		for i, event := range history {
+			if event.ID == lastEventID {
+				backlog = append(backlog, history[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return sub, backlog
+}
+
+// Unsubscribe removes the connection from the hub.
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs := h.subscribers[sub.userID]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.userID)
+	}
+	sub.close()
+}
+
+// Publish records the event in its owner's history and delivers it to every
+// open connection of that user. Slow connections are dropped instead of
+// blocking delivery for everyone else.
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	history := append(h.history[event.UserID], event)
+	if len(history) > h.historySize {
+		history = history[len(history)-h.historySize:]
+	}
+	h.history[event.UserID] = history
+	h.published[event.UserID] = time.Now()
+
+	for sub := range h.subscribers[event.UserID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.log.WithField("user_id", event.UserID).Warn("stream subscriber is too slow, dropping connection")
+			delete(h.subscribers[event.UserID], sub)
+			sub.close()
+		}
+	}
+	if len(h.subscribers[event.UserID]) == 0 {
+		delete(h.subscribers, event.UserID)
+	}
+}
+
+// Cleanup evicts the history of users without connections that haven't been
+// published an event for idle, every interval until ctx is cancelled. Their
+// clients can't resume after that, and start over from new events.
+func (h *Hub) Cleanup(ctx context.Context, interval, idle time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.evictIdle(time.Now().Add(-idle))
+		}
+	}
+}
+
+// evictIdle drops the history of users without connections last published an
+// event before cutoff.
+func (h *Hub) evictIdle(cutoff time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for userID, published := range h.published {
+		if published.Before(cutoff) && len(h.subscribers[userID]) == 0 {
+			delete(h.history, userID)
+			delete(h.published, userID)
+		}
+	}
+}
+
+// Close drops every subscriber so their connections end and clients reconnect,
+// e.g. to another instance during a rolling deploy.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for userID, subs := range h.subscribers {
+		for sub := range subs {
+			sub.close()
+		}
+		delete(h.subscribers, userID)
+	}
+}
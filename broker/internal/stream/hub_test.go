@@ -0,0 +1,178 @@
+package stream
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEvent(userID, offset int) Event {
+	return Event{
+		ID:     fmt.Sprintf("deposit_completed-0-%d", offset),
+		Type:   "deposit_completed",
+		UserID: userID,
+	}
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		published       []Event
+		lastEventID     string
+		expectedBacklog []string
+	}{
+		{
+			name:            "when no last event ID is given, it should return no backlog",
+			published:       []Event{newEvent(1, 1), newEvent(1, 2)},
+			lastEventID:     "",
+			expectedBacklog: nil,
+		},
+		{
+			name:            "when the last event ID is in the history, it should return the events after it",
+			published:       []Event{newEvent(1, 1), newEvent(1, 2), newEvent(1, 3)},
+			lastEventID:     "deposit_completed-0-1",
+			expectedBacklog: []string{"deposit_completed-0-2", "deposit_completed-0-3"},
+		},
+		{
+			name:            "when the last event ID is unknown, it should return no backlog",
+			published:       []Event{newEvent(1, 1)},
+			lastEventID:     "deposit_completed-0-42",
+			expectedBacklog: nil,
+		},
+		{
+			name:            "when events belong to other users, it should not return them",
+			published:       []Event{newEvent(1, 1), newEvent(2, 2), newEvent(1, 3)},
+			lastEventID:     "deposit_completed-0-1",
+			expectedBacklog: []string{"deposit_completed-0-3"},
+		},
+		{
+			name:            "when the history is full, it should only keep the latest events",
+			published:       []Event{newEvent(1, 1), newEvent(1, 2), newEvent(1, 3), newEvent(1, 4)},
+			lastEventID:     "deposit_completed-0-1",
+			expectedBacklog: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hub := NewHub(3, 10, logrus.New())
+			for _, event := range tc.published {
+				hub.Publish(event)
+			}
+
+			_, backlog := hub.Subscribe(1, tc.lastEventID)
+
+			var ids []string
+			for _, event := range backlog {
+				ids = append(ids, event.ID)
+			}
+			assert.Equal(t, tc.expectedBacklog, ids)
+		})
+	}
+}
+
+func TestHub_Publish(t *testing.T) {
+	t.Parallel()
+
+	t.Run("when a user has several connections, it should deliver to all of them", func(t *testing.T) {
+		hub := NewHub(10, 10, logrus.New())
+		first, _ := hub.Subscribe(1, "")
+		second, _ := hub.Subscribe(1, "")
+		other, _ := hub.Subscribe(2, "")
+
+		hub.Publish(newEvent(1, 1))
+
+		assert.Len(t, first.Events(), 1)
+		assert.Len(t, second.Events(), 1)
+		assert.Len(t, other.Events(), 0)
+	})
+
+	t.Run("when a connection falls behind, it should be dropped", func(t *testing.T) {
+		hub := NewHub(10, 1, logrus.New())
+		sub, _ := hub.Subscribe(1, "")
+
+		hub.Publish(newEvent(1, 1))
+		hub.Publish(newEvent(1, 2))
+
+		select {
+		case <-sub.Done():
+		default:
+			t.Fatal("expected slow subscriber to be dropped")
+		}
+	})
+}
+
+func TestHub_Close(t *testing.T) {
+	t.Parallel()
+
+	t.Run("when the hub is closed, it should end every subscription", func(t *testing.T) {
+		hub := NewHub(10, 10, logrus.New())
+		first, _ := hub.Subscribe(1, "")
+		second, _ := hub.Subscribe(2, "")
+
+		hub.Close()
+
+		for _, sub := range []*Subscriber{first, second} {
+			select {
+			case <-sub.Done():
+			default:
+				t.Fatal("expected subscriber to be closed")
+			}
+		}
+	})
+}
+
+func TestHub_EvictIdle(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		subscribed      bool
+		cutoff          time.Duration
+		expectedBacklog []string
+	}{
+		{
+			name:            "when the user was published an event recently, it should keep their history",
+			cutoff:          -time.Hour,
+			expectedBacklog: []string{"deposit_completed-0-2"},
+		},
+		{
+			name:   "when the user is idle, it should evict their history",
+			cutoff: time.Hour,
+		},
+		{
+			name:            "when the idle user still has a connection, it should keep their history",
+			subscribed:      true,
+			cutoff:          time.Hour,
+			expectedBacklog: []string{"deposit_completed-0-2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hub := NewHub(10, 10, logrus.New())
+			if tc.subscribed {
+				hub.Subscribe(1, "")
+			}
+			hub.Publish(newEvent(1, 1))
+			hub.Publish(newEvent(1, 2))
+
+			hub.evictIdle(time.Now().Add(tc.cutoff))
+
+			_, backlog := hub.Subscribe(1, "deposit_completed-0-1")
+			var ids []string
+			for _, event := range backlog {
+				ids = append(ids, event.ID)
+			}
+			assert.Equal(t, tc.expectedBacklog, ids)
+			if tc.expectedBacklog == nil {
+				assert.NotContains(t, hub.published, 1)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/status"
 	"log"
 	"net/http"
@@ -8,13 +10,18 @@ import (
 	"google.golang.org/grpc/codes"
 )
 
-// HandleGRPCError handles errors from gRPC service calls
+// HandleGRPCError translates errors from gRPC service calls into problem responses.
+// The error code and field violations are read from the status details when the
+// service attached them.
 func HandleGRPCError(w http.ResponseWriter, err error) {
-	st, ok := status.FromError(err)
-	if !ok {
-		Respond(w, http.StatusInternalServerError, "", nil, err)
+	// Clients wrap the status, use the original one to keep its message.
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &grpcErr) {
+		log.Printf("non-gRPC error from service call: %v", err)
+		RespondProblem(w, CodeInternal, "")
 		return
 	}
+	st := grpcErr.GRPCStatus()
 
 	code := st.Code()
 	message := st.Message()
@@ -27,17 +34,49 @@ func HandleGRPCError(w http.ResponseWriter, err error) {
 		httpStatus,
 	)
 
-	// For security, we may want to sanitize some error messages before sending to clients
-	clientMessage := getSafeErrorMessage(code, message)
+	errorCode := fallbackCode(code)
+	var violations []Violation
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			errorCode = d.GetReason()
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				violations = append(violations, Violation{Field: v.GetField(), Message: v.GetDescription()})
+			}
+		}
+	}
+
+	WriteProblem(w, NewProblem(errorCode, httpStatus, getSafeErrorMessage(code, message), violations...))
+}
 
-	Respond(w, httpStatus, clientMessage, nil, nil)
+// fallbackCode is used for statuses returned without an ErrorInfo detail.
+func fallbackCode(code codes.Code) string {
+	switch code {
+	case codes.NotFound:
+		return CodeNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return CodeConflict
+	case codes.PermissionDenied:
+		return CodeForbidden
+	case codes.Unauthenticated:
+		return CodeUnauthenticated
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return CodeInvalidArgument
+	case codes.Unavailable:
+		return CodeUnavailable
+	case codes.DeadlineExceeded:
+		return CodeTimeout
+	default:
+		return CodeInternal
+	}
 }
 
 // getSafeErrorMessage ensures we don't leak sensitive information in error messages
 func getSafeErrorMessage(code codes.Code, originalMessage string) string {
 	// For some error types, we might want to sanitize or customize the message
 	switch code {
-	case codes.Internal:
+	case codes.Internal, codes.Unknown, codes.DataLoss:
 		return "An internal error occurred"
 	case codes.Unavailable:
 		return "Service temporarily unavailable"
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHandleGRPCError(t *testing.T) {
+	t.Parallel()
+
+	withDetails := func(code codes.Code, message string, details ...*errdetails.ErrorInfo) error {
+		st := status.New(code, message)
+		for _, d := range details {
+			st, _ = st.WithDetails(d)
+		}
+		return st.Err()
+	}
+
+	amountInvalid, _ := status.New(codes.InvalidArgument, "amount must be positive").WithDetails(
+		&errdetails.ErrorInfo{Reason: CodeAmountInvalid},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "amount", Description: "must be greater than 0"},
+		}},
+	)
+
+	testCases := []struct {
+		name               string
+		err                error
+		expectedStatus     int
+		expectedCode       string
+		expectedDetail     string
+		expectedViolations []Violation
+	}{
+		{
+			name:           "when the status carries a known code, it should use the catalogue entry",
+			err:            withDetails(codes.NotFound, "wallet not found", &errdetails.ErrorInfo{Reason: CodeWalletNotFound}),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   CodeWalletNotFound,
+			expectedDetail: "wallet not found",
+		},
+		{
+			name:           "when the transaction service refuses a reused idempotency key, it should use the broker's code",
+			err:            withDetails(codes.AlreadyExists, "idempotency key was used for another request", &errdetails.ErrorInfo{Reason: "idempotency.key_reused"}),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   CodeIdempotencyReused,
+			expectedDetail: "idempotency key was used for another request",
+		},
+		{
+			name:           "when a transaction can't be reversed, it should use the catalogue entry",
+			err:            withDetails(codes.FailedPrecondition, "only deposits can be reversed or refunded", &errdetails.ErrorInfo{Reason: "transaction.not_reversible"}),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   CodeTransactionNotReversible,
+			expectedDetail: "only deposits can be reversed or refunded",
+		},
+		{
+			name:               "when the status carries field violations, it should list them",
+			err:                amountInvalid.Err(),
+			expectedStatus:     http.StatusBadRequest,
+			expectedCode:       CodeAmountInvalid,
+			expectedDetail:     "amount must be positive",
+			expectedViolations: []Violation{{Field: "amount", Message: "must be greater than 0"}},
+		},
+		{
+			name:           "when the status has no details, it should fall back to a generic code",
+			err:            fmt.Errorf("failed to deposit: %w", status.Error(codes.AlreadyExists, "duplicate")),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   CodeConflict,
+			expectedDetail: "duplicate",
+		},
+		{
+			name:           "when the status is internal, it should hide the message",
+			err:            status.Error(codes.Internal, "pq: connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   CodeInternal,
+			expectedDetail: "An internal error occurred",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			w.Header().Set(RequestIDHeader, "req-1")
+
+			HandleGRPCError(w, tc.err)
+
+			var problem Problem
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, problem.Status)
+			assert.Equal(t, tc.expectedCode, problem.Code)
+			assert.Equal(t, problemTypePrefix+tc.expectedCode, problem.Type)
+			assert.Equal(t, tc.expectedDetail, problem.Detail)
+			assert.Equal(t, "req-1", problem.Instance)
+			assert.Equal(t, tc.expectedViolations, problem.Violations)
+		})
+	}
+}
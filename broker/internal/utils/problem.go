@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	RequestIDHeader    = "X-Request-ID"
+	problemTypePrefix  = "urn:wall-e:problem:"
+)
+
+// Error codes returned to clients in the "code" member of a problem. Codes set by
+// the gRPC services travel in errdetails.ErrorInfo.Reason and must match these.
+const (
+	CodeInternal            = "internal"
+	CodeUnavailable         = "service_unavailable"
+	CodeTimeout             = "timeout"
+	CodeNotFound            = "not_found"
+	CodeConflict            = "conflict"
+	CodeForbidden           = "forbidden"
+	CodeInvalidArgument     = "invalid_argument"
+	CodeRequestInvalid      = "request.invalid"
+	CodeRequestMalformed    = "request.malformed"
+	CodeUnauthenticated     = "auth.unauthenticated"
+	CodeTokenInvalid        = "auth.token_invalid"
+	CodeInvalidCredentials  = "auth.invalid_credentials"
+	CodeUserExists          = "auth.user_exists"
+	CodeWalletNotFound      = "wallet.not_found"
+	CodeWalletNameTaken     = "wallet.name_taken"
+	CodeWalletForbidden     = "wallet.forbidden"
+	CodeWalletClosed        = "wallet.closed"
+	CodeWalletNotEmpty      = "wallet.not_empty"
+	CodeWalletPending       = "wallet.pending_transactions"
+	CodeInsufficientFunds   = "wallet.insufficient_funds"
+	CodeAmountInvalid       = "transaction.amount_invalid"
+	CodeCurrencyInvalid     = "currency.invalid"
+	CodeCurrencyMismatch    = "wallet.currency_mismatch"
+	CodeTransactionNotFound = "transaction.not_found"
+	// CodeTransactionNotReversible is a transaction that isn't a completed deposit
+	// with something left to reverse or refund.
+	CodeTransactionNotReversible = "transaction.not_reversible"
+	CodeRefundExceedsRemaining   = "transaction.refund_exceeds_remaining"
+	CodeHoldNotFound             = "hold.not_found"
+	CodeHoldNotActive            = "hold.not_active"
+	CodeHoldExpired              = "hold.expired"
+	CodeQuoteNotFound            = "fx.quote_not_found"
+	CodeQuoteNotOpen             = "fx.quote_not_open"
+	CodeQuoteExpired             = "fx.quote_expired"
+	CodeRateUnavailable          = "fx.rate_unavailable"
+	CodeWebhookNotFound          = "webhook.not_found"
+	CodeWebhookURLInvalid        = "webhook.url_invalid"
+	CodeDeliveryNotFound         = "webhook.delivery_not_found"
+	CodeIdempotencyInvalid       = "idempotency.key_invalid"
+	CodeIdempotencyReused        = "idempotency.key_reused"
+	CodeIdempotencyPending       = "idempotency.in_flight"
+)
+
+type problemType struct {
+	Title  string
+	Status int
+}
+
+// problemTypes is the catalogue of known codes. Statuses set here take precedence
+// over the one derived from the gRPC code.
+var problemTypes = map[string]problemType{
+	CodeInternal:                 {Title: "Internal error", Status: http.StatusInternalServerError},
+	CodeUnavailable:              {Title: "Service temporarily unavailable", Status: http.StatusServiceUnavailable},
+	CodeTimeout:                  {Title: "Request timed out", Status: http.StatusGatewayTimeout},
+	CodeNotFound:                 {Title: "Resource not found", Status: http.StatusNotFound},
+	CodeConflict:                 {Title: "Resource conflict", Status: http.StatusConflict},
+	CodeForbidden:                {Title: "Access denied", Status: http.StatusForbidden},
+	CodeInvalidArgument:          {Title: "Invalid argument", Status: http.StatusBadRequest},
+	CodeRequestInvalid:           {Title: "Request does not match the API specification", Status: http.StatusBadRequest},
+	CodeRequestMalformed:         {Title: "Malformed request body", Status: http.StatusBadRequest},
+	CodeUnauthenticated:          {Title: "Authentication required", Status: http.StatusUnauthorized},
+	CodeTokenInvalid:             {Title: "Invalid or expired token", Status: http.StatusUnauthorized},
+	CodeInvalidCredentials:       {Title: "Invalid credentials", Status: http.StatusUnauthorized},
+	CodeUserExists:               {Title: "User already exists", Status: http.StatusConflict},
+	CodeWalletNotFound:           {Title: "Wallet not found", Status: http.StatusNotFound},
+	CodeWalletNameTaken:          {Title: "Wallet name already in use", Status: http.StatusConflict},
+	CodeWalletForbidden:          {Title: "Wallet belongs to another user", Status: http.StatusForbidden},
+	CodeWalletClosed:             {Title: "Wallet is closed", Status: http.StatusConflict},
+	CodeWalletNotEmpty:           {Title: "Wallet balance is not zero", Status: http.StatusConflict},
+	CodeWalletPending:            {Title: "Wallet has pending transactions", Status: http.StatusConflict},
+	CodeInsufficientFunds:        {Title: "Insufficient funds", Status: http.StatusUnprocessableEntity},
+	CodeAmountInvalid:            {Title: "Invalid amount", Status: http.StatusBadRequest},
+	CodeCurrencyInvalid:          {Title: "Unsupported currency", Status: http.StatusBadRequest},
+	CodeCurrencyMismatch:         {Title: "Currency differs from the wallet's", Status: http.StatusUnprocessableEntity},
+	CodeTransactionNotFound:      {Title: "Transaction not found", Status: http.StatusNotFound},
+	CodeTransactionNotReversible: {Title: "Transaction can't be reversed or refunded", Status: http.StatusConflict},
+	CodeRefundExceedsRemaining:   {Title: "Refund exceeds what is left of the transaction", Status: http.StatusUnprocessableEntity},
+	CodeHoldNotFound:             {Title: "Hold not found", Status: http.StatusNotFound},
+	CodeHoldNotActive:            {Title: "Hold is no longer active", Status: http.StatusConflict},
+	CodeHoldExpired:              {Title: "Hold has expired", Status: http.StatusConflict},
+	CodeQuoteNotFound:            {Title: "Quote not found", Status: http.StatusNotFound},
+	CodeQuoteNotOpen:             {Title: "Quote was already executed", Status: http.StatusConflict},
+	CodeQuoteExpired:             {Title: "Quote has expired", Status: http.StatusConflict},
+	CodeRateUnavailable:          {Title: "Exchange rate unavailable", Status: http.StatusServiceUnavailable},
+	CodeWebhookNotFound:          {Title: "Webhook endpoint not found", Status: http.StatusNotFound},
+	CodeWebhookURLInvalid:        {Title: "Invalid webhook endpoint", Status: http.StatusBadRequest},
+	CodeDeliveryNotFound:         {Title: "Webhook delivery not found", Status: http.StatusNotFound},
+	CodeIdempotencyInvalid:       {Title: "Invalid idempotency key", Status: http.StatusBadRequest},
+	CodeIdempotencyReused:        {Title: "Idempotency key reused with a different request", Status: http.StatusUnprocessableEntity},
+	CodeIdempotencyPending:       {Title: "Request with this idempotency key is in progress", Status: http.StatusConflict},
+}
+
+// Violation points at a single invalid field of the request.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 error response.
+type Problem struct {
+	Type       string      `json:"type"`
+	Title      string      `json:"title"`
+	Status     int         `json:"status"`
+	Detail     string      `json:"detail,omitempty"`
+	Instance   string      `json:"instance,omitempty"`
+	Code       string      `json:"code"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// NewProblem builds a problem for code. Codes in the catalogue use their own
+// status, status is only used for codes missing from it.
+func NewProblem(code string, status int, detail string, violations ...Violation) Problem {
+	title := http.StatusText(status)
+	if known, ok := problemTypes[code]; ok {
+		title = known.Title
+		status = known.Status
+	}
+	if status == 0 {
+		status = http.StatusInternalServerError
+		title = http.StatusText(status)
+	}
+
+	return Problem{
+		Type:       problemTypePrefix + code,
+		Title:      title,
+		Status:     status,
+		Detail:     detail,
+		Code:       code,
+		Violations: violations,
+	}
+}
+
+// RespondProblem writes an application/problem+json response for code. The
+// instance is the request ID set by the RequestID middleware.
+func RespondProblem(w http.ResponseWriter, code string, detail string, violations ...Violation) {
+	WriteProblem(w, NewProblem(code, 0, detail, violations...))
+}
+
+func WriteProblem(w http.ResponseWriter, problem Problem) {
+	problem.Instance = w.Header().Get(RequestIDHeader)
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+
+	if err := json.NewEncoder(w).Encode(&problem); err != nil {
+		log.Printf("Error encoding problem: %v", err)
+	}
+}
@@ -1,7 +1,7 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
 // versions:
 // - protoc-gen-go-grpc v1.5.1
-// - protoc             v3.12.4
+// - protoc             v5.29.3
 // source: transaction.proto
 
 package gen
@@ -19,7 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TransactionService_Deposit_FullMethodName = "/transaction.TransactionService/Deposit"
+	TransactionService_Deposit_FullMethodName            = "/transaction.TransactionService/Deposit"
+	TransactionService_Withdraw_FullMethodName           = "/transaction.TransactionService/Withdraw"
+	TransactionService_Transfer_FullMethodName           = "/transaction.TransactionService/Transfer"
+	TransactionService_GetTransaction_FullMethodName     = "/transaction.TransactionService/GetTransaction"
+	TransactionService_ListTransactions_FullMethodName   = "/transaction.TransactionService/ListTransactions"
+	TransactionService_TransitionStatus_FullMethodName   = "/transaction.TransactionService/TransitionStatus"
+	TransactionService_ReverseTransaction_FullMethodName = "/transaction.TransactionService/ReverseTransaction"
+	TransactionService_Refund_FullMethodName             = "/transaction.TransactionService/Refund"
 )
 
 // TransactionServiceClient is the client API for TransactionService service.
@@ -27,6 +34,26 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type TransactionServiceClient interface {
 	Deposit(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	// Withdraw records a PENDING withdrawal. The wallet service debits it, or
+	// fails it when the balance is insufficient.
+	Withdraw(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	// Transfer records a PENDING debit of the source and credit of the destination,
+	// linked by a transfer ID. The wallet service applies both or neither.
+	Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error)
+	GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	// TransitionStatus moves transactions along their lifecycle, for the
+	// services that drive its later stages, such as capture. Transactions the
+	// lifecycle doesn't allow to move are left as they are and reported rejected.
+	TransitionStatus(ctx context.Context, in *TransitionStatusRequest, opts ...grpc.CallOption) (*TransitionStatusResponse, error)
+	// ReverseTransaction takes back what remains of a completed deposit, and
+	// Refund part of it, for admin and support tools, not on behalf of a user.
+	// Both record a PENDING compensating transaction linked to the deposit, which
+	// the wallet service debits from its wallet or refuses, e.g. with
+	// insufficient_funds. The deposit moves to REVERSED or PARTIALLY_REFUNDED
+	// once the debit completes.
+	ReverseTransaction(ctx context.Context, in *ReverseTransactionRequest, opts ...grpc.CallOption) (*CompensationResponse, error)
+	Refund(ctx context.Context, in *RefundRequest, opts ...grpc.CallOption) (*CompensationResponse, error)
 }
 
 type transactionServiceClient struct {
@@ -47,11 +74,101 @@ func (c *transactionServiceClient) Deposit(ctx context.Context, in *TransactionR
 	return out, nil
 }
 
+func (c *transactionServiceClient) Withdraw(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, TransactionService_Withdraw_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactionServiceClient) Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferResponse)
+	err := c.cc.Invoke(ctx, TransactionService_Transfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactionServiceClient) GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, TransactionService_GetTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactionServiceClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTransactionsResponse)
+	err := c.cc.Invoke(ctx, TransactionService_ListTransactions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactionServiceClient) TransitionStatus(ctx context.Context, in *TransitionStatusRequest, opts ...grpc.CallOption) (*TransitionStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransitionStatusResponse)
+	err := c.cc.Invoke(ctx, TransactionService_TransitionStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactionServiceClient) ReverseTransaction(ctx context.Context, in *ReverseTransactionRequest, opts ...grpc.CallOption) (*CompensationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompensationResponse)
+	err := c.cc.Invoke(ctx, TransactionService_ReverseTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactionServiceClient) Refund(ctx context.Context, in *RefundRequest, opts ...grpc.CallOption) (*CompensationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompensationResponse)
+	err := c.cc.Invoke(ctx, TransactionService_Refund_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TransactionServiceServer is the server API for TransactionService service.
 // All implementations must embed UnimplementedTransactionServiceServer
 // for forward compatibility.
 type TransactionServiceServer interface {
 	Deposit(context.Context, *TransactionRequest) (*TransactionResponse, error)
+	// Withdraw records a PENDING withdrawal. The wallet service debits it, or
+	// fails it when the balance is insufficient.
+	Withdraw(context.Context, *TransactionRequest) (*TransactionResponse, error)
+	// Transfer records a PENDING debit of the source and credit of the destination,
+	// linked by a transfer ID. The wallet service applies both or neither.
+	Transfer(context.Context, *TransferRequest) (*TransferResponse, error)
+	GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error)
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error)
+	// TransitionStatus moves transactions along their lifecycle, for the
+	// services that drive its later stages, such as capture. Transactions the
+	// lifecycle doesn't allow to move are left as they are and reported rejected.
+	TransitionStatus(context.Context, *TransitionStatusRequest) (*TransitionStatusResponse, error)
+	// ReverseTransaction takes back what remains of a completed deposit, and
+	// Refund part of it, for admin and support tools, not on behalf of a user.
+	// Both record a PENDING compensating transaction linked to the deposit, which
+	// the wallet service debits from its wallet or refuses, e.g. with
+	// insufficient_funds. The deposit moves to REVERSED or PARTIALLY_REFUNDED
+	// once the debit completes.
+	ReverseTransaction(context.Context, *ReverseTransactionRequest) (*CompensationResponse, error)
+	Refund(context.Context, *RefundRequest) (*CompensationResponse, error)
 	mustEmbedUnimplementedTransactionServiceServer()
 }
 
@@ -65,6 +182,27 @@ type UnimplementedTransactionServiceServer struct{}
 func (UnimplementedTransactionServiceServer) Deposit(context.Context, *TransactionRequest) (*TransactionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Deposit not implemented")
 }
+func (UnimplementedTransactionServiceServer) Withdraw(context.Context, *TransactionRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Withdraw not implemented")
+}
+func (UnimplementedTransactionServiceServer) Transfer(context.Context, *TransferRequest) (*TransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (UnimplementedTransactionServiceServer) GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedTransactionServiceServer) ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedTransactionServiceServer) TransitionStatus(context.Context, *TransitionStatusRequest) (*TransitionStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransitionStatus not implemented")
+}
+func (UnimplementedTransactionServiceServer) ReverseTransaction(context.Context, *ReverseTransactionRequest) (*CompensationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReverseTransaction not implemented")
+}
+func (UnimplementedTransactionServiceServer) Refund(context.Context, *RefundRequest) (*CompensationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Refund not implemented")
+}
 func (UnimplementedTransactionServiceServer) mustEmbedUnimplementedTransactionServiceServer() {}
 func (UnimplementedTransactionServiceServer) testEmbeddedByValue()                            {}
 
@@ -104,6 +242,132 @@ func _TransactionService_Deposit_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TransactionService_Withdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionServiceServer).Withdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionService_Withdraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionServiceServer).Withdraw(ctx, req.(*TransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransactionService_Transfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionServiceServer).Transfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionService_Transfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionServiceServer).Transfer(ctx, req.(*TransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransactionService_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionServiceServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionService_GetTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionServiceServer).GetTransaction(ctx, req.(*GetTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransactionService_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionServiceServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionService_ListTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionServiceServer).ListTransactions(ctx, req.(*ListTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransactionService_TransitionStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransitionStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionServiceServer).TransitionStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionService_TransitionStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionServiceServer).TransitionStatus(ctx, req.(*TransitionStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransactionService_ReverseTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReverseTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionServiceServer).ReverseTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionService_ReverseTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionServiceServer).ReverseTransaction(ctx, req.(*ReverseTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransactionService_Refund_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionServiceServer).Refund(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionService_Refund_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionServiceServer).Refund(ctx, req.(*RefundRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TransactionService_ServiceDesc is the grpc.ServiceDesc for TransactionService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +379,34 @@ var TransactionService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Deposit",
 			Handler:    _TransactionService_Deposit_Handler,
 		},
+		{
+			MethodName: "Withdraw",
+			Handler:    _TransactionService_Withdraw_Handler,
+		},
+		{
+			MethodName: "Transfer",
+			Handler:    _TransactionService_Transfer_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _TransactionService_GetTransaction_Handler,
+		},
+		{
+			MethodName: "ListTransactions",
+			Handler:    _TransactionService_ListTransactions_Handler,
+		},
+		{
+			MethodName: "TransitionStatus",
+			Handler:    _TransactionService_TransitionStatus_Handler,
+		},
+		{
+			MethodName: "ReverseTransaction",
+			Handler:    _TransactionService_ReverseTransaction_Handler,
+		},
+		{
+			MethodName: "Refund",
+			Handler:    _TransactionService_Refund_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "transaction.proto",
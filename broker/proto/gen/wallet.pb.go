@@ -67,9 +67,18 @@ func (x *ViewBalanceRequest) GetWalletId() string {
 }
 
 type ViewBalanceResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Balance       float64                `protobuf:"fixed64,1,opt,name=balance,proto3" json:"balance,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: use balance_money, kept for clients that predate it.
+	//
+	// Deprecated: Marked as deprecated in wallet.proto.
+	Balance      float64 `protobuf:"fixed64,1,opt,name=balance,proto3" json:"balance,omitempty"`
+	Name         string  `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	BalanceMoney *Money  `protobuf:"bytes,3,opt,name=balance_money,json=balanceMoney,proto3" json:"balance_money,omitempty"`
+	// available_money is balance_money less held_money, what can be spent.
+	AvailableMoney *Money `protobuf:"bytes,4,opt,name=available_money,json=availableMoney,proto3" json:"available_money,omitempty"`
+	HeldMoney      *Money `protobuf:"bytes,5,opt,name=held_money,json=heldMoney,proto3" json:"held_money,omitempty"`
+	// ISO 4217 code of the wallet, the currency of all its amounts.
+	Currency      string `protobuf:"bytes,6,opt,name=currency,proto3" json:"currency,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -104,6 +113,7 @@ func (*ViewBalanceResponse) Descriptor() ([]byte, []int) {
 	return file_wallet_proto_rawDescGZIP(), []int{1}
 }
 
+// Deprecated: Marked as deprecated in wallet.proto.
 func (x *ViewBalanceResponse) GetBalance() float64 {
 	if x != nil {
 		return x.Balance
@@ -118,9 +128,39 @@ func (x *ViewBalanceResponse) GetName() string {
 	return ""
 }
 
+func (x *ViewBalanceResponse) GetBalanceMoney() *Money {
+	if x != nil {
+		return x.BalanceMoney
+	}
+	return nil
+}
+
+func (x *ViewBalanceResponse) GetAvailableMoney() *Money {
+	if x != nil {
+		return x.AvailableMoney
+	}
+	return nil
+}
+
+func (x *ViewBalanceResponse) GetHeldMoney() *Money {
+	if x != nil {
+		return x.HeldMoney
+	}
+	return nil
+}
+
+func (x *ViewBalanceResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
 type CreateWalletRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// ISO 4217 code, EUR when empty. A wallet's currency never changes.
+	Currency      string `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -162,6 +202,13 @@ func (x *CreateWalletRequest) GetName() string {
 	return ""
 }
 
+func (x *CreateWalletRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
 type CreateWalletResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	WalletId      string                 `protobuf:"bytes,1,opt,name=wallet_id,json=walletId,proto3" json:"wallet_id,omitempty"`
@@ -259,8 +306,10 @@ func (x *IsOwnerRequest) GetWalletId() string {
 }
 
 type IsOwnerResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Valid         bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Valid bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	// Closed wallets are still owned, but take no new transactions.
+	Closed        bool `protobuf:"varint,2,opt,name=closed,proto3" json:"closed,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -302,29 +351,1523 @@ func (x *IsOwnerResponse) GetValid() bool {
 	return false
 }
 
-var File_wallet_proto protoreflect.FileDescriptor
+func (x *IsOwnerResponse) GetClosed() bool {
+	if x != nil {
+		return x.Closed
+	}
+	return false
+}
 
-const file_wallet_proto_rawDesc = "" +
-	"\n" +
-	"\fwallet.proto\x12\x06wallet\x1a\x1bgoogle/protobuf/empty.proto\"1\n" +
-	"\x12ViewBalanceRequest\x12\x1b\n" +
-	"\twallet_id\x18\x01 \x01(\tR\bwalletId\"C\n" +
-	"\x13ViewBalanceResponse\x12\x18\n" +
-	"\abalance\x18\x01 \x01(\x01R\abalance\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\")\n" +
-	"\x13CreateWalletRequest\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\"3\n" +
-	"\x14CreateWalletResponse\x12\x1b\n" +
-	"\twallet_id\x18\x01 \x01(\tR\bwalletId\"F\n" +
-	"\x0eIsOwnerRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x1b\n" +
-	"\twallet_id\x18\x02 \x01(\tR\bwalletId\"'\n" +
-	"\x0fIsOwnerResponse\x12\x14\n" +
-	"\x05valid\x18\x01 \x01(\bR\x05valid2\xa3\x02\n" +
+type Wallet struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Deprecated: use balance_money, kept for clients that predate it.
+	//
+	// Deprecated: Marked as deprecated in wallet.proto.
+	Balance float64 `protobuf:"fixed64,3,opt,name=balance,proto3" json:"balance,omitempty"`
+	// ACTIVE or CLOSED.
+	Status string `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	// RFC 3339 timestamps, closed_at is empty while the wallet is active.
+	CreatedAt     string `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     string `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ClosedAt      string `protobuf:"bytes,7,opt,name=closed_at,json=closedAt,proto3" json:"closed_at,omitempty"`
+	BalanceMoney  *Money `protobuf:"bytes,8,opt,name=balance_money,json=balanceMoney,proto3" json:"balance_money,omitempty"`
+	Currency      string `protobuf:"bytes,9,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Wallet) Reset() {
+	*x = Wallet{}
+	mi := &file_wallet_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Wallet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Wallet) ProtoMessage() {}
+
+func (x *Wallet) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Wallet.ProtoReflect.Descriptor instead.
+func (*Wallet) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Wallet) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Wallet) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in wallet.proto.
+func (x *Wallet) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *Wallet) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Wallet) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *Wallet) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+func (x *Wallet) GetClosedAt() string {
+	if x != nil {
+		return x.ClosedAt
+	}
+	return ""
+}
+
+func (x *Wallet) GetBalanceMoney() *Money {
+	if x != nil {
+		return x.BalanceMoney
+	}
+	return nil
+}
+
+func (x *Wallet) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type WalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WalletId      string                 `protobuf:"bytes,1,opt,name=wallet_id,json=walletId,proto3" json:"wallet_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletRequest) Reset() {
+	*x = WalletRequest{}
+	mi := &file_wallet_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletRequest) ProtoMessage() {}
+
+func (x *WalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletRequest.ProtoReflect.Descriptor instead.
+func (*WalletRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WalletRequest) GetWalletId() string {
+	if x != nil {
+		return x.WalletId
+	}
+	return ""
+}
+
+type WalletResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Wallet        *Wallet                `protobuf:"bytes,1,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletResponse) Reset() {
+	*x = WalletResponse{}
+	mi := &file_wallet_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletResponse) ProtoMessage() {}
+
+func (x *WalletResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletResponse.ProtoReflect.Descriptor instead.
+func (*WalletResponse) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WalletResponse) GetWallet() *Wallet {
+	if x != nil {
+		return x.Wallet
+	}
+	return nil
+}
+
+type ListWalletsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Wallets       []*Wallet              `protobuf:"bytes,1,rep,name=wallets,proto3" json:"wallets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWalletsResponse) Reset() {
+	*x = ListWalletsResponse{}
+	mi := &file_wallet_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWalletsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWalletsResponse) ProtoMessage() {}
+
+func (x *ListWalletsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWalletsResponse.ProtoReflect.Descriptor instead.
+func (*ListWalletsResponse) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListWalletsResponse) GetWallets() []*Wallet {
+	if x != nil {
+		return x.Wallets
+	}
+	return nil
+}
+
+type RenameWalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WalletId      string                 `protobuf:"bytes,1,opt,name=wallet_id,json=walletId,proto3" json:"wallet_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenameWalletRequest) Reset() {
+	*x = RenameWalletRequest{}
+	mi := &file_wallet_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenameWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameWalletRequest) ProtoMessage() {}
+
+func (x *RenameWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameWalletRequest.ProtoReflect.Descriptor instead.
+func (*RenameWalletRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RenameWalletRequest) GetWalletId() string {
+	if x != nil {
+		return x.WalletId
+	}
+	return ""
+}
+
+func (x *RenameWalletRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetLedgerRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	WalletId string                 `protobuf:"bytes,1,opt,name=wallet_id,json=walletId,proto3" json:"wallet_id,omitempty"`
+	Limit    int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	// next_cursor of the previous page, empty for the first page.
+	Cursor        string `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLedgerRequest) Reset() {
+	*x = GetLedgerRequest{}
+	mi := &file_wallet_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLedgerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLedgerRequest) ProtoMessage() {}
+
+func (x *GetLedgerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLedgerRequest.ProtoReflect.Descriptor instead.
+func (*GetLedgerRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetLedgerRequest) GetWalletId() string {
+	if x != nil {
+		return x.WalletId
+	}
+	return ""
+}
+
+func (x *GetLedgerRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetLedgerRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+// LedgerEntry is a posting to the wallet's account.
+type LedgerEntry struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	JournalId string                 `protobuf:"bytes,2,opt,name=journal_id,json=journalId,proto3" json:"journal_id,omitempty"`
+	// DEPOSIT, WITHDRAW, TRANSFER, CAPTURE, CONVERSION, REVERSAL or OPENING.
+	Kind          string `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
+	TransactionId string `protobuf:"bytes,4,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	// CREDIT raises the balance, DEBIT lowers it.
+	Direction    string `protobuf:"bytes,5,opt,name=direction,proto3" json:"direction,omitempty"`
+	Amount       *Money `protobuf:"bytes,6,opt,name=amount,proto3" json:"amount,omitempty"`
+	BalanceAfter *Money `protobuf:"bytes,7,opt,name=balance_after,json=balanceAfter,proto3" json:"balance_after,omitempty"`
+	// RFC 3339 timestamp.
+	CreatedAt string `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// The other side of the journal: a system account, or "wallet" with its ID.
+	CounterAccount  string `protobuf:"bytes,9,opt,name=counter_account,json=counterAccount,proto3" json:"counter_account,omitempty"`
+	CounterWalletId string `protobuf:"bytes,10,opt,name=counter_wallet_id,json=counterWalletId,proto3" json:"counter_wallet_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *LedgerEntry) Reset() {
+	*x = LedgerEntry{}
+	mi := &file_wallet_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LedgerEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LedgerEntry) ProtoMessage() {}
+
+func (x *LedgerEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LedgerEntry.ProtoReflect.Descriptor instead.
+func (*LedgerEntry) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *LedgerEntry) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *LedgerEntry) GetJournalId() string {
+	if x != nil {
+		return x.JournalId
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetDirection() string {
+	if x != nil {
+		return x.Direction
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *LedgerEntry) GetBalanceAfter() *Money {
+	if x != nil {
+		return x.BalanceAfter
+	}
+	return nil
+}
+
+func (x *LedgerEntry) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetCounterAccount() string {
+	if x != nil {
+		return x.CounterAccount
+	}
+	return ""
+}
+
+func (x *LedgerEntry) GetCounterWalletId() string {
+	if x != nil {
+		return x.CounterWalletId
+	}
+	return ""
+}
+
+type GetLedgerResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Entries []*LedgerEntry         `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// Empty on the last page.
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLedgerResponse) Reset() {
+	*x = GetLedgerResponse{}
+	mi := &file_wallet_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLedgerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLedgerResponse) ProtoMessage() {}
+
+func (x *GetLedgerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLedgerResponse.ProtoReflect.Descriptor instead.
+func (*GetLedgerResponse) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetLedgerResponse) GetEntries() []*LedgerEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *GetLedgerResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type ProcessedTransactionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessedTransactionRequest) Reset() {
+	*x = ProcessedTransactionRequest{}
+	mi := &file_wallet_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessedTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessedTransactionRequest) ProtoMessage() {}
+
+func (x *ProcessedTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessedTransactionRequest.ProtoReflect.Descriptor instead.
+func (*ProcessedTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ProcessedTransactionRequest) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+type ProcessedTransactionResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// False when the wallet service hasn't seen the transaction's event, the
+	// other fields are then empty.
+	Processed bool `protobuf:"varint,1,opt,name=processed,proto3" json:"processed,omitempty"`
+	// DEPOSIT.
+	Kind string `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	// COMPLETED or FAILED.
+	Status        string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	FailureReason string `protobuf:"bytes,4,opt,name=failure_reason,json=failureReason,proto3" json:"failure_reason,omitempty"`
+	// RFC 3339 timestamp.
+	ProcessedAt   string `protobuf:"bytes,5,opt,name=processed_at,json=processedAt,proto3" json:"processed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessedTransactionResponse) Reset() {
+	*x = ProcessedTransactionResponse{}
+	mi := &file_wallet_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessedTransactionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessedTransactionResponse) ProtoMessage() {}
+
+func (x *ProcessedTransactionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessedTransactionResponse.ProtoReflect.Descriptor instead.
+func (*ProcessedTransactionResponse) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ProcessedTransactionResponse) GetProcessed() bool {
+	if x != nil {
+		return x.Processed
+	}
+	return false
+}
+
+func (x *ProcessedTransactionResponse) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *ProcessedTransactionResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ProcessedTransactionResponse) GetFailureReason() string {
+	if x != nil {
+		return x.FailureReason
+	}
+	return ""
+}
+
+func (x *ProcessedTransactionResponse) GetProcessedAt() string {
+	if x != nil {
+		return x.ProcessedAt
+	}
+	return ""
+}
+
+// BalanceSummary is a wallet's balance next to the ledger postings it should
+// equal.
+type BalanceSummary struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	WalletId string                 `protobuf:"bytes,1,opt,name=wallet_id,json=walletId,proto3" json:"wallet_id,omitempty"`
+	Balance  *Money                 `protobuf:"bytes,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	// The net of all the wallet's ledger postings.
+	LedgerBalance *Money `protobuf:"bytes,3,opt,name=ledger_balance,json=ledgerBalance,proto3" json:"ledger_balance,omitempty"`
+	// The net of the postings made without a transaction of the transaction
+	// service: hold captures and conversions.
+	Adjustments   *Money `protobuf:"bytes,4,opt,name=adjustments,proto3" json:"adjustments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BalanceSummary) Reset() {
+	*x = BalanceSummary{}
+	mi := &file_wallet_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BalanceSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BalanceSummary) ProtoMessage() {}
+
+func (x *BalanceSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BalanceSummary.ProtoReflect.Descriptor instead.
+func (*BalanceSummary) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BalanceSummary) GetWalletId() string {
+	if x != nil {
+		return x.WalletId
+	}
+	return ""
+}
+
+func (x *BalanceSummary) GetBalance() *Money {
+	if x != nil {
+		return x.Balance
+	}
+	return nil
+}
+
+func (x *BalanceSummary) GetLedgerBalance() *Money {
+	if x != nil {
+		return x.LedgerBalance
+	}
+	return nil
+}
+
+func (x *BalanceSummary) GetAdjustments() *Money {
+	if x != nil {
+		return x.Adjustments
+	}
+	return nil
+}
+
+type PlaceHoldRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	WalletId string                 `protobuf:"bytes,1,opt,name=wallet_id,json=walletId,proto3" json:"wallet_id,omitempty"`
+	// In the wallet's currency, refused otherwise.
+	Amount *Money `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	// How long the hold lasts before it is released, 7 days when zero.
+	TtlSeconds int64 `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	// The caller's own identifier for the payment, e.g. an order ID.
+	Reference     string `protobuf:"bytes,4,opt,name=reference,proto3" json:"reference,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceHoldRequest) Reset() {
+	*x = PlaceHoldRequest{}
+	mi := &file_wallet_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceHoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceHoldRequest) ProtoMessage() {}
+
+func (x *PlaceHoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceHoldRequest.ProtoReflect.Descriptor instead.
+func (*PlaceHoldRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PlaceHoldRequest) GetWalletId() string {
+	if x != nil {
+		return x.WalletId
+	}
+	return ""
+}
+
+func (x *PlaceHoldRequest) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *PlaceHoldRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *PlaceHoldRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+type CaptureHoldRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	HoldId string                 `protobuf:"bytes,1,opt,name=hold_id,json=holdId,proto3" json:"hold_id,omitempty"`
+	// At most the held amount and in its currency, the whole hold when unset.
+	Amount        *Money `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CaptureHoldRequest) Reset() {
+	*x = CaptureHoldRequest{}
+	mi := &file_wallet_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CaptureHoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureHoldRequest) ProtoMessage() {}
+
+func (x *CaptureHoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureHoldRequest.ProtoReflect.Descriptor instead.
+func (*CaptureHoldRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CaptureHoldRequest) GetHoldId() string {
+	if x != nil {
+		return x.HoldId
+	}
+	return ""
+}
+
+func (x *CaptureHoldRequest) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+type HoldRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HoldId        string                 `protobuf:"bytes,1,opt,name=hold_id,json=holdId,proto3" json:"hold_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldRequest) Reset() {
+	*x = HoldRequest{}
+	mi := &file_wallet_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldRequest) ProtoMessage() {}
+
+func (x *HoldRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldRequest.ProtoReflect.Descriptor instead.
+func (*HoldRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *HoldRequest) GetHoldId() string {
+	if x != nil {
+		return x.HoldId
+	}
+	return ""
+}
+
+type Hold struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	WalletId string                 `protobuf:"bytes,2,opt,name=wallet_id,json=walletId,proto3" json:"wallet_id,omitempty"`
+	Amount   *Money                 `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	// Set once the hold is captured.
+	CapturedAmount *Money `protobuf:"bytes,4,opt,name=captured_amount,json=capturedAmount,proto3" json:"captured_amount,omitempty"`
+	// ACTIVE, CAPTURED, VOIDED or EXPIRED.
+	Status    string `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	Reference string `protobuf:"bytes,6,opt,name=reference,proto3" json:"reference,omitempty"`
+	// RFC 3339 timestamps.
+	ExpiresAt     string `protobuf:"bytes,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	CreatedAt     string `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     string `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hold) Reset() {
+	*x = Hold{}
+	mi := &file_wallet_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hold) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hold) ProtoMessage() {}
+
+func (x *Hold) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hold.ProtoReflect.Descriptor instead.
+func (*Hold) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Hold) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Hold) GetWalletId() string {
+	if x != nil {
+		return x.WalletId
+	}
+	return ""
+}
+
+func (x *Hold) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *Hold) GetCapturedAmount() *Money {
+	if x != nil {
+		return x.CapturedAmount
+	}
+	return nil
+}
+
+func (x *Hold) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Hold) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *Hold) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *Hold) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *Hold) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+type HoldResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hold          *Hold                  `protobuf:"bytes,1,opt,name=hold,proto3" json:"hold,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldResponse) Reset() {
+	*x = HoldResponse{}
+	mi := &file_wallet_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldResponse) ProtoMessage() {}
+
+func (x *HoldResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldResponse.ProtoReflect.Descriptor instead.
+func (*HoldResponse) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *HoldResponse) GetHold() *Hold {
+	if x != nil {
+		return x.Hold
+	}
+	return nil
+}
+
+type CreateQuoteRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SourceWalletId string                 `protobuf:"bytes,1,opt,name=source_wallet_id,json=sourceWalletId,proto3" json:"source_wallet_id,omitempty"`
+	// Any active wallet in another currency, including other users' ones.
+	DestinationWalletId string `protobuf:"bytes,2,opt,name=destination_wallet_id,json=destinationWalletId,proto3" json:"destination_wallet_id,omitempty"`
+	// The amount to sell, in the source wallet's currency.
+	Amount        *Money `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateQuoteRequest) Reset() {
+	*x = CreateQuoteRequest{}
+	mi := &file_wallet_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateQuoteRequest) ProtoMessage() {}
+
+func (x *CreateQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateQuoteRequest.ProtoReflect.Descriptor instead.
+func (*CreateQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *CreateQuoteRequest) GetSourceWalletId() string {
+	if x != nil {
+		return x.SourceWalletId
+	}
+	return ""
+}
+
+func (x *CreateQuoteRequest) GetDestinationWalletId() string {
+	if x != nil {
+		return x.DestinationWalletId
+	}
+	return ""
+}
+
+func (x *CreateQuoteRequest) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+type ExecuteConversionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QuoteId       string                 `protobuf:"bytes,1,opt,name=quote_id,json=quoteId,proto3" json:"quote_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteConversionRequest) Reset() {
+	*x = ExecuteConversionRequest{}
+	mi := &file_wallet_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteConversionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteConversionRequest) ProtoMessage() {}
+
+func (x *ExecuteConversionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteConversionRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteConversionRequest) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ExecuteConversionRequest) GetQuoteId() string {
+	if x != nil {
+		return x.QuoteId
+	}
+	return ""
+}
+
+type Quote struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Id                  string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	SourceWalletId      string                 `protobuf:"bytes,2,opt,name=source_wallet_id,json=sourceWalletId,proto3" json:"source_wallet_id,omitempty"`
+	DestinationWalletId string                 `protobuf:"bytes,3,opt,name=destination_wallet_id,json=destinationWalletId,proto3" json:"destination_wallet_id,omitempty"`
+	// source_amount is debited from the source wallet. fee, in the same
+	// currency, is taken from it before target_amount is bought at rate.
+	SourceAmount *Money `protobuf:"bytes,4,opt,name=source_amount,json=sourceAmount,proto3" json:"source_amount,omitempty"`
+	Fee          *Money `protobuf:"bytes,5,opt,name=fee,proto3" json:"fee,omitempty"`
+	TargetAmount *Money `protobuf:"bytes,6,opt,name=target_amount,json=targetAmount,proto3" json:"target_amount,omitempty"`
+	// Decimal strings, the price of one source unit in the target currency.
+	// rate is mid_rate less the spread.
+	MidRate   string `protobuf:"bytes,7,opt,name=mid_rate,json=midRate,proto3" json:"mid_rate,omitempty"`
+	Rate      string `protobuf:"bytes,8,opt,name=rate,proto3" json:"rate,omitempty"`
+	SpreadBps int64  `protobuf:"varint,9,opt,name=spread_bps,json=spreadBps,proto3" json:"spread_bps,omitempty"`
+	FeeBps    int64  `protobuf:"varint,10,opt,name=fee_bps,json=feeBps,proto3" json:"fee_bps,omitempty"`
+	// OPEN, EXECUTED or EXPIRED.
+	Status string `protobuf:"bytes,11,opt,name=status,proto3" json:"status,omitempty"`
+	// RFC 3339 timestamps, executed_at is set once executed.
+	ExpiresAt     string `protobuf:"bytes,12,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	CreatedAt     string `protobuf:"bytes,13,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExecutedAt    string `protobuf:"bytes,14,opt,name=executed_at,json=executedAt,proto3" json:"executed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quote) Reset() {
+	*x = Quote{}
+	mi := &file_wallet_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quote) ProtoMessage() {}
+
+func (x *Quote) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quote.ProtoReflect.Descriptor instead.
+func (*Quote) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *Quote) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Quote) GetSourceWalletId() string {
+	if x != nil {
+		return x.SourceWalletId
+	}
+	return ""
+}
+
+func (x *Quote) GetDestinationWalletId() string {
+	if x != nil {
+		return x.DestinationWalletId
+	}
+	return ""
+}
+
+func (x *Quote) GetSourceAmount() *Money {
+	if x != nil {
+		return x.SourceAmount
+	}
+	return nil
+}
+
+func (x *Quote) GetFee() *Money {
+	if x != nil {
+		return x.Fee
+	}
+	return nil
+}
+
+func (x *Quote) GetTargetAmount() *Money {
+	if x != nil {
+		return x.TargetAmount
+	}
+	return nil
+}
+
+func (x *Quote) GetMidRate() string {
+	if x != nil {
+		return x.MidRate
+	}
+	return ""
+}
+
+func (x *Quote) GetRate() string {
+	if x != nil {
+		return x.Rate
+	}
+	return ""
+}
+
+func (x *Quote) GetSpreadBps() int64 {
+	if x != nil {
+		return x.SpreadBps
+	}
+	return 0
+}
+
+func (x *Quote) GetFeeBps() int64 {
+	if x != nil {
+		return x.FeeBps
+	}
+	return 0
+}
+
+func (x *Quote) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Quote) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *Quote) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *Quote) GetExecutedAt() string {
+	if x != nil {
+		return x.ExecutedAt
+	}
+	return ""
+}
+
+type QuoteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quote         *Quote                 `protobuf:"bytes,1,opt,name=quote,proto3" json:"quote,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuoteResponse) Reset() {
+	*x = QuoteResponse{}
+	mi := &file_wallet_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuoteResponse) ProtoMessage() {}
+
+func (x *QuoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wallet_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuoteResponse.ProtoReflect.Descriptor instead.
+func (*QuoteResponse) Descriptor() ([]byte, []int) {
+	return file_wallet_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *QuoteResponse) GetQuote() *Quote {
+	if x != nil {
+		return x.Quote
+	}
+	return nil
+}
+
+var File_wallet_proto protoreflect.FileDescriptor
+
+const file_wallet_proto_rawDesc = "" +
+	"\n" +
+	"\fwallet.proto\x12\x06wallet\x1a\x1bgoogle/protobuf/empty.proto\x1a\vmoney.proto\"1\n" +
+	"\x12ViewBalanceRequest\x12\x1b\n" +
+	"\twallet_id\x18\x01 \x01(\tR\bwalletId\"\xfa\x01\n" +
+	"\x13ViewBalanceResponse\x12\x1c\n" +
+	"\abalance\x18\x01 \x01(\x01B\x02\x18\x01R\abalance\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x121\n" +
+	"\rbalance_money\x18\x03 \x01(\v2\f.money.MoneyR\fbalanceMoney\x125\n" +
+	"\x0favailable_money\x18\x04 \x01(\v2\f.money.MoneyR\x0eavailableMoney\x12+\n" +
+	"\n" +
+	"held_money\x18\x05 \x01(\v2\f.money.MoneyR\theldMoney\x12\x1a\n" +
+	"\bcurrency\x18\x06 \x01(\tR\bcurrency\"E\n" +
+	"\x13CreateWalletRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\"3\n" +
+	"\x14CreateWalletResponse\x12\x1b\n" +
+	"\twallet_id\x18\x01 \x01(\tR\bwalletId\"F\n" +
+	"\x0eIsOwnerRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x1b\n" +
+	"\twallet_id\x18\x02 \x01(\tR\bwalletId\"?\n" +
+	"\x0fIsOwnerResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12\x16\n" +
+	"\x06closed\x18\x02 \x01(\bR\x06closed\"\x8c\x02\n" +
+	"\x06Wallet\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1c\n" +
+	"\abalance\x18\x03 \x01(\x01B\x02\x18\x01R\abalance\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\tR\tupdatedAt\x12\x1b\n" +
+	"\tclosed_at\x18\a \x01(\tR\bclosedAt\x121\n" +
+	"\rbalance_money\x18\b \x01(\v2\f.money.MoneyR\fbalanceMoney\x12\x1a\n" +
+	"\bcurrency\x18\t \x01(\tR\bcurrency\",\n" +
+	"\rWalletRequest\x12\x1b\n" +
+	"\twallet_id\x18\x01 \x01(\tR\bwalletId\"8\n" +
+	"\x0eWalletResponse\x12&\n" +
+	"\x06wallet\x18\x01 \x01(\v2\x0e.wallet.WalletR\x06wallet\"?\n" +
+	"\x13ListWalletsResponse\x12(\n" +
+	"\awallets\x18\x01 \x03(\v2\x0e.wallet.WalletR\awallets\"F\n" +
+	"\x13RenameWalletRequest\x12\x1b\n" +
+	"\twallet_id\x18\x01 \x01(\tR\bwalletId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"]\n" +
+	"\x10GetLedgerRequest\x12\x1b\n" +
+	"\twallet_id\x18\x01 \x01(\tR\bwalletId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x03 \x01(\tR\x06cursor\"\xe2\x02\n" +
+	"\vLedgerEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\n" +
+	"journal_id\x18\x02 \x01(\tR\tjournalId\x12\x12\n" +
+	"\x04kind\x18\x03 \x01(\tR\x04kind\x12%\n" +
+	"\x0etransaction_id\x18\x04 \x01(\tR\rtransactionId\x12\x1c\n" +
+	"\tdirection\x18\x05 \x01(\tR\tdirection\x12$\n" +
+	"\x06amount\x18\x06 \x01(\v2\f.money.MoneyR\x06amount\x121\n" +
+	"\rbalance_after\x18\a \x01(\v2\f.money.MoneyR\fbalanceAfter\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\tR\tcreatedAt\x12'\n" +
+	"\x0fcounter_account\x18\t \x01(\tR\x0ecounterAccount\x12*\n" +
+	"\x11counter_wallet_id\x18\n" +
+	" \x01(\tR\x0fcounterWalletId\"c\n" +
+	"\x11GetLedgerResponse\x12-\n" +
+	"\aentries\x18\x01 \x03(\v2\x13.wallet.LedgerEntryR\aentries\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"D\n" +
+	"\x1bProcessedTransactionRequest\x12%\n" +
+	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId\"\xb2\x01\n" +
+	"\x1cProcessedTransactionResponse\x12\x1c\n" +
+	"\tprocessed\x18\x01 \x01(\bR\tprocessed\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12%\n" +
+	"\x0efailure_reason\x18\x04 \x01(\tR\rfailureReason\x12!\n" +
+	"\fprocessed_at\x18\x05 \x01(\tR\vprocessedAt\"\xba\x01\n" +
+	"\x0eBalanceSummary\x12\x1b\n" +
+	"\twallet_id\x18\x01 \x01(\tR\bwalletId\x12&\n" +
+	"\abalance\x18\x02 \x01(\v2\f.money.MoneyR\abalance\x123\n" +
+	"\x0eledger_balance\x18\x03 \x01(\v2\f.money.MoneyR\rledgerBalance\x12.\n" +
+	"\vadjustments\x18\x04 \x01(\v2\f.money.MoneyR\vadjustments\"\x94\x01\n" +
+	"\x10PlaceHoldRequest\x12\x1b\n" +
+	"\twallet_id\x18\x01 \x01(\tR\bwalletId\x12$\n" +
+	"\x06amount\x18\x02 \x01(\v2\f.money.MoneyR\x06amount\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x03R\n" +
+	"ttlSeconds\x12\x1c\n" +
+	"\treference\x18\x04 \x01(\tR\treference\"S\n" +
+	"\x12CaptureHoldRequest\x12\x17\n" +
+	"\ahold_id\x18\x01 \x01(\tR\x06holdId\x12$\n" +
+	"\x06amount\x18\x02 \x01(\v2\f.money.MoneyR\x06amount\"&\n" +
+	"\vHoldRequest\x12\x17\n" +
+	"\ahold_id\x18\x01 \x01(\tR\x06holdId\"\xa3\x02\n" +
+	"\x04Hold\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\twallet_id\x18\x02 \x01(\tR\bwalletId\x12$\n" +
+	"\x06amount\x18\x03 \x01(\v2\f.money.MoneyR\x06amount\x125\n" +
+	"\x0fcaptured_amount\x18\x04 \x01(\v2\f.money.MoneyR\x0ecapturedAmount\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1c\n" +
+	"\treference\x18\x06 \x01(\tR\treference\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\tR\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\tR\tupdatedAt\"0\n" +
+	"\fHoldResponse\x12 \n" +
+	"\x04hold\x18\x01 \x01(\v2\f.wallet.HoldR\x04hold\"\x98\x01\n" +
+	"\x12CreateQuoteRequest\x12(\n" +
+	"\x10source_wallet_id\x18\x01 \x01(\tR\x0esourceWalletId\x122\n" +
+	"\x15destination_wallet_id\x18\x02 \x01(\tR\x13destinationWalletId\x12$\n" +
+	"\x06amount\x18\x03 \x01(\v2\f.money.MoneyR\x06amount\"5\n" +
+	"\x18ExecuteConversionRequest\x12\x19\n" +
+	"\bquote_id\x18\x01 \x01(\tR\aquoteId\"\xd9\x03\n" +
+	"\x05Quote\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12(\n" +
+	"\x10source_wallet_id\x18\x02 \x01(\tR\x0esourceWalletId\x122\n" +
+	"\x15destination_wallet_id\x18\x03 \x01(\tR\x13destinationWalletId\x121\n" +
+	"\rsource_amount\x18\x04 \x01(\v2\f.money.MoneyR\fsourceAmount\x12\x1e\n" +
+	"\x03fee\x18\x05 \x01(\v2\f.money.MoneyR\x03fee\x121\n" +
+	"\rtarget_amount\x18\x06 \x01(\v2\f.money.MoneyR\ftargetAmount\x12\x19\n" +
+	"\bmid_rate\x18\a \x01(\tR\amidRate\x12\x12\n" +
+	"\x04rate\x18\b \x01(\tR\x04rate\x12\x1d\n" +
+	"\n" +
+	"spread_bps\x18\t \x01(\x03R\tspreadBps\x12\x17\n" +
+	"\afee_bps\x18\n" +
+	" \x01(\x03R\x06feeBps\x12\x16\n" +
+	"\x06status\x18\v \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\f \x01(\tR\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\r \x01(\tR\tcreatedAt\x12\x1f\n" +
+	"\vexecuted_at\x18\x0e \x01(\tR\n" +
+	"executedAt\"4\n" +
+	"\rQuoteResponse\x12#\n" +
+	"\x05quote\x18\x01 \x01(\v2\r.wallet.QuoteR\x05quote2\xd7\b\n" +
 	"\rWalletService\x12I\n" +
 	"\fCreateWallet\x12\x1b.wallet.CreateWalletRequest\x1a\x1c.wallet.CreateWalletResponse\x12F\n" +
 	"\vViewBalance\x12\x1a.wallet.ViewBalanceRequest\x1a\x1b.wallet.ViewBalanceResponse\x12@\n" +
-	"\rIsWalletOwner\x12\x16.wallet.IsOwnerRequest\x1a\x17.wallet.IsOwnerResponse\x12=\n" +
+	"\rIsWalletOwner\x12\x16.wallet.IsOwnerRequest\x1a\x17.wallet.IsOwnerResponse\x12B\n" +
+	"\vListWallets\x12\x16.google.protobuf.Empty\x1a\x1b.wallet.ListWalletsResponse\x12:\n" +
+	"\tGetWallet\x12\x15.wallet.WalletRequest\x1a\x16.wallet.WalletResponse\x12C\n" +
+	"\fRenameWallet\x12\x1b.wallet.RenameWalletRequest\x1a\x16.wallet.WalletResponse\x12<\n" +
+	"\vCloseWallet\x12\x15.wallet.WalletRequest\x1a\x16.wallet.WalletResponse\x12@\n" +
+	"\tGetLedger\x12\x18.wallet.GetLedgerRequest\x1a\x19.wallet.GetLedgerResponse\x12d\n" +
+	"\x17GetProcessedTransaction\x12#.wallet.ProcessedTransactionRequest\x1a$.wallet.ProcessedTransactionResponse\x12B\n" +
+	"\x0eStreamBalances\x12\x16.google.protobuf.Empty\x1a\x16.wallet.BalanceSummary0\x01\x12;\n" +
+	"\tPlaceHold\x12\x18.wallet.PlaceHoldRequest\x1a\x14.wallet.HoldResponse\x12?\n" +
+	"\vCaptureHold\x12\x1a.wallet.CaptureHoldRequest\x1a\x14.wallet.HoldResponse\x125\n" +
+	"\bVoidHold\x12\x13.wallet.HoldRequest\x1a\x14.wallet.HoldResponse\x12@\n" +
+	"\vCreateQuote\x12\x1a.wallet.CreateQuoteRequest\x1a\x15.wallet.QuoteResponse\x12L\n" +
+	"\x11ExecuteConversion\x12 .wallet.ExecuteConversionRequest\x1a\x15.wallet.QuoteResponse\x12=\n" +
 	"\vHealthCheck\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.EmptyB\tZ\a./protob\x06proto3"
 
 var (
@@ -339,30 +1882,97 @@ func file_wallet_proto_rawDescGZIP() []byte {
 	return file_wallet_proto_rawDescData
 }
 
-var file_wallet_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_wallet_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
 var file_wallet_proto_goTypes = []any{
-	(*ViewBalanceRequest)(nil),   // 0: wallet.ViewBalanceRequest
-	(*ViewBalanceResponse)(nil),  // 1: wallet.ViewBalanceResponse
-	(*CreateWalletRequest)(nil),  // 2: wallet.CreateWalletRequest
-	(*CreateWalletResponse)(nil), // 3: wallet.CreateWalletResponse
-	(*IsOwnerRequest)(nil),       // 4: wallet.IsOwnerRequest
-	(*IsOwnerResponse)(nil),      // 5: wallet.IsOwnerResponse
-	(*emptypb.Empty)(nil),        // 6: google.protobuf.Empty
+	(*ViewBalanceRequest)(nil),           // 0: wallet.ViewBalanceRequest
+	(*ViewBalanceResponse)(nil),          // 1: wallet.ViewBalanceResponse
+	(*CreateWalletRequest)(nil),          // 2: wallet.CreateWalletRequest
+	(*CreateWalletResponse)(nil),         // 3: wallet.CreateWalletResponse
+	(*IsOwnerRequest)(nil),               // 4: wallet.IsOwnerRequest
+	(*IsOwnerResponse)(nil),              // 5: wallet.IsOwnerResponse
+	(*Wallet)(nil),                       // 6: wallet.Wallet
+	(*WalletRequest)(nil),                // 7: wallet.WalletRequest
+	(*WalletResponse)(nil),               // 8: wallet.WalletResponse
+	(*ListWalletsResponse)(nil),          // 9: wallet.ListWalletsResponse
+	(*RenameWalletRequest)(nil),          // 10: wallet.RenameWalletRequest
+	(*GetLedgerRequest)(nil),             // 11: wallet.GetLedgerRequest
+	(*LedgerEntry)(nil),                  // 12: wallet.LedgerEntry
+	(*GetLedgerResponse)(nil),            // 13: wallet.GetLedgerResponse
+	(*ProcessedTransactionRequest)(nil),  // 14: wallet.ProcessedTransactionRequest
+	(*ProcessedTransactionResponse)(nil), // 15: wallet.ProcessedTransactionResponse
+	(*BalanceSummary)(nil),               // 16: wallet.BalanceSummary
+	(*PlaceHoldRequest)(nil),             // 17: wallet.PlaceHoldRequest
+	(*CaptureHoldRequest)(nil),           // 18: wallet.CaptureHoldRequest
+	(*HoldRequest)(nil),                  // 19: wallet.HoldRequest
+	(*Hold)(nil),                         // 20: wallet.Hold
+	(*HoldResponse)(nil),                 // 21: wallet.HoldResponse
+	(*CreateQuoteRequest)(nil),           // 22: wallet.CreateQuoteRequest
+	(*ExecuteConversionRequest)(nil),     // 23: wallet.ExecuteConversionRequest
+	(*Quote)(nil),                        // 24: wallet.Quote
+	(*QuoteResponse)(nil),                // 25: wallet.QuoteResponse
+	(*Money)(nil),                        // 26: money.Money
+	(*emptypb.Empty)(nil),                // 27: google.protobuf.Empty
 }
 var file_wallet_proto_depIdxs = []int32{
-	2, // 0: wallet.WalletService.CreateWallet:input_type -> wallet.CreateWalletRequest
-	0, // 1: wallet.WalletService.ViewBalance:input_type -> wallet.ViewBalanceRequest
-	4, // 2: wallet.WalletService.IsWalletOwner:input_type -> wallet.IsOwnerRequest
-	6, // 3: wallet.WalletService.HealthCheck:input_type -> google.protobuf.Empty
-	3, // 4: wallet.WalletService.CreateWallet:output_type -> wallet.CreateWalletResponse
-	1, // 5: wallet.WalletService.ViewBalance:output_type -> wallet.ViewBalanceResponse
-	5, // 6: wallet.WalletService.IsWalletOwner:output_type -> wallet.IsOwnerResponse
-	6, // 7: wallet.WalletService.HealthCheck:output_type -> google.protobuf.Empty
-	4, // [4:8] is the sub-list for method output_type
-	0, // [0:4] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	26, // 0: wallet.ViewBalanceResponse.balance_money:type_name -> money.Money
+	26, // 1: wallet.ViewBalanceResponse.available_money:type_name -> money.Money
+	26, // 2: wallet.ViewBalanceResponse.held_money:type_name -> money.Money
+	26, // 3: wallet.Wallet.balance_money:type_name -> money.Money
+	6,  // 4: wallet.WalletResponse.wallet:type_name -> wallet.Wallet
+	6,  // 5: wallet.ListWalletsResponse.wallets:type_name -> wallet.Wallet
+	26, // 6: wallet.LedgerEntry.amount:type_name -> money.Money
+	26, // 7: wallet.LedgerEntry.balance_after:type_name -> money.Money
+	12, // 8: wallet.GetLedgerResponse.entries:type_name -> wallet.LedgerEntry
+	26, // 9: wallet.BalanceSummary.balance:type_name -> money.Money
+	26, // 10: wallet.BalanceSummary.ledger_balance:type_name -> money.Money
+	26, // 11: wallet.BalanceSummary.adjustments:type_name -> money.Money
+	26, // 12: wallet.PlaceHoldRequest.amount:type_name -> money.Money
+	26, // 13: wallet.CaptureHoldRequest.amount:type_name -> money.Money
+	26, // 14: wallet.Hold.amount:type_name -> money.Money
+	26, // 15: wallet.Hold.captured_amount:type_name -> money.Money
+	20, // 16: wallet.HoldResponse.hold:type_name -> wallet.Hold
+	26, // 17: wallet.CreateQuoteRequest.amount:type_name -> money.Money
+	26, // 18: wallet.Quote.source_amount:type_name -> money.Money
+	26, // 19: wallet.Quote.fee:type_name -> money.Money
+	26, // 20: wallet.Quote.target_amount:type_name -> money.Money
+	24, // 21: wallet.QuoteResponse.quote:type_name -> wallet.Quote
+	2,  // 22: wallet.WalletService.CreateWallet:input_type -> wallet.CreateWalletRequest
+	0,  // 23: wallet.WalletService.ViewBalance:input_type -> wallet.ViewBalanceRequest
+	4,  // 24: wallet.WalletService.IsWalletOwner:input_type -> wallet.IsOwnerRequest
+	27, // 25: wallet.WalletService.ListWallets:input_type -> google.protobuf.Empty
+	7,  // 26: wallet.WalletService.GetWallet:input_type -> wallet.WalletRequest
+	10, // 27: wallet.WalletService.RenameWallet:input_type -> wallet.RenameWalletRequest
+	7,  // 28: wallet.WalletService.CloseWallet:input_type -> wallet.WalletRequest
+	11, // 29: wallet.WalletService.GetLedger:input_type -> wallet.GetLedgerRequest
+	14, // 30: wallet.WalletService.GetProcessedTransaction:input_type -> wallet.ProcessedTransactionRequest
+	27, // 31: wallet.WalletService.StreamBalances:input_type -> google.protobuf.Empty
+	17, // 32: wallet.WalletService.PlaceHold:input_type -> wallet.PlaceHoldRequest
+	18, // 33: wallet.WalletService.CaptureHold:input_type -> wallet.CaptureHoldRequest
+	19, // 34: wallet.WalletService.VoidHold:input_type -> wallet.HoldRequest
+	22, // 35: wallet.WalletService.CreateQuote:input_type -> wallet.CreateQuoteRequest
+	23, // 36: wallet.WalletService.ExecuteConversion:input_type -> wallet.ExecuteConversionRequest
+	27, // 37: wallet.WalletService.HealthCheck:input_type -> google.protobuf.Empty
+	3,  // 38: wallet.WalletService.CreateWallet:output_type -> wallet.CreateWalletResponse
+	1,  // 39: wallet.WalletService.ViewBalance:output_type -> wallet.ViewBalanceResponse
+	5,  // 40: wallet.WalletService.IsWalletOwner:output_type -> wallet.IsOwnerResponse
+	9,  // 41: wallet.WalletService.ListWallets:output_type -> wallet.ListWalletsResponse
+	8,  // 42: wallet.WalletService.GetWallet:output_type -> wallet.WalletResponse
+	8,  // 43: wallet.WalletService.RenameWallet:output_type -> wallet.WalletResponse
+	8,  // 44: wallet.WalletService.CloseWallet:output_type -> wallet.WalletResponse
+	13, // 45: wallet.WalletService.GetLedger:output_type -> wallet.GetLedgerResponse
+	15, // 46: wallet.WalletService.GetProcessedTransaction:output_type -> wallet.ProcessedTransactionResponse
+	16, // 47: wallet.WalletService.StreamBalances:output_type -> wallet.BalanceSummary
+	21, // 48: wallet.WalletService.PlaceHold:output_type -> wallet.HoldResponse
+	21, // 49: wallet.WalletService.CaptureHold:output_type -> wallet.HoldResponse
+	21, // 50: wallet.WalletService.VoidHold:output_type -> wallet.HoldResponse
+	25, // 51: wallet.WalletService.CreateQuote:output_type -> wallet.QuoteResponse
+	25, // 52: wallet.WalletService.ExecuteConversion:output_type -> wallet.QuoteResponse
+	27, // 53: wallet.WalletService.HealthCheck:output_type -> google.protobuf.Empty
+	38, // [38:54] is the sub-list for method output_type
+	22, // [22:38] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
 }
 
 func init() { file_wallet_proto_init() }
@@ -370,13 +1980,14 @@ func file_wallet_proto_init() {
 	if File_wallet_proto != nil {
 		return
 	}
+	file_money_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_wallet_proto_rawDesc), len(file_wallet_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   26,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
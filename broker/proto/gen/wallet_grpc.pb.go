@@ -20,10 +20,22 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	WalletService_CreateWallet_FullMethodName  = "/wallet.WalletService/CreateWallet"
-	WalletService_ViewBalance_FullMethodName   = "/wallet.WalletService/ViewBalance"
-	WalletService_IsWalletOwner_FullMethodName = "/wallet.WalletService/IsWalletOwner"
-	WalletService_HealthCheck_FullMethodName   = "/wallet.WalletService/HealthCheck"
+	WalletService_CreateWallet_FullMethodName            = "/wallet.WalletService/CreateWallet"
+	WalletService_ViewBalance_FullMethodName             = "/wallet.WalletService/ViewBalance"
+	WalletService_IsWalletOwner_FullMethodName           = "/wallet.WalletService/IsWalletOwner"
+	WalletService_ListWallets_FullMethodName             = "/wallet.WalletService/ListWallets"
+	WalletService_GetWallet_FullMethodName               = "/wallet.WalletService/GetWallet"
+	WalletService_RenameWallet_FullMethodName            = "/wallet.WalletService/RenameWallet"
+	WalletService_CloseWallet_FullMethodName             = "/wallet.WalletService/CloseWallet"
+	WalletService_GetLedger_FullMethodName               = "/wallet.WalletService/GetLedger"
+	WalletService_GetProcessedTransaction_FullMethodName = "/wallet.WalletService/GetProcessedTransaction"
+	WalletService_StreamBalances_FullMethodName          = "/wallet.WalletService/StreamBalances"
+	WalletService_PlaceHold_FullMethodName               = "/wallet.WalletService/PlaceHold"
+	WalletService_CaptureHold_FullMethodName             = "/wallet.WalletService/CaptureHold"
+	WalletService_VoidHold_FullMethodName                = "/wallet.WalletService/VoidHold"
+	WalletService_CreateQuote_FullMethodName             = "/wallet.WalletService/CreateQuote"
+	WalletService_ExecuteConversion_FullMethodName       = "/wallet.WalletService/ExecuteConversion"
+	WalletService_HealthCheck_FullMethodName             = "/wallet.WalletService/HealthCheck"
 )
 
 // WalletServiceClient is the client API for WalletService service.
@@ -33,6 +45,33 @@ type WalletServiceClient interface {
 	CreateWallet(ctx context.Context, in *CreateWalletRequest, opts ...grpc.CallOption) (*CreateWalletResponse, error)
 	ViewBalance(ctx context.Context, in *ViewBalanceRequest, opts ...grpc.CallOption) (*ViewBalanceResponse, error)
 	IsWalletOwner(ctx context.Context, in *IsOwnerRequest, opts ...grpc.CallOption) (*IsOwnerResponse, error)
+	ListWallets(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListWalletsResponse, error)
+	GetWallet(ctx context.Context, in *WalletRequest, opts ...grpc.CallOption) (*WalletResponse, error)
+	RenameWallet(ctx context.Context, in *RenameWalletRequest, opts ...grpc.CallOption) (*WalletResponse, error)
+	CloseWallet(ctx context.Context, in *WalletRequest, opts ...grpc.CallOption) (*WalletResponse, error)
+	// GetLedger lists the ledger entries of any wallet, oldest first, for audits.
+	// It doesn't check ownership, the broker does.
+	GetLedger(ctx context.Context, in *GetLedgerRequest, opts ...grpc.CallOption) (*GetLedgerResponse, error)
+	// GetProcessedTransaction tells whether the event of a transaction was
+	// applied or refused, for the transaction service to settle transactions
+	// whose outcome event it never received.
+	GetProcessedTransaction(ctx context.Context, in *ProcessedTransactionRequest, opts ...grpc.CallOption) (*ProcessedTransactionResponse, error)
+	// StreamBalances streams the balance summary of every wallet, ordered by
+	// wallet ID in byte order, for the transaction service to reconcile them.
+	StreamBalances(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BalanceSummary], error)
+	// PlaceHold sets part of the available balance aside until the hold is
+	// captured, voided or expires.
+	PlaceHold(ctx context.Context, in *PlaceHoldRequest, opts ...grpc.CallOption) (*HoldResponse, error)
+	// CaptureHold debits all or part of a hold and releases the rest.
+	CaptureHold(ctx context.Context, in *CaptureHoldRequest, opts ...grpc.CallOption) (*HoldResponse, error)
+	VoidHold(ctx context.Context, in *HoldRequest, opts ...grpc.CallOption) (*HoldResponse, error)
+	// CreateQuote prices converting an amount from one of the user's wallets
+	// into a wallet in another currency, at a rate locked until the quote
+	// expires.
+	CreateQuote(ctx context.Context, in *CreateQuoteRequest, opts ...grpc.CallOption) (*QuoteResponse, error)
+	// ExecuteConversion debits and credits the wallets of an open quote at its
+	// locked rate.
+	ExecuteConversion(ctx context.Context, in *ExecuteConversionRequest, opts ...grpc.CallOption) (*QuoteResponse, error)
 	HealthCheck(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
@@ -74,6 +113,135 @@ func (c *walletServiceClient) IsWalletOwner(ctx context.Context, in *IsOwnerRequ
 	return out, nil
 }
 
+func (c *walletServiceClient) ListWallets(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListWalletsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWalletsResponse)
+	err := c.cc.Invoke(ctx, WalletService_ListWallets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) GetWallet(ctx context.Context, in *WalletRequest, opts ...grpc.CallOption) (*WalletResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WalletResponse)
+	err := c.cc.Invoke(ctx, WalletService_GetWallet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) RenameWallet(ctx context.Context, in *RenameWalletRequest, opts ...grpc.CallOption) (*WalletResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WalletResponse)
+	err := c.cc.Invoke(ctx, WalletService_RenameWallet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) CloseWallet(ctx context.Context, in *WalletRequest, opts ...grpc.CallOption) (*WalletResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WalletResponse)
+	err := c.cc.Invoke(ctx, WalletService_CloseWallet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) GetLedger(ctx context.Context, in *GetLedgerRequest, opts ...grpc.CallOption) (*GetLedgerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetLedgerResponse)
+	err := c.cc.Invoke(ctx, WalletService_GetLedger_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) GetProcessedTransaction(ctx context.Context, in *ProcessedTransactionRequest, opts ...grpc.CallOption) (*ProcessedTransactionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProcessedTransactionResponse)
+	err := c.cc.Invoke(ctx, WalletService_GetProcessedTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) StreamBalances(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BalanceSummary], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WalletService_ServiceDesc.Streams[0], WalletService_StreamBalances_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[emptypb.Empty, BalanceSummary]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WalletService_StreamBalancesClient = grpc.ServerStreamingClient[BalanceSummary]
+
+func (c *walletServiceClient) PlaceHold(ctx context.Context, in *PlaceHoldRequest, opts ...grpc.CallOption) (*HoldResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HoldResponse)
+	err := c.cc.Invoke(ctx, WalletService_PlaceHold_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) CaptureHold(ctx context.Context, in *CaptureHoldRequest, opts ...grpc.CallOption) (*HoldResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HoldResponse)
+	err := c.cc.Invoke(ctx, WalletService_CaptureHold_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) VoidHold(ctx context.Context, in *HoldRequest, opts ...grpc.CallOption) (*HoldResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HoldResponse)
+	err := c.cc.Invoke(ctx, WalletService_VoidHold_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) CreateQuote(ctx context.Context, in *CreateQuoteRequest, opts ...grpc.CallOption) (*QuoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuoteResponse)
+	err := c.cc.Invoke(ctx, WalletService_CreateQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) ExecuteConversion(ctx context.Context, in *ExecuteConversionRequest, opts ...grpc.CallOption) (*QuoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuoteResponse)
+	err := c.cc.Invoke(ctx, WalletService_ExecuteConversion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *walletServiceClient) HealthCheck(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -91,6 +259,33 @@ type WalletServiceServer interface {
 	CreateWallet(context.Context, *CreateWalletRequest) (*CreateWalletResponse, error)
 	ViewBalance(context.Context, *ViewBalanceRequest) (*ViewBalanceResponse, error)
 	IsWalletOwner(context.Context, *IsOwnerRequest) (*IsOwnerResponse, error)
+	ListWallets(context.Context, *emptypb.Empty) (*ListWalletsResponse, error)
+	GetWallet(context.Context, *WalletRequest) (*WalletResponse, error)
+	RenameWallet(context.Context, *RenameWalletRequest) (*WalletResponse, error)
+	CloseWallet(context.Context, *WalletRequest) (*WalletResponse, error)
+	// GetLedger lists the ledger entries of any wallet, oldest first, for audits.
+	// It doesn't check ownership, the broker does.
+	GetLedger(context.Context, *GetLedgerRequest) (*GetLedgerResponse, error)
+	// GetProcessedTransaction tells whether the event of a transaction was
+	// applied or refused, for the transaction service to settle transactions
+	// whose outcome event it never received.
+	GetProcessedTransaction(context.Context, *ProcessedTransactionRequest) (*ProcessedTransactionResponse, error)
+	// StreamBalances streams the balance summary of every wallet, ordered by
+	// wallet ID in byte order, for the transaction service to reconcile them.
+	StreamBalances(*emptypb.Empty, grpc.ServerStreamingServer[BalanceSummary]) error
+	// PlaceHold sets part of the available balance aside until the hold is
+	// captured, voided or expires.
+	PlaceHold(context.Context, *PlaceHoldRequest) (*HoldResponse, error)
+	// CaptureHold debits all or part of a hold and releases the rest.
+	CaptureHold(context.Context, *CaptureHoldRequest) (*HoldResponse, error)
+	VoidHold(context.Context, *HoldRequest) (*HoldResponse, error)
+	// CreateQuote prices converting an amount from one of the user's wallets
+	// into a wallet in another currency, at a rate locked until the quote
+	// expires.
+	CreateQuote(context.Context, *CreateQuoteRequest) (*QuoteResponse, error)
+	// ExecuteConversion debits and credits the wallets of an open quote at its
+	// locked rate.
+	ExecuteConversion(context.Context, *ExecuteConversionRequest) (*QuoteResponse, error)
 	HealthCheck(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	mustEmbedUnimplementedWalletServiceServer()
 }
@@ -111,6 +306,42 @@ func (UnimplementedWalletServiceServer) ViewBalance(context.Context, *ViewBalanc
 func (UnimplementedWalletServiceServer) IsWalletOwner(context.Context, *IsOwnerRequest) (*IsOwnerResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method IsWalletOwner not implemented")
 }
+func (UnimplementedWalletServiceServer) ListWallets(context.Context, *emptypb.Empty) (*ListWalletsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWallets not implemented")
+}
+func (UnimplementedWalletServiceServer) GetWallet(context.Context, *WalletRequest) (*WalletResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWallet not implemented")
+}
+func (UnimplementedWalletServiceServer) RenameWallet(context.Context, *RenameWalletRequest) (*WalletResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameWallet not implemented")
+}
+func (UnimplementedWalletServiceServer) CloseWallet(context.Context, *WalletRequest) (*WalletResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseWallet not implemented")
+}
+func (UnimplementedWalletServiceServer) GetLedger(context.Context, *GetLedgerRequest) (*GetLedgerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLedger not implemented")
+}
+func (UnimplementedWalletServiceServer) GetProcessedTransaction(context.Context, *ProcessedTransactionRequest) (*ProcessedTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProcessedTransaction not implemented")
+}
+func (UnimplementedWalletServiceServer) StreamBalances(*emptypb.Empty, grpc.ServerStreamingServer[BalanceSummary]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamBalances not implemented")
+}
+func (UnimplementedWalletServiceServer) PlaceHold(context.Context, *PlaceHoldRequest) (*HoldResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlaceHold not implemented")
+}
+func (UnimplementedWalletServiceServer) CaptureHold(context.Context, *CaptureHoldRequest) (*HoldResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CaptureHold not implemented")
+}
+func (UnimplementedWalletServiceServer) VoidHold(context.Context, *HoldRequest) (*HoldResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VoidHold not implemented")
+}
+func (UnimplementedWalletServiceServer) CreateQuote(context.Context, *CreateQuoteRequest) (*QuoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateQuote not implemented")
+}
+func (UnimplementedWalletServiceServer) ExecuteConversion(context.Context, *ExecuteConversionRequest) (*QuoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExecuteConversion not implemented")
+}
 func (UnimplementedWalletServiceServer) HealthCheck(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method HealthCheck not implemented")
 }
@@ -189,6 +420,215 @@ func _WalletService_IsWalletOwner_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WalletService_ListWallets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).ListWallets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_ListWallets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).ListWallets(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_GetWallet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).GetWallet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_GetWallet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).GetWallet(ctx, req.(*WalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_RenameWallet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameWalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).RenameWallet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_RenameWallet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).RenameWallet(ctx, req.(*RenameWalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_CloseWallet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WalletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).CloseWallet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_CloseWallet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).CloseWallet(ctx, req.(*WalletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_GetLedger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLedgerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).GetLedger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_GetLedger_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).GetLedger(ctx, req.(*GetLedgerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_GetProcessedTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessedTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).GetProcessedTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_GetProcessedTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).GetProcessedTransaction(ctx, req.(*ProcessedTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_StreamBalances_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WalletServiceServer).StreamBalances(m, &grpc.GenericServerStream[emptypb.Empty, BalanceSummary]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WalletService_StreamBalancesServer = grpc.ServerStreamingServer[BalanceSummary]
+
+func _WalletService_PlaceHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlaceHoldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).PlaceHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_PlaceHold_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).PlaceHold(ctx, req.(*PlaceHoldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_CaptureHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CaptureHoldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).CaptureHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_CaptureHold_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).CaptureHold(ctx, req.(*CaptureHoldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_VoidHold_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HoldRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).VoidHold(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_VoidHold_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).VoidHold(ctx, req.(*HoldRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_CreateQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).CreateQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_CreateQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).CreateQuote(ctx, req.(*CreateQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_ExecuteConversion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecuteConversionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).ExecuteConversion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_ExecuteConversion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).ExecuteConversion(ctx, req.(*ExecuteConversionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _WalletService_HealthCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -226,11 +666,61 @@ var WalletService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "IsWalletOwner",
 			Handler:    _WalletService_IsWalletOwner_Handler,
 		},
+		{
+			MethodName: "ListWallets",
+			Handler:    _WalletService_ListWallets_Handler,
+		},
+		{
+			MethodName: "GetWallet",
+			Handler:    _WalletService_GetWallet_Handler,
+		},
+		{
+			MethodName: "RenameWallet",
+			Handler:    _WalletService_RenameWallet_Handler,
+		},
+		{
+			MethodName: "CloseWallet",
+			Handler:    _WalletService_CloseWallet_Handler,
+		},
+		{
+			MethodName: "GetLedger",
+			Handler:    _WalletService_GetLedger_Handler,
+		},
+		{
+			MethodName: "GetProcessedTransaction",
+			Handler:    _WalletService_GetProcessedTransaction_Handler,
+		},
+		{
+			MethodName: "PlaceHold",
+			Handler:    _WalletService_PlaceHold_Handler,
+		},
+		{
+			MethodName: "CaptureHold",
+			Handler:    _WalletService_CaptureHold_Handler,
+		},
+		{
+			MethodName: "VoidHold",
+			Handler:    _WalletService_VoidHold_Handler,
+		},
+		{
+			MethodName: "CreateQuote",
+			Handler:    _WalletService_CreateQuote_Handler,
+		},
+		{
+			MethodName: "ExecuteConversion",
+			Handler:    _WalletService_ExecuteConversion_Handler,
+		},
 		{
 			MethodName: "HealthCheck",
 			Handler:    _WalletService_HealthCheck_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamBalances",
+			Handler:       _WalletService_StreamBalances_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "wallet.proto",
 }
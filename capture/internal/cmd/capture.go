@@ -3,10 +3,13 @@ package cmd
 import (
 	"capture/internal/config"
 	"capture/internal/db"
+	"capture/internal/mtls"
 	"capture/internal/transaction"
+	pb "capture/proto/gen"
 	"context"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc"
 	"log"
 	"time"
 )
@@ -36,6 +39,16 @@ func ExecuteCaptureAuthTransactions() {
 	}
 	defer db.DB.Close()
 
+	creds, err := mtls.DialOption(cfg.TLS)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+	conn, err := grpc.NewClient(cfg.TransactionGrpcHost, creds)
+	if err != nil {
+		log.Fatalf("Failed to connect to the transaction service: %v", err)
+	}
+	defer conn.Close()
+
 	repository := transaction.NewPostgresTransactionRepository(db.DB)
 
 	start := time.Now().Add(-2 * time.Hour).UTC().Format("2006-01-02 15:04:05")
@@ -43,7 +56,7 @@ func ExecuteCaptureAuthTransactions() {
 	end := time.Now().Add(-1 * time.Hour).UTC().Format("2006-01-02 15:04:05")
 	log.Printf("Start time: %s", end)
 
-	transactions, err := repository.GetTransactionsForProcessing(ctx, start, end)
+	transactions, err := transaction.GetTransactionsForProcessing(ctx, repository, pb.NewTransactionServiceClient(conn), start, end)
 	if err != nil {
 		log.Fatalf("Failed to get authorized transactions: %v", err)
 	}
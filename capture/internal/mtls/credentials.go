@@ -0,0 +1,48 @@
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+
+	"capture/internal/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// DialOption returns the transport credentials used to call the transaction service.
+// Capture runs as a one-shot job, so certificates are loaded once instead of being
+// watched for rotation. When TLS is disabled the connection stays plaintext.
+func DialOption(cfg config.TLS) (grpc.DialOption, error) {
+	if !cfg.Enabled {
+		log.Println("TLS is disabled, dialing plaintext gRPC")
+		return grpc.WithTransportCredentials(insecure.NewCredentials()), nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)), nil
+}
@@ -3,17 +3,22 @@ package transaction
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
+
+	pb "capture/proto/gen"
 )
 
 const COMPLETED = "COMPLETED"
 const PROCESSING = "PROCESSING"
 
+// ACTOR is recorded in the transaction status history for the moves capture asks for.
+const ACTOR = "capture"
+
+// maxTransitionIDs mirrors the cap the transaction service puts on a TransitionStatus call.
+const maxTransitionIDs = 1000
+
 type Repository interface {
-	GetAuthorizedTransactionsForPeriod(ctx context.Context, tx *sql.Tx, start, end string) ([]*Transaction, error)
-	UpdateTransactionsStatus(ctx context.Context, tx *sql.Tx, ids []string, status string) error
-	GetTransactionsForProcessing(ctx context.Context, start, end string) ([]*Transaction, error)
+	GetAuthorizedTransactionsForPeriod(ctx context.Context, start, end string) ([]*Transaction, error)
 }
 
 type PostgresTransactionRepository struct {
@@ -24,11 +29,12 @@ func NewPostgresTransactionRepository(db *sql.DB) Repository {
 	return &PostgresTransactionRepository{db: db}
 }
 
-// GetAuthorizedTransactionsForPeriod fetches transactions with locking
-func (r *PostgresTransactionRepository) GetAuthorizedTransactionsForPeriod(ctx context.Context, tx *sql.Tx, start, end string) ([]*Transaction, error) {
-	rows, err := tx.QueryContext(
+// GetAuthorizedTransactionsForPeriod fetches the completed transactions created in the period.
+// Nothing is locked: claiming them is left to the transaction service.
+func (r *PostgresTransactionRepository) GetAuthorizedTransactionsForPeriod(ctx context.Context, start, end string) ([]*Transaction, error) {
+	rows, err := r.db.QueryContext(
 		ctx,
-		"SELECT id, amount, status, type FROM transactions WHERE status = $1 AND created_at BETWEEN $2 AND $3 FOR UPDATE",
+		"SELECT id, amount, status, type FROM transactions WHERE status = $1 AND created_at BETWEEN $2 AND $3",
 		COMPLETED,
 		start,
 		end,
@@ -53,73 +59,50 @@ func (r *PostgresTransactionRepository) GetAuthorizedTransactionsForPeriod(ctx c
 		transactions = append(transactions, &transaction)
 	}
 
-	return transactions, nil
-}
-
-// UpdateTransactionsStatus updates the status of specified transactions
-func (r *PostgresTransactionRepository) UpdateTransactionsStatus(ctx context.Context, tx *sql.Tx, ids []string, status string) error {
-	if len(ids) == 0 {
-		return nil
-	}
-
-	// Build query with individual placeholders
-	query := "UPDATE transactions SET status = $1 WHERE id IN ("
-	args := make([]interface{}, 0, len(ids)+1)
-	args = append(args, status) // $1 is status
-
-	for i, id := range ids {
-		if i > 0 {
-			query += ", "
-		}
-		query += fmt.Sprintf("$%d", i+2) // $2, $3, etc.
-		args = append(args, id)
-	}
-	query += ")"
-
-	_, err := tx.ExecContext(ctx, query, args...)
-	return err
+	return transactions, rows.Err()
 }
 
-// GetTransactionsForProcessing combines both operations in a single transaction and returns the transactions
-func (r *PostgresTransactionRepository) GetTransactionsForProcessing(ctx context.Context, start, end string) ([]*Transaction, error) {
+// GetTransactionsForProcessing moves the period's completed transactions to PROCESSING through
+// the transaction service and returns the ones it moved. Those another run already claimed, or
+// whose status changed since they were read, are rejected by the service and left out.
+func GetTransactionsForProcessing(ctx context.Context, repo Repository, client pb.TransactionServiceClient, start, end string) ([]*Transaction, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Step 1: Get transactions with lock
-	transactions, err := r.GetAuthorizedTransactionsForPeriod(ctx, tx, start, end)
+	transactions, err := repo.GetAuthorizedTransactionsForPeriod(ctx, start, end)
 	if err != nil {
 		return nil, err
 	}
 
-	// Step 2: Update their status if we have transactions
-	if len(transactions) > 0 {
-		ids := make([]string, len(transactions))
-		for i, t := range transactions {
-			ids[i] = t.ID
-			// Update in-memory objects too
-			t.Status = PROCESSING
+	moved := make(map[string]bool, len(transactions))
+	for i := 0; i < len(transactions); i += maxTransitionIDs {
+		batch := transactions[i:min(i+maxTransitionIDs, len(transactions))]
+		ids := make([]string, len(batch))
+		for j, t := range batch {
+			ids[j] = t.ID
 		}
 
-		err = r.UpdateTransactionsStatus(ctx, tx, ids, PROCESSING)
+		resp, err := client.TransitionStatus(ctx, &pb.TransitionStatusRequest{
+			TransactionIds: ids,
+			Status:         PROCESSING,
+			Actor:          ACTOR,
+			Reason:         "picked up for capture",
+		})
 		if err != nil {
 			return nil, err
 		}
+		for _, id := range resp.GetTransitionedIds() {
+			moved[id] = true
+		}
 	}
 
-	// Commit the transaction
-	if err = tx.Commit(); err != nil {
-		return nil, err
+	claimed := make([]*Transaction, 0, len(moved))
+	for _, t := range transactions {
+		if moved[t.ID] {
+			t.Status = PROCESSING
+			claimed = append(claimed, t)
+		}
 	}
 
-	return transactions, nil
+	return claimed, nil
 }
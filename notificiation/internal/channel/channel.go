@@ -1,5 +1,12 @@
 package channel
 
+import "errors"
+
+// ErrInvalidNotification is wrapped by the errors of notifications that can
+// never be sent, such as one missing its template, as opposed to a failure of
+// the channel.
+var ErrInvalidNotification = errors.New("invalid notification")
+
 type NotificationType string
 
 const (
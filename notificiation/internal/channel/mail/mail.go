@@ -1,11 +1,12 @@
 package mail
 
 import (
-	"errors"
 	"fmt"
 	"github.com/jordan-wright/email"
+	"math"
 	"notification/internal/channel"
 	"notification/internal/config"
+	"strings"
 )
 
 type Mail struct {
@@ -16,15 +17,31 @@ func (m *Mail) Send(n channel.Notification) error {
 	newMetadata := n.GetMetadata()
 	template, ok := newMetadata["template"]
 	if !ok {
-		return errors.New("template not found in metadata")
+		return fmt.Errorf("%w: template not found in metadata", channel.ErrInvalidNotification)
 	}
 	switch template {
 	case "deposit":
 		if err := m.DepositTemplate(n.GetMetadata()); err != nil {
-			return errors.New("failed to send deposit template: " + err.Error())
+			return fmt.Errorf("failed to send deposit template: %w", err)
+		}
+	case "deposit_failed":
+		if err := m.DepositFailedTemplate(n.GetMetadata()); err != nil {
+			return fmt.Errorf("failed to send deposit failed template: %w", err)
+		}
+	case "withdraw":
+		if err := m.WithdrawTemplate(n.GetMetadata()); err != nil {
+			return fmt.Errorf("failed to send withdraw template: %w", err)
+		}
+	case "withdraw_failed":
+		if err := m.WithdrawFailedTemplate(n.GetMetadata()); err != nil {
+			return fmt.Errorf("failed to send withdraw failed template: %w", err)
+		}
+	case "transfer_sent", "transfer_received":
+		if err := m.TransferTemplate(n.GetMetadata(), template == "transfer_sent"); err != nil {
+			return fmt.Errorf("failed to send transfer template: %w", err)
 		}
 	default:
-		return errors.New("template not found")
+		return fmt.Errorf("%w: template %v not found", channel.ErrInvalidNotification, template)
 	}
 
 	return nil
@@ -42,20 +59,170 @@ func (m *Mail) DepositTemplate(meta map[string]any) error {
 	e.To = []string{"recipient@tobeadded.com"}
 	e.Subject = "Deposit Notification"
 
-	amount, ok := meta["amount"].(float64)
+	amount, err := formatAmount(meta)
+	if err != nil {
+		return err
+	}
+	walletID, ok := meta["wallet_id"].(string)
+	if !ok {
+		return fmt.Errorf("%w: wallet_id not found in metadata", channel.ErrInvalidNotification)
+	}
+	transactionID, ok := meta["transaction_id"].(string)
+	if !ok {
+		return fmt.Errorf("%w: transaction_id not found in metadata", channel.ErrInvalidNotification)
+	}
+
+	e.Text = []byte(fmt.Sprintf("Deposit of %s, with transactionID: %s to wallet %s was successful, you", amount, transactionID, walletID))
+
+	return e.Send(m.Config.SMTPHost+":"+m.Config.SMTPPort, m.Config.Auth)
+}
+
+// DepositFailedTemplate tells the owner of a wallet that a deposit into it was
+// refused, and why.
+func (m *Mail) DepositFailedTemplate(meta map[string]any) error {
+	e := email.NewEmail()
+	e.From = "wall-e-go@gmail.com"
+	e.To = []string{"recipient@tobeadded.com"}
+	e.Subject = "Deposit Failed"
+
+	amount, err := formatAmount(meta)
+	if err != nil {
+		return err
+	}
+	walletID, ok := meta["wallet_id"].(string)
+	if !ok {
+		return fmt.Errorf("%w: wallet_id not found in metadata", channel.ErrInvalidNotification)
+	}
+	transactionID, ok := meta["transaction_id"].(string)
+	if !ok {
+		return fmt.Errorf("%w: transaction_id not found in metadata", channel.ErrInvalidNotification)
+	}
+	reason, ok := meta["failure_reason"].(string)
+	if !ok {
+		return fmt.Errorf("%w: failure_reason not found in metadata", channel.ErrInvalidNotification)
+	}
+
+	e.Text = []byte(fmt.Sprintf("Deposit of %s, with transactionID: %s to wallet %s failed: %s", amount, transactionID, walletID, strings.ReplaceAll(reason, "_", " ")))
+
+	return e.Send(m.Config.SMTPHost+":"+m.Config.SMTPPort, m.Config.Auth)
+}
+
+func (m *Mail) WithdrawTemplate(meta map[string]any) error {
+	e := email.NewEmail()
+	e.From = "wall-e-go@gmail.com"
+	e.To = []string{"recipient@tobeadded.com"}
+	e.Subject = "Withdrawal Notification"
+
+	amount, err := formatAmount(meta)
+	if err != nil {
+		return err
+	}
+	walletID, ok := meta["wallet_id"].(string)
 	if !ok {
-		return errors.New("amount not found in metadata")
+		return fmt.Errorf("%w: wallet_id not found in metadata", channel.ErrInvalidNotification)
+	}
+	transactionID, ok := meta["transaction_id"].(string)
+	if !ok {
+		return fmt.Errorf("%w: transaction_id not found in metadata", channel.ErrInvalidNotification)
+	}
+
+	e.Text = []byte(fmt.Sprintf("Withdrawal of %s, with transactionID: %s from wallet %s was successful", amount, transactionID, walletID))
+
+	return e.Send(m.Config.SMTPHost+":"+m.Config.SMTPPort, m.Config.Auth)
+}
+
+// WithdrawFailedTemplate tells the owner of a wallet that a withdrawal from it
+// was refused, and why.
+func (m *Mail) WithdrawFailedTemplate(meta map[string]any) error {
+	e := email.NewEmail()
+	e.From = "wall-e-go@gmail.com"
+	e.To = []string{"recipient@tobeadded.com"}
+	e.Subject = "Withdrawal Failed"
+
+	amount, err := formatAmount(meta)
+	if err != nil {
+		return err
 	}
 	walletID, ok := meta["wallet_id"].(string)
 	if !ok {
-		return errors.New("wallet_id not found in metadata")
+		return fmt.Errorf("%w: wallet_id not found in metadata", channel.ErrInvalidNotification)
 	}
 	transactionID, ok := meta["transaction_id"].(string)
 	if !ok {
-		return errors.New("transaction_id not found in metadata")
+		return fmt.Errorf("%w: transaction_id not found in metadata", channel.ErrInvalidNotification)
+	}
+	reason, ok := meta["failure_reason"].(string)
+	if !ok {
+		return fmt.Errorf("%w: failure_reason not found in metadata", channel.ErrInvalidNotification)
+	}
+
+	e.Text = []byte(fmt.Sprintf("Withdrawal of %s, with transactionID: %s from wallet %s failed: %s", amount, transactionID, walletID, strings.ReplaceAll(reason, "_", " ")))
+
+	return e.Send(m.Config.SMTPHost+":"+m.Config.SMTPPort, m.Config.Auth)
+}
+
+// TransferTemplate tells one party of a transfer, the sender or the recipient,
+// about it.
+func (m *Mail) TransferTemplate(meta map[string]any, sent bool) error {
+	e := email.NewEmail()
+	e.From = "wall-e-go@gmail.com"
+	e.To = []string{"recipient@tobeadded.com"}
+	e.Subject = "Transfer Notification"
+
+	amount, err := formatAmount(meta)
+	if err != nil {
+		return err
+	}
+	walletID, ok := meta["wallet_id"].(string)
+	if !ok {
+		return fmt.Errorf("%w: wallet_id not found in metadata", channel.ErrInvalidNotification)
+	}
+	transferID, ok := meta["transfer_id"].(string)
+	if !ok {
+		return fmt.Errorf("%w: transfer_id not found in metadata", channel.ErrInvalidNotification)
 	}
 
-	e.Text = []byte(fmt.Sprintf("Deposit of %.2f, with transactionID: %s to wallet %s was successful, you", amount, transactionID, walletID))
+	if sent {
+		e.Text = []byte(fmt.Sprintf("Transfer of %s, with transferID: %s from wallet %s was successful", amount, transferID, walletID))
+	} else {
+		e.Text = []byte(fmt.Sprintf("Transfer of %s, with transferID: %s was received into wallet %s", amount, transferID, walletID))
+	}
 
 	return e.Send(m.Config.SMTPHost+":"+m.Config.SMTPPort, m.Config.Auth)
 }
+
+// zeroDecimalCurrencies have no minor unit, their amounts are whole.
+var zeroDecimalCurrencies = map[string]bool{"JPY": true}
+
+// formatAmount prefers the exact minor_units, in minor units of the event's
+// currency, then the amount_minor of events that predate it, in hundredths
+// whatever the currency, and the float amount of older events still. The
+// currency code follows the amount when the event carries one.
+func formatAmount(meta map[string]any) (string, error) {
+	currency, _ := meta["currency"].(string)
+	var cents int64
+	if units, ok := meta["minor_units"].(float64); ok && units != 0 && currency != "" {
+		cents = int64(units)
+		if zeroDecimalCurrencies[currency] {
+			cents *= 100
+		}
+	} else if minor, ok := meta["amount_minor"].(float64); ok && minor != 0 {
+		cents = int64(minor)
+	} else if amount, ok := meta["amount"].(float64); ok {
+		cents = int64(math.Round(amount * 100))
+	} else {
+		return "", fmt.Errorf("%w: amount not found in metadata", channel.ErrInvalidNotification)
+	}
+
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	if currency == "" {
+		return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100), nil
+	}
+	if zeroDecimalCurrencies[currency] {
+		return fmt.Sprintf("%s%d %s", sign, cents/100, currency), nil
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, cents/100, cents%100, currency), nil
+}
@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"github.com/stretchr/testify/assert"
+	"notification/internal/channel"
+	"testing"
+)
+
+func TestFormatAmount(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		meta        map[string]any
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "when the event has minor units of euros, it should format cents",
+			meta:     map[string]any{"minor_units": float64(1050), "amount": 10.5, "currency": "EUR"},
+			expected: "10.50 EUR",
+		},
+		{
+			name:     "when the event has minor units of yen, it should format whole yen",
+			meta:     map[string]any{"minor_units": float64(500), "amount": float64(500), "currency": "JPY"},
+			expected: "500 JPY",
+		},
+		{
+			name:     "when the event predates minor units, it should read hundredths",
+			meta:     map[string]any{"amount_minor": float64(50000), "amount": float64(500), "currency": "JPY"},
+			expected: "500 JPY",
+		},
+		{
+			name:     "when the event has only the float amount, it should format it",
+			meta:     map[string]any{"amount": 10.5},
+			expected: "10.50",
+		},
+		{
+			name:        "when the event has no amount, it should return an error",
+			meta:        map[string]any{"currency": "EUR"},
+			expectedErr: channel.ErrInvalidNotification,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, err := formatAmount(tc.meta)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Equal(t, tc.expected, amount)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"notification/internal/config"
+	"notification/internal/dlq"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// NewDLQCmd creates and returns the dlq command
+func NewDLQCmd() *cobra.Command {
+	dlqCmd := &cobra.Command{
+		Use:   "dlq",
+		Short: "Manage the dead-letter topic of the notification consumer",
+	}
+
+	dlqCmd.AddCommand(newDLQReplayCmd())
+
+	return dlqCmd
+}
+
+func newDLQReplayCmd() *cobra.Command {
+	var (
+		limit int
+		idle  time.Duration
+	)
+
+	replayCmd := &cobra.Command{
+		Use:   "replay [topic]",
+		Short: "Re-inject dead-lettered notifications into the topic they failed on",
+		Long: `Re-inject dead-lettered notifications into the topic they failed on, once
+the cause is fixed. The topic defaults to the dead-letter topic of the
+configured one. Notifications replayed once aren't replayed again.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			cfg := config.LoadConfig()
+
+			topic := cfg.Kafka.Topic
+			if len(args) > 0 {
+				topic = args[0]
+			}
+			if !strings.HasSuffix(topic, dlq.Suffix) {
+				topic = dlq.Topic(topic)
+			}
+
+			reader := dlq.NewReplayReader(cfg.Kafka.Brokers, topic, cfg.Kafka.GroupID)
+			defer reader.Close()
+			writer := dlq.NewKafkaWriter(cfg.Kafka.Brokers[0])
+			defer writer.Close()
+
+			replayed, err := dlq.Replay(ctx, reader, writer, limit, idle)
+			fmt.Printf("Replayed %d notifications from %s\n", replayed, topic)
+			if err != nil {
+				return fmt.Errorf("failed to replay %s: %w", topic, err)
+			}
+			return nil
+		},
+	}
+
+	replayCmd.Flags().IntVar(&limit, "limit", 0, "Replay at most this many notifications, 0 for all")
+	replayCmd.Flags().DurationVar(&idle, "idle", 10*time.Second, "Stop once no notification has arrived for this long")
+
+	return replayCmd
+}
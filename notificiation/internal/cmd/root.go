@@ -16,6 +16,7 @@ func NewRootCmd() *cobra.Command {
 	}
 
 	rootCmd.AddCommand(NewServeCmd())
+	rootCmd.AddCommand(NewDLQCmd())
 
 	rootCmd.Flags().String("config", "", "Path to the config file (eg. config.yaml)")
 	_ = viper.BindPFlag("config", rootCmd.Flags().Lookup("config"))
@@ -6,8 +6,12 @@ import (
 	"notification/internal/channel/mail"
 	"notification/internal/config"
 	"notification/internal/consumer"
+	"notification/internal/dlq"
 	"notification/internal/service"
 	"notification/logger"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func NewServeCmd() *cobra.Command {
@@ -16,7 +20,9 @@ func NewServeCmd() *cobra.Command {
 		Short: "Start the notification service",
 		Run: func(cmd *cobra.Command, args []string) {
 			log := logger.NewLogger()
-			ctx := context.Background()
+			// Cancelled on SIGTERM so that Kubernetes rolling deploys drain the pod.
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
 			cfg := config.LoadConfig()
 
 			// Initialize notification service
@@ -29,15 +35,43 @@ func NewServeCmd() *cobra.Command {
 				log.Fatalf("Failed to register mail channel: %v", err)
 			}
 
+			// Notifications that keep failing go to <topic>.dlq, see the dlq
+			// replay command.
+			dlqWriter := dlq.NewKafkaWriter(cfg.Kafka.Brokers[0])
+			defer dlqWriter.Close()
+			deadLetters := dlq.NewHandler(dlq.Policy{
+				MaxAttempts:    cfg.DLQ.MaxAttempts,
+				InitialBackoff: cfg.DLQ.InitialBackoff,
+				MaxBackoff:     cfg.DLQ.MaxBackoff,
+			}, dlqWriter, cfg.Kafka.GroupID)
+
 			// Initialize consumer with dependency injection
 			log.Info("Starting consumer...")
 			notificationConsumer := consumer.NewConsumer(
 				cfg.Kafka,
 				notificationSvc,
+				deadLetters,
 			)
 			defer notificationConsumer.Close()
 
-			notificationConsumer.Consume(ctx)
+			consumerDone := make(chan struct{})
+			go func() {
+				defer close(consumerDone)
+				notificationConsumer.Consume(ctx)
+			}()
+
+			select {
+			case <-consumerDone:
+				return
+			case <-ctx.Done():
+			}
+
+			log.Infof("Shutting down, draining for up to %s", cfg.ShutdownTimeout)
+			select {
+			case <-consumerDone:
+			case <-time.After(cfg.ShutdownTimeout):
+				log.Warn("Timed out waiting for in-flight notifications")
+			}
 		},
 	}
 
@@ -24,9 +24,19 @@ type Kafka struct {
 	BatchTimeout   time.Duration
 }
 
+// DLQ is how a notification that fails to send is retried before it is
+// dead-lettered.
+type DLQ struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
 type Config struct {
 	*Mail
 	*Kafka
+	*DLQ
+	ShutdownTimeout time.Duration
 }
 
 func LoadConfig() *Config {
@@ -40,6 +50,12 @@ func LoadConfig() *Config {
 	viper.SetDefault("kafka.batch_size", 100)
 	viper.SetDefault("kafka.batch_timeout", 1*time.Second)
 
+	viper.SetDefault("dlq.max_attempts", 5)
+	viper.SetDefault("dlq.initial_backoff", 200*time.Millisecond)
+	viper.SetDefault("dlq.max_backoff", 5*time.Second)
+
+	viper.SetDefault("shutdown_timeout", 25*time.Second)
+
 	viper.SetDefault("mail.smtp_host", "localhost")
 	viper.SetDefault("mail.smtp_port", "1025")
 	viper.SetDefault("mail.auth", nil)
@@ -71,5 +87,11 @@ func LoadConfig() *Config {
 			BatchSize:      viper.GetInt("kafka.batch_size"),
 			BatchTimeout:   viper.GetDuration("kafka.batch_timeout"),
 		},
+		&DLQ{
+			MaxAttempts:    viper.GetInt("dlq.max_attempts"),
+			InitialBackoff: viper.GetDuration("dlq.initial_backoff"),
+			MaxBackoff:     viper.GetDuration("dlq.max_backoff"),
+		},
+		viper.GetDuration("shutdown_timeout"),
 	}
 }
@@ -3,10 +3,12 @@ package consumer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/segmentio/kafka-go"
 	"notification/internal/channel"
 	"notification/internal/config"
+	"notification/internal/dlq"
 	"notification/internal/service"
 	"notification/logger"
 	"sync"
@@ -23,12 +25,13 @@ var log = logger.NewLogger()
 type Consumer struct {
 	reader       *kafka.Reader
 	sender       service.NotificationService
+	deadLetters  *dlq.Handler
 	batchSize    int
 	batchTimeout time.Duration
 	numWorkers   int
 }
 
-func NewConsumer(cfg *config.Kafka, sender service.NotificationService) *Consumer {
+func NewConsumer(cfg *config.Kafka, sender service.NotificationService, deadLetters *dlq.Handler) *Consumer {
 	return &Consumer{
 		reader: kafka.NewReader(kafka.ReaderConfig{
 			Brokers:        cfg.Brokers,
@@ -41,6 +44,7 @@ func NewConsumer(cfg *config.Kafka, sender service.NotificationService) *Consume
 		batchSize:    cfg.BatchSize,
 		batchTimeout: cfg.BatchTimeout,
 		sender:       sender,
+		deadLetters:  deadLetters,
 		numWorkers:   cfg.NumWorkers,
 	}
 }
@@ -52,6 +56,10 @@ func (c *Consumer) Consume(ctx context.Context) {
 	// Create a channel for messages to be processed
 	notifications := make(chan kafka.Message, c.batchSize)
 
+	// Fetched messages are still sent and committed after ctx is cancelled, so
+	// nothing buffered is lost on shutdown.
+	workCtx := context.WithoutCancel(ctx)
+
 	// Start workers
 	for i := 0; i < c.numWorkers; i++ {
 		wg.Add(1)
@@ -60,23 +68,15 @@ func (c *Consumer) Consume(ctx context.Context) {
 			log.Printf("Worker %d started", workerID)
 
 			for msg := range notifications {
-
-				// Unmarshal the message to NotificationEvent
-				var event NotificationEvent
-				if err := json.Unmarshal(msg.Value, &event); err != nil {
-					log.Printf("Failed to unmarshal message: %v", err)
+				// A notification that keeps failing is dead-lettered, and committed
+				// like a sent one. One that can be neither is retried until it is,
+				// or left uncommitted on shutdown.
+				if !c.deadLetters.HandleUntilDone(ctx, []kafka.Message{msg}, c.send) {
 					continue
 				}
 
-				mailNotification := channel.NewNotification(event.Data)
-				if err := c.sender.SendNotification(ctx, mailNotification); err != nil {
-					log.Printf("Failed to send notification for message: %v", err)
-				} else {
-					log.Printf("Successfully processed message: offset=%d", msg.Offset)
-				}
-
 				// Commit the message
-				if err := c.reader.CommitMessages(ctx, msg); err != nil {
+				if err := c.reader.CommitMessages(workCtx, msg); err != nil {
 					log.Printf("Failed to commit message: %v", err)
 				}
 			}
@@ -95,13 +95,14 @@ func (c *Consumer) Consume(ctx context.Context) {
 				log.Println("Context cancelled, stopping consumer")
 				return
 			default:
-				msg, err := c.reader.ReadMessage(ctx)
+				// Fetch instead of read, offsets are committed by the workers once sent.
+				msg, err := c.reader.FetchMessage(ctx)
 				if err != nil {
-					log.Printf("Error reading message: %v", err)
 					// If context was cancelled, exit gracefully
 					if ctx.Err() != nil {
 						return
 					}
+					log.Printf("Error reading message: %v", err)
 					// Otherwise wait a bit and continue
 					time.Sleep(time.Second)
 					continue
@@ -114,6 +115,29 @@ func (c *Consumer) Consume(ctx context.Context) {
 
 	// Wait for all workers to finish
 	wg.Wait()
+	log.Println("Notification consumer stopped")
+}
+
+// send sends the notification of each message, the consumer hands it one at a
+// time.
+func (c *Consumer) send(ctx context.Context, messages []kafka.Message) error {
+	for _, msg := range messages {
+		// Unmarshal the message to NotificationEvent
+		var event NotificationEvent
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return dlq.Poison(fmt.Errorf("failed to unmarshal message: %w", err))
+		}
+
+		mailNotification := channel.NewNotification(event.Data)
+		if err := c.sender.SendNotification(ctx, mailNotification); err != nil {
+			if errors.Is(err, channel.ErrInvalidNotification) {
+				return dlq.Poison(err)
+			}
+			return fmt.Errorf("failed to send notification: %w", err)
+		}
+		log.Printf("Successfully processed message: offset=%d", msg.Offset)
+	}
+	return nil
 }
 
 func (c *Consumer) Close() {
@@ -10,6 +10,9 @@ mkdir -p logs
 # Start the wallet service in a subshell
 (cd wallet && go run main.go serve > ../logs/wallet.log 2>&1) &
 
+# Start the webhook service in a subshell
+(cd webhook && go run main.go serve > ../logs/webhook.log 2>&1) &
+
 # Wait briefly
 sleep 1
 
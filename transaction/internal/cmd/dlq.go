@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+	"log"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"transaction/internal/config"
+	"transaction/internal/consumer"
+	"transaction/internal/dlq"
+)
+
+func NewDLQCmd() *cobra.Command {
+	dlqCmd := &cobra.Command{
+		Use:   "dlq",
+		Short: "Manage the dead-letter topics of the transaction consumer",
+	}
+
+	dlqCmd.AddCommand(newDLQReplayCmd())
+
+	return dlqCmd
+}
+
+func newDLQReplayCmd() *cobra.Command {
+	var (
+		limit int
+		idle  time.Duration
+	)
+
+	replayCmd := &cobra.Command{
+		Use:   "replay <topic>",
+		Short: "Re-inject dead-lettered outcome events into the topic they failed on",
+		Long: `Re-inject dead-lettered outcome events into the topic they failed on, once
+the cause is fixed. The topic is either the dead-letter topic, eg.
+withdraw_failed.dlq, or the one it belongs to. Events replayed once aren't
+replayed again.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			cfg := config.NewConfig()
+
+			topic := args[0]
+			if !strings.HasSuffix(topic, dlq.Suffix) {
+				topic = dlq.Topic(topic)
+			}
+
+			reader := dlq.NewReplayReader([]string{cfg.KAFKA_HOST}, topic, consumer.GROUP_ID)
+			defer reader.Close()
+			writer := dlq.NewKafkaWriter(cfg.KAFKA_HOST)
+			defer writer.Close()
+
+			replayed, err := dlq.Replay(ctx, reader, writer, limit, idle)
+			log.Printf("Replayed %d events from %s", replayed, topic)
+			if err != nil {
+				log.Fatalf("Failed to replay %s: %v", topic, err)
+			}
+		},
+	}
+
+	replayCmd.Flags().IntVar(&limit, "limit", 0, "Replay at most this many events, 0 for all")
+	replayCmd.Flags().DurationVar(&idle, "idle", 10*time.Second, "Stop once no event has arrived for this long")
+
+	return replayCmd
+}
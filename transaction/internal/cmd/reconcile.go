@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+	"transaction/internal/config"
+	"transaction/internal/database"
+	"transaction/internal/domain/repositories"
+	"transaction/internal/mtls"
+	"transaction/internal/producer"
+	"transaction/internal/reconciler"
+	pb "transaction/proto/gen"
+)
+
+func NewReconcileCmd() *cobra.Command {
+	var (
+		olderThan time.Duration
+		limit     int
+		dryRun    bool
+	)
+
+	reconcileCmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Settle deposits stuck in PENDING",
+		Long: `Settle deposits stuck in PENDING because their outcome event never arrived.
+Each one is looked up in the wallet service: a deposit it credited or refused
+is marked COMPLETED or FAILED, one it never received has its deposit_initiated
+event re-published through the outbox. Meant to run periodically, eg. as a
+cron job, alongside the serve command that relays the outbox.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			cfg := config.NewConfig()
+			if !cmd.Flags().Changed("older-than") {
+				olderThan = cfg.RECONCILE_OLDER_THAN
+			}
+			if !cmd.Flags().Changed("limit") {
+				limit = cfg.RECONCILE_LIMIT
+			}
+
+			dbConn := database.ConnectToDB(cfg.DSN)
+			if dbConn == nil {
+				log.Fatal("Can't connect to Postgres!")
+			}
+			defer dbConn.Close()
+
+			creds, err := mtls.DialOption(ctx, cfg.TLS)
+			if err != nil {
+				log.Fatalf("Failed to configure TLS: %v", err)
+			}
+			conn, err := grpc.NewClient(cfg.WALLET_GRPC_HOST, creds)
+			if err != nil {
+				log.Fatalf("Failed to connect to the wallet service: %v", err)
+			}
+			defer conn.Close()
+
+			rec := reconciler.NewReconciler(
+				repositories.NewPostgresTransactionRepository(dbConn),
+				pb.NewWalletServiceClient(conn),
+				producer.NewProducer(),
+				dryRun,
+			)
+			actions, err := rec.Reconcile(ctx, olderThan, limit)
+			if err != nil {
+				log.Fatalf("Failed to reconcile deposits: %v", err)
+			}
+
+			if unreconciled := printReport(actions, dryRun); unreconciled > 0 {
+				log.Fatalf("%d deposits could not be reconciled", unreconciled)
+			}
+		},
+	}
+
+	reconcileCmd.Flags().DurationVar(&olderThan, "older-than", 15*time.Minute, "Reconcile deposits PENDING for longer than this")
+	reconcileCmd.Flags().IntVar(&limit, "limit", 100, "Reconcile at most this many deposits, oldest first")
+	reconcileCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would be done without doing it")
+
+	return reconcileCmd
+}
+
+// printReport writes a line per reconciled deposit and a summary to stdout, and
+// returns how many deposits could not be reconciled.
+func printReport(actions []reconciler.Action, dryRun bool) int {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TRANSACTION\tWALLET\tCREATED\tACTION\tDETAIL")
+	counts := map[string]int{}
+	for _, a := range actions {
+		counts[a.Action]++
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", a.TransactionID, a.WalletID, a.CreatedAt.UTC().Format(time.RFC3339), a.Action, a.Detail)
+	}
+	w.Flush()
+
+	prefix := "Reconciled"
+	if dryRun {
+		prefix = "Dry run, would have reconciled"
+	}
+	fmt.Printf("%s %d deposits: %d republished, %d completed, %d failed, %d errors\n",
+		prefix, len(actions), counts[reconciler.ACTION_REPUBLISHED], counts[reconciler.ACTION_COMPLETED],
+		counts[reconciler.ACTION_FAILED], counts[reconciler.ACTION_ERROR])
+	return counts[reconciler.ACTION_ERROR]
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"transaction/internal/config"
+	"transaction/internal/database"
+	"transaction/internal/domain/entities"
+	"transaction/internal/domain/repositories"
+	"transaction/internal/mtls"
+	"transaction/internal/producer"
+	"transaction/internal/reconciler"
+	pb "transaction/proto/gen"
+)
+
+func NewReconcileBalancesCmd() *cobra.Command {
+	var outputDir string
+
+	reconcileBalancesCmd := &cobra.Command{
+		Use:   "reconcile-balances",
+		Short: "Compare every wallet balance with the transactions behind it",
+		Long: `Compare every wallet balance with the net of its COMPLETED transactions plus
+the hold captures and conversions the wallet service posts on its own, and
+with the wallet's ledger. The reconciliation is stored for audit with the
+discrepancies it found, written as a JSON and a CSV report, and each
+discrepancy that needs looking into is published to balance_discrepancy
+through the outbox. Meant to run nightly, eg. as a cron job, alongside the
+serve command that relays the outbox.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			cfg := config.NewConfig()
+
+			dbConn := database.ConnectToDB(cfg.DSN)
+			if dbConn == nil {
+				log.Fatal("Can't connect to Postgres!")
+			}
+			defer dbConn.Close()
+
+			creds, err := mtls.DialOption(ctx, cfg.TLS)
+			if err != nil {
+				log.Fatalf("Failed to configure TLS: %v", err)
+			}
+			conn, err := grpc.NewClient(cfg.WALLET_GRPC_HOST, creds)
+			if err != nil {
+				log.Fatalf("Failed to connect to the wallet service: %v", err)
+			}
+			defer conn.Close()
+
+			rec := reconciler.NewBalanceReconciler(
+				repositories.NewPostgresTransactionRepository(dbConn),
+				pb.NewWalletServiceClient(conn),
+				producer.NewProducer(),
+			)
+			reconciliation, err := rec.Reconcile(ctx)
+			if err != nil {
+				log.Fatalf("Failed to reconcile balances: %v", err)
+			}
+
+			name := filepath.Join(outputDir, "balance-reconciliation-"+reconciliation.StartedAt.UTC().Format("20060102T150405Z"))
+			if err := writeReport(name+".json", reconciliation, reconciler.WriteJSON); err != nil {
+				log.Fatalf("Failed to write the JSON report of reconciliation %s: %v", reconciliation.ID, err)
+			}
+			if err := writeReport(name+".csv", reconciliation, reconciler.WriteCSV); err != nil {
+				log.Fatalf("Failed to write the CSV report of reconciliation %s: %v", reconciliation.ID, err)
+			}
+
+			alerts := 0
+			for _, d := range reconciliation.Discrepancies {
+				if d.Alert() {
+					alerts++
+				}
+			}
+			fmt.Printf("Reconciliation %s checked %d wallets: %d discrepancies, %d alerted. Reports in %s.{json,csv}\n",
+				reconciliation.ID, reconciliation.WalletsChecked, len(reconciliation.Discrepancies), alerts, name)
+		},
+	}
+
+	reconcileBalancesCmd.Flags().StringVar(&outputDir, "output-dir", ".", "Directory to write the reports to")
+
+	return reconcileBalancesCmd
+}
+
+func writeReport(path string, reconciliation *entities.BalanceReconciliation, write func(io.Writer, *entities.BalanceReconciliation) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, reconciliation); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -17,6 +17,9 @@ func NewRootCmd() *cobra.Command {
 
 	// Add commands
 	rootCmd.AddCommand(NewServeCmd())
+	rootCmd.AddCommand(NewDLQCmd())
+	rootCmd.AddCommand(NewReconcileCmd())
+	rootCmd.AddCommand(NewReconcileBalancesCmd())
 
 	rootCmd.Flags().String("config", "", "Path to the config file (eg. config.yaml)")
 	_ = viper.BindPFlag("config", rootCmd.Flags().Lookup("config"))
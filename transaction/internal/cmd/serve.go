@@ -8,11 +8,16 @@ import (
 	"google.golang.org/grpc"
 	"log"
 	"net"
+	"os/signal"
+	"syscall"
 	"transaction/internal/config"
 	"transaction/internal/consumer"
 	"transaction/internal/database"
+	"transaction/internal/dlq"
+	"transaction/internal/domain/entities"
 	"transaction/internal/domain/repositories"
 	"transaction/internal/domain/services"
+	"transaction/internal/mtls"
 	"transaction/internal/producer"
 	pb "transaction/proto/gen"
 
@@ -22,8 +27,14 @@ import (
 )
 
 const (
-	DEPOSIT_INITIATED string = "deposit_initiated"
-	DEPOSIT_COMPLETED string = "deposit_completed"
+	DEPOSIT_COMPLETED  string = "deposit_completed"
+	DEPOSIT_FAILED     string = "deposit_failed"
+	WITHDRAW_COMPLETED string = "withdraw_completed"
+	WITHDRAW_FAILED    string = "withdraw_failed"
+	TRANSFER_COMPLETED string = "transfer_completed"
+	TRANSFER_FAILED    string = "transfer_failed"
+	REVERSAL_COMPLETED string = "reversal_completed"
+	REVERSAL_FAILED    string = "reversal_failed"
 )
 
 func NewServeCmd() *cobra.Command {
@@ -50,13 +61,58 @@ func NewServeCmd() *cobra.Command {
 			}(dbConn)
 			tsxRepo := repositories.NewPostgresTransactionRepository(dbConn)
 
-			trxProducer := producer.NewProducer(DEPOSIT_INITIATED)
-			defer trxProducer.Close()
+			trxProducer := producer.NewProducer()
 
-			trxConsumer := consumer.NewConsumer(DEPOSIT_COMPLETED, tsxRepo)
+			kafkaWriter := producer.NewKafkaWriter(cfg.KAFKA_HOST)
+			defer func() {
+				if err := kafkaWriter.Close(); err != nil {
+					log.Println("Failed to close Kafka writer:", err)
+				}
+			}()
+			outboxRelay := producer.NewRelay(dbConn, kafkaWriter, cfg.OUTBOX_POLL_INTERVAL, cfg.OUTBOX_BATCH_SIZE, cfg.OUTBOX_RETENTION)
+
+			// Outcome events that keep failing go to <topic>.dlq, see the dlq
+			// replay command.
+			dlqWriter := dlq.NewKafkaWriter(cfg.KAFKA_HOST)
+			defer func() {
+				if err := dlqWriter.Close(); err != nil {
+					log.Println("Failed to close Kafka writer:", err)
+				}
+			}()
+			deadLetters := dlq.NewHandler(dlq.Policy{
+				MaxAttempts:    cfg.DLQ_MAX_ATTEMPTS,
+				InitialBackoff: cfg.DLQ_INITIAL_BACKOFF,
+				MaxBackoff:     cfg.DLQ_MAX_BACKOFF,
+			}, dlqWriter, consumer.GROUP_ID)
+
+			trxConsumer := consumer.NewConsumer(cfg.KAFKA_HOST, map[string]entities.TransactionStatus{
+				DEPOSIT_COMPLETED:  entities.TRANSACTION_STATUS_COMPLETED,
+				DEPOSIT_FAILED:     entities.TRANSACTION_STATUS_FAILED,
+				WITHDRAW_COMPLETED: entities.TRANSACTION_STATUS_COMPLETED,
+				WITHDRAW_FAILED:    entities.TRANSACTION_STATUS_FAILED,
+				TRANSFER_COMPLETED: entities.TRANSACTION_STATUS_COMPLETED,
+				TRANSFER_FAILED:    entities.TRANSACTION_STATUS_FAILED,
+				// Completing a reversal or refund moves its original transaction too.
+				REVERSAL_COMPLETED: entities.TRANSACTION_STATUS_COMPLETED,
+				REVERSAL_FAILED:    entities.TRANSACTION_STATUS_FAILED,
+			}, tsxRepo, deadLetters)
 			defer trxConsumer.Close()
 
-			go trxConsumer.Consume(context.Background())
+			// Cancelled on SIGTERM so that Kubernetes rolling deploys drain the pod.
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			consumerDone := make(chan struct{})
+			go func() {
+				defer close(consumerDone)
+				trxConsumer.Consume(ctx)
+			}()
+
+			relayDone := make(chan struct{})
+			go func() {
+				defer close(relayDone)
+				outboxRelay.Run(ctx)
+			}()
 
 			tsxSvc := services.NewTransactionService(tsxRepo, trxProducer)
 
@@ -65,15 +121,64 @@ func NewServeCmd() *cobra.Command {
 				log.Fatal(err)
 			}
 
-			s := grpc.NewServer()
+			serverOpts, err := mtls.ServerOptions(ctx, cfg.TLS)
+			if err != nil {
+				log.Fatalf("Failed to configure TLS: %v", err)
+			}
+
+			s := grpc.NewServer(serverOpts...)
 			pb.RegisterTransactionServiceServer(s, tsxSvc)
 
-			log.Printf("Transaction service running on port :%s", cfg.GRPC_PORT)
-			if err := s.Serve(lis); err != nil {
+			serveErr := make(chan error, 1)
+			go func() {
+				log.Printf("Transaction service running on port :%s", cfg.GRPC_PORT)
+				serveErr <- s.Serve(lis)
+			}()
+
+			select {
+			case err := <-serveErr:
 				log.Fatal(err)
+			case <-ctx.Done():
 			}
+
+			log.Printf("Shutting down, draining for up to %s", cfg.SHUTDOWN_TIMEOUT)
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.SHUTDOWN_TIMEOUT)
+			defer cancel()
+
+			// Stop taking transactions first. Those the relay hasn't published yet
+			// stay in the outbox and are published on the next start.
+			gracefulStop(shutdownCtx, s)
+
+			select {
+			case <-consumerDone:
+			case <-shutdownCtx.Done():
+				log.Println("Timed out waiting for the in-flight status batch")
+			}
+
+			select {
+			case <-relayDone:
+			case <-shutdownCtx.Done():
+				log.Println("Timed out waiting for the outbox relay")
+			}
+
+			log.Println("Transaction service stopped")
 		},
 	}
 
 	return serveCmdInstance
 }
+
+// gracefulStop stops the gRPC server once in-flight RPCs have finished, or forcefully when ctx expires.
+func gracefulStop(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
@@ -1,20 +1,62 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type TLS struct {
+	Enabled        bool
+	CertFile       string
+	KeyFile        string
+	CAFile         string
+	AllowedSANs    []string
+	ReloadInterval time.Duration
+}
 
 type Config struct {
 	TRANSACTION_GRPC_HOST string
+	WALLET_GRPC_HOST      string
 	KAFKA_HOST            string
 	GRPC_PORT             string
 	DSN                   string
+	SHUTDOWN_TIMEOUT      time.Duration
+	OUTBOX_POLL_INTERVAL  time.Duration
+	OUTBOX_BATCH_SIZE     int
+	OUTBOX_RETENTION      time.Duration
+	DLQ_MAX_ATTEMPTS      int
+	DLQ_INITIAL_BACKOFF   time.Duration
+	DLQ_MAX_BACKOFF       time.Duration
+	RECONCILE_OLDER_THAN  time.Duration
+	RECONCILE_LIMIT       int
+	TLS                   TLS
 }
 
 func NewConfig() *Config {
 	viper.SetDefault("transaction_grpc_host", "localhost:50053")
+	viper.SetDefault("wallet_grpc_host", "localhost:50052") // the wallet's TLS_ALLOWED_SANS must allow us
 	viper.SetDefault("kafka_host", "localhost:9092")
 	viper.SetDefault("grpc_port", "50053")
+	viper.SetDefault("shutdown_timeout", 25*time.Second) // below the pod's termination grace period
+	viper.SetDefault("outbox_poll_interval", 500*time.Millisecond)
+	viper.SetDefault("outbox_batch_size", 100)
+	viper.SetDefault("outbox_retention", 72*time.Hour) // sent events are kept for investigating
+	viper.SetDefault("dlq_max_attempts", 5)            // before an outcome event is dead-lettered
+	viper.SetDefault("dlq_initial_backoff", 200*time.Millisecond)
+	viper.SetDefault("dlq_max_backoff", 5*time.Second)
+	viper.SetDefault("reconcile_older_than", 15*time.Minute) // well past the time a deposit normally takes
+	viper.SetDefault("reconcile_limit", 100)
 	viper.SetDefault("dsn", "host=localhost port=5435 user=user password=password dbname=transaction_db sslmode=disable timezone=UTC connect_timeout=5")
 
+	viper.SetDefault("tls_enabled", false)
+	viper.SetDefault("tls_cert_file", "")
+	viper.SetDefault("tls_key_file", "")
+	viper.SetDefault("tls_ca_file", "")
+	viper.SetDefault("tls_allowed_sans", []string{}) // only the broker should call us, eg. "broker"
+	viper.SetDefault("tls_reload_interval", 30*time.Second)
+
 	viper.SetEnvPrefix("TRANSACTION")
 	viper.AutomaticEnv() // maps TRANSACTION_GRPC_HOST to TRANSACTION_GRPC_HOST, etc.
 
@@ -27,8 +69,41 @@ func NewConfig() *Config {
 
 	return &Config{
 		TRANSACTION_GRPC_HOST: viper.GetString("transaction_grpc_host"),
+		WALLET_GRPC_HOST:      viper.GetString("wallet_grpc_host"),
 		KAFKA_HOST:            viper.GetString("kafka_host"),
 		GRPC_PORT:             viper.GetString("grpc_port"),
 		DSN:                   viper.GetString("dsn"),
+		SHUTDOWN_TIMEOUT:      viper.GetDuration("shutdown_timeout"),
+		OUTBOX_POLL_INTERVAL:  viper.GetDuration("outbox_poll_interval"),
+		OUTBOX_BATCH_SIZE:     viper.GetInt("outbox_batch_size"),
+		OUTBOX_RETENTION:      viper.GetDuration("outbox_retention"),
+		DLQ_MAX_ATTEMPTS:      viper.GetInt("dlq_max_attempts"),
+		DLQ_INITIAL_BACKOFF:   viper.GetDuration("dlq_initial_backoff"),
+		DLQ_MAX_BACKOFF:       viper.GetDuration("dlq_max_backoff"),
+		RECONCILE_OLDER_THAN:  viper.GetDuration("reconcile_older_than"),
+		RECONCILE_LIMIT:       viper.GetInt("reconcile_limit"),
+		TLS: TLS{
+			Enabled:        viper.GetBool("tls_enabled"),
+			CertFile:       viper.GetString("tls_cert_file"),
+			KeyFile:        viper.GetString("tls_key_file"),
+			CAFile:         viper.GetString("tls_ca_file"),
+			AllowedSANs:    stringList("tls_allowed_sans"),
+			ReloadInterval: viper.GetDuration("tls_reload_interval"),
+		},
+	}
+}
+
+// stringList reads a list setting. A list from the environment is one string,
+// its items separated by commas like the other services' TLS_ALLOWED_SANS, so
+// each item is split on commas too.
+func stringList(key string) []string {
+	var list []string
+	for _, item := range viper.GetStringSlice(key) {
+		for _, s := range strings.Split(item, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
 	}
+	return list
 }
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringList(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    any
+		expected []string
+	}{
+		{name: "when the environment lists items separated by commas, it should split them", value: "broker, capture", expected: []string{"broker", "capture"}},
+		{name: "when the config file has a list, it should keep its items", value: []string{"broker", "capture"}, expected: []string{"broker", "capture"}},
+		{name: "when the list is empty, it should return none", value: "", expected: nil},
+		{name: "when the list has empty items, it should drop them", value: "broker,,", expected: []string{"broker"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			viper.Set("tls_allowed_sans", tc.value)
+			defer viper.Set("tls_allowed_sans", nil)
+
+			assert.Equal(t, tc.expected, stringList("tls_allowed_sans"))
+		})
+	}
+}
@@ -3,154 +3,192 @@ package consumer
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
-	"sync"
 	"time"
+	"transaction/internal/dlq"
 	"transaction/internal/domain/entities"
 	"transaction/internal/domain/repositories"
 
 	"github.com/segmentio/kafka-go"
 )
 
-const TRANSACTION_STATUS_COMPLETED entities.TransactionStatus = "COMPLETED"
+const GROUP_ID = "transaction-group"
+
+// ACTOR is who the status history says moved transactions on outcome events.
+const ACTOR = "transaction-consumer"
 
 type Consumer struct {
 	reader          *kafka.Reader
 	transactionRepo *repositories.PostgresTransactionRepository
-	batchSize       int
-	batchTimeout    time.Duration
+	// statuses maps each outcome topic to the status its transactions move to.
+	statuses     map[string]entities.TransactionStatus
+	deadLetters  *dlq.Handler
+	batchSize    int
+	batchTimeout time.Duration
 }
 
-func NewConsumer(topic string, transactionRepo *repositories.PostgresTransactionRepository) *Consumer {
+func NewConsumer(kafkaHost string, statuses map[string]entities.TransactionStatus, transactionRepo *repositories.PostgresTransactionRepository, deadLetters *dlq.Handler) *Consumer {
+	topics := make([]string, 0, len(statuses))
+	for topic := range statuses {
+		topics = append(topics, topic)
+	}
+
 	return &Consumer{
 		reader: kafka.NewReader(kafka.ReaderConfig{
-			Brokers:        []string{"localhost:9092"},
-			Topic:          topic,
-			GroupID:        "transaction-group",
+			Brokers:        []string{kafkaHost},
+			GroupTopics:    topics,
+			GroupID:        GROUP_ID,
 			MinBytes:       1e3,  // 1KB
 			MaxBytes:       10e6, // 10MB
 			CommitInterval: 1 * time.Second,
 		}),
 		transactionRepo: transactionRepo,
+		statuses:        statuses,
+		deadLetters:     deadLetters,
 		batchSize:       100,             // Process up to 100 messages in a batch
 		batchTimeout:    1 * time.Second, // Process batch every second or when full
 	}
 }
 
+// Consume reads outcome events, such as deposit_completed or withdraw_failed, and
+// moves their transactions to the matching status in batches. Offsets are committed only after a batch is applied. When ctx is cancelled
+// the pending batch is still applied and committed before Consume returns.
 func (c *Consumer) Consume(ctx context.Context) {
 	log.Printf("Starting transaction Kafka consumer with batch size: %d", c.batchSize)
 
-	// Channel to collect transaction IDs
-	transactionIDs := make([]string, 0, c.batchSize)
-
-	// Mutex to protect access to the transaction IDs slice
-	var mu sync.Mutex
-
-	// Create a ticker for batch processing
 	ticker := time.NewTicker(c.batchTimeout)
 	defer ticker.Stop()
 
-	// Create a channel to signal batch processing
-	processBatch := make(chan struct{})
-
-	// Start a goroutine to process batches
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				processBatch <- struct{}{}
-			}
-		}
-	}()
+	batch := make([]kafka.Message, 0, c.batchSize)
 
-	// Process batches of messages
 	for {
 		select {
 		case <-ctx.Done():
-			// Process any remaining transactions before exiting
-			c.processBatch(ctx, transactionIDs)
+			c.flush(ctx, batch)
+			log.Println("Transaction consumer stopped")
 			return
+		case <-ticker.C:
+			batch = c.flush(ctx, batch)
+			continue
+		default:
+		}
 
-		case <-processBatch:
-			// Check if we have any transactions to process
-			mu.Lock()
-			if len(transactionIDs) > 0 {
-				// Process the batch and reset
-				ids := make([]string, len(transactionIDs))
-				copy(ids, transactionIDs)
-				transactionIDs = transactionIDs[:0] // Clear the slice
-				mu.Unlock()
-
-				// Process the batch in the background
-				go c.processBatch(ctx, ids)
-			} else {
-				mu.Unlock()
-			}
+		// Try to read a message with a short timeout
+		readCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		msg, err := c.reader.FetchMessage(readCtx)
+		cancel()
 
-		default:
-			// Try to read a message with a short timeout
-			readCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
-			msg, err := c.reader.FetchMessage(readCtx)
-			cancel()
-
-			if err != nil {
-				if readCtx.Err() != context.DeadlineExceeded {
-					log.Printf("Error fetching message: %v", err)
-				}
-				// No message available, try again after a short sleep
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, context.DeadlineExceeded) {
+				log.Printf("Error fetching message: %v", err)
+				// Back off before retrying
 				time.Sleep(50 * time.Millisecond)
-				continue
 			}
+			continue
+		}
 
-			// Process the message
-			var event struct {
-				TransactionID string `json:"transaction_id"`
-			}
+		batch = append(batch, msg)
+		if len(batch) >= c.batchSize {
+			batch = c.flush(ctx, batch)
+		}
+	}
+}
 
-			if err := json.Unmarshal(msg.Value, &event); err != nil {
-				log.Println("Failed to unmarshal event:", err)
-				c.reader.CommitMessages(ctx, msg)
-				continue
-			}
+// flush applies the batch, dead-lettering the events that keep failing, and
+// commits its offsets. A batch that can be neither applied nor dead-lettered is
+// retried until it is, nothing is fetched past it meanwhile, so a later commit
+// can't skip it. It returns the emptied batch.
+func (c *Consumer) flush(ctx context.Context, messages []kafka.Message) []kafka.Message {
+	if len(messages) == 0 {
+		return messages
+	}
 
-			// Add transaction ID to the batch
-			mu.Lock()
-			transactionIDs = append(transactionIDs, event.TransactionID)
-			currentBatchSize := len(transactionIDs)
-			mu.Unlock()
+	if !c.deadLetters.HandleUntilDone(ctx, messages, c.apply) {
+		// Stopped first, leave the offsets uncommitted so the batch is redelivered after a restart or rebalance.
+		return messages[:0]
+	}
 
-			// Mark message as processed
-			if err := c.reader.CommitMessages(ctx, msg); err != nil {
-				log.Printf("Failed to commit message: %v", err)
-			}
+	if err := c.reader.CommitMessages(context.WithoutCancel(ctx), messages...); err != nil {
+		log.Printf("Failed to commit batch of %d messages: %v", len(messages), err)
+	}
+	return messages[:0]
+}
 
-			// If batch is full, process it immediately
-			if currentBatchSize >= c.batchSize {
-				processBatch <- struct{}{}
+// apply moves the transactions of the events to their status. Status updates
+// are idempotent, so a batch that partly failed is applied again whole.
+func (c *Consumer) apply(ctx context.Context, messages []kafka.Message) error {
+	transactionIDs := make(map[outcome][]string)
+	for _, msg := range messages {
+		status, ok := c.statuses[msg.Topic]
+		if !ok {
+			log.Printf("Skipping event from unexpected topic %s", msg.Topic)
+			continue
+		}
+		// Transfer outcomes carry both of their transactions.
+		var event struct {
+			TransactionID       string `json:"transaction_id"`
+			DebitTransactionID  string `json:"debit_transaction_id"`
+			CreditTransactionID string `json:"credit_transaction_id"`
+			FailureReason       string `json:"failure_reason"`
+		}
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return dlq.Poison(fmt.Errorf("failed to unmarshal event: %w", err))
+		}
+		for _, id := range []string{event.TransactionID, event.DebitTransactionID, event.CreditTransactionID} {
+			if id != "" {
+				key := outcome{status: status, topic: msg.Topic, failureReason: event.FailureReason}
+				transactionIDs[key] = append(transactionIDs[key], id)
 			}
 		}
 	}
+
+	for key, ids := range transactionIDs {
+		if err := c.processBatch(ctx, ids, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outcome is what a batch of transactions is moved to, and the event topic
+// that moved them.
+type outcome struct {
+	status        entities.TransactionStatus
+	topic         string
+	failureReason string
 }
 
-// processBatch updates the status of a batch of transactions
-func (c *Consumer) processBatch(ctx context.Context, transactionIDs []string) {
+// processBatch moves a batch of transactions to the status of their outcome
+func (c *Consumer) processBatch(ctx context.Context, transactionIDs []string, key outcome) error {
 	if len(transactionIDs) == 0 {
-		return
+		return nil
 	}
 
-	log.Printf("Processing batch of %d transaction status updates", len(transactionIDs))
+	log.Printf("Processing batch of %d transaction updates to %s", len(transactionIDs), key.status)
 	start := time.Now()
 
+	reason := key.topic
+	if key.failureReason != "" {
+		reason += ": " + key.failureReason
+	}
+
 	// Use the concurrent update method from the repository
-	err := c.transactionRepo.UpdateStatusConcurrently(ctx, transactionIDs, TRANSACTION_STATUS_COMPLETED)
+	moved, err := c.transactionRepo.UpdateStatusConcurrently(ctx, transactionIDs, repositories.StatusChange{
+		Status:        key.status,
+		Actor:         ACTOR,
+		Reason:        reason,
+		FailureReason: key.failureReason,
+	})
 	if err != nil {
 		log.Printf("Error updating transaction statuses: %v", err)
-	} else {
-		elapsed := time.Since(start)
-		log.Printf("Successfully updated %d transactions in %v", len(transactionIDs), elapsed)
+		return err
 	}
+
+	elapsed := time.Since(start)
+	log.Printf("Successfully updated %d of %d transactions in %v", len(moved), len(transactionIDs), elapsed)
+	return nil
 }
 
 func (c *Consumer) Close() {
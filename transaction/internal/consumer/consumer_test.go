@@ -0,0 +1,79 @@
+package consumer
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"transaction/internal/domain/entities"
+	"transaction/internal/domain/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// arrayConverter passes the slices bound to ANY($n) through to sqlmock, as the
+// pgx driver does.
+type arrayConverter struct{}
+
+func (arrayConverter) ConvertValue(v any) (driver.Value, error) {
+	if _, ok := v.([]string); ok {
+		return v, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                  string
+		topic                 string
+		event                 string
+		expectedStatus        entities.TransactionStatus
+		expectedFailureReason string
+		expectedReason        string
+	}{
+		{
+			name:                  "when a deposit is refused, it should fail the transaction with the wallet's reason",
+			topic:                 "deposit_failed",
+			event:                 `{"transaction_id":"tx-1","failure_reason":"wallet_closed"}`,
+			expectedStatus:        entities.TRANSACTION_STATUS_FAILED,
+			expectedFailureReason: "wallet_closed",
+			expectedReason:        "deposit_failed: wallet_closed",
+		},
+		{
+			name:           "when a refusal carries no reason, it should fail the transaction and keep any stored reason",
+			topic:          "deposit_failed",
+			event:          `{"transaction_id":"tx-1"}`,
+			expectedStatus: entities.TRANSACTION_STATUS_FAILED,
+			expectedReason: "deposit_failed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+			assert.NoError(t, err)
+			defer db.Close()
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE transactions t SET status = \$3`).
+				WithArgs([]string{"tx-1"}, sqlmock.AnyArg(), string(tc.expectedStatus), sqlmock.AnyArg(), tc.expectedFailureReason, ACTOR, tc.expectedReason).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("tx-1"))
+			mock.ExpectCommit()
+			c := &Consumer{
+				transactionRepo: repositories.NewPostgresTransactionRepository(db),
+				statuses: map[string]entities.TransactionStatus{
+					"deposit_failed": entities.TRANSACTION_STATUS_FAILED,
+				},
+			}
+
+			err = c.apply(context.Background(), []kafka.Message{{Topic: tc.topic, Value: []byte(tc.event)}})
+
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,256 @@
+// Package dlq is the failure policy of the Kafka consumers. A message that
+// fails is retried in place with backoff, unless its error is poison, which no
+// retry can fix. A message that still fails is dead-lettered: published to
+// <topic>.dlq with headers saying where it came from and why it failed, and
+// its offset committed so the consumer moves on. Replay re-injects
+// dead-lettered messages once the cause is fixed.
+package dlq
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Suffix names the dead-letter topic of a topic.
+const Suffix = ".dlq"
+
+// Headers of a dead-lettered message.
+const (
+	HeaderTopic         = "dlq-topic"
+	HeaderPartition     = "dlq-partition"
+	HeaderOffset        = "dlq-offset"
+	HeaderConsumerGroup = "dlq-consumer-group"
+	HeaderError         = "dlq-error"
+	// HeaderErrorClass is ClassPoison or ClassRetryable.
+	HeaderErrorClass = "dlq-error-class"
+	HeaderAttempts   = "dlq-attempts"
+	HeaderFailedAt   = "dlq-failed-at"
+)
+
+const (
+	ClassPoison    = "poison"
+	ClassRetryable = "retryable"
+)
+
+type poisonError struct {
+	err error
+}
+
+func (e poisonError) Error() string { return e.err.Error() }
+func (e poisonError) Unwrap() error { return e.err }
+
+// Poison marks err as one that retrying can't fix, such as a message that
+// doesn't parse. Any other error is retryable.
+func Poison(err error) error {
+	if err == nil {
+		return nil
+	}
+	return poisonError{err: err}
+}
+
+func IsPoison(err error) bool {
+	var poison poisonError
+	return errors.As(err, &poison)
+}
+
+func class(err error) string {
+	if IsPoison(err) {
+		return ClassPoison
+	}
+	return ClassRetryable
+}
+
+// Topic is the dead-letter topic of topic.
+func Topic(topic string) string {
+	return topic + Suffix
+}
+
+// Policy is how often and how patiently a failing message is retried.
+type Policy struct {
+	// MaxAttempts counts the first attempt.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// backoff is the wait after the attempt-th failed attempt, doubling from
+// InitialBackoff up to MaxBackoff.
+func (p Policy) backoff(attempt int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < attempt && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, p.MaxBackoff)
+}
+
+// Retry calls fn until it succeeds, fails with a poison error or has been
+// attempted MaxAttempts times, and returns the attempts made and the last
+// error.
+func (p Policy) Retry(ctx context.Context, fn func(context.Context) error) (int, error) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil || IsPoison(err) || attempt >= p.MaxAttempts {
+			return attempt, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(p.backoff(attempt)):
+		}
+	}
+}
+
+// Writer publishes messages, implemented by kafka.Writer.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// NewKafkaWriter returns a writer for dead-letter topics, and for the topics
+// Replay re-injects into, each message names its topic.
+func NewKafkaWriter(addr string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(addr),
+		Balancer:               &kafka.Hash{},
+		AllowAutoTopicCreation: true,
+		RequiredAcks:           kafka.RequireAll,
+	}
+}
+
+// Handler applies a consumer group's policy to the messages it fetches.
+type Handler struct {
+	policy Policy
+	writer Writer
+	group  string
+}
+
+func NewHandler(policy Policy, writer Writer, group string) *Handler {
+	return &Handler{
+		policy: policy,
+		writer: writer,
+		group:  group,
+	}
+}
+
+// Handle processes a batch with process, which must apply all of it or
+// nothing, retrying it in place. When the batch fails on a poison message or
+// keeps failing, each message is processed on its own, so one bad message
+// doesn't hold up the others, and those that still fail are dead-lettered. It
+// returns false when a message could be neither processed nor dead-lettered,
+// and the batch's offsets must not be committed.
+func (h *Handler) Handle(ctx context.Context, messages []kafka.Message, process func(context.Context, []kafka.Message) error) bool {
+	if len(messages) > 1 {
+		_, err := h.policy.Retry(ctx, func(ctx context.Context) error {
+			return process(ctx, messages)
+		})
+		if err == nil {
+			return true
+		}
+		log.Printf("Batch of %d messages failed, processing them one by one: %v", len(messages), err)
+	}
+
+	for _, msg := range messages {
+		attempts, err := h.policy.Retry(ctx, func(ctx context.Context) error {
+			return process(ctx, []kafka.Message{msg})
+		})
+		if err == nil {
+			continue
+		}
+		if err := h.DeadLetter(ctx, msg, err, attempts); err != nil {
+			log.Printf("Failed to dead-letter message %s/%d@%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+			return false
+		}
+	}
+	return true
+}
+
+// HandleUntilDone calls Handle until the batch is processed or dead-lettered,
+// waiting between calls like between attempts, so a batch is never left behind
+// while later messages are fetched and committed past it. Each call runs
+// without ctx's cancellation, so a call in progress finishes on shutdown, and
+// ctx only cuts the waits short. It returns false when ctx is done before the
+// batch is, the batch's offsets must then not be committed and nothing more
+// fetched.
+func (h *Handler) HandleUntilDone(ctx context.Context, messages []kafka.Message, process func(context.Context, []kafka.Message) error) bool {
+	for attempt := 1; ; attempt++ {
+		if h.Handle(context.WithoutCancel(ctx), messages, process) {
+			return true
+		}
+		wait := h.policy.backoff(attempt)
+		log.Printf("Batch of %d messages could not be handled, retrying in %s", len(messages), wait)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(wait):
+		}
+	}
+}
+
+// DeadLetter publishes msg to its dead-letter topic, retrying in place.
+func (h *Handler) DeadLetter(ctx context.Context, msg kafka.Message, cause error, attempts int) error {
+	letter := deadLetter(msg, cause, attempts, h.group, time.Now())
+	if _, err := h.policy.Retry(ctx, func(ctx context.Context) error {
+		return h.writer.WriteMessages(ctx, letter)
+	}); err != nil {
+		return err
+	}
+
+	log.Printf("Dead-lettered message %s/%d@%d to %s after %d attempts: %v", msg.Topic, msg.Partition, msg.Offset, letter.Topic, attempts, cause)
+	return nil
+}
+
+// deadLetter is msg addressed to its dead-letter topic, keeping its key,
+// value and headers.
+func deadLetter(msg kafka.Message, cause error, attempts int, group string, failedAt time.Time) kafka.Message {
+	headers := append(withoutDLQHeaders(msg.Headers),
+		kafka.Header{Key: HeaderTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderConsumerGroup, Value: []byte(group)},
+		kafka.Header{Key: HeaderError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderErrorClass, Value: []byte(class(cause))},
+		kafka.Header{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderFailedAt, Value: []byte(failedAt.UTC().Format(time.RFC3339))},
+	)
+	return kafka.Message{
+		Topic:   Topic(msg.Topic),
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
+
+// withoutDLQHeaders drops the headers dead-lettering adds.
+func withoutDLQHeaders(headers []kafka.Header) []kafka.Header {
+	kept := make([]kafka.Header, 0, len(headers))
+	for _, h := range headers {
+		switch h.Key {
+		case HeaderTopic, HeaderPartition, HeaderOffset, HeaderConsumerGroup, HeaderError, HeaderErrorClass, HeaderAttempts, HeaderFailedAt:
+		default:
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
+// replayable is a dead-lettered message addressed back to the topic it failed
+// on, without the dead-letter headers.
+func replayable(letter kafka.Message) kafka.Message {
+	topic := strings.TrimSuffix(letter.Topic, Suffix)
+	for _, h := range letter.Headers {
+		if h.Key == HeaderTopic {
+			topic = string(h.Value)
+		}
+	}
+	return kafka.Message{
+		Topic:   topic,
+		Key:     letter.Key,
+		Value:   letter.Value,
+		Headers: withoutDLQHeaders(letter.Headers),
+	}
+}
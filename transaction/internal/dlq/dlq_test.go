@@ -0,0 +1,289 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeWriter struct {
+	written []kafka.Message
+	err     error
+	// failures is how many writes fail before the writer recovers.
+	failures int
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("kafka is down")
+	}
+	w.written = append(w.written, msgs...)
+	return nil
+}
+
+type fakeReader struct {
+	messages  []kafka.Message
+	committed []kafka.Message
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if len(r.messages) == 0 {
+		<-ctx.Done()
+		return kafka.Message{}, ctx.Err()
+	}
+	msg := r.messages[0]
+	r.messages = r.messages[1:]
+	return msg, nil
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+var testPolicy = Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := Policy{InitialBackoff: 200 * time.Millisecond, MaxBackoff: time.Second}
+	testCases := []struct {
+		name     string
+		attempt  int
+		expected time.Duration
+	}{
+		{name: "when the first attempt fails, it should wait the initial backoff", attempt: 1, expected: 200 * time.Millisecond},
+		{name: "when attempts keep failing, it should double the wait", attempt: 3, expected: 800 * time.Millisecond},
+		{name: "when the wait reaches the cap, it should stay there", attempt: 10, expected: time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, policy.backoff(tc.attempt))
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	t.Parallel()
+
+	messages := []kafka.Message{
+		{Topic: "deposit_completed", Partition: 1, Offset: 10, Key: []byte("a"), Value: []byte(`{"transaction_id":"a"}`)},
+		{Topic: "deposit_completed", Partition: 1, Offset: 11, Key: []byte("b"), Value: []byte(`not json`)},
+	}
+	errPoison := Poison(errors.New("failed to unmarshal event"))
+
+	testCases := []struct {
+		name           string
+		process        func(calls map[string]int) func(context.Context, []kafka.Message) error
+		writerErr      error
+		expectedOK     bool
+		expectedLetter []int64
+		expectedClass  string
+	}{
+		{
+			name: "when the batch fails once, it should retry it in place and dead-letter nothing",
+			process: func(calls map[string]int) func(context.Context, []kafka.Message) error {
+				return func(ctx context.Context, batch []kafka.Message) error {
+					calls["batch"]++
+					if calls["batch"] == 1 {
+						return errors.New("connection reset")
+					}
+					return nil
+				}
+			},
+			expectedOK: true,
+		},
+		{
+			name: "when a message is poison, it should process the others and dead-letter it without retrying",
+			process: func(calls map[string]int) func(context.Context, []kafka.Message) error {
+				return func(ctx context.Context, batch []kafka.Message) error {
+					for _, msg := range batch {
+						if msg.Offset == 11 {
+							calls["poison"]++
+							return errPoison
+						}
+					}
+					return nil
+				}
+			},
+			expectedOK:     true,
+			expectedLetter: []int64{11},
+			expectedClass:  ClassPoison,
+		},
+		{
+			name: "when every attempt fails, it should dead-letter every message as retryable",
+			process: func(calls map[string]int) func(context.Context, []kafka.Message) error {
+				return func(ctx context.Context, batch []kafka.Message) error {
+					return errors.New("connection refused")
+				}
+			},
+			expectedOK:     true,
+			expectedLetter: []int64{10, 11},
+			expectedClass:  ClassRetryable,
+		},
+		{
+			name: "when dead-lettering fails, it should not let the offsets be committed",
+			process: func(calls map[string]int) func(context.Context, []kafka.Message) error {
+				return func(ctx context.Context, batch []kafka.Message) error {
+					return errPoison
+				}
+			},
+			writerErr:  errors.New("kafka is down"),
+			expectedOK: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := map[string]int{}
+			writer := &fakeWriter{err: tc.writerErr}
+			handler := NewHandler(testPolicy, writer, "transaction-group")
+
+			ok := handler.Handle(context.Background(), messages, tc.process(calls))
+
+			assert.Equal(t, tc.expectedOK, ok)
+			if tc.expectedClass == ClassPoison {
+				// Once in the batch and once on its own.
+				assert.Equal(t, 2, calls["poison"])
+			}
+			var offsets []int64
+			for _, letter := range writer.written {
+				offset, _ := strconv.ParseInt(header(letter, HeaderOffset), 10, 64)
+				offsets = append(offsets, offset)
+				assert.Equal(t, "deposit_completed.dlq", letter.Topic)
+				assert.Equal(t, "deposit_completed", header(letter, HeaderTopic))
+				assert.Equal(t, "transaction-group", header(letter, HeaderConsumerGroup))
+				assert.Equal(t, tc.expectedClass, header(letter, HeaderErrorClass))
+				assert.NotEmpty(t, header(letter, HeaderError))
+			}
+			assert.Equal(t, tc.expectedLetter, offsets)
+		})
+	}
+}
+
+func TestHandleUntilDone(t *testing.T) {
+	t.Parallel()
+
+	messages := []kafka.Message{{Topic: "deposit_completed", Partition: 1, Offset: 10, Value: []byte(`not json`)}}
+	poison := func(ctx context.Context, batch []kafka.Message) error {
+		return Poison(errors.New("failed to unmarshal event"))
+	}
+
+	testCases := []struct {
+		name            string
+		writer          *fakeWriter
+		cancelled       bool
+		expectedOK      bool
+		expectedLetters int
+	}{
+		{
+			name:            "when dead-lettering fails for a while, it should retry the batch until it is dead-lettered",
+			writer:          &fakeWriter{failures: 4},
+			expectedOK:      true,
+			expectedLetters: 1,
+		},
+		{
+			name:       "when dead-lettering keeps failing and ctx is done, it should stop without the batch handled",
+			writer:     &fakeWriter{err: errors.New("kafka is down")},
+			cancelled:  true,
+			expectedOK: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tc.cancelled {
+				cancel()
+			} else {
+				defer cancel()
+			}
+			handler := NewHandler(testPolicy, tc.writer, "transaction-group")
+
+			ok := handler.HandleUntilDone(ctx, messages, poison)
+
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Len(t, tc.writer.written, tc.expectedLetters)
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	original := kafka.Message{
+		Topic:   "withdraw_completed",
+		Key:     []byte("tx-1"),
+		Value:   []byte(`{"transaction_id":"tx-1"}`),
+		Headers: []kafka.Header{{Key: "trace-id", Value: []byte("abc")}},
+	}
+	letter := deadLetter(original, errors.New("connection refused"), 5, "transaction-group", time.Now())
+
+	testCases := []struct {
+		name             string
+		letters          []kafka.Message
+		limit            int
+		expectedReplayed int
+	}{
+		{
+			name:             "when the topic has dead-lettered messages, it should replay them all to their topic",
+			letters:          []kafka.Message{letter, letter},
+			expectedReplayed: 2,
+		},
+		{
+			name:             "when a limit is set, it should stop there",
+			letters:          []kafka.Message{letter, letter},
+			limit:            1,
+			expectedReplayed: 1,
+		},
+		{
+			name:             "when the topic is empty, it should stop once idle",
+			expectedReplayed: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			reader := &fakeReader{messages: tc.letters}
+			writer := &fakeWriter{}
+
+			replayed, err := Replay(context.Background(), reader, writer, tc.limit, 10*time.Millisecond)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedReplayed, replayed)
+			assert.Len(t, reader.committed, tc.expectedReplayed)
+			for _, msg := range writer.written {
+				assert.Equal(t, original.Topic, msg.Topic)
+				assert.Equal(t, original.Key, msg.Key)
+				assert.Equal(t, original.Value, msg.Value)
+				assert.Equal(t, original.Headers, msg.Headers)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"time"
+	"transaction/internal/money"
+)
+
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyLedger is a wallet balance that isn't the net of its ledger
+	// postings.
+	DiscrepancyLedger DiscrepancyKind = "LEDGER_MISMATCH"
+	// DiscrepancyBalance is a wallet balance that isn't the net of its applied
+	// transactions plus its adjustments.
+	DiscrepancyBalance DiscrepancyKind = "BALANCE_MISMATCH"
+	// DiscrepancyUnsettled is a balance mismatch of a wallet with PENDING
+	// transactions, which may explain it. It isn't alerted on.
+	DiscrepancyUnsettled DiscrepancyKind = "UNSETTLED"
+	// DiscrepancyUnknownWallet is a wallet with transactions that the wallet
+	// service doesn't have.
+	DiscrepancyUnknownWallet DiscrepancyKind = "UNKNOWN_WALLET"
+	// DiscrepancyCurrency is a wallet with transactions in another currency than
+	// its own.
+	DiscrepancyCurrency DiscrepancyKind = "CURRENCY_MISMATCH"
+)
+
+// BalanceDiscrepancy is a wallet whose balance doesn't match the transactions
+// behind it. Amounts are in the wallet's currency.
+type BalanceDiscrepancy struct {
+	WalletID      string
+	Kind          DiscrepancyKind
+	Currency      money.Currency
+	WalletBalance money.Amount
+	LedgerBalance money.Amount
+	// Adjustments is the net of the wallet's postings made without a
+	// transaction, hold captures and conversions.
+	Adjustments money.Amount
+	// TransactionNet is the applied deposits and incoming transfers less the
+	// withdrawals, outgoing transfers, reversals and refunds.
+	TransactionNet money.Amount
+	// Difference is how much the balance exceeds what it should be.
+	Difference          money.Amount
+	PendingTransactions int
+}
+
+// Alert tells whether the discrepancy needs someone to look into it.
+func (d BalanceDiscrepancy) Alert() bool {
+	return d.Kind != DiscrepancyUnsettled
+}
+
+// BalanceReconciliation is one comparison of every wallet balance with the
+// transactions behind it.
+type BalanceReconciliation struct {
+	ID             string
+	StartedAt      time.Time
+	FinishedAt     time.Time
+	WalletsChecked int
+	Discrepancies  []BalanceDiscrepancy
+}
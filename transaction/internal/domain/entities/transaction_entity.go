@@ -1,18 +1,74 @@
 package entities
 
-import "time"
+import (
+	"fmt"
+	"time"
+	"transaction/internal/money"
+)
 
 type TransactionType int
 
+const (
+	TypeDeposit     TransactionType = 0
+	TypeWithdraw    TransactionType = 1
+	TypeTransferOut TransactionType = 2
+	TypeTransferIn  TransactionType = 3
+	// TypeReversal and TypeRefund take back all or part of a completed deposit.
+	TypeReversal TransactionType = 4
+	TypeRefund   TransactionType = 5
+)
+
+var transactionTypeNames = map[TransactionType]string{
+	TypeDeposit:     "DEPOSIT",
+	TypeWithdraw:    "WITHDRAW",
+	TypeTransferOut: "TRANSFER_OUT",
+	TypeTransferIn:  "TRANSFER_IN",
+	TypeReversal:    "REVERSAL",
+	TypeRefund:      "REFUND",
+}
+
+// String returns the value stored in the transaction_type column.
+func (t TransactionType) String() string {
+	if name, ok := transactionTypeNames[t]; ok {
+		return name
+	}
+	return transactionTypeNames[TypeDeposit]
+}
+
+// ParseTransactionType reads a transaction_type column value.
+func ParseTransactionType(name string) (TransactionType, error) {
+	for t, n := range transactionTypeNames {
+		if n == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown transaction type %q", name)
+}
+
+// Compensates tells whether transactions of the type undo part of another one.
+func (t TransactionType) Compensates() bool {
+	return t == TypeReversal || t == TypeRefund
+}
+
 type TransactionStatus string
 
 type Transaction struct {
 	ID             string
 	WalletID       string
-	Amount         float64
+	Amount         money.Amount
+	Currency       money.Currency
 	Type           TransactionType
 	IdempotencyKey string
 	Status         string
-	updatedAt      time.Time
-	CreatedAt      time.Time
+	// TransferID links the two sides of a transfer, empty otherwise.
+	TransferID string
+	// FailureReason says why a FAILED transaction was refused, e.g. wallet_closed.
+	FailureReason string
+	// OriginalTransactionID is the transaction a reversal or refund takes back,
+	// RequestedBy and Reason who asked for it and why. Empty otherwise.
+	OriginalTransactionID string
+	RequestedBy           string
+	Reason                string
+	UpdatedAt             time.Time
+	CreatedAt             time.Time
 }
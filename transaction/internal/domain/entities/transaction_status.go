@@ -0,0 +1,116 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// TRANSACTION_STATUS_PENDING is a transaction recorded and published to the
+	// wallet service, which hasn't applied it yet.
+	TRANSACTION_STATUS_PENDING   TransactionStatus = "PENDING"
+	TRANSACTION_STATUS_COMPLETED TransactionStatus = "COMPLETED"
+	// TRANSACTION_STATUS_PROCESSING is a completed transaction picked up by
+	// capture.
+	TRANSACTION_STATUS_PROCESSING TransactionStatus = "PROCESSING"
+	TRANSACTION_STATUS_CAPTURED   TransactionStatus = "CAPTURED"
+	TRANSACTION_STATUS_SETTLED    TransactionStatus = "SETTLED"
+	// TRANSACTION_STATUS_PARTIALLY_REFUNDED is a transaction part of whose
+	// balance change was refunded.
+	TRANSACTION_STATUS_PARTIALLY_REFUNDED TransactionStatus = "PARTIALLY_REFUNDED"
+	// TRANSACTION_STATUS_FAILED is a transaction the wallet service refused.
+	TRANSACTION_STATUS_FAILED TransactionStatus = "FAILED"
+	// TRANSACTION_STATUS_REVERSED is a transaction whose balance change was
+	// undone after it completed, by a reversal or by refunds of all of it.
+	TRANSACTION_STATUS_REVERSED TransactionStatus = "REVERSED"
+	// TRANSACTION_STATUS_EXPIRED is a pending transaction given up on.
+	TRANSACTION_STATUS_EXPIRED TransactionStatus = "EXPIRED"
+)
+
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+// statuses are the transaction statuses in lifecycle order.
+var statuses = []TransactionStatus{
+	TRANSACTION_STATUS_PENDING,
+	TRANSACTION_STATUS_COMPLETED,
+	TRANSACTION_STATUS_PROCESSING,
+	TRANSACTION_STATUS_CAPTURED,
+	TRANSACTION_STATUS_SETTLED,
+	TRANSACTION_STATUS_PARTIALLY_REFUNDED,
+	TRANSACTION_STATUS_FAILED,
+	TRANSACTION_STATUS_REVERSED,
+	TRANSACTION_STATUS_EXPIRED,
+}
+
+// transitions is the transaction lifecycle: the statuses each status may move
+// to. FAILED, REVERSED and EXPIRED are final. A transaction moves to REVERSED
+// or PARTIALLY_REFUNDED only once a reversal or refund of it completes.
+var transitions = map[TransactionStatus][]TransactionStatus{
+	TRANSACTION_STATUS_PENDING: {
+		TRANSACTION_STATUS_COMPLETED,
+		TRANSACTION_STATUS_FAILED,
+		TRANSACTION_STATUS_EXPIRED,
+	},
+	TRANSACTION_STATUS_COMPLETED: {
+		TRANSACTION_STATUS_PROCESSING,
+		TRANSACTION_STATUS_PARTIALLY_REFUNDED,
+		TRANSACTION_STATUS_REVERSED,
+	},
+	// Back to COMPLETED when capture gives a transaction up. A refund completing
+	// during capture takes the transaction out of it.
+	TRANSACTION_STATUS_PROCESSING: {
+		TRANSACTION_STATUS_CAPTURED,
+		TRANSACTION_STATUS_COMPLETED,
+		TRANSACTION_STATUS_PARTIALLY_REFUNDED,
+		TRANSACTION_STATUS_REVERSED,
+	},
+	TRANSACTION_STATUS_CAPTURED: {
+		TRANSACTION_STATUS_SETTLED,
+		TRANSACTION_STATUS_PARTIALLY_REFUNDED,
+		TRANSACTION_STATUS_REVERSED,
+	},
+	TRANSACTION_STATUS_SETTLED: {
+		TRANSACTION_STATUS_PARTIALLY_REFUNDED,
+		TRANSACTION_STATUS_REVERSED,
+	},
+	// Once the refunds add up to the whole transaction.
+	TRANSACTION_STATUS_PARTIALLY_REFUNDED: {
+		TRANSACTION_STATUS_REVERSED,
+	},
+}
+
+// TransactionStatuses returns every transaction status in lifecycle order.
+func TransactionStatuses() []TransactionStatus {
+	return append([]TransactionStatus(nil), statuses...)
+}
+
+// ParseTransactionStatus reads a status column value.
+func ParseTransactionStatus(name string) (TransactionStatus, error) {
+	for _, s := range statuses {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown transaction status %q", name)
+}
+
+// CanTransitionTo tells whether a transaction may move from s to status.
+func (s TransactionStatus) CanTransitionTo(status TransactionStatus) bool {
+	for _, next := range transitions[s] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionsTo returns the statuses a transaction may move to status from.
+func TransitionsTo(status TransactionStatus) []TransactionStatus {
+	var from []TransactionStatus
+	for _, s := range statuses {
+		if s.CanTransitionTo(status) {
+			from = append(from, s)
+		}
+	}
+	return from
+}
@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransitionTo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		from     TransactionStatus
+		to       TransactionStatus
+		expected bool
+	}{
+		{name: "when the wallet service applies a pending transaction, it should complete", from: TRANSACTION_STATUS_PENDING, to: TRANSACTION_STATUS_COMPLETED, expected: true},
+		{name: "when the wallet service refuses a pending transaction, it should fail", from: TRANSACTION_STATUS_PENDING, to: TRANSACTION_STATUS_FAILED, expected: true},
+		{name: "when capture picks up a completed transaction, it should be processing", from: TRANSACTION_STATUS_COMPLETED, to: TRANSACTION_STATUS_PROCESSING, expected: true},
+		{name: "when a settled transaction is reversed, it should be allowed", from: TRANSACTION_STATUS_SETTLED, to: TRANSACTION_STATUS_REVERSED, expected: true},
+		{name: "when a pending transaction is captured, it should be rejected", from: TRANSACTION_STATUS_PENDING, to: TRANSACTION_STATUS_PROCESSING},
+		{name: "when a failed transaction completes, it should be rejected", from: TRANSACTION_STATUS_FAILED, to: TRANSACTION_STATUS_COMPLETED},
+		{name: "when a completed transaction fails, it should be rejected", from: TRANSACTION_STATUS_COMPLETED, to: TRANSACTION_STATUS_FAILED},
+		{name: "when a transaction stays in its status, it should be rejected", from: TRANSACTION_STATUS_COMPLETED, to: TRANSACTION_STATUS_COMPLETED},
+		{name: "when a reversed transaction moves again, it should be rejected", from: TRANSACTION_STATUS_REVERSED, to: TRANSACTION_STATUS_SETTLED},
+		{name: "when the rest of a partially refunded transaction is refunded, it should be reversed", from: TRANSACTION_STATUS_PARTIALLY_REFUNDED, to: TRANSACTION_STATUS_REVERSED, expected: true},
+		{name: "when a pending transaction is refunded, it should be rejected", from: TRANSACTION_STATUS_PENDING, to: TRANSACTION_STATUS_PARTIALLY_REFUNDED},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, tc.from.CanTransitionTo(tc.to))
+		})
+	}
+}
+
+func TestTransitionsTo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		to       TransactionStatus
+		expected []TransactionStatus
+	}{
+		{name: "when moving to COMPLETED, it should allow PENDING and a given up capture", to: TRANSACTION_STATUS_COMPLETED, expected: []TransactionStatus{TRANSACTION_STATUS_PENDING, TRANSACTION_STATUS_PROCESSING}},
+		{name: "when moving to REVERSED, it should allow every status that moved money", to: TRANSACTION_STATUS_REVERSED, expected: []TransactionStatus{TRANSACTION_STATUS_COMPLETED, TRANSACTION_STATUS_PROCESSING, TRANSACTION_STATUS_CAPTURED, TRANSACTION_STATUS_SETTLED, TRANSACTION_STATUS_PARTIALLY_REFUNDED}},
+		{name: "when moving to PENDING, it should allow nothing", to: TRANSACTION_STATUS_PENDING},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, TransitionsTo(tc.to))
+		})
+	}
+}
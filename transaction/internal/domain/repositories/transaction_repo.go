@@ -3,25 +3,98 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 	"transaction/internal/domain/entities"
+	"transaction/internal/money"
 )
 
 const DB_TIMEOUT = time.Second * 10
 
-const TRANSACTION_STATUS_PENDING entities.TransactionStatus = "PENDING"
-
 type TransactionRepository interface {
-	InsertOne(tx *sql.Tx, deposit entities.Transaction) (string, error)
-	GetTxByKey(tx *sql.Tx, key string) (string, error)
+	InsertOne(tx *sql.Tx, transaction entities.Transaction) (string, error)
+	InsertTransfer(tx *sql.Tx, debit, credit entities.Transaction) (*Transfer, error)
+	GetTransferByKey(tx *sql.Tx, key string) (*Transfer, error)
+	InsertCompensation(tx *sql.Tx, compensation entities.Transaction) (string, error)
+	GetByKey(tx *sql.Tx, key string) (*entities.Transaction, error)
+	GetForUpdate(tx *sql.Tx, id string) (*entities.Transaction, error)
+	CompensatedAmount(tx *sql.Tx, originalID string) (money.Amount, error)
 	BeginTx(ctx context.Context) (*sql.Tx, error)
 
-	UpdateStatusBatch(ctx context.Context, transactionIDs []string, status entities.TransactionStatus) error
-	UpdateStatusConcurrently(ctx context.Context, transactionIDs []string, status entities.TransactionStatus) error
+	Transition(ctx context.Context, tx *sql.Tx, transactionIDs []string, change StatusChange) ([]string, error)
+	UpdateStatusBatch(ctx context.Context, transactionIDs []string, change StatusChange) ([]string, error)
+	UpdateStatusConcurrently(ctx context.Context, transactionIDs []string, change StatusChange) ([]string, error)
+
+	GetByID(ctx context.Context, id string) (*entities.Transaction, error)
+	List(ctx context.Context, filter TransactionFilter) ([]*entities.Transaction, error)
+	ListPending(ctx context.Context, txnType entities.TransactionType, createdBefore time.Time, limit int) ([]*entities.Transaction, error)
+
+	EachWalletTotals(ctx context.Context, fn func(WalletTotals) error) error
+	InsertBalanceReconciliation(tx *sql.Tx, reconciliation *entities.BalanceReconciliation) (string, error)
+}
+
+// TransactionFilter selects a page of a wallet's transactions, newest first.
+// Zero values leave a filter out.
+type TransactionFilter struct {
+	WalletID string
+	Type     string
+	Status   string
+	From     time.Time
+	To       time.Time
+	// After continues below the last transaction of the previous page.
+	After *Cursor
+	Limit int
+}
+
+// Transfer holds the IDs of a transfer and of its two transactions.
+type Transfer struct {
+	ID                  string
+	DebitID             string
+	CreditID            string
+	SourceWalletID      string
+	DestinationWalletID string
+	Amount              money.Amount
+	Currency            money.Currency
+}
+
+// StatusChange moves transactions to Status, recorded in their status history
+// with who made it and why.
+type StatusChange struct {
+	Status entities.TransactionStatus
+	// Actor is the service or operator making the change, e.g. "capture".
+	Actor  string
+	Reason string
+	// FailureReason is recorded on transactions moved to FAILED.
+	FailureReason string
+}
+
+// WalletTotals sums up the transactions of a wallet.
+type WalletTotals struct {
+	WalletID string
+	// Currency is the currency of the wallet's transactions, Currencies how many
+	// there are, more than one when they disagree.
+	Currency   money.Currency
+	Currencies int
+	// Completed is the deposits and incoming transfers the wallet service
+	// applied, less the withdrawals, outgoing transfers, reversals and refunds.
+	Completed money.Amount
+	Pending   int
 }
 
+// Cursor is the position of a transaction in the created_at, id ordering.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// created_at is a TIMESTAMP without time zone holding UTC, bound as text so the
+// session time zone doesn't shift it.
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
 type PostgresTransactionRepository struct {
 	db *sql.DB
 }
@@ -35,14 +108,16 @@ func (r *PostgresTransactionRepository) BeginTx(ctx context.Context) (*sql.Tx, e
 	return tx, err
 }
 
-func (r *PostgresTransactionRepository) InsertOne(tx *sql.Tx, deposit entities.Transaction) (string, error) {
-	query := `INSERT INTO transactions (wallet_id, amount, type, idempotency_key) VALUES ($1, $2, 'DEPOSIT', $3) RETURNING id`
+func (r *PostgresTransactionRepository) InsertOne(tx *sql.Tx, transaction entities.Transaction) (string, error) {
+	query := `INSERT INTO transactions (wallet_id, amount, currency, type, idempotency_key) VALUES ($1, $2, $3, $4::transaction_type, $5) RETURNING id`
 
 	var newID string
 	err := tx.QueryRow(query,
-		deposit.WalletID,
-		deposit.Amount,
-		deposit.IdempotencyKey,
+		transaction.WalletID,
+		transaction.Amount,
+		transaction.Currency,
+		transaction.Type.String(),
+		transaction.IdempotencyKey,
 	).Scan(&newID)
 
 	if err != nil {
@@ -53,23 +128,466 @@ func (r *PostgresTransactionRepository) InsertOne(tx *sql.Tx, deposit entities.T
 	return newID, nil
 }
 
-func (r *PostgresTransactionRepository) GetTxByKey(tx *sql.Tx, key string) (string, error) {
-	query := `SELECT id FROM transactions WHERE idempotency_key = $1`
+// InsertTransfer inserts the TRANSFER_OUT and TRANSFER_IN sides of a transfer
+// under a new transfer ID. The idempotency key is kept on the debit.
+func (r *PostgresTransactionRepository) InsertTransfer(tx *sql.Tx, debit, credit entities.Transaction) (*Transfer, error) {
+	transfer := &Transfer{
+		SourceWalletID:      debit.WalletID,
+		DestinationWalletID: credit.WalletID,
+		Amount:              debit.Amount,
+		Currency:            debit.Currency,
+	}
+	if err := tx.QueryRow(`SELECT gen_random_uuid()::text`).Scan(&transfer.ID); err != nil {
+		return nil, fmt.Errorf("failed to generate transfer ID: %w", err)
+	}
 
-	var existingID string
-	err := tx.QueryRow(query, key).Scan(&existingID)
-	if err != sql.ErrNoRows {
-		log.Println(err)
-		return "", err
+	query := `INSERT INTO transactions (wallet_id, amount, currency, type, idempotency_key, transfer_id) VALUES ($1, $2, $3, $4::transaction_type, NULLIF($5, ''), $6) RETURNING id`
+	if err := tx.QueryRow(query, debit.WalletID, debit.Amount, debit.Currency, entities.TypeTransferOut.String(), debit.IdempotencyKey, transfer.ID).Scan(&transfer.DebitID); err != nil {
+		return nil, fmt.Errorf("failed to insert transfer debit: %w", err)
+	}
+	if err := tx.QueryRow(query, credit.WalletID, credit.Amount, credit.Currency, entities.TypeTransferIn.String(), "", transfer.ID).Scan(&transfer.CreditID); err != nil {
+		return nil, fmt.Errorf("failed to insert transfer credit: %w", err)
 	}
+	return transfer, nil
+}
 
-	return existingID, nil
+// GetTransferByKey returns the transfer recorded under an idempotency key, with
+// an empty ID when there is none.
+func (r *PostgresTransactionRepository) GetTransferByKey(tx *sql.Tx, key string) (*Transfer, error) {
+	query := `SELECT debit.transfer_id, debit.id, credit.id, debit.wallet_id, credit.wallet_id, debit.amount, debit.currency
+		FROM transactions debit
+		JOIN transactions credit ON credit.transfer_id = debit.transfer_id AND credit.type = 'TRANSFER_IN'
+		WHERE debit.idempotency_key = $1 AND debit.type = 'TRANSFER_OUT'`
+
+	transfer := &Transfer{}
+	err := tx.QueryRow(query, key).Scan(&transfer.ID, &transfer.DebitID, &transfer.CreditID,
+		&transfer.SourceWalletID, &transfer.DestinationWalletID, &transfer.Amount, &transfer.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Transfer{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer: %w", err)
+	}
+	return transfer, nil
+}
+
+// InsertCompensation inserts a PENDING reversal or refund of
+// compensation.OriginalTransactionID
+func (r *PostgresTransactionRepository) InsertCompensation(tx *sql.Tx, compensation entities.Transaction) (string, error) {
+	query := `INSERT INTO transactions (wallet_id, amount, currency, type, idempotency_key, original_transaction_id, requested_by, reason)
+		VALUES ($1, $2, $3, $4::transaction_type, $5, $6, $7, NULLIF($8, '')) RETURNING id`
+
+	var id string
+	err := tx.QueryRow(query,
+		compensation.WalletID,
+		compensation.Amount,
+		compensation.Currency,
+		compensation.Type.String(),
+		compensation.IdempotencyKey,
+		compensation.OriginalTransactionID,
+		compensation.RequestedBy,
+		compensation.Reason,
+	).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert %s: %w", strings.ToLower(compensation.Type.String()), err)
+	}
+	return id, nil
+}
+
+// GetByKey returns the transaction recorded under an idempotency key, with an
+// empty ID when there is none
+func (r *PostgresTransactionRepository) GetByKey(tx *sql.Tx, key string) (*entities.Transaction, error) {
+	t, err := scanTransaction(tx.QueryRow(`SELECT `+transactionColumns+` FROM transactions WHERE idempotency_key = $1`, key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &entities.Transaction{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction by idempotency key: %w", err)
+	}
+	return t, nil
+}
+
+// GetForUpdate returns a transaction locked until tx ends, with an empty ID when
+// it doesn't exist
+func (r *PostgresTransactionRepository) GetForUpdate(tx *sql.Tx, id string) (*entities.Transaction, error) {
+	t, err := scanTransaction(tx.QueryRow(`SELECT `+transactionColumns+` FROM transactions WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &entities.Transaction{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock transaction: %w", err)
+	}
+	return t, nil
 }
 
-// UpdateStatusBatch updates status for multiple transactions in a single database operation
-func (r *PostgresTransactionRepository) UpdateStatusBatch(ctx context.Context, transactionIDs []string, status entities.TransactionStatus) error {
+// CompensatedAmount sums the reversals and refunds of a transaction that are
+// applied or may still be, that is all but the FAILED and EXPIRED ones
+func (r *PostgresTransactionRepository) CompensatedAmount(tx *sql.Tx, originalID string) (money.Amount, error) {
+	var amount money.Amount
+	err := tx.QueryRow(
+		`SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE original_transaction_id = $1 AND NOT (status = ANY($2))`,
+		originalID, statusNames([]entities.TransactionStatus{entities.TRANSACTION_STATUS_FAILED, entities.TRANSACTION_STATUS_EXPIRED}),
+	).Scan(&amount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum up compensations of transaction %s: %w", originalID, err)
+	}
+	return amount, nil
+}
+
+const transactionColumns = `id, wallet_id, amount, currency, type, status, transfer_id, failure_reason,
+	original_transaction_id, requested_by, reason, created_at, updated_at`
+
+func scanTransaction(row interface{ Scan(...any) error }) (*entities.Transaction, error) {
+	var (
+		t             entities.Transaction
+		txnType       string
+		transferID    sql.NullString
+		failureReason sql.NullString
+		originalID    sql.NullString
+		requestedBy   sql.NullString
+		reason        sql.NullString
+		err           error
+	)
+	if err := row.Scan(&t.ID, &t.WalletID, &t.Amount, &t.Currency, &txnType, &t.Status, &transferID, &failureReason,
+		&originalID, &requestedBy, &reason, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if t.Type, err = entities.ParseTransactionType(txnType); err != nil {
+		return nil, err
+	}
+	t.TransferID = transferID.String
+	t.FailureReason = failureReason.String
+	t.OriginalTransactionID = originalID.String
+	t.RequestedBy = requestedBy.String
+	t.Reason = reason.String
+	return &t, nil
+}
+
+// GetByID returns a transaction, with an empty ID when it doesn't exist
+func (r *PostgresTransactionRepository) GetByID(ctx context.Context, id string) (*entities.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, DB_TIMEOUT)
+	defer cancel()
+
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1`
+
+	t, err := scanTransaction(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &entities.Transaction{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	return t, nil
+}
+
+// List returns up to filter.Limit transactions of a wallet, newest first
+func (r *PostgresTransactionRepository) List(ctx context.Context, filter TransactionFilter) ([]*entities.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, DB_TIMEOUT)
+	defer cancel()
+
+	conditions := []string{"wallet_id = $1"}
+	args := []any{filter.WalletID}
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Type != "" {
+		where("type = $%d", filter.Type)
+	}
+	if filter.Status != "" {
+		where("status = $%d", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		where("created_at >= $%d::timestamp", filter.From.UTC().Format(timestampLayout))
+	}
+	if !filter.To.IsZero() {
+		where("created_at < $%d::timestamp", filter.To.UTC().Format(timestampLayout))
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt.UTC().Format(timestampLayout), filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d::timestamp, $%d::uuid)", len(args)-1, len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf(`SELECT %s FROM transactions WHERE %s ORDER BY created_at DESC, id DESC LIMIT $%d`,
+		transactionColumns, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*entities.Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// ListPending returns up to limit PENDING transactions of a type created before
+// createdBefore, oldest first
+func (r *PostgresTransactionRepository) ListPending(ctx context.Context, txnType entities.TransactionType, createdBefore time.Time, limit int) ([]*entities.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, DB_TIMEOUT)
+	defer cancel()
+
+	query := `SELECT ` + transactionColumns + ` FROM transactions
+		WHERE type = $1 AND status = $2 AND created_at < $3::timestamp
+		ORDER BY created_at, id LIMIT $4`
+
+	rows, err := r.db.QueryContext(ctx, query, txnType.String(), entities.TRANSACTION_STATUS_PENDING, createdBefore.UTC().Format(timestampLayout), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*entities.Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list pending transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// EachWalletTotals calls fn with the totals of every wallet that has
+// transactions, ordered by wallet ID in byte order, as the rows are read. It
+// takes as long as the wallets take, there is no timeout. Transactions count
+// once the wallet service applied them, whatever they moved to since, reversals
+// and refunds included.
+func (r *PostgresTransactionRepository) EachWalletTotals(ctx context.Context, fn func(WalletTotals) error) error {
+	query := `SELECT wallet_id, MIN(currency), COUNT(DISTINCT currency),
+			COALESCE(SUM(CASE WHEN type IN ('DEPOSIT', 'TRANSFER_IN') THEN amount ELSE -amount END) FILTER (WHERE NOT (status = ANY($2))), 0),
+			COUNT(*) FILTER (WHERE status = $1)
+		FROM transactions
+		GROUP BY wallet_id
+		ORDER BY wallet_id COLLATE "C"`
+
+	unapplied := []entities.TransactionStatus{entities.TRANSACTION_STATUS_PENDING, entities.TRANSACTION_STATUS_FAILED, entities.TRANSACTION_STATUS_EXPIRED}
+	rows, err := r.db.QueryContext(ctx, query, entities.TRANSACTION_STATUS_PENDING, statusNames(unapplied))
+	if err != nil {
+		return fmt.Errorf("failed to sum up wallet transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var totals WalletTotals
+		if err := rows.Scan(&totals.WalletID, &totals.Currency, &totals.Currencies, &totals.Completed, &totals.Pending); err != nil {
+			return fmt.Errorf("failed to scan wallet totals: %w", err)
+		}
+		if err := fn(totals); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to sum up wallet transactions: %w", err)
+	}
+	return nil
+}
+
+// InsertBalanceReconciliation stores a reconciliation and its discrepancies for
+// audit, and returns its ID
+func (r *PostgresTransactionRepository) InsertBalanceReconciliation(tx *sql.Tx, reconciliation *entities.BalanceReconciliation) (string, error) {
+	var id string
+	err := tx.QueryRow(
+		`INSERT INTO balance_reconciliations (started_at, finished_at, wallets_checked, discrepancies)
+		VALUES ($1::timestamp, $2::timestamp, $3, $4) RETURNING id`,
+		reconciliation.StartedAt.UTC().Format(timestampLayout),
+		reconciliation.FinishedAt.UTC().Format(timestampLayout),
+		reconciliation.WalletsChecked,
+		len(reconciliation.Discrepancies),
+	).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert balance reconciliation: %w", err)
+	}
+
+	query := `INSERT INTO balance_discrepancies (reconciliation_id, wallet_id, kind, currency, wallet_balance,
+			ledger_balance, adjustments, transaction_net, difference, pending_transactions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	for _, d := range reconciliation.Discrepancies {
+		_, err := tx.Exec(query, id, d.WalletID, d.Kind, d.Currency, d.WalletBalance,
+			d.LedgerBalance, d.Adjustments, d.TransactionNet, d.Difference, d.PendingTransactions)
+		if err != nil {
+			return "", fmt.Errorf("failed to insert balance discrepancy of wallet %s: %w", d.WalletID, err)
+		}
+	}
+	return id, nil
+}
+
+// Transition moves transactions to change.Status where their lifecycle allows
+// it, recording each move in their status history, and returns the IDs of
+// those it moved. The others are left as they are: those already in
+// change.Status, e.g. after a redelivered event, and those the move is illegal
+// for, which are logged. Reversals and refunds moved to COMPLETED move the
+// transactions they take back as well, see settleOriginals.
+func (r *PostgresTransactionRepository) Transition(ctx context.Context, tx *sql.Tx, transactionIDs []string, change StatusChange) ([]string, error) {
+	if len(transactionIDs) == 0 {
+		return nil, nil
+	}
+	from := entities.TransitionsTo(change.Status)
+	if len(from) == 0 {
+		return nil, fmt.Errorf("%w: nothing moves to %s", entities.ErrIllegalTransition, change.Status)
+	}
+
+	query := `WITH locked AS (
+			SELECT id, status FROM transactions WHERE id = ANY($1) AND status = ANY($2) FOR UPDATE
+		), moved AS (
+			UPDATE transactions t SET status = $3, updated_at = $4, failure_reason = COALESCE(NULLIF($5, ''), t.failure_reason)
+			FROM locked WHERE t.id = locked.id
+			RETURNING t.id, locked.status AS from_status
+		), history AS (
+			INSERT INTO transaction_status_history (transaction_id, from_status, to_status, actor, reason)
+			SELECT id, from_status, $3, $6, NULLIF($7, '') FROM moved
+		)
+		SELECT id FROM moved`
+
+	rows, err := tx.QueryContext(ctx, query, transactionIDs, statusNames(from), string(change.Status), time.Now(),
+		change.FailureReason, change.Actor, change.Reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move transactions to %s: %w", change.Status, err)
+	}
+	moved := make([]string, 0, len(transactionIDs))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan moved transaction: %w", err)
+		}
+		moved = append(moved, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to move transactions to %s: %w", change.Status, err)
+	}
+
+	if len(moved) < len(transactionIDs) {
+		r.logRejected(ctx, tx, transactionIDs, moved, change.Status)
+	}
+	if change.Status == entities.TRANSACTION_STATUS_COMPLETED && len(moved) > 0 {
+		if err := r.settleOriginals(ctx, tx, moved, change.Actor); err != nil {
+			return nil, err
+		}
+	}
+	return moved, nil
+}
+
+// settleOriginals moves the transactions that the completed reversals and
+// refunds among compensationIDs take back to REVERSED, once their completed
+// compensations add up to their whole amount, or to PARTIALLY_REFUNDED. The
+// history records every compensation, requested by whom and why, including the
+// refunds of transactions already PARTIALLY_REFUNDED.
+func (r *PostgresTransactionRepository) settleOriginals(ctx context.Context, tx *sql.Tx, compensationIDs []string, actor string) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, type, original_transaction_id, COALESCE(requested_by, ''), COALESCE(reason, '')
+		FROM transactions WHERE id = ANY($1) AND original_transaction_id IS NOT NULL
+		ORDER BY original_transaction_id, id`,
+		compensationIDs,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to look up completed compensations: %w", err)
+	}
+	type compensation struct{ id, txnType, originalID, requestedBy, reason string }
+	var compensations []compensation
+	for rows.Next() {
+		var c compensation
+		if err := rows.Scan(&c.id, &c.txnType, &c.originalID, &c.requestedBy, &c.reason); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan completed compensation: %w", err)
+		}
+		compensations = append(compensations, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to look up completed compensations: %w", err)
+	}
+
+	for _, c := range compensations {
+		var amount, compensated money.Amount
+		err := tx.QueryRowContext(ctx,
+			`SELECT o.amount, (SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE original_transaction_id = o.id AND status = $2)
+			FROM transactions o WHERE o.id = $1 FOR UPDATE`,
+			c.originalID, entities.TRANSACTION_STATUS_COMPLETED,
+		).Scan(&amount, &compensated)
+		if err != nil {
+			return fmt.Errorf("failed to sum up compensations of transaction %s: %w", c.originalID, err)
+		}
+
+		status := entities.TRANSACTION_STATUS_PARTIALLY_REFUNDED
+		if compensated >= amount {
+			status = entities.TRANSACTION_STATUS_REVERSED
+		}
+		reason := fmt.Sprintf("%s %s requested by %s", strings.ToLower(c.txnType), c.id, c.requestedBy)
+		if c.reason != "" {
+			reason += ": " + c.reason
+		}
+		moved, err := r.Transition(ctx, tx, []string{c.originalID}, StatusChange{Status: status, Actor: actor, Reason: reason})
+		if err != nil {
+			return err
+		}
+		if len(moved) == 0 && status == entities.TRANSACTION_STATUS_PARTIALLY_REFUNDED {
+			// Later partial refunds leave the transaction where it is, the
+			// history still records each of them.
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO transaction_status_history (transaction_id, from_status, to_status, actor, reason)
+				SELECT id, status, status, $2, $3 FROM transactions WHERE id = $1 AND status = $4`,
+				c.originalID, actor, reason, status,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to record refund %s of transaction %s: %w", c.id, c.originalID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// logRejected logs the transactions Transition didn't move because their
+// lifecycle doesn't allow it.
+func (r *PostgresTransactionRepository) logRejected(ctx context.Context, tx *sql.Tx, transactionIDs, moved []string, status entities.TransactionStatus) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, status FROM transactions WHERE id = ANY($1) AND NOT (id = ANY($2))`, transactionIDs, moved)
+	if err != nil {
+		log.Printf("Failed to look up transactions not moved to %s: %v", status, err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, current string
+		if err := rows.Scan(&id, &current); err != nil {
+			log.Printf("Failed to look up transactions not moved to %s: %v", status, err)
+			return
+		}
+		if entities.TransactionStatus(current) != status {
+			log.Printf("Rejected moving transaction %s from %s to %s: %v", id, current, status, entities.ErrIllegalTransition)
+		}
+	}
+}
+
+func statusNames(statuses []entities.TransactionStatus) []string {
+	names := make([]string, len(statuses))
+	for i, s := range statuses {
+		names[i] = string(s)
+	}
+	return names
+}
+
+// UpdateStatusBatch moves transactions to change.Status in a single database
+// transaction, see Transition
+func (r *PostgresTransactionRepository) UpdateStatusBatch(ctx context.Context, transactionIDs []string, change StatusChange) ([]string, error) {
 	if len(transactionIDs) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// Create context with timeout
@@ -80,34 +598,29 @@ func (r *PostgresTransactionRepository) UpdateStatusBatch(ctx context.Context, t
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		log.Printf("Failed to begin transaction for batch status update: %v", err)
-		return err
+		return nil, err
 	}
 	defer tx.Rollback()
 
-	// Create a parameterized query for batch update
-	// This uses PostgreSQL's ANY operator with an array
-	query := `UPDATE transactions SET status = $1, updated_at = $2 WHERE id = ANY($3) AND status = $4`
-
-	// Execute update
-	_, err = tx.ExecContext(ctx, query, string(status), time.Now(), transactionIDs, TRANSACTION_STATUS_PENDING)
+	moved, err := r.Transition(ctx, tx, transactionIDs, change)
 	if err != nil {
 		log.Printf("Failed to batch update transaction statuses: %v", err)
-		return err
+		return nil, err
 	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		log.Printf("Failed to commit batch update transaction: %v", err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	return moved, nil
 }
 
-// UpdateStatusConcurrently updates transaction statuses concurrently using multiple goroutines
-func (r *PostgresTransactionRepository) UpdateStatusConcurrently(ctx context.Context, transactionIDs []string, status entities.TransactionStatus) error {
+// UpdateStatusConcurrently moves transactions to change.Status using multiple goroutines, see Transition
+func (r *PostgresTransactionRepository) UpdateStatusConcurrently(ctx context.Context, transactionIDs []string, change StatusChange) ([]string, error) {
 	if len(transactionIDs) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// If only a few transactions, just use the batch method
@@ -115,7 +628,7 @@ func (r *PostgresTransactionRepository) UpdateStatusConcurrently(ctx context.Con
 		return r.UpdateStatusBatch(
 			ctx,
 			transactionIDs,
-			status,
+			change,
 		)
 	}
 
@@ -130,7 +643,11 @@ func (r *PostgresTransactionRepository) UpdateStatusConcurrently(ctx context.Con
 	}
 
 	// Process chunks concurrently
-	var wg sync.WaitGroup
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		moved []string
+	)
 	errChan := make(chan error, len(chunks))
 
 	for _, chunk := range chunks {
@@ -142,9 +659,14 @@ func (r *PostgresTransactionRepository) UpdateStatusConcurrently(ctx context.Con
 			chunkCtx, cancel := context.WithTimeout(ctx, DB_TIMEOUT)
 			defer cancel()
 
-			if err := r.UpdateStatusBatch(chunkCtx, ids, status); err != nil {
+			chunkMoved, err := r.UpdateStatusBatch(chunkCtx, ids, change)
+			if err != nil {
 				errChan <- err
+				return
 			}
+			mu.Lock()
+			moved = append(moved, chunkMoved...)
+			mu.Unlock()
 		}(chunk)
 	}
 
@@ -155,11 +677,11 @@ func (r *PostgresTransactionRepository) UpdateStatusConcurrently(ctx context.Con
 	// Check for errors
 	for err := range errChan {
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	return nil
+	return moved, nil
 }
 
 // Helper function for determining min value
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+	"transaction/internal/domain/entities"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// arrayConverter passes the slices bound to ANY($n) through to sqlmock, as the
+// pgx driver does.
+type arrayConverter struct{}
+
+func (arrayConverter) ConvertValue(v any) (driver.Value, error) {
+	if _, ok := v.([]string); ok {
+		return v, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func TestUpdateStatusBatchFailureReason(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		change         StatusChange
+		expectedReason string
+	}{
+		{
+			name:           "when a deposit is refused, it should store why on the row",
+			change:         StatusChange{Status: entities.TRANSACTION_STATUS_FAILED, Actor: "transaction-consumer", Reason: "deposit_failed: wallet_closed", FailureReason: "wallet_closed"},
+			expectedReason: "wallet_closed",
+		},
+		{
+			name:   "when a transaction expires, it should leave the failure reason alone",
+			change: StatusChange{Status: entities.TRANSACTION_STATUS_EXPIRED, Actor: "reconciler"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+			assert.NoError(t, err)
+			defer db.Close()
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE transactions t SET status = \$3, updated_at = \$4, failure_reason = COALESCE\(NULLIF\(\$5, ''\), t.failure_reason\)`).
+				WithArgs([]string{"tx-1"}, sqlmock.AnyArg(), string(tc.change.Status), sqlmock.AnyArg(), tc.expectedReason, tc.change.Actor, tc.change.Reason).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("tx-1"))
+			mock.ExpectCommit()
+
+			moved, err := NewPostgresTransactionRepository(db).UpdateStatusBatch(context.Background(), []string{"tx-1"}, tc.change)
+
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"tx-1"}, moved)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetByIDFailureReason(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		status        string
+		failureReason driver.Value
+		expected      string
+	}{
+		{name: "when the transaction failed, it should return why", status: "FAILED", failureReason: "wallet_not_found", expected: "wallet_not_found"},
+		{name: "when the transaction didn't fail, it should return no reason", status: "COMPLETED", failureReason: nil, expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+			mock.ExpectQuery(`FROM transactions WHERE id = \$1`).
+				WithArgs("tx-1").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "wallet_id", "amount", "currency", "type", "status", "transfer_id", "failure_reason",
+					"original_transaction_id", "requested_by", "reason", "created_at", "updated_at"}).
+					AddRow("tx-1", "wallet-1", "10.00", "EUR", "DEPOSIT", tc.status, nil, tc.failureReason, nil, nil, nil, time.Now(), time.Now()))
+
+			transaction, err := NewPostgresTransactionRepository(db).GetByID(context.Background(), "tx-1")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.status, transaction.Status)
+			assert.Equal(t, tc.expected, transaction.FailureReason)
+		})
+	}
+}
+
+func TestTransitionSettlesOriginals(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name              string
+		compensated       string
+		status            entities.TransactionStatus
+		movedOriginal     bool
+		expectedRecording bool
+	}{
+		{
+			name:          "when the first refund of a transaction completes, it should move it to PARTIALLY_REFUNDED",
+			compensated:   "3.00",
+			status:        entities.TRANSACTION_STATUS_PARTIALLY_REFUNDED,
+			movedOriginal: true,
+		},
+		{
+			name:              "when a later refund of a transaction completes, it should record it in the history",
+			compensated:       "6.00",
+			status:            entities.TRANSACTION_STATUS_PARTIALLY_REFUNDED,
+			expectedRecording: true,
+		},
+		{
+			name:          "when the refunds add up to the whole transaction, it should move it to REVERSED",
+			compensated:   "10.00",
+			status:        entities.TRANSACTION_STATUS_REVERSED,
+			movedOriginal: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+			assert.NoError(t, err)
+			defer db.Close()
+			mock.ExpectBegin()
+			mock.ExpectQuery(`UPDATE transactions t SET status = \$3`).
+				WithArgs([]string{"refund-2"}, sqlmock.AnyArg(), string(entities.TRANSACTION_STATUS_COMPLETED), sqlmock.AnyArg(), "", "transaction-consumer", "").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("refund-2"))
+			mock.ExpectQuery(`SELECT id, type, original_transaction_id`).
+				WithArgs([]string{"refund-2"}).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "type", "original_transaction_id", "requested_by", "reason"}).
+					AddRow("refund-2", "REFUND", "tx-1", "ops@example.com", "damaged item"))
+			mock.ExpectQuery(`SELECT o.amount`).
+				WithArgs("tx-1", entities.TRANSACTION_STATUS_COMPLETED).
+				WillReturnRows(sqlmock.NewRows([]string{"amount", "compensated"}).AddRow("10.00", tc.compensated))
+			reason := "refund refund-2 requested by ops@example.com: damaged item"
+			moved := sqlmock.NewRows([]string{"id"})
+			if tc.movedOriginal {
+				moved.AddRow("tx-1")
+			}
+			mock.ExpectQuery(`UPDATE transactions t SET status = \$3`).
+				WithArgs([]string{"tx-1"}, sqlmock.AnyArg(), string(tc.status), sqlmock.AnyArg(), "", "transaction-consumer", reason).
+				WillReturnRows(moved)
+			if tc.expectedRecording {
+				mock.ExpectQuery(`SELECT id, status FROM transactions`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("tx-1", string(tc.status)))
+				mock.ExpectExec(`INSERT INTO transaction_status_history`).
+					WithArgs("tx-1", "transaction-consumer", reason, tc.status).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			mock.ExpectCommit()
+			tx, err := db.Begin()
+			assert.NoError(t, err)
+
+			_, err = NewPostgresTransactionRepository(db).Transition(context.Background(), tx, []string{"refund-2"},
+				StatusChange{Status: entities.TRANSACTION_STATUS_COMPLETED, Actor: "transaction-consumer"})
+
+			assert.NoError(t, err)
+			assert.NoError(t, tx.Commit())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -2,23 +2,63 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"log"
+	"slices"
+	"strings"
+	"time"
 	"transaction/internal/domain/entities"
 	"transaction/internal/domain/repositories"
+	"transaction/internal/errcodes"
+	"transaction/internal/money"
 	"transaction/internal/producer"
 	"transaction/proto/gen"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 const (
-	Deposit  entities.TransactionType = 0
-	Withdraw entities.TransactionType = 1
+	Deposit  = entities.TypeDeposit
+	Withdraw = entities.TypeWithdraw
 )
 
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+	maxTransitionIDs = 1000
+	// Compensation reasons end up in the status history of the original
+	// transaction along with the compensation and who asked for it.
+	maxActorLength  = 64
+	maxReasonLength = 120
+)
+
+var (
+	transactionTypes    = []string{Deposit.String(), Withdraw.String(), entities.TypeTransferOut.String(), entities.TypeTransferIn.String(), entities.TypeReversal.String(), entities.TypeRefund.String()}
+	transactionStatuses = statusNames()
+)
+
+func statusNames() []string {
+	var names []string
+	for _, s := range entities.TransactionStatuses() {
+		names = append(names, string(s))
+	}
+	return names
+}
+
 type TransactionService interface {
 	Deposit(ctx context.Context, req *gen.TransactionRequest) (*gen.TransactionResponse, error)
+	Withdraw(ctx context.Context, req *gen.TransactionRequest) (*gen.TransactionResponse, error)
+	Transfer(ctx context.Context, req *gen.TransferRequest) (*gen.TransferResponse, error)
+	GetTransaction(ctx context.Context, req *gen.GetTransactionRequest) (*gen.Transaction, error)
+	ListTransactions(ctx context.Context, req *gen.ListTransactionsRequest) (*gen.ListTransactionsResponse, error)
+	TransitionStatus(ctx context.Context, req *gen.TransitionStatusRequest) (*gen.TransitionStatusResponse, error)
+	ReverseTransaction(ctx context.Context, req *gen.ReverseTransactionRequest) (*gen.CompensationResponse, error)
+	Refund(ctx context.Context, req *gen.RefundRequest) (*gen.CompensationResponse, error)
 }
 
 type TransactionServiceImpl struct {
@@ -35,15 +75,31 @@ func NewTransactionService(transactionRepo *repositories.PostgresTransactionRepo
 }
 
 func (s *TransactionServiceImpl) Deposit(ctx context.Context, req *gen.TransactionRequest) (*gen.TransactionResponse, error) {
-	deposit := entities.Transaction{
-		WalletID:       req.GetWalletId(),
-		Amount:         req.GetAmount(),
-		IdempotencyKey: req.GetIdempotencyKey(),
-		Type:           Deposit,
+	return s.initiate(ctx, req, Deposit, s.producer.PublishDepositInitiated)
+}
+
+// Withdraw records a PENDING withdrawal. Funds are checked when the wallet
+// service applies it, which fails the transaction if the balance is too low.
+func (s *TransactionServiceImpl) Withdraw(ctx context.Context, req *gen.TransactionRequest) (*gen.TransactionResponse, error) {
+	return s.initiate(ctx, req, Withdraw, s.producer.PublishWithdrawInitiated)
+}
+
+type publishFunc func(ctx context.Context, tx *sql.Tx, walletID string, amount money.Amount, currency money.Currency, transactionID string) error
+
+// initiate inserts a PENDING transaction and, in the same database transaction,
+// publishes it for the wallet service through the outbox.
+func (s *TransactionServiceImpl) initiate(ctx context.Context, req *gen.TransactionRequest, txnType entities.TransactionType, publish publishFunc) (*gen.TransactionResponse, error) {
+	amount, currency, err := validateTransactionRequest(req, txnType)
+	if err != nil {
+		return nil, err
 	}
 
-	if req.Amount <= 0 {
-		return nil, status.Errorf(codes.FailedPrecondition, "amount must be positive")
+	transaction := entities.Transaction{
+		WalletID:       req.GetWalletId(),
+		Amount:         amount,
+		Currency:       currency,
+		IdempotencyKey: req.GetIdempotencyKey(),
+		Type:           txnType,
 	}
 
 	// Start a transaction
@@ -54,33 +110,545 @@ func (s *TransactionServiceImpl) Deposit(ctx context.Context, req *gen.Transacti
 	defer tx.Rollback() // Roll back if not committed
 
 	// Check idempotency within transaction
-	existingID, err := s.transactionRepo.GetTxByKey(tx, req.GetIdempotencyKey())
+	existing, err := s.transactionRepo.GetByKey(tx, transaction.IdempotencyKey)
 	if err != nil {
 		return nil, err
 	}
-	if existingID != "" {
+	if existing.ID != "" {
+		if !sameTransaction(existing, transaction) {
+			return nil, errcodes.Error(codes.AlreadyExists, errcodes.IdempotencyKeyReused, "idempotency key was used for another request")
+		}
 		tx.Commit()
-		return &gen.TransactionResponse{TransactionId: existingID}, nil
+		return &gen.TransactionResponse{TransactionId: existing.ID}, nil
 	}
 
 	// Insert PENDING transaction
-	txID, err := s.transactionRepo.InsertOne(tx, deposit)
+	txID, err := s.transactionRepo.InsertOne(tx, transaction)
 	if err != nil {
 		return nil, err
 	}
 
-	// Commit transaction before Kafka
+	// The relay publishes it to Kafka once this commits.
+	if err := publish(ctx, tx, transaction.WalletID, transaction.Amount, transaction.Currency, txID); err != nil {
+		log.Printf("Failed to publish transaction %s: %v", txID, err)
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
-	// Publish to Kafka (non-transactional)
-	err = s.producer.PublishDepositInitiated(ctx, deposit.WalletID, deposit.Amount, txID)
+	return &gen.TransactionResponse{TransactionId: txID}, nil
+}
+
+// sameTransaction tells whether existing, found under the idempotency key of
+// requested, was recorded by the same deposit or withdrawal request.
+func sameTransaction(existing *entities.Transaction, requested entities.Transaction) bool {
+	return existing.Type == requested.Type &&
+		existing.WalletID == requested.WalletID &&
+		existing.Amount == requested.Amount &&
+		existing.Currency == requested.Currency
+}
+
+// Transfer records both sides of a transfer as PENDING and publishes it through
+// the outbox. The wallets share one database, so the wallet service debits and
+// credits them in a single database transaction and no compensation is needed.
+func (s *TransactionServiceImpl) Transfer(ctx context.Context, req *gen.TransferRequest) (*gen.TransferResponse, error) {
+	amount, currency, err := validateTransferRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.transactionRepo.BeginTx(ctx)
 	if err != nil {
-		log.Printf("Failed to publish to Kafka: %v; transaction %s is PENDING", err, txID)
-		// TODO: Mark transaction as failed in Postgres
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return &gen.TransactionResponse{TransactionId: txID}, nil
+	existing, err := s.transactionRepo.GetTransferByKey(tx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	if existing.ID != "" {
+		if !sameTransfer(existing, req, amount, currency) {
+			return nil, errcodes.Error(codes.AlreadyExists, errcodes.IdempotencyKeyReused, "idempotency key was used for another request")
+		}
+		tx.Commit()
+		return toProtoTransfer(existing), nil
+	}
+
+	transfer, err := s.transactionRepo.InsertTransfer(tx,
+		entities.Transaction{WalletID: req.GetSourceWalletId(), Amount: amount, Currency: currency, IdempotencyKey: req.GetIdempotencyKey()},
+		entities.Transaction{WalletID: req.GetDestinationWalletId(), Amount: amount, Currency: currency},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.producer.PublishTransferInitiated(ctx, tx, transfer.ID, req.GetSourceWalletId(), req.GetDestinationWalletId(), amount, currency, transfer.DebitID, transfer.CreditID)
+	if err != nil {
+		log.Printf("Failed to publish transfer %s: %v", transfer.ID, err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return toProtoTransfer(transfer), nil
+}
+
+// sameTransfer reports whether a retried transfer request asks for the
+// recorded transfer.
+func sameTransfer(existing *repositories.Transfer, req *gen.TransferRequest, amount money.Amount, currency money.Currency) bool {
+	return existing.SourceWalletID == req.GetSourceWalletId() &&
+		existing.DestinationWalletID == req.GetDestinationWalletId() &&
+		existing.Amount == amount &&
+		existing.Currency == currency
+}
+
+func toProtoTransfer(t *repositories.Transfer) *gen.TransferResponse {
+	return &gen.TransferResponse{
+		TransferId:          t.ID,
+		DebitTransactionId:  t.DebitID,
+		CreditTransactionId: t.CreditID,
+	}
+}
+
+// GetTransaction returns a transaction by ID. The broker checks that its wallet
+// belongs to the caller.
+func (s *TransactionServiceImpl) GetTransaction(ctx context.Context, req *gen.GetTransactionRequest) (*gen.Transaction, error) {
+	if req.GetTransactionId() == "" {
+		return nil, errcodes.Invalid(errcodes.InvalidArgument, "transaction ID is required", errcodes.Violation("transaction_id", "must not be empty"))
+	}
+
+	t, err := s.transactionRepo.GetByID(ctx, req.GetTransactionId())
+	if err != nil {
+		log.Printf("Failed to get transaction %s: %v", req.GetTransactionId(), err)
+		return nil, status.Error(codes.Internal, "error getting transaction")
+	}
+	if t.ID == "" {
+		return nil, errcodes.Error(codes.NotFound, errcodes.TransactionNotFound, "transaction not found")
+	}
+
+	return toProtoTransaction(t), nil
+}
+
+// ListTransactions returns a page of a wallet's transactions, newest first. The
+// broker checks that the wallet belongs to the caller.
+func (s *TransactionServiceImpl) ListTransactions(ctx context.Context, req *gen.ListTransactionsRequest) (*gen.ListTransactionsResponse, error) {
+	filter, err := listFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra row tells whether there is a next page.
+	limit := filter.Limit
+	filter.Limit++
+
+	transactions, err := s.transactionRepo.List(ctx, filter)
+	if err != nil {
+		log.Printf("Failed to list transactions of wallet %s: %v", req.GetWalletId(), err)
+		return nil, status.Error(codes.Internal, "error listing transactions")
+	}
+
+	resp := &gen.ListTransactionsResponse{}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		resp.NextCursor = encodeCursor(repositories.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	resp.Transactions = make([]*gen.Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		resp.Transactions = append(resp.Transactions, toProtoTransaction(t))
+	}
+	return resp, nil
+}
+
+// TransitionStatus moves transactions to a status where their lifecycle allows
+// it. It is called by other services, such as capture, not on behalf of a user.
+func (s *TransactionServiceImpl) TransitionStatus(ctx context.Context, req *gen.TransitionStatusRequest) (*gen.TransitionStatusResponse, error) {
+	change, err := statusChange(req)
+	if err != nil {
+		return nil, err
+	}
+
+	moved, err := s.transactionRepo.UpdateStatusConcurrently(ctx, req.GetTransactionIds(), change)
+	if err != nil {
+		log.Printf("Failed to move transactions to %s: %v", change.Status, err)
+		return nil, status.Error(codes.Internal, "error updating transaction statuses")
+	}
+
+	resp := &gen.TransitionStatusResponse{TransitionedIds: moved}
+	for _, id := range req.GetTransactionIds() {
+		if !slices.Contains(moved, id) {
+			resp.RejectedIds = append(resp.RejectedIds, id)
+		}
+	}
+	return resp, nil
+}
+
+// ReverseTransaction takes back what remains of a completed deposit, see
+// compensate. It is called by admin and support tools, not on behalf of a user.
+func (s *TransactionServiceImpl) ReverseTransaction(ctx context.Context, req *gen.ReverseTransactionRequest) (*gen.CompensationResponse, error) {
+	if violations := compensationViolations(req.GetTransactionId(), req.GetIdempotencyKey(), req.GetActor(), req.GetReason()); len(violations) > 0 {
+		return nil, errcodes.Invalid(errcodes.InvalidArgument, "invalid reversal request", violations...)
+	}
+
+	return s.compensate(ctx, entities.Transaction{
+		Type:                  entities.TypeReversal,
+		IdempotencyKey:        req.GetIdempotencyKey(),
+		OriginalTransactionID: req.GetTransactionId(),
+		RequestedBy:           req.GetActor(),
+		Reason:                req.GetReason(),
+	})
+}
+
+// Refund takes back part of a completed deposit, see compensate. Refunds of a
+// deposit may add up to all of it, which reverses it.
+func (s *TransactionServiceImpl) Refund(ctx context.Context, req *gen.RefundRequest) (*gen.CompensationResponse, error) {
+	amount, currency, err := validateRefundRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.compensate(ctx, entities.Transaction{
+		Type:                  entities.TypeRefund,
+		Amount:                amount,
+		Currency:              currency,
+		IdempotencyKey:        req.GetIdempotencyKey(),
+		OriginalTransactionID: req.GetTransactionId(),
+		RequestedBy:           req.GetActor(),
+		Reason:                req.GetReason(),
+	})
+}
+
+// compensate records a PENDING reversal or refund of a completed deposit and, in
+// the same database transaction, publishes it for the wallet service to debit
+// the deposit's wallet. A reversal, with no amount, takes back what the
+// deposit's other reversals and refunds leave of it. The deposit is locked
+// meanwhile, so compensations never add up to more than it.
+func (s *TransactionServiceImpl) compensate(ctx context.Context, compensation entities.Transaction) (*gen.CompensationResponse, error) {
+	tx, err := s.transactionRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	existing, err := s.transactionRepo.GetByKey(tx, compensation.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing.ID != "" {
+		if !sameCompensation(existing, compensation) {
+			return nil, errcodes.Error(codes.AlreadyExists, errcodes.IdempotencyKeyReused, "idempotency key was used for another request")
+		}
+		tx.Commit()
+		return toProtoCompensation(existing), nil
+	}
+
+	original, err := s.transactionRepo.GetForUpdate(tx, compensation.OriginalTransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if original.ID == "" {
+		return nil, errcodes.Error(codes.NotFound, errcodes.TransactionNotFound, "transaction not found")
+	}
+	if original.Type != Deposit {
+		return nil, errcodes.Error(codes.FailedPrecondition, errcodes.TransactionNotReversible, "only deposits can be reversed or refunded")
+	}
+	if !entities.TransactionStatus(original.Status).CanTransitionTo(entities.TRANSACTION_STATUS_REVERSED) {
+		return nil, errcodes.Error(codes.FailedPrecondition, errcodes.TransactionNotReversible, fmt.Sprintf("a %s deposit can't be reversed or refunded", original.Status))
+	}
+	if compensation.Currency != "" && compensation.Currency != original.Currency {
+		return nil, errcodes.Invalid(errcodes.CurrencyInvalid, "invalid refund request",
+			errcodes.Violation("amount.currency", "must be the currency of the transaction, "+string(original.Currency)))
+	}
+
+	compensated, err := s.transactionRepo.CompensatedAmount(tx, original.ID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := original.Amount - compensated
+	switch {
+	case remaining <= 0:
+		return nil, errcodes.Error(codes.FailedPrecondition, errcodes.TransactionNotReversible, "the deposit is reversed already, or will be once its pending reversals and refunds complete")
+	case compensation.Amount == 0:
+		compensation.Amount = remaining
+	case compensation.Amount > remaining:
+		return nil, errcodes.Invalid(errcodes.RefundExceedsRemaining, "invalid refund request",
+			errcodes.Violation("amount", fmt.Sprintf("must be at most %s, what is left of the transaction", remaining)))
+	}
+
+	compensation.WalletID = original.WalletID
+	compensation.Currency = original.Currency
+	compensation.Status = string(entities.TRANSACTION_STATUS_PENDING)
+	if compensation.ID, err = s.transactionRepo.InsertCompensation(tx, compensation); err != nil {
+		return nil, err
+	}
+
+	// The relay publishes it to Kafka once this commits.
+	if err := s.producer.PublishReversalInitiated(ctx, tx, compensation); err != nil {
+		log.Printf("Failed to publish %s %s: %v", strings.ToLower(compensation.Type.String()), compensation.ID, err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("%s %s of %s for transaction %s requested by %s", compensation.Type, compensation.ID, compensation.Amount, original.ID, compensation.RequestedBy)
+	return toProtoCompensation(&compensation), nil
+}
+
+// sameCompensation tells whether existing, found under the idempotency key of
+// requested, was recorded by the same request.
+func sameCompensation(existing *entities.Transaction, requested entities.Transaction) bool {
+	return existing.Type == requested.Type &&
+		existing.OriginalTransactionID == requested.OriginalTransactionID &&
+		(requested.Amount == 0 || existing.Amount == requested.Amount)
+}
+
+func toProtoCompensation(t *entities.Transaction) *gen.CompensationResponse {
+	return &gen.CompensationResponse{
+		TransactionId:         t.ID,
+		OriginalTransactionId: t.OriginalTransactionID,
+		Amount:                t.Amount.ProtoIn(t.Currency),
+		Status:                t.Status,
+	}
+}
+
+// statusChange validates a transition request, collecting every invalid field.
+func statusChange(req *gen.TransitionStatusRequest) (repositories.StatusChange, error) {
+	change := repositories.StatusChange{
+		Actor:  req.GetActor(),
+		Reason: req.GetReason(),
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	if n := len(req.GetTransactionIds()); n == 0 || n > maxTransitionIDs {
+		violations = append(violations, errcodes.Violation("transaction_ids", fmt.Sprintf("must hold between 1 and %d IDs", maxTransitionIDs)))
+	}
+	status, err := entities.ParseTransactionStatus(strings.ToUpper(req.GetStatus()))
+	if err != nil {
+		violations = append(violations, errcodes.Violation("status", "must be one of "+strings.Join(transactionStatuses, ", ")))
+	} else if len(entities.TransitionsTo(status)) == 0 {
+		violations = append(violations, errcodes.Violation("status", "no transaction can move to "+string(status)))
+	} else if status == entities.TRANSACTION_STATUS_REVERSED || status == entities.TRANSACTION_STATUS_PARTIALLY_REFUNDED {
+		// Only a completed reversal or refund, which moved the money back, does.
+		violations = append(violations, errcodes.Violation("status", "use ReverseTransaction or Refund to move transactions to "+string(status)))
+	}
+	change.Status = status
+	if change.Actor == "" {
+		violations = append(violations, errcodes.Violation("actor", "must not be empty"))
+	}
+
+	if len(violations) > 0 {
+		return change, errcodes.Invalid(errcodes.InvalidArgument, "invalid status transition request", violations...)
+	}
+	return change, nil
+}
+
+// listFilter validates a list request, collecting every invalid field.
+func listFilter(req *gen.ListTransactionsRequest) (repositories.TransactionFilter, error) {
+	filter := repositories.TransactionFilter{
+		WalletID: req.GetWalletId(),
+		Type:     strings.ToUpper(req.GetType()),
+		Status:   strings.ToUpper(req.GetStatus()),
+		Limit:    int(req.GetLimit()),
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	if filter.WalletID == "" {
+		violations = append(violations, errcodes.Violation("wallet_id", "must not be empty"))
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxListLimit {
+		violations = append(violations, errcodes.Violation("limit", fmt.Sprintf("must be between 1 and %d", maxListLimit)))
+	}
+	if filter.Type != "" && !slices.Contains(transactionTypes, filter.Type) {
+		violations = append(violations, errcodes.Violation("type", "must be one of "+strings.Join(transactionTypes, ", ")))
+	}
+	if filter.Status != "" && !slices.Contains(transactionStatuses, filter.Status) {
+		violations = append(violations, errcodes.Violation("status", "must be one of "+strings.Join(transactionStatuses, ", ")))
+	}
+
+	var err error
+	if req.GetFrom() != "" {
+		if filter.From, err = time.Parse(time.RFC3339, req.GetFrom()); err != nil {
+			violations = append(violations, errcodes.Violation("from", "must be an RFC 3339 timestamp"))
+		}
+	}
+	if req.GetTo() != "" {
+		if filter.To, err = time.Parse(time.RFC3339, req.GetTo()); err != nil {
+			violations = append(violations, errcodes.Violation("to", "must be an RFC 3339 timestamp"))
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		violations = append(violations, errcodes.Violation("to", "must be after from"))
+	}
+	if req.GetCursor() != "" {
+		cursor, err := decodeCursor(req.GetCursor())
+		if err != nil {
+			violations = append(violations, errcodes.Violation("cursor", "is invalid or expired"))
+		}
+		filter.After = cursor
+	}
+
+	if len(violations) > 0 {
+		return filter, errcodes.Invalid(errcodes.InvalidArgument, "invalid list transactions request", violations...)
+	}
+	return filter, nil
+}
+
+// encodeCursor makes the position of the last transaction of a page opaque to clients.
+func encodeCursor(c repositories.Cursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID))
+}
+
+func decodeCursor(raw string) (*repositories.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	createdAt, id, ok := strings.Cut(string(b), "|")
+	if !ok || id == "" {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	return &repositories.Cursor{CreatedAt: t, ID: id}, nil
+}
+
+func toProtoTransaction(t *entities.Transaction) *gen.Transaction {
+	return &gen.Transaction{
+		Id:                    t.ID,
+		WalletId:              t.WalletID,
+		Amount:                t.Amount.Float64(),
+		AmountMoney:           t.Amount.ProtoIn(t.Currency),
+		Type:                  t.Type.String(),
+		Status:                t.Status,
+		CreatedAt:             t.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:             t.UpdatedAt.UTC().Format(time.RFC3339),
+		TransferId:            t.TransferID,
+		FailureReason:         t.FailureReason,
+		OriginalTransactionId: t.OriginalTransactionID,
+	}
+}
+
+// readAmount reads an amount and its currency, the default one for older
+// clients, refusing amounts finer than the currency's minor unit.
+func readAmount(m *gen.Money, legacy float64) (money.Amount, money.Currency, error) {
+	currency, err := money.CurrencyOf(m)
+	if err != nil {
+		return 0, "", err
+	}
+	amount, err := money.FromProto(m, legacy)
+	if err != nil {
+		return 0, "", err
+	}
+	return amount, currency, currency.Check(amount)
+}
+
+// amountViolation describes why an amount read by readAmount is invalid, with
+// the error code to return.
+func amountViolation(amount money.Amount, err error) (string, *errdetails.BadRequest_FieldViolation) {
+	switch {
+	case errors.Is(err, money.ErrCurrency):
+		return errcodes.CurrencyInvalid, errcodes.Violation("amount.currency", err.Error())
+	case err != nil:
+		return errcodes.AmountInvalid, errcodes.Violation("amount", fmt.Sprintf("must be a whole number of minor units: %v", err))
+	case amount <= 0:
+		return errcodes.AmountInvalid, errcodes.Violation("amount", "must be greater than 0")
+	}
+	return "", nil
+}
+
+// validateTransactionRequest checks the request and returns its exact amount,
+// read from amount_money or, for older clients, the legacy amount, and its
+// currency.
+func validateTransactionRequest(req *gen.TransactionRequest, txnType entities.TransactionType) (money.Amount, money.Currency, error) {
+	reason := errcodes.InvalidArgument
+	var violations []*errdetails.BadRequest_FieldViolation
+	amount, currency, err := readAmount(req.GetAmountMoney(), req.GetAmount())
+	if r, violation := amountViolation(amount, err); violation != nil {
+		reason = r
+		violations = append(violations, violation)
+	}
+	if req.GetWalletId() == "" {
+		violations = append(violations, errcodes.Violation("wallet_id", "must not be empty"))
+	}
+	if req.GetIdempotencyKey() == "" {
+		violations = append(violations, errcodes.Violation("idempotency_key", "must not be empty"))
+	}
+
+	if len(violations) > 0 {
+		return 0, "", errcodes.Invalid(reason, fmt.Sprintf("invalid %s request", strings.ToLower(txnType.String())), violations...)
+	}
+	return amount, currency, nil
+}
+
+func validateTransferRequest(req *gen.TransferRequest) (money.Amount, money.Currency, error) {
+	reason := errcodes.InvalidArgument
+	var violations []*errdetails.BadRequest_FieldViolation
+	amount, currency, err := readAmount(req.GetAmount(), 0)
+	if r, violation := amountViolation(amount, err); violation != nil {
+		reason = r
+		violations = append(violations, violation)
+	}
+	if req.GetSourceWalletId() == "" {
+		violations = append(violations, errcodes.Violation("source_wallet_id", "must not be empty"))
+	}
+	if req.GetDestinationWalletId() == "" {
+		violations = append(violations, errcodes.Violation("destination_wallet_id", "must not be empty"))
+	} else if req.GetDestinationWalletId() == req.GetSourceWalletId() {
+		violations = append(violations, errcodes.Violation("destination_wallet_id", "must differ from source_wallet_id"))
+	}
+	if req.GetIdempotencyKey() == "" {
+		violations = append(violations, errcodes.Violation("idempotency_key", "must not be empty"))
+	}
+
+	if len(violations) > 0 {
+		return 0, "", errcodes.Invalid(reason, "invalid transfer request", violations...)
+	}
+	return amount, currency, nil
+}
+
+func validateRefundRequest(req *gen.RefundRequest) (money.Amount, money.Currency, error) {
+	reason := errcodes.InvalidArgument
+	var violations []*errdetails.BadRequest_FieldViolation
+	amount, currency, err := readAmount(req.GetAmount(), 0)
+	if r, violation := amountViolation(amount, err); violation != nil {
+		reason = r
+		violations = append(violations, violation)
+	}
+	violations = append(violations, compensationViolations(req.GetTransactionId(), req.GetIdempotencyKey(), req.GetActor(), req.GetReason())...)
+
+	if len(violations) > 0 {
+		return 0, "", errcodes.Invalid(reason, "invalid refund request", violations...)
+	}
+	return amount, currency, nil
+}
+
+// compensationViolations checks the fields reversal and refund requests share.
+func compensationViolations(transactionID, idempotencyKey, actor, reason string) []*errdetails.BadRequest_FieldViolation {
+	var violations []*errdetails.BadRequest_FieldViolation
+	if transactionID == "" {
+		violations = append(violations, errcodes.Violation("transaction_id", "must not be empty"))
+	}
+	if idempotencyKey == "" {
+		violations = append(violations, errcodes.Violation("idempotency_key", "must not be empty"))
+	}
+	if actor == "" || len(actor) > maxActorLength {
+		violations = append(violations, errcodes.Violation("actor", fmt.Sprintf("must hold between 1 and %d characters", maxActorLength)))
+	}
+	if reason == "" || len(reason) > maxReasonLength {
+		violations = append(violations, errcodes.Violation("reason", fmt.Sprintf("must hold between 1 and %d characters", maxReasonLength)))
+	}
+	return violations
 }
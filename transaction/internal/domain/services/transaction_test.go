@@ -0,0 +1,499 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+	"transaction/internal/domain/entities"
+	"transaction/internal/domain/repositories"
+	"transaction/internal/errcodes"
+	"transaction/internal/money"
+	"transaction/proto/gen"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListFilter(t *testing.T) {
+	t.Parallel()
+
+	cursor := repositories.Cursor{
+		CreatedAt: time.Date(2025, 3, 1, 10, 30, 0, 123456000, time.UTC),
+		ID:        "8f0c6f8e-2d7a-4c1e-9a59-0c6a2b7d1e11",
+	}
+
+	testCases := []struct {
+		name          string
+		request       *gen.ListTransactionsRequest
+		expected      repositories.TransactionFilter
+		expectedError bool
+	}{
+		{
+			name:    "when only the wallet is given, it should use the default limit",
+			request: &gen.ListTransactionsRequest{WalletId: "wallet-1"},
+			expected: repositories.TransactionFilter{
+				WalletID: "wallet-1",
+				Limit:    defaultListLimit,
+			},
+		},
+		{
+			name: "when filters and a cursor are given, it should continue after the cursor",
+			request: &gen.ListTransactionsRequest{
+				WalletId: "wallet-1",
+				Limit:    5,
+				Type:     "deposit",
+				Status:   "COMPLETED",
+				From:     "2025-03-01T00:00:00Z",
+				To:       "2025-04-01T00:00:00Z",
+				Cursor:   encodeCursor(cursor),
+			},
+			expected: repositories.TransactionFilter{
+				WalletID: "wallet-1",
+				Type:     "DEPOSIT",
+				Status:   "COMPLETED",
+				From:     time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+				To:       time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+				After:    &cursor,
+				Limit:    5,
+			},
+		},
+		{
+			name:          "when the type is unknown, it should return an error",
+			request:       &gen.ListTransactionsRequest{WalletId: "wallet-1", Type: "CHARGEBACK"},
+			expectedError: true,
+		},
+		{
+			name:          "when the cursor is tampered with, it should return an error",
+			request:       &gen.ListTransactionsRequest{WalletId: "wallet-1", Cursor: "not-a-cursor"},
+			expectedError: true,
+		},
+		{
+			name: "when the date range is reversed, it should return an error",
+			request: &gen.ListTransactionsRequest{
+				WalletId: "wallet-1",
+				From:     "2025-04-01T00:00:00Z",
+				To:       "2025-03-01T00:00:00Z",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := listFilter(tc.request)
+
+			if tc.expectedError {
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected.WalletID, filter.WalletID)
+			assert.Equal(t, tc.expected.Type, filter.Type)
+			assert.Equal(t, tc.expected.Status, filter.Status)
+			assert.Equal(t, tc.expected.Limit, filter.Limit)
+			assert.True(t, tc.expected.From.Equal(filter.From))
+			assert.True(t, tc.expected.To.Equal(filter.To))
+			if tc.expected.After == nil {
+				assert.Nil(t, filter.After)
+			} else {
+				assert.True(t, tc.expected.After.CreatedAt.Equal(filter.After.CreatedAt))
+				assert.Equal(t, tc.expected.After.ID, filter.After.ID)
+			}
+		})
+	}
+}
+
+func reasonOf(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+	return ""
+}
+
+func TestValidateTransactionRequest(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		request          *gen.TransactionRequest
+		expected         money.Amount
+		expectedCurrency money.Currency
+		expectedReason   string
+	}{
+		{
+			name:             "when the amount is sent as money, it should use it",
+			request:          &gen.TransactionRequest{WalletId: "wallet-1", IdempotencyKey: "k1", AmountMoney: &gen.Money{MinorUnits: 1010}},
+			expected:         1010,
+			expectedCurrency: money.DefaultCurrency,
+		},
+		{
+			name:             "when only the legacy amount is sent, it should convert it",
+			request:          &gen.TransactionRequest{WalletId: "wallet-1", IdempotencyKey: "k1", Amount: 10.1},
+			expected:         1010,
+			expectedCurrency: money.DefaultCurrency,
+		},
+		{
+			name:             "when the money names a currency, it should read minor units of it",
+			request:          &gen.TransactionRequest{WalletId: "wallet-1", IdempotencyKey: "k1", AmountMoney: &gen.Money{MinorUnits: 1500, Currency: "JPY"}},
+			expected:         150000,
+			expectedCurrency: "JPY",
+		},
+		{
+			name:           "when the currency isn't supported, it should return an error",
+			request:        &gen.TransactionRequest{WalletId: "wallet-1", IdempotencyKey: "k1", AmountMoney: &gen.Money{MinorUnits: 1500, Currency: "XYZ"}},
+			expectedReason: errcodes.CurrencyInvalid,
+		},
+		{
+			name:           "when the legacy amount has sub-cent precision, it should return an error",
+			request:        &gen.TransactionRequest{WalletId: "wallet-1", IdempotencyKey: "k1", Amount: 10.001},
+			expectedReason: errcodes.AmountInvalid,
+		},
+		{
+			name:           "when the amount is not positive, it should return an error",
+			request:        &gen.TransactionRequest{WalletId: "wallet-1", IdempotencyKey: "k1", AmountMoney: &gen.Money{}},
+			expectedReason: errcodes.AmountInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, currency, err := validateTransactionRequest(tc.request, Withdraw)
+
+			if tc.expectedReason != "" {
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, amount)
+			assert.Equal(t, tc.expectedCurrency, currency)
+		})
+	}
+}
+
+func TestValidateTransferRequest(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		request       *gen.TransferRequest
+		expectedError bool
+	}{
+		{
+			name: "when the request is complete, it should return its amount",
+			request: &gen.TransferRequest{
+				SourceWalletId: "wallet-1", DestinationWalletId: "wallet-2", IdempotencyKey: "k1", Amount: &gen.Money{MinorUnits: 500, Currency: "GBP"},
+			},
+		},
+		{
+			name: "when the source and destination are the same wallet, it should return an error",
+			request: &gen.TransferRequest{
+				SourceWalletId: "wallet-1", DestinationWalletId: "wallet-1", IdempotencyKey: "k1", Amount: &gen.Money{MinorUnits: 500},
+			},
+			expectedError: true,
+		},
+		{
+			name: "when the amount is missing, it should return an error",
+			request: &gen.TransferRequest{
+				SourceWalletId: "wallet-1", DestinationWalletId: "wallet-2", IdempotencyKey: "k1",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, currency, err := validateTransferRequest(tc.request)
+
+			if tc.expectedError {
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, money.Amount(tc.request.GetAmount().GetMinorUnits()), amount)
+			assert.Equal(t, money.Currency(tc.request.GetAmount().GetCurrency()), currency)
+		})
+	}
+}
+
+func TestValidateRefundRequest(t *testing.T) {
+	t.Parallel()
+
+	valid := func(modify func(*gen.RefundRequest)) *gen.RefundRequest {
+		req := &gen.RefundRequest{
+			TransactionId:  "tx-1",
+			Amount:         &gen.Money{MinorUnits: 250, Currency: "EUR"},
+			IdempotencyKey: "k1",
+			Actor:          "support:alice",
+			Reason:         "duplicate deposit",
+		}
+		modify(req)
+		return req
+	}
+
+	testCases := []struct {
+		name           string
+		request        *gen.RefundRequest
+		expected       money.Amount
+		expectedReason string
+	}{
+		{
+			name:     "when the request is complete, it should return its amount",
+			request:  valid(func(*gen.RefundRequest) {}),
+			expected: 250,
+		},
+		{
+			name:           "when the amount is not positive, it should return an error",
+			request:        valid(func(r *gen.RefundRequest) { r.Amount = &gen.Money{Currency: "EUR"} }),
+			expectedReason: errcodes.AmountInvalid,
+		},
+		{
+			name:           "when no reason is given, it should return an error",
+			request:        valid(func(r *gen.RefundRequest) { r.Reason = "" }),
+			expectedReason: errcodes.InvalidArgument,
+		},
+		{
+			name:           "when the reason is too long for the status history, it should return an error",
+			request:        valid(func(r *gen.RefundRequest) { r.Reason = strings.Repeat("a", maxReasonLength+1) }),
+			expectedReason: errcodes.InvalidArgument,
+		},
+		{
+			name:           "when the actor is missing, it should return an error",
+			request:        valid(func(r *gen.RefundRequest) { r.Actor = "" }),
+			expectedReason: errcodes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			amount, _, err := validateRefundRequest(tc.request)
+
+			if tc.expectedReason != "" {
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, amount)
+		})
+	}
+}
+
+func TestStatusChange(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		request        *gen.TransitionStatusRequest
+		expectedStatus entities.TransactionStatus
+		expectedError  bool
+	}{
+		{
+			name:           "when capture moves transactions to processing, it should accept it",
+			request:        &gen.TransitionStatusRequest{TransactionIds: []string{"tx-1"}, Status: "processing", Actor: "capture"},
+			expectedStatus: entities.TRANSACTION_STATUS_PROCESSING,
+		},
+		{
+			name:          "when the status is unknown, it should return an error",
+			request:       &gen.TransitionStatusRequest{TransactionIds: []string{"tx-1"}, Status: "REFUNDED", Actor: "capture"},
+			expectedError: true,
+		},
+		{
+			name:          "when nothing can move to the status, it should return an error",
+			request:       &gen.TransitionStatusRequest{TransactionIds: []string{"tx-1"}, Status: "PENDING", Actor: "capture"},
+			expectedError: true,
+		},
+		{
+			name:          "when transactions are moved to REVERSED without a reversal, it should return an error",
+			request:       &gen.TransitionStatusRequest{TransactionIds: []string{"tx-1"}, Status: "REVERSED", Actor: "capture"},
+			expectedError: true,
+		},
+		{
+			name:          "when no transaction is given, it should return an error",
+			request:       &gen.TransitionStatusRequest{Status: "PROCESSING", Actor: "capture"},
+			expectedError: true,
+		},
+		{
+			name:          "when the actor is missing, it should return an error",
+			request:       &gen.TransitionStatusRequest{TransactionIds: []string{"tx-1"}, Status: "PROCESSING"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			change, err := statusChange(tc.request)
+
+			if tc.expectedError {
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, change.Status)
+			assert.Equal(t, tc.request.Actor, change.Actor)
+		})
+	}
+}
+
+func TestInitiateRetry(t *testing.T) {
+	t.Parallel()
+
+	columns := []string{"id", "wallet_id", "amount", "currency", "type", "status", "transfer_id", "failure_reason",
+		"original_transaction_id", "requested_by", "reason", "created_at", "updated_at"}
+	existing := func(walletID, amount, txnType string) []driver.Value {
+		return []driver.Value{"tx-1", walletID, amount, "EUR", txnType, "PENDING", nil, nil, nil, nil, nil, time.Now(), time.Now()}
+	}
+	request := &gen.TransactionRequest{WalletId: "wallet-1", IdempotencyKey: "k1", AmountMoney: &gen.Money{MinorUnits: 1010}}
+
+	testCases := []struct {
+		name           string
+		txnType        entities.TransactionType
+		existing       []driver.Value
+		expectedID     string
+		expectedCode   codes.Code
+		expectedReason string
+	}{
+		{
+			name:       "when the same request is retried, it should return the recorded transaction",
+			txnType:    Deposit,
+			existing:   existing("wallet-1", "10.10", "DEPOSIT"),
+			expectedID: "tx-1",
+		},
+		{
+			name:           "when the key was used for another amount, it should return already exists",
+			txnType:        Deposit,
+			existing:       existing("wallet-1", "20.00", "DEPOSIT"),
+			expectedCode:   codes.AlreadyExists,
+			expectedReason: errcodes.IdempotencyKeyReused,
+		},
+		{
+			name:           "when the key was used for another wallet, it should return already exists",
+			txnType:        Deposit,
+			existing:       existing("wallet-2", "10.10", "DEPOSIT"),
+			expectedCode:   codes.AlreadyExists,
+			expectedReason: errcodes.IdempotencyKeyReused,
+		},
+		{
+			name:           "when the key was used for a deposit and a withdrawal is retried, it should return already exists",
+			txnType:        Withdraw,
+			existing:       existing("wallet-1", "10.10", "DEPOSIT"),
+			expectedCode:   codes.AlreadyExists,
+			expectedReason: errcodes.IdempotencyKeyReused,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+			mock.ExpectBegin()
+			mock.ExpectQuery("FROM transactions WHERE idempotency_key = \\$1").
+				WithArgs("k1").
+				WillReturnRows(sqlmock.NewRows(columns).AddRow(tc.existing...))
+			if tc.expectedID != "" {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+			s := NewTransactionService(repositories.NewPostgresTransactionRepository(db), nil)
+
+			resp, err := s.initiate(context.Background(), request, tc.txnType, nil)
+
+			if tc.expectedReason != "" {
+				assert.Equal(t, tc.expectedCode, status.Code(err))
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedID, resp.GetTransactionId())
+			}
+			// Nothing is inserted or published again.
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTransferRetry(t *testing.T) {
+	t.Parallel()
+
+	columns := []string{"transfer_id", "debit_id", "credit_id", "source_wallet_id", "destination_wallet_id", "amount", "currency"}
+	request := &gen.TransferRequest{SourceWalletId: "wallet-1", DestinationWalletId: "wallet-2", IdempotencyKey: "k1", Amount: &gen.Money{MinorUnits: 1010, Currency: "EUR"}}
+
+	testCases := []struct {
+		name           string
+		existing       []driver.Value
+		expectedID     string
+		expectedReason string
+	}{
+		{
+			name:       "when the same transfer is retried, it should return the recorded transfer",
+			existing:   []driver.Value{"tr-1", "tx-1", "tx-2", "wallet-1", "wallet-2", "10.10", "EUR"},
+			expectedID: "tr-1",
+		},
+		{
+			name:           "when the key was used for another destination, it should return already exists",
+			existing:       []driver.Value{"tr-1", "tx-1", "tx-2", "wallet-1", "wallet-3", "10.10", "EUR"},
+			expectedReason: errcodes.IdempotencyKeyReused,
+		},
+		{
+			name:           "when the key was used for another source, it should return already exists",
+			existing:       []driver.Value{"tr-1", "tx-1", "tx-2", "wallet-3", "wallet-2", "10.10", "EUR"},
+			expectedReason: errcodes.IdempotencyKeyReused,
+		},
+		{
+			name:           "when the key was used for another amount, it should return already exists",
+			existing:       []driver.Value{"tr-1", "tx-1", "tx-2", "wallet-1", "wallet-2", "20.00", "EUR"},
+			expectedReason: errcodes.IdempotencyKeyReused,
+		},
+		{
+			name:           "when the key was used for another currency, it should return already exists",
+			existing:       []driver.Value{"tr-1", "tx-1", "tx-2", "wallet-1", "wallet-2", "10.10", "USD"},
+			expectedReason: errcodes.IdempotencyKeyReused,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+			mock.ExpectBegin()
+			mock.ExpectQuery("WHERE debit.idempotency_key = \\$1").
+				WithArgs("k1").
+				WillReturnRows(sqlmock.NewRows(columns).AddRow(tc.existing...))
+			if tc.expectedID != "" {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+			s := NewTransactionService(repositories.NewPostgresTransactionRepository(db), nil)
+
+			resp, err := s.Transfer(context.Background(), request)
+
+			if tc.expectedReason != "" {
+				assert.Equal(t, codes.AlreadyExists, status.Code(err))
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedID, resp.GetTransferId())
+			}
+			// Nothing is inserted or published again.
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
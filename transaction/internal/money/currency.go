@@ -0,0 +1,85 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+// DefaultCurrency is the currency of wallets and amounts that predate
+// currencies, and of requests that don't name one.
+const DefaultCurrency Currency = "EUR"
+
+var (
+	ErrCurrency          = errors.New("unsupported currency")
+	ErrCurrencyPrecision = errors.New("amount is finer than the currency's minor unit")
+)
+
+// exponents are the supported currencies and their number of minor unit
+// digits. Amounts have Scale decimal places, so currencies with more, such as
+// BHD or KWD, can't be supported.
+var exponents = map[Currency]int{
+	"EUR": 2,
+	"GBP": 2,
+	"USD": 2,
+	"CHF": 2,
+	"SEK": 2,
+	"NOK": 2,
+	"DKK": 2,
+	"PLN": 2,
+	"CZK": 2,
+	"JPY": 0,
+}
+
+// ParseCurrency parses a currency code in any case, empty is DefaultCurrency.
+func ParseCurrency(s string) (Currency, error) {
+	if s == "" {
+		return DefaultCurrency, nil
+	}
+	c := Currency(strings.ToUpper(s))
+	if _, ok := exponents[c]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrCurrency, s)
+	}
+	return c, nil
+}
+
+// Exponent returns the number of minor unit digits of the currency.
+func (c Currency) Exponent() int {
+	if exp, ok := exponents[c]; ok {
+		return exp
+	}
+	return Scale
+}
+
+// unit is the amount of one minor unit of the currency, 100 for JPY.
+func (c Currency) unit() Amount {
+	unit := Amount(1)
+	for i := c.Exponent(); i < Scale; i++ {
+		unit *= 10
+	}
+	return unit
+}
+
+// MinorUnits returns the amount as a whole number of c's minor units, 500 for
+// 500 JPY and 1050 for 10.50 EUR. It's the unit of Money messages and of the
+// minor_units of events. The amount must pass c.Check.
+func (a Amount) MinorUnits(c Currency) int64 {
+	return int64(a / c.unit())
+}
+
+// FromMinorUnits returns the amount of units minor units of c.
+func FromMinorUnits(units int64, c Currency) Amount {
+	return Amount(units) * c.unit()
+}
+
+// Check refuses amounts that aren't a whole number of the currency's minor
+// units, such as 10.50 JPY.
+func (c Currency) Check(a Amount) error {
+	if a%c.unit() != 0 {
+		return fmt.Errorf("%w: %s has %d decimal places", ErrCurrencyPrecision, c, c.Exponent())
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		input       string
+		expected    Amount
+		expectedErr error
+	}{
+		{name: "when the amount is whole, it should add the minor units", input: "10", expected: 1000},
+		{name: "when the amount has one decimal, it should pad it", input: "10.5", expected: 1050},
+		{name: "when the amount is negative, it should keep the sign", input: "-0.05", expected: -5},
+		{name: "when a DECIMAL has a larger scale, it should drop trailing zeros", input: "12.3400", expected: 1234},
+		{name: "when the amount has sub-cent precision, it should return an error", input: "0.001", expectedErr: ErrPrecision},
+		{name: "when the amount isn't a number, it should return an error", input: "1e3", expectedErr: ErrInvalid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, err := Parse(tc.input)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedErr == nil {
+				assert.Equal(t, tc.expected, amount)
+			}
+		})
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		input       float64
+		expected    Amount
+		expectedErr error
+	}{
+		{name: "when the float has binary noise, it should round it away", input: 0.1 + 0.2, expected: 30},
+		{name: "when the float is a whole number of cents, it should be exact", input: 19.99, expected: 1999},
+		{name: "when the float has sub-cent precision, it should return an error", input: 10.005, expectedErr: ErrPrecision},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, err := FromFloat(tc.input)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedErr == nil {
+				assert.Equal(t, tc.expected, amount)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected Amount
+	}{
+		{name: "when the amount is a string, it should parse it", input: `"10.10"`, expected: 1010},
+		{name: "when an older client sends a number, it should parse it exactly", input: `10.10`, expected: 1010},
+		{name: "when the number has an exponent, it should fall back to a float", input: `1e2`, expected: 10000},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var amount Amount
+			assert.NoError(t, json.Unmarshal([]byte(tc.input), &amount))
+			assert.Equal(t, tc.expected, amount)
+
+			encoded, err := json.Marshal(amount)
+			assert.NoError(t, err)
+			assert.Equal(t, `"`+amount.String()+`"`, string(encoded))
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package money
+
+import "transaction/proto/gen"
+
+// Proto returns the amount as a Money message in the default currency.
+func (a Amount) Proto() *gen.Money {
+	return &gen.Money{MinorUnits: int64(a)}
+}
+
+// ProtoIn returns the amount as a Money message in minor units of c. The amount
+// must pass c.Check.
+func (a Amount) ProtoIn(c Currency) *gen.Money {
+	return &gen.Money{MinorUnits: a.MinorUnits(c), Currency: string(c)}
+}
+
+// FromProto reads an amount sent as Money, falling back to the legacy double
+// field for senders that predate it. Money in an unsupported currency is
+// refused.
+func FromProto(m *gen.Money, legacy float64) (Amount, error) {
+	if m != nil {
+		c, err := ParseCurrency(m.GetCurrency())
+		if err != nil {
+			return 0, err
+		}
+		return FromMinorUnits(m.GetMinorUnits(), c), nil
+	}
+	return FromFloat(legacy)
+}
+
+// CurrencyOf returns the currency of m, DefaultCurrency when it names none.
+func CurrencyOf(m *gen.Money) (Currency, error) {
+	return ParseCurrency(m.GetCurrency())
+}
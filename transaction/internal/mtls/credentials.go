@@ -0,0 +1,106 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"transaction/internal/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ServerOptions returns the gRPC server options for cfg. When TLS is disabled the
+// server stays plaintext. With a CA bundle configured, client certificates are
+// required and, if AllowedSANs is set, checked against it on every call.
+func ServerOptions(ctx context.Context, cfg config.TLS) ([]grpc.ServerOption, error) {
+	if !cfg.Enabled {
+		log.Println("TLS is disabled, serving plaintext gRPC")
+		return nil, nil
+	}
+
+	reloader, err := NewReloader(cfg.CertFile, cfg.KeyFile, cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificates: %w", err)
+	}
+	// Every handshake presents the current certificate, fail now rather than on
+	// the first one if there is none.
+	if reloader.Certificate() == nil {
+		return nil, errors.New("TLS is enabled but no server certificate and key are configured")
+	}
+	go reloader.Watch(ctx, cfg.ReloadInterval)
+
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			serverCfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*reloader.Certificate()},
+			}
+			if pool := reloader.CAPool(); pool != nil {
+				serverCfg.ClientCAs = pool
+				serverCfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return serverCfg, nil
+		},
+	}
+
+	return []grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(tlsCfg)),
+		grpc.ChainUnaryInterceptor(AuthorizeSANs(cfg.AllowedSANs)),
+		grpc.ChainStreamInterceptor(AuthorizeSANsStream(cfg.AllowedSANs)),
+	}, nil
+}
+
+// DialOption returns the transport credentials used to call the wallet service.
+// When TLS is disabled the connection stays plaintext.
+func DialOption(ctx context.Context, cfg config.TLS) (grpc.DialOption, error) {
+	if !cfg.Enabled {
+		log.Println("TLS is disabled, dialing plaintext gRPC")
+		return grpc.WithTransportCredentials(insecure.NewCredentials()), nil
+	}
+
+	reloader, err := NewReloader(cfg.CertFile, cfg.KeyFile, cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificates: %w", err)
+	}
+	go reloader.Watch(ctx, cfg.ReloadInterval)
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(clientTLSConfig(reloader))), nil
+}
+
+func clientTLSConfig(reloader *Reloader) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := reloader.Certificate(); cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+		// Verification is done in VerifyConnection against the current CA pool,
+		// since RootCAs cannot be swapped on an existing config.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+
+			opts := x509.VerifyOptions{
+				DNSName:       cs.ServerName,
+				Roots:         reloader.CAPool(),
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}
+}
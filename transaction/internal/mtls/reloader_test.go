@@ -0,0 +1,149 @@
+package mtls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"transaction/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeKeyPair writes a self-signed certificate for name and its key, dated
+// modTime on disk.
+func writeKeyPair(t *testing.T, certFile, keyFile, name string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	assert.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	assert.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func certificateName(r *Reloader) string {
+	cert := r.Certificate()
+	if cert == nil {
+		return ""
+	}
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return ""
+	}
+	return parsed.Subject.CommonName
+}
+
+func TestReloaderWatch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		rotate   func(t *testing.T, certFile, keyFile string, modTime time.Time)
+		expected string
+	}{
+		{
+			name: "when the key pair is rotated, it should serve the new certificate",
+			rotate: func(t *testing.T, certFile, keyFile string, modTime time.Time) {
+				writeKeyPair(t, certFile, keyFile, "rotated", modTime)
+			},
+			expected: "rotated",
+		},
+		{
+			name: "when the rotated files don't load, it should keep the previous certificate",
+			rotate: func(t *testing.T, certFile, keyFile string, modTime time.Time) {
+				assert.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0o600))
+				assert.NoError(t, os.Chtimes(certFile, modTime, modTime))
+			},
+			expected: "original",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+			writeKeyPair(t, certFile, keyFile, "original", time.Now().Add(-time.Minute))
+			reloader, err := NewReloader(certFile, keyFile, "")
+			assert.NoError(t, err)
+			assert.Equal(t, "original", certificateName(reloader))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go reloader.Watch(ctx, 5*time.Millisecond)
+			tc.rotate(t, certFile, keyFile, time.Now())
+
+			if tc.expected == "original" {
+				time.Sleep(50 * time.Millisecond)
+				assert.Equal(t, tc.expected, certificateName(reloader))
+				return
+			}
+			assert.Eventually(t, func() bool { return certificateName(reloader) == tc.expected }, time.Second, 5*time.Millisecond)
+		})
+	}
+}
+
+func TestServerOptions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeKeyPair(t, certFile, keyFile, "transaction", time.Now())
+
+	testCases := []struct {
+		name        string
+		cfg         config.TLS
+		expectError bool
+	}{
+		{
+			name: "when TLS is disabled, it should serve plaintext",
+			cfg:  config.TLS{},
+		},
+		{
+			name: "when a key pair is configured, it should serve TLS",
+			cfg:  config.TLS{Enabled: true, CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Minute},
+		},
+		{
+			name:        "when TLS is enabled without a key pair, it should fail at startup",
+			cfg:         config.TLS{Enabled: true, ReloadInterval: time.Minute},
+			expectError: true,
+		},
+		{
+			name:        "when the key file is missing, it should fail at startup",
+			cfg:         config.TLS{Enabled: true, CertFile: certFile, KeyFile: filepath.Join(dir, "missing.key"), ReloadInterval: time.Minute},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			_, err := ServerOptions(ctx, tc.cfg)
+
+			assert.Equal(t, tc.expectError, err != nil)
+		})
+	}
+}
@@ -2,56 +2,125 @@ package producer
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
-	"log"
-	"time"
+	"fmt"
+	"transaction/internal/domain/entities"
+	"transaction/internal/money"
+)
 
-	"github.com/segmentio/kafka-go"
+const (
+	DEPOSIT_INITIATED  string = "deposit_initiated"
+	WITHDRAW_INITIATED string = "withdraw_initiated"
+	TRANSFER_INITIATED string = "transfer_initiated"
+	// REVERSAL_INITIATED carries both reversals and refunds, which the wallet
+	// service applies alike.
+	REVERSAL_INITIATED string = "reversal_initiated"
+	// BALANCE_DISCREPANCY alerts on a wallet whose balance doesn't match its
+	// transactions.
+	BALANCE_DISCREPANCY string = "balance_discrepancy"
 )
 
-type Producer struct {
-	writer *kafka.Writer
+// Producer writes the *_initiated events to the outbox in the transaction that
+// records them, and the Relay publishes them once it commits. An event is
+// therefore published exactly when its transaction commits, at least once.
+type Producer struct{}
+
+func NewProducer() *Producer {
+	return &Producer{}
 }
 
-func NewProducer(topic string) *Producer {
-	writer := &kafka.Writer{
-		Addr:                   kafka.TCP("localhost:9092"),
-		Topic:                  topic,
-		Balancer:               &kafka.LeastBytes{},
-		AllowAutoTopicCreation: true,
+func (p *Producer) PublishDepositInitiated(ctx context.Context, tx *sql.Tx, walletID string, amount money.Amount, currency money.Currency, TransactionID string) error {
+	return p.publishInitiated(ctx, tx, DEPOSIT_INITIATED, walletID, amount, currency, TransactionID)
+}
 
-		Compression:  kafka.Snappy,
-		BatchSize:    1000,                  //MAX
-		BatchBytes:   104857,                //1MB
-		BatchTimeout: 20 * time.Millisecond, // wait for more messages before sending
+func (p *Producer) PublishWithdrawInitiated(ctx context.Context, tx *sql.Tx, walletID string, amount money.Amount, currency money.Currency, TransactionID string) error {
+	return p.publishInitiated(ctx, tx, WITHDRAW_INITIATED, walletID, amount, currency, TransactionID)
+}
 
-		RequiredAcks: kafka.RequireAll,
+// publishInitiated publishes the amount both exactly, in minor units of the
+// currency as in the Money message, and as the legacy float that consumers
+// predating minor_units read.
+func (p *Producer) publishInitiated(ctx context.Context, tx *sql.Tx, topic string, walletID string, amount money.Amount, currency money.Currency, TransactionID string) error {
+
+	event := map[string]interface{}{
+		"wallet_id":      walletID,
+		"amount":         amount.Float64(),
+		"minor_units":    amount.MinorUnits(currency),
+		"currency":       currency,
+		"transaction_id": TransactionID,
 	}
 
-	return &Producer{writer: writer}
+	return enqueue(ctx, tx, topic, TransactionID, event)
 }
 
-func (p *Producer) PublishDepositInitiated(ctx context.Context, walletID string, amount float64, TransactionID string) error {
+// PublishTransferInitiated publishes both sides of a transfer in one event, so
+// that the wallet service applies them together.
+func (p *Producer) PublishTransferInitiated(ctx context.Context, tx *sql.Tx, transferID, sourceWalletID, destinationWalletID string, amount money.Amount, currency money.Currency, debitID, creditID string) error {
 
 	event := map[string]interface{}{
-		"wallet_id":      walletID,
-		"amount":         amount,
-		"transaction_id": TransactionID,
+		"transfer_id":           transferID,
+		"source_wallet_id":      sourceWalletID,
+		"destination_wallet_id": destinationWalletID,
+		"amount":                amount.Float64(),
+		"minor_units":           amount.MinorUnits(currency),
+		"currency":              currency,
+		"debit_transaction_id":  debitID,
+		"credit_transaction_id": creditID,
 	}
-	msg, err := json.Marshal(event)
-	if err != nil {
-		log.Printf("error marshalling message: %s", err)
-		return err
+
+	// Keyed by the source so a wallet's transfers are applied in order.
+	return enqueue(ctx, tx, TRANSFER_INITIATED, sourceWalletID, event)
+}
+
+// PublishReversalInitiated publishes a reversal or refund for the wallet service
+// to debit the wallet the original transaction credited.
+func (p *Producer) PublishReversalInitiated(ctx context.Context, tx *sql.Tx, compensation entities.Transaction) error {
+
+	event := map[string]interface{}{
+		"wallet_id":               compensation.WalletID,
+		"amount":                  compensation.Amount.Float64(),
+		"minor_units":             compensation.Amount.MinorUnits(compensation.Currency),
+		"currency":                compensation.Currency,
+		"transaction_id":          compensation.ID,
+		"original_transaction_id": compensation.OriginalTransactionID,
+		"type":                    compensation.Type.String(),
 	}
 
-	return p.writer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte(TransactionID),
-		Value: msg,
-	})
+	return enqueue(ctx, tx, REVERSAL_INITIATED, compensation.ID, event)
 }
 
-func (p *Producer) Close() {
-	if err := p.writer.Close(); err != nil {
-		log.Println("Failed to close Kafka writer:", err)
+// PublishBalanceDiscrepancy publishes an alert for a discrepancy found by a
+// balance reconciliation.
+func (p *Producer) PublishBalanceDiscrepancy(ctx context.Context, tx *sql.Tx, reconciliationID string, d entities.BalanceDiscrepancy) error {
+
+	event := map[string]interface{}{
+		"reconciliation_id":    reconciliationID,
+		"wallet_id":            d.WalletID,
+		"kind":                 d.Kind,
+		"currency":             d.Currency,
+		"wallet_balance":       d.WalletBalance,
+		"ledger_balance":       d.LedgerBalance,
+		"adjustments":          d.Adjustments,
+		"transaction_net":      d.TransactionNet,
+		"difference":           d.Difference,
+		"pending_transactions": d.PendingTransactions,
+	}
+
+	return enqueue(ctx, tx, BALANCE_DISCREPANCY, d.WalletID, event)
+}
+
+// enqueue writes an event to the outbox in tx, it is never published if tx
+// rolls back.
+func enqueue(ctx context.Context, tx *sql.Tx, topic, key string, event any) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event %s: %w", topic, key, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO outbox (topic, key, payload) VALUES ($1, $2, $3)`, topic, key, payload)
+	if err != nil {
+		return fmt.Errorf("failed to write %s event %s to the outbox: %w", topic, key, err)
 	}
+	return nil
 }
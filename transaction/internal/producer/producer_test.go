@@ -0,0 +1,77 @@
+package producer
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"transaction/internal/money"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// payload matches an outbox payload by its decoded fields.
+type payload map[string]any
+
+func (p payload) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	if !ok {
+		return false
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return false
+	}
+	for key, expected := range p {
+		if decoded[key] != expected {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPublishDepositInitiated(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		amount   money.Amount
+		currency money.Currency
+		expected payload
+	}{
+		{
+			name:     "when the currency has cents, it should publish the amount in cents",
+			amount:   money.FromMinor(1050),
+			currency: "EUR",
+			expected: payload{"minor_units": float64(1050), "amount": 10.5, "currency": "EUR"},
+		},
+		{
+			name:     "when the currency has no minor unit, it should publish the amount in whole yen",
+			amount:   money.FromMinorUnits(500, "JPY"),
+			currency: "JPY",
+			expected: payload{"minor_units": float64(500), "amount": float64(500), "currency": "JPY"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+			mock.ExpectBegin()
+			mock.ExpectExec(`INSERT INTO outbox`).
+				WithArgs(DEPOSIT_INITIATED, "tx-1", tc.expected).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			tx, err := db.Begin()
+			assert.NoError(t, err)
+
+			err = NewProducer().PublishDepositInitiated(context.Background(), tx, "wallet-1", tc.amount, tc.currency, "tx-1")
+
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
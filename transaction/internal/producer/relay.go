@@ -0,0 +1,201 @@
+package producer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// MAX_BACKOFF caps the wait between attempts while Kafka is failing.
+	MAX_BACKOFF    = time.Minute
+	PRUNE_INTERVAL = time.Hour
+)
+
+// Writer publishes messages, implemented by kafka.Writer.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Relay publishes the outbox to Kafka in the order events were written and
+// marks them sent.
+type Relay struct {
+	db        *sql.DB
+	writer    Writer
+	interval  time.Duration
+	batchSize int
+	// retention is how long sent events are kept.
+	retention time.Duration
+	prunedAt  time.Time
+}
+
+func NewRelay(db *sql.DB, writer Writer, interval time.Duration, batchSize int, retention time.Duration) *Relay {
+	return &Relay{
+		db:        db,
+		writer:    writer,
+		interval:  interval,
+		batchSize: batchSize,
+		retention: retention,
+	}
+}
+
+// NewKafkaWriter returns a writer for the *_initiated topics, each message
+// names its own topic.
+func NewKafkaWriter(kafkaHost string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(kafkaHost),
+		Balancer:               &kafka.LeastBytes{},
+		AllowAutoTopicCreation: true,
+
+		Compression:  kafka.Snappy,
+		BatchSize:    1000,                  //MAX
+		BatchBytes:   104857,                //1MB
+		BatchTimeout: 20 * time.Millisecond, // wait for more messages before sending
+
+		RequiredAcks: kafka.RequireAll,
+	}
+}
+
+// Run relays every interval until ctx is cancelled. While publishing fails it
+// waits twice as long before each attempt, up to MAX_BACKOFF, and retries the
+// same events so that they keep their order.
+func (r *Relay) Run(ctx context.Context) {
+	wait := r.interval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if err := r.Relay(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			wait = backoff(wait, r.interval)
+			log.Printf("Failed to relay outbox, retrying in %s: %v", wait, err)
+			continue
+		}
+		wait = r.interval
+		r.prune(ctx)
+	}
+}
+
+// backoff doubles the wait after a failed attempt, up to MAX_BACKOFF.
+func backoff(wait, interval time.Duration) time.Duration {
+	wait *= 2
+	if wait < interval {
+		wait = interval
+	}
+	if wait > MAX_BACKOFF {
+		wait = MAX_BACKOFF
+	}
+	return wait
+}
+
+// Relay publishes unsent events a batch at a time until none are left.
+func (r *Relay) Relay(ctx context.Context) error {
+	for {
+		sent, err := r.relayBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if sent < r.batchSize {
+			return nil
+		}
+	}
+}
+
+// relayBatch publishes the oldest unsent events and marks them sent. The rows
+// stay locked while they are published, so replicas never publish the same
+// batch concurrently.
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, topic, key, payload FROM outbox WHERE sent_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`,
+		r.batchSize,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	var (
+		ids      []int64
+		messages []kafka.Message
+	)
+	for rows.Next() {
+		var (
+			id         int64
+			topic, key string
+			payload    []byte
+		)
+		if err := rows.Scan(&id, &topic, &key, &payload); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		ids = append(ids, id)
+		messages = append(messages, kafka.Message{Topic: topic, Key: []byte(key), Value: payload})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	if err := r.writer.WriteMessages(ctx, messages...); err != nil {
+		r.recordFailure(ctx, tx, ids, err)
+		return 0, fmt.Errorf("failed to publish %d outbox events: %w", len(messages), err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE outbox SET sent_at = NOW(), attempts = attempts + 1 WHERE id = ANY($1)`, ids); err != nil {
+		return 0, fmt.Errorf("failed to mark outbox events sent: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		// Published but not marked, they are published again.
+		return 0, fmt.Errorf("failed to mark outbox events sent: %w", err)
+	}
+	return len(messages), nil
+}
+
+// recordFailure keeps the attempt count and last error of events Kafka
+// refused, for whoever looks into a stuck outbox. It goes through tx, which
+// holds their locks.
+func (r *Relay) recordFailure(ctx context.Context, tx *sql.Tx, ids []int64, cause error) {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = ANY($1)`,
+		ids, cause.Error(),
+	)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Failed to record outbox publishing failure: %v", err)
+	}
+}
+
+// prune deletes events sent longer than the retention ago, at most once per
+// PRUNE_INTERVAL.
+func (r *Relay) prune(ctx context.Context) {
+	if time.Since(r.prunedAt) < PRUNE_INTERVAL {
+		return
+	}
+	r.prunedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM outbox WHERE sent_at < NOW() - $1::bigint * interval '1 second'`,
+		int64(r.retention.Seconds()),
+	)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Failed to prune sent outbox events: %v", err)
+	}
+}
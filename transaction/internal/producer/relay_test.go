@@ -0,0 +1,40 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	interval := 500 * time.Millisecond
+
+	tests := []struct {
+		name string
+		wait time.Duration
+		want time.Duration
+	}{
+		{
+			name: "when the first attempt fails, it should wait twice the interval",
+			wait: interval,
+			want: time.Second,
+		},
+		{
+			name: "when attempts keep failing, it should keep doubling the wait",
+			wait: 8 * time.Second,
+			want: 16 * time.Second,
+		},
+		{
+			name: "when doubling passes the cap, it should wait MAX_BACKOFF",
+			wait: 45 * time.Second,
+			want: MAX_BACKOFF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, backoff(tt.wait, interval))
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"transaction/internal/domain/entities"
+	"transaction/internal/domain/repositories"
+	"transaction/internal/money"
+	"transaction/internal/producer"
+	"transaction/proto/gen"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// BalanceSource streams the wallet balances, implemented by the wallet service
+// client.
+type BalanceSource interface {
+	StreamBalances(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[gen.BalanceSummary], error)
+}
+
+// BalanceReconciler proves that every wallet balance is the net of the applied
+// transactions behind it, reversals and refunds included, plus the postings the wallet service makes
+// on its own: hold captures and conversions.
+type BalanceReconciler struct {
+	transactionRepo repositories.TransactionRepository
+	wallet          BalanceSource
+	producer        *producer.Producer
+}
+
+func NewBalanceReconciler(transactionRepo repositories.TransactionRepository, wallet BalanceSource, producer *producer.Producer) *BalanceReconciler {
+	return &BalanceReconciler{
+		transactionRepo: transactionRepo,
+		wallet:          wallet,
+		producer:        producer,
+	}
+}
+
+// walletBalance is a wallet's balance summary as the wallet service sent it.
+type walletBalance struct {
+	WalletID      string
+	Currency      money.Currency
+	Balance       money.Amount
+	LedgerBalance money.Amount
+	Adjustments   money.Amount
+}
+
+// Reconcile streams the wallet balances and the transaction totals of every
+// wallet, both ordered by wallet ID, and compares them wallet by wallet without
+// holding either side in memory. The reconciliation is stored with its
+// discrepancies, and an alert is published through the outbox for each one
+// that needs it, in a single database transaction.
+func (r *BalanceReconciler) Reconcile(ctx context.Context) (*entities.BalanceReconciliation, error) {
+	reconciliation := &entities.BalanceReconciliation{StartedAt: time.Now()}
+	check := func(balance *walletBalance, totals *repositories.WalletTotals) {
+		reconciliation.WalletsChecked++
+		reconciliation.Discrepancies = append(reconciliation.Discrepancies, compare(balance, totals)...)
+	}
+
+	// Cancelled on return, which ends the stream if reconciling fails midway.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := r.wallet.StreamBalances(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream wallet balances: %w", err)
+	}
+	balances := &balanceCursor{stream: stream}
+	if err := balances.next(); err != nil {
+		return nil, err
+	}
+
+	var lastWalletID string
+	err = r.transactionRepo.EachWalletTotals(ctx, func(totals repositories.WalletTotals) error {
+		if lastWalletID != "" && totals.WalletID <= lastWalletID {
+			return fmt.Errorf("wallet totals out of order, %s after %s", totals.WalletID, lastWalletID)
+		}
+		lastWalletID = totals.WalletID
+
+		for balances.current != nil && balances.current.WalletID < totals.WalletID {
+			check(balances.current, nil)
+			if err := balances.next(); err != nil {
+				return err
+			}
+		}
+		if balances.current != nil && balances.current.WalletID == totals.WalletID {
+			check(balances.current, &totals)
+			return balances.next()
+		}
+		check(nil, &totals)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for balances.current != nil {
+		check(balances.current, nil)
+		if err := balances.next(); err != nil {
+			return nil, err
+		}
+	}
+	reconciliation.FinishedAt = time.Now()
+
+	if err := r.store(ctx, reconciliation); err != nil {
+		return nil, err
+	}
+	return reconciliation, nil
+}
+
+// store saves the reconciliation for audit and publishes its alerts.
+func (r *BalanceReconciler) store(ctx context.Context, reconciliation *entities.BalanceReconciliation) error {
+	tx, err := r.transactionRepo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if reconciliation.ID, err = r.transactionRepo.InsertBalanceReconciliation(tx, reconciliation); err != nil {
+		return err
+	}
+	for _, d := range reconciliation.Discrepancies {
+		if !d.Alert() {
+			continue
+		}
+		if err := r.producer.PublishBalanceDiscrepancy(ctx, tx, reconciliation.ID, d); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to store balance reconciliation: %w", err)
+	}
+	return nil
+}
+
+// compare returns the discrepancies of a wallet, given its balance or nil when
+// the wallet service doesn't have it, and its transaction totals or nil when it
+// has no transactions.
+func compare(balance *walletBalance, totals *repositories.WalletTotals) []entities.BalanceDiscrepancy {
+	if balance == nil {
+		if totals.Completed == 0 && totals.Pending == 0 {
+			// Only FAILED transactions, e.g. deposits into a wallet that doesn't exist.
+			return nil
+		}
+		return []entities.BalanceDiscrepancy{{
+			WalletID:            totals.WalletID,
+			Kind:                entities.DiscrepancyUnknownWallet,
+			Currency:            totals.Currency,
+			TransactionNet:      totals.Completed,
+			Difference:          -totals.Completed,
+			PendingTransactions: totals.Pending,
+		}}
+	}
+	if totals == nil {
+		totals = &repositories.WalletTotals{WalletID: balance.WalletID}
+	}
+
+	discrepancy := func(kind entities.DiscrepancyKind, difference money.Amount) entities.BalanceDiscrepancy {
+		return entities.BalanceDiscrepancy{
+			WalletID:            balance.WalletID,
+			Kind:                kind,
+			Currency:            balance.Currency,
+			WalletBalance:       balance.Balance,
+			LedgerBalance:       balance.LedgerBalance,
+			Adjustments:         balance.Adjustments,
+			TransactionNet:      totals.Completed,
+			Difference:          difference,
+			PendingTransactions: totals.Pending,
+		}
+	}
+
+	var found []entities.BalanceDiscrepancy
+	if balance.Balance != balance.LedgerBalance {
+		found = append(found, discrepancy(entities.DiscrepancyLedger, balance.Balance-balance.LedgerBalance))
+	}
+	if totals.Currencies > 1 || (totals.Currencies == 1 && totals.Currency != balance.Currency) {
+		// Amounts in different currencies can't be compared.
+		return append(found, discrepancy(entities.DiscrepancyCurrency, 0))
+	}
+	if expected := totals.Completed + balance.Adjustments; balance.Balance != expected {
+		kind := entities.DiscrepancyBalance
+		if totals.Pending > 0 {
+			kind = entities.DiscrepancyUnsettled
+		}
+		found = append(found, discrepancy(kind, balance.Balance-expected))
+	}
+	return found
+}
+
+// balanceCursor reads the wallet balances one at a time, current is nil once
+// they are all read.
+type balanceCursor struct {
+	stream  grpc.ServerStreamingClient[gen.BalanceSummary]
+	current *walletBalance
+}
+
+func (c *balanceCursor) next() error {
+	summary, err := c.stream.Recv()
+	if errors.Is(err, io.EOF) {
+		c.current = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to receive wallet balance: %w", err)
+	}
+
+	balance, err := toWalletBalance(summary)
+	if err != nil {
+		return fmt.Errorf("invalid balance of wallet %s: %w", summary.WalletId, err)
+	}
+	if c.current != nil && balance.WalletID <= c.current.WalletID {
+		return fmt.Errorf("wallet balances out of order, %s after %s", balance.WalletID, c.current.WalletID)
+	}
+	c.current = balance
+	return nil
+}
+
+func toWalletBalance(summary *gen.BalanceSummary) (*walletBalance, error) {
+	balance := &walletBalance{WalletID: summary.WalletId}
+	var err error
+	if balance.Currency, err = money.CurrencyOf(summary.Balance); err != nil {
+		return nil, err
+	}
+	if balance.Balance, err = money.FromProto(summary.Balance, 0); err != nil {
+		return nil, err
+	}
+	if balance.LedgerBalance, err = money.FromProto(summary.LedgerBalance, 0); err != nil {
+		return nil, err
+	}
+	if balance.Adjustments, err = money.FromProto(summary.Adjustments, 0); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
@@ -0,0 +1,88 @@
+package reconciler
+
+import (
+	"testing"
+	"transaction/internal/domain/entities"
+	"transaction/internal/domain/repositories"
+	"transaction/internal/money"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                string
+		balance             *walletBalance
+		totals              *repositories.WalletTotals
+		expectedKinds       []entities.DiscrepancyKind
+		expectedDifferences []money.Amount
+	}{
+		{
+			name:    "when the balance is the transactions plus the adjustments, it should find nothing",
+			balance: &walletBalance{WalletID: "w1", Currency: "EUR", Balance: 2500, LedgerBalance: 2500, Adjustments: -500},
+			totals:  &repositories.WalletTotals{WalletID: "w1", Currency: "EUR", Currencies: 1, Completed: 3000},
+		},
+		{
+			name:    "when the wallet has no transactions and no balance, it should find nothing",
+			balance: &walletBalance{WalletID: "w1", Currency: "EUR"},
+		},
+		{
+			name:                "when the balance exceeds the transactions, it should find a balance mismatch",
+			balance:             &walletBalance{WalletID: "w1", Currency: "EUR", Balance: 3100, LedgerBalance: 3100},
+			totals:              &repositories.WalletTotals{WalletID: "w1", Currency: "EUR", Currencies: 1, Completed: 3000},
+			expectedKinds:       []entities.DiscrepancyKind{entities.DiscrepancyBalance},
+			expectedDifferences: []money.Amount{100},
+		},
+		{
+			name:                "when transactions are pending, it should find the mismatch unsettled",
+			balance:             &walletBalance{WalletID: "w1", Currency: "EUR", Balance: 3100, LedgerBalance: 3100},
+			totals:              &repositories.WalletTotals{WalletID: "w1", Currency: "EUR", Currencies: 1, Completed: 3000, Pending: 1},
+			expectedKinds:       []entities.DiscrepancyKind{entities.DiscrepancyUnsettled},
+			expectedDifferences: []money.Amount{100},
+		},
+		{
+			name:                "when the balance isn't the ledger's, it should find a ledger mismatch",
+			balance:             &walletBalance{WalletID: "w1", Currency: "EUR", Balance: 3000, LedgerBalance: 2900},
+			totals:              &repositories.WalletTotals{WalletID: "w1", Currency: "EUR", Currencies: 1, Completed: 3000},
+			expectedKinds:       []entities.DiscrepancyKind{entities.DiscrepancyLedger},
+			expectedDifferences: []money.Amount{100},
+		},
+		{
+			name:                "when the transactions are in another currency, it should find a currency mismatch",
+			balance:             &walletBalance{WalletID: "w1", Currency: "EUR", Balance: 3000, LedgerBalance: 3000},
+			totals:              &repositories.WalletTotals{WalletID: "w1", Currency: "USD", Currencies: 1, Completed: 3000},
+			expectedKinds:       []entities.DiscrepancyKind{entities.DiscrepancyCurrency},
+			expectedDifferences: []money.Amount{0},
+		},
+		{
+			name:                "when the wallet service doesn't have the wallet, it should find an unknown wallet",
+			totals:              &repositories.WalletTotals{WalletID: "w1", Currency: "EUR", Currencies: 1, Completed: 3000},
+			expectedKinds:       []entities.DiscrepancyKind{entities.DiscrepancyUnknownWallet},
+			expectedDifferences: []money.Amount{-3000},
+		},
+		{
+			name:   "when an unknown wallet only has failed transactions, it should find nothing",
+			totals: &repositories.WalletTotals{WalletID: "w1", Currency: "EUR", Currencies: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				kinds       []entities.DiscrepancyKind
+				differences []money.Amount
+			)
+			for _, d := range compare(tc.balance, tc.totals) {
+				kinds = append(kinds, d.Kind)
+				differences = append(differences, d.Difference)
+			}
+
+			assert.Equal(t, tc.expectedKinds, kinds)
+			assert.Equal(t, tc.expectedDifferences, differences)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Package reconciler settles deposits left PENDING because their outcome event
+// never arrived, e.g. after a lost Kafka message or a crash between the wallet
+// service's commit and its publish. It asks the wallet service what became of
+// each one.
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"transaction/internal/domain/entities"
+	"transaction/internal/domain/repositories"
+	"transaction/internal/producer"
+	"transaction/proto/gen"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// ACTION_REPUBLISHED is a deposit the wallet service never received, its
+	// deposit_initiated event is written to the outbox again.
+	ACTION_REPUBLISHED = "REPUBLISHED"
+	ACTION_COMPLETED   = "COMPLETED"
+	ACTION_FAILED      = "FAILED"
+	// ACTION_ERROR is a deposit left PENDING because it couldn't be reconciled.
+	ACTION_ERROR = "ERROR"
+)
+
+// ACTOR is who the status history says settled the deposits.
+const ACTOR = "reconciler"
+
+// Wallet is the part of the wallet service client the reconciler calls.
+type Wallet interface {
+	GetProcessedTransaction(ctx context.Context, in *gen.ProcessedTransactionRequest, opts ...grpc.CallOption) (*gen.ProcessedTransactionResponse, error)
+}
+
+// Action is what the reconciler did with a deposit, or would have done in a
+// dry run.
+type Action struct {
+	TransactionID string
+	WalletID      string
+	CreatedAt     time.Time
+	Action        string
+	// Detail is the failure reason of a FAILED deposit, or the error of an
+	// ERROR.
+	Detail string
+}
+
+type Reconciler struct {
+	transactionRepo repositories.TransactionRepository
+	wallet          Wallet
+	producer        *producer.Producer
+	dryRun          bool
+}
+
+func NewReconciler(transactionRepo repositories.TransactionRepository, wallet Wallet, producer *producer.Producer, dryRun bool) *Reconciler {
+	return &Reconciler{
+		transactionRepo: transactionRepo,
+		wallet:          wallet,
+		producer:        producer,
+		dryRun:          dryRun,
+	}
+}
+
+// Reconcile settles up to limit deposits PENDING for longer than olderThan,
+// oldest first, and returns what it did with each. A deposit that can't be
+// reconciled is reported and left PENDING for the next run.
+func (r *Reconciler) Reconcile(ctx context.Context, olderThan time.Duration, limit int) ([]Action, error) {
+	pending, err := r.transactionRepo.ListPending(ctx, entities.TypeDeposit, time.Now().Add(-olderThan), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make([]Action, 0, len(pending))
+	for _, t := range pending {
+		actions = append(actions, r.reconcile(ctx, t))
+	}
+	return actions, nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, t *entities.Transaction) Action {
+	action := Action{TransactionID: t.ID, WalletID: t.WalletID, CreatedAt: t.CreatedAt}
+
+	processed, err := r.wallet.GetProcessedTransaction(ctx, &gen.ProcessedTransactionRequest{TransactionId: t.ID})
+	if err != nil {
+		return action.failed(fmt.Errorf("failed to get it from the wallet service: %w", err))
+	}
+	action.Action, action.Detail, err = decide(processed)
+	if err != nil {
+		return action.failed(err)
+	}
+	if r.dryRun {
+		return action
+	}
+
+	// The updates only move transactions still PENDING, so an outcome event
+	// consumed in the meantime wins.
+	switch action.Action {
+	case ACTION_REPUBLISHED:
+		err = r.republish(ctx, t)
+	case ACTION_COMPLETED:
+		_, err = r.transactionRepo.UpdateStatusBatch(ctx, []string{t.ID}, repositories.StatusChange{
+			Status: entities.TRANSACTION_STATUS_COMPLETED,
+			Actor:  ACTOR,
+			Reason: "deposit_completed never arrived",
+		})
+	case ACTION_FAILED:
+		_, err = r.transactionRepo.UpdateStatusBatch(ctx, []string{t.ID}, repositories.StatusChange{
+			Status:        entities.TRANSACTION_STATUS_FAILED,
+			Actor:         ACTOR,
+			Reason:        "deposit_failed never arrived",
+			FailureReason: action.Detail,
+		})
+	}
+	if err != nil {
+		return action.failed(err)
+	}
+	return action
+}
+
+func (a Action) failed(err error) Action {
+	a.Action, a.Detail = ACTION_ERROR, err.Error()
+	return a
+}
+
+// decide returns the action a deposit needs, and the failure reason of a
+// FAILED one, from what the wallet service knows of it.
+func decide(processed *gen.ProcessedTransactionResponse) (string, string, error) {
+	switch {
+	case !processed.Processed:
+		return ACTION_REPUBLISHED, "", nil
+	case processed.Kind != entities.TypeDeposit.String():
+		return "", "", fmt.Errorf("the wallet service processed it as a %s", processed.Kind)
+	case processed.Status == string(entities.TRANSACTION_STATUS_COMPLETED):
+		return ACTION_COMPLETED, "", nil
+	case processed.Status == string(entities.TRANSACTION_STATUS_FAILED):
+		return ACTION_FAILED, processed.FailureReason, nil
+	}
+	return "", "", fmt.Errorf("the wallet service returned an unknown status %q", processed.Status)
+}
+
+// republish writes the deposit_initiated event to the outbox again, for the
+// relay of a running service to publish. The wallet service skips it if the
+// first one arrives after all.
+func (r *Reconciler) republish(ctx context.Context, t *entities.Transaction) error {
+	tx, err := r.transactionRepo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.producer.PublishDepositInitiated(ctx, tx, t.WalletID, t.Amount, t.Currency, t.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
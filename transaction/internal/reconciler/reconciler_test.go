@@ -0,0 +1,63 @@
+package reconciler
+
+import (
+	"testing"
+	"transaction/proto/gen"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecide(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		processed      *gen.ProcessedTransactionResponse
+		expectedAction string
+		expectedReason string
+		expectedError  bool
+	}{
+		{
+			name:           "when the wallet service never received the deposit, it should republish it",
+			processed:      &gen.ProcessedTransactionResponse{},
+			expectedAction: ACTION_REPUBLISHED,
+		},
+		{
+			name:           "when the wallet service credited the deposit, it should complete it",
+			processed:      &gen.ProcessedTransactionResponse{Processed: true, Kind: "DEPOSIT", Status: "COMPLETED"},
+			expectedAction: ACTION_COMPLETED,
+		},
+		{
+			name:           "when the wallet service refused the deposit, it should fail it with the reason",
+			processed:      &gen.ProcessedTransactionResponse{Processed: true, Kind: "DEPOSIT", Status: "FAILED", FailureReason: "wallet_closed"},
+			expectedAction: ACTION_FAILED,
+			expectedReason: "wallet_closed",
+		},
+		{
+			name:          "when the wallet service processed it as another kind, it should return an error",
+			processed:     &gen.ProcessedTransactionResponse{Processed: true, Kind: "WITHDRAW", Status: "COMPLETED"},
+			expectedError: true,
+		},
+		{
+			name:          "when the status is unknown, it should return an error",
+			processed:     &gen.ProcessedTransactionResponse{Processed: true, Kind: "DEPOSIT", Status: "REVERSED"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			action, reason, err := decide(tc.processed)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedAction, action)
+			assert.Equal(t, tc.expectedReason, reason)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package reconciler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+	"transaction/internal/domain/entities"
+	"transaction/internal/money"
+)
+
+// balanceReport is the JSON report of a balance reconciliation. Amounts are
+// decimal strings.
+type balanceReport struct {
+	ID             string              `json:"id"`
+	StartedAt      time.Time           `json:"started_at"`
+	FinishedAt     time.Time           `json:"finished_at"`
+	WalletsChecked int                 `json:"wallets_checked"`
+	Discrepancies  []discrepancyReport `json:"discrepancies"`
+}
+
+type discrepancyReport struct {
+	WalletID            string         `json:"wallet_id"`
+	Kind                string         `json:"kind"`
+	Currency            money.Currency `json:"currency"`
+	WalletBalance       money.Amount   `json:"wallet_balance"`
+	LedgerBalance       money.Amount   `json:"ledger_balance"`
+	Adjustments         money.Amount   `json:"adjustments"`
+	TransactionNet      money.Amount   `json:"transaction_net"`
+	Difference          money.Amount   `json:"difference"`
+	PendingTransactions int            `json:"pending_transactions"`
+	Alert               bool           `json:"alert"`
+}
+
+// WriteJSON writes the report of a reconciliation as JSON.
+func WriteJSON(w io.Writer, reconciliation *entities.BalanceReconciliation) error {
+	report := balanceReport{
+		ID:             reconciliation.ID,
+		StartedAt:      reconciliation.StartedAt.UTC(),
+		FinishedAt:     reconciliation.FinishedAt.UTC(),
+		WalletsChecked: reconciliation.WalletsChecked,
+		Discrepancies:  make([]discrepancyReport, 0, len(reconciliation.Discrepancies)),
+	}
+	for _, d := range reconciliation.Discrepancies {
+		report.Discrepancies = append(report.Discrepancies, discrepancyReport{
+			WalletID:            d.WalletID,
+			Kind:                string(d.Kind),
+			Currency:            d.Currency,
+			WalletBalance:       d.WalletBalance,
+			LedgerBalance:       d.LedgerBalance,
+			Adjustments:         d.Adjustments,
+			TransactionNet:      d.TransactionNet,
+			Difference:          d.Difference,
+			PendingTransactions: d.PendingTransactions,
+			Alert:               d.Alert(),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+var csvHeader = []string{
+	"reconciliation_id", "wallet_id", "kind", "currency", "wallet_balance", "ledger_balance",
+	"adjustments", "transaction_net", "difference", "pending_transactions", "alert",
+}
+
+// WriteCSV writes the discrepancies of a reconciliation as CSV, a row each.
+func WriteCSV(w io.Writer, reconciliation *entities.BalanceReconciliation) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, d := range reconciliation.Discrepancies {
+		err := writer.Write([]string{
+			reconciliation.ID,
+			d.WalletID,
+			string(d.Kind),
+			string(d.Currency),
+			d.WalletBalance.String(),
+			d.LedgerBalance.String(),
+			d.Adjustments.String(),
+			d.TransactionNet.String(),
+			d.Difference.String(),
+			strconv.Itoa(d.PendingTransactions),
+			strconv.FormatBool(d.Alert()),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS transactions_wallet_id_created_at_idx ON transactions (wallet_id, created_at DESC, id DESC);
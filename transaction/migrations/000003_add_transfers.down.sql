@@ -0,0 +1,4 @@
+-- Enum values can't be dropped, TRANSFER_OUT and TRANSFER_IN stay in transaction_type.
+DROP INDEX IF EXISTS transactions_transfer_id_idx;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS transfer_id;
@@ -0,0 +1,7 @@
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'TRANSFER_OUT';
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'TRANSFER_IN';
+
+-- Both sides of a transfer share its transfer_id.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS transfer_id uuid;
+
+CREATE INDEX IF NOT EXISTS transactions_transfer_id_idx ON transactions (transfer_id) WHERE transfer_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Transactions before currencies were all in EUR.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'EUR';
@@ -0,0 +1,15 @@
+-- Initiated events written in the same transaction as the PENDING rows they
+-- describe, and published to Kafka by the outbox relay once it commits.
+CREATE TABLE IF NOT EXISTS outbox(
+id BIGSERIAL PRIMARY KEY,
+topic VARCHAR(255) NOT NULL,
+key TEXT NOT NULL,
+payload JSONB NOT NULL,
+attempts INT NOT NULL DEFAULT 0,
+last_error TEXT,
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+sent_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unsent_idx ON outbox (id) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_sent_at_idx ON outbox (sent_at) WHERE sent_at IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Why a FAILED transaction was refused, as reported by the wallet service.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS failure_reason VARCHAR(64);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS balance_discrepancies;
+DROP TABLE IF EXISTS balance_reconciliations;
@@ -0,0 +1,28 @@
+-- Comparisons of the wallet balances with the transactions behind them, kept
+-- for audit along with the discrepancies each one found.
+CREATE TABLE IF NOT EXISTS balance_reconciliations(
+id uuid DEFAULT gen_random_uuid(),
+started_at TIMESTAMP NOT NULL,
+finished_at TIMESTAMP NOT NULL,
+wallets_checked INT NOT NULL,
+discrepancies INT NOT NULL,
+
+PRIMARY KEY(id)
+);
+
+CREATE TABLE IF NOT EXISTS balance_discrepancies(
+id BIGSERIAL PRIMARY KEY,
+reconciliation_id uuid NOT NULL REFERENCES balance_reconciliations(id),
+wallet_id VARCHAR(255) NOT NULL,
+kind VARCHAR(32) NOT NULL,
+currency CHAR(3) NOT NULL,
+wallet_balance DECIMAL(15,2) NOT NULL,
+ledger_balance DECIMAL(15,2) NOT NULL,
+adjustments DECIMAL(15,2) NOT NULL,
+transaction_net DECIMAL(15,2) NOT NULL,
+difference DECIMAL(15,2) NOT NULL,
+pending_transactions INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS balance_discrepancies_reconciliation_id_idx ON balance_discrepancies (reconciliation_id);
+CREATE INDEX IF NOT EXISTS balance_discrepancies_wallet_id_idx ON balance_discrepancies (wallet_id);
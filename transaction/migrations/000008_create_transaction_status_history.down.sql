@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+DROP TABLE IF EXISTS transaction_status_history;
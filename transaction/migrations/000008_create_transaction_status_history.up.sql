@@ -0,0 +1,17 @@
+-- Every status transition of a transaction, with who made it and why.
+CREATE TABLE IF NOT EXISTS transaction_status_history(
+id BIGSERIAL PRIMARY KEY,
+transaction_id uuid NOT NULL REFERENCES transactions(id),
+from_status VARCHAR(20) NOT NULL,
+to_status VARCHAR(20) NOT NULL,
+actor VARCHAR(64) NOT NULL,
+reason VARCHAR(255),
+created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS transaction_status_history_transaction_id_idx ON transaction_status_history (transaction_id, id);
+
+-- The lifecycle itself is defined in the transaction service, which makes
+-- every transition. This only keeps unknown statuses out.
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+CHECK (status IN ('PENDING', 'COMPLETED', 'PROCESSING', 'CAPTURED', 'SETTLED', 'FAILED', 'REVERSED', 'EXPIRED'));
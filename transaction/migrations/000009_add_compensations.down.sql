@@ -0,0 +1,13 @@
+-- Enum values can't be dropped, REVERSAL and REFUND stay in transaction_type.
+-- Partially refunded transactions have to be moved out of PARTIALLY_REFUNDED
+-- before the previous status check can be restored.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+CHECK (status IN ('PENDING', 'COMPLETED', 'PROCESSING', 'CAPTURED', 'SETTLED', 'FAILED', 'REVERSED', 'EXPIRED'));
+
+DROP INDEX IF EXISTS transactions_original_transaction_id_idx;
+
+ALTER TABLE transactions
+DROP COLUMN IF EXISTS original_transaction_id,
+DROP COLUMN IF EXISTS requested_by,
+DROP COLUMN IF EXISTS reason;
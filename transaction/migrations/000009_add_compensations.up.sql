@@ -0,0 +1,15 @@
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'REVERSAL';
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'REFUND';
+
+-- A reversal or refund links to the transaction it takes back, with who asked
+-- for it and why.
+ALTER TABLE transactions
+ADD COLUMN IF NOT EXISTS original_transaction_id uuid REFERENCES transactions(id),
+ADD COLUMN IF NOT EXISTS requested_by VARCHAR(64),
+ADD COLUMN IF NOT EXISTS reason VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS transactions_original_transaction_id_idx ON transactions (original_transaction_id) WHERE original_transaction_id IS NOT NULL;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+CHECK (status IN ('PENDING', 'COMPLETED', 'PROCESSING', 'CAPTURED', 'SETTLED', 'PARTIALLY_REFUNDED', 'FAILED', 'REVERSED', 'EXPIRED'));
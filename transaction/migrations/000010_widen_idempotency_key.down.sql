@@ -0,0 +1 @@
+ALTER TABLE transactions ALTER COLUMN idempotency_key TYPE VARCHAR(255);
@@ -0,0 +1,3 @@
+-- The broker scopes clients' keys of up to 255 characters to their user, as
+-- "<user ID>:<key>".
+ALTER TABLE transactions ALTER COLUMN idempotency_key TYPE VARCHAR(320);
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 	"gopkg.in/natefinch/lumberjack.v2"
 	"io"
 	"os"
@@ -84,3 +85,18 @@ func newLogger(cfg config.Log) *logrus.Logger {
 
 	return log.WithField("service", "auth").Logger
 }
+
+// gracefulStop stops the gRPC server once in-flight RPCs have finished, or forcefully when ctx expires.
+func gracefulStop(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
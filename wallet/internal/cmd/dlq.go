@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"wallet/internal/dlq"
+
+	"github.com/spf13/cobra"
+)
+
+// NewDLQCmd creates and returns the dlq command
+func NewDLQCmd() *cobra.Command {
+	dlqCmd := &cobra.Command{
+		Use:   "dlq",
+		Short: "Manage the dead-letter topics of the wallet consumers",
+	}
+
+	dlqCmd.AddCommand(newDLQReplayCmd())
+
+	return dlqCmd
+}
+
+func newDLQReplayCmd() *cobra.Command {
+	var (
+		brokers []string
+		limit   int
+		idle    time.Duration
+	)
+
+	replayCmd := &cobra.Command{
+		Use:   "replay <topic>",
+		Short: "Re-inject dead-lettered events into the topic they failed on",
+		Long: `Re-inject dead-lettered events into the topic they failed on, once the cause
+is fixed. The topic is either the dead-letter topic, eg. deposit_initiated.dlq,
+or the one it belongs to. Events replayed once aren't replayed again.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			topic := args[0]
+			if !strings.HasSuffix(topic, dlq.Suffix) {
+				topic = dlq.Topic(topic)
+			}
+
+			reader := dlq.NewReplayReader(brokers, topic, "wallet")
+			defer reader.Close()
+			writer := dlq.NewKafkaWriter(brokers[0])
+			defer writer.Close()
+
+			replayed, err := dlq.Replay(ctx, reader, writer, limit, idle)
+			fmt.Printf("Replayed %d events from %s\n", replayed, topic)
+			if err != nil {
+				return fmt.Errorf("failed to replay %s: %w", topic, err)
+			}
+			return nil
+		},
+	}
+
+	replayCmd.Flags().StringSliceVar(&brokers, "brokers", []string{"localhost:9092"}, "Kafka brokers")
+	replayCmd.Flags().IntVar(&limit, "limit", 0, "Replay at most this many events, 0 for all")
+	replayCmd.Flags().DurationVar(&idle, "idle", 10*time.Second, "Stop once no event has arrived for this long")
+
+	return replayCmd
+}
@@ -17,6 +17,7 @@ func NewRootCmd() *cobra.Command {
 
 	// Add commands
 	rootCmd.AddCommand(NewServeCmd())
+	rootCmd.AddCommand(NewDLQCmd())
 
 	return rootCmd
 }
@@ -5,9 +5,17 @@ import (
 	"fmt"
 	"github.com/joho/godotenv"
 	"net"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 	"wallet/internal/config"
 	"wallet/internal/consumers"
+	"wallet/internal/dlq"
+	"wallet/internal/fx"
+	"wallet/internal/metrics"
+	"wallet/internal/mtls"
+	"wallet/internal/outbox"
 	"wallet/internal/producers"
 	"wallet/internal/wallet"
 	pb "wallet/proto/gen"
@@ -25,7 +33,9 @@ func NewServeCmd() *cobra.Command {
 		Use:   "serve",
 		Short: "Start the wallet service",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := context.Background()
+			// Cancelled on SIGTERM so that Kubernetes rolling deploys drain the pod.
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
 
 			// Load .env file
 			if err := godotenv.Load(); err != nil {
@@ -43,16 +53,32 @@ func NewServeCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to create postgres pool: %w", err)
 			}
+			defer pgPool.Close()
 
 			log.Info("Successfully connected to postgres")
 
 			// Create dependencies
 			walletRepo := wallet.NewPostgresWalletRepository(pgPool, log)
-			walletSvc := wallet.NewWalletService(walletRepo, log)
-
-			// Initialize producers.
-			depositProducer := producers.NewDepositCompletedProducer("localhost:9092", "deposit_completed", 100, 20*time.Millisecond)
-			notifyProducer := producers.NewNotificationProducer("localhost:9092", "notification", 100, 20*time.Millisecond)
+			holdRepo := wallet.NewPostgresHoldRepository(pgPool, log)
+			fxRepo := wallet.NewPostgresFXRepository(pgPool, log)
+			walletSvc := wallet.NewWalletService(walletRepo, holdRepo, fxRepo, wallet.FXPolicy{
+				SpreadBps:  cfg.FX.SpreadBps,
+				FeeBps:     cfg.FX.FeeBps,
+				QuoteTTL:   cfg.FX.QuoteTTL,
+				MaxRateAge: cfg.FX.MaxRateAge,
+			}, log)
+			holdSweeper := wallet.NewHoldSweeper(holdRepo, cfg.HoldSweepInterval, cfg.HoldSweepBatchSize, log)
+			rateRefresher := fx.NewRefresher(fx.NewProvider(cfg.FX.RatesSource, cfg.FX.RefreshInterval), fxRepo, cfg.FX.RefreshInterval, log)
+
+			// Producers write to the outbox in the consumers' database
+			// transactions, the relay publishes it.
+			depositProducer := producers.NewDepositOutcomeProducer("deposit_completed", "deposit_failed")
+			notifyProducer := producers.NewNotificationProducer("notification")
+			withdrawProducer := producers.NewWithdrawOutcomeProducer("withdraw_completed", "withdraw_failed")
+			transferProducer := producers.NewTransferOutcomeProducer("transfer_completed", "transfer_failed")
+			reversalProducer := producers.NewReversalOutcomeProducer("reversal_completed", "reversal_failed")
+			outboxWriter := outbox.NewKafkaWriter("localhost:9092", 100, 20*time.Millisecond)
+			outboxRelay := outbox.NewRelay(pgPool, outboxWriter, cfg.OutboxPollInterval, cfg.OutboxBatchSize, cfg.OutboxRetention, log)
 
 			// Initialize consumer with config.
 			consumerCfg := &consumers.Config{
@@ -65,30 +91,124 @@ func NewServeCmd() *cobra.Command {
 				CommitInterval: 1 * time.Second,
 			}
 
-			consumer := consumers.NewConsumer(pgPool, consumerCfg, depositProducer, notifyProducer)
-			ctx, cancel := context.WithCancel(context.Background())
+			// Withdrawals, transfers and reversals get their own groups so that a
+			// backlog of deposits doesn't hold them up.
+			withdrawCfg := *consumerCfg
+			withdrawCfg.Topic = "withdraw_initiated"
+			withdrawCfg.GroupID = "wallet-withdraw-group"
+			transferCfg := *consumerCfg
+			transferCfg.Topic = "transfer_initiated"
+			transferCfg.GroupID = "wallet-transfer-group"
+			reversalCfg := *consumerCfg
+			reversalCfg.Topic = "reversal_initiated"
+			reversalCfg.GroupID = "wallet-reversal-group"
+
+			// Events a consumer keeps failing on go to <topic>.dlq, see the dlq
+			// replay command.
+			dlqPolicy := dlq.Policy{
+				MaxAttempts:    cfg.DLQ.MaxAttempts,
+				InitialBackoff: cfg.DLQ.InitialBackoff,
+				MaxBackoff:     cfg.DLQ.MaxBackoff,
+			}
+			dlqWriter := dlq.NewKafkaWriter("localhost:9092")
+
+			// Deferred in reverse: the readers commit their last offsets, then the
+			// outbox writer flushes what the relay published. Events the final
+			// batches wrote after the relay stopped are published on the next start.
+			consumer := consumers.NewConsumer(pgPool, consumerCfg, depositProducer, notifyProducer,
+				dlq.NewHandler(dlqPolicy, dlqWriter, consumerCfg.GroupID))
+			withdrawConsumer := consumers.NewWithdrawConsumer(pgPool, &withdrawCfg, withdrawProducer, notifyProducer,
+				dlq.NewHandler(dlqPolicy, dlqWriter, withdrawCfg.GroupID))
+			transferConsumer := consumers.NewTransferConsumer(pgPool, &transferCfg, transferProducer, notifyProducer,
+				dlq.NewHandler(dlqPolicy, dlqWriter, transferCfg.GroupID))
+			reversalConsumer := consumers.NewReversalConsumer(pgPool, &reversalCfg, reversalProducer,
+				dlq.NewHandler(dlqPolicy, dlqWriter, reversalCfg.GroupID))
+			defer outboxWriter.Close()
+			defer dlqWriter.Close()
+			defer consumer.Close()
+			defer withdrawConsumer.Close()
+			defer transferConsumer.Close()
+			defer reversalConsumer.Close()
+
+			var consumerWG sync.WaitGroup
+			consumerWG.Add(8)
 			go func() {
+				defer consumerWG.Done()
 				consumer.Consume(ctx)
 			}()
-			defer cancel()
-			defer consumer.Close()
-			defer depositProducer.Close()
-			defer notifyProducer.Close()
+			go func() {
+				defer consumerWG.Done()
+				withdrawConsumer.Consume(ctx)
+			}()
+			go func() {
+				defer consumerWG.Done()
+				transferConsumer.Consume(ctx)
+			}()
+			go func() {
+				defer consumerWG.Done()
+				reversalConsumer.Consume(ctx)
+			}()
+			go func() {
+				defer consumerWG.Done()
+				holdSweeper.Run(ctx)
+			}()
+			go func() {
+				defer consumerWG.Done()
+				rateRefresher.Run(ctx)
+			}()
+			go func() {
+				defer consumerWG.Done()
+				metrics.Serve(ctx, ":"+cfg.MetricsPort, log)
+			}()
+			go func() {
+				defer consumerWG.Done()
+				outboxRelay.Run(ctx)
+			}()
+			consumerDone := make(chan struct{})
+			go func() {
+				consumerWG.Wait()
+				close(consumerDone)
+			}()
 
 			// Set up gRPC server
 			lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.ListenPort))
 			if err != nil {
-				log.Fatal(err)
+				return fmt.Errorf("failed to listen: %w", err)
+			}
+
+			serverOpts, err := mtls.ServerOptions(ctx, cfg.TLS, log)
+			if err != nil {
+				return fmt.Errorf("failed to configure TLS: %w", err)
 			}
 
-			s := grpc.NewServer()
+			s := grpc.NewServer(serverOpts...)
 			pb.RegisterWalletServiceServer(s, walletSvc)
 
-			log.Printf("Wallet service running on :%s", cfg.ListenPort)
-			if err := s.Serve(lis); err != nil {
-				log.Fatal(err)
+			serveErr := make(chan error, 1)
+			go func() {
+				log.Printf("Wallet service running on :%s", cfg.ListenPort)
+				serveErr <- s.Serve(lis)
+			}()
+
+			select {
+			case err := <-serveErr:
+				return fmt.Errorf("failed to serve: %w", err)
+			case <-ctx.Done():
+			}
+
+			log.Infof("Shutting down, draining for up to %s", cfg.ShutdownTimeout)
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+			defer cancel()
+
+			gracefulStop(shutdownCtx, s)
+
+			select {
+			case <-consumerDone:
+			case <-shutdownCtx.Done():
+				log.Warn("Timed out waiting for the in-flight deposit, withdraw, transfer and reversal batches")
 			}
 
+			log.Info("Wallet service stopped")
 			return nil
 		},
 	}
@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+// DLQ is how consumers retry a failing event before dead-lettering it.
+type DLQ struct {
+	// MaxAttempts counts the first attempt.
+	MaxAttempts int `default:"5" envconfig:"DLQ_MAX_ATTEMPTS"`
+
+	// InitialBackoff is the wait after the first failed attempt, doubled after each next one up to MaxBackoff.
+	InitialBackoff time.Duration `default:"200ms" envconfig:"DLQ_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `default:"5s" envconfig:"DLQ_MAX_BACKOFF"`
+}
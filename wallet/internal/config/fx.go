@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+type FX struct {
+	// RatesSource is where exchange rates are read from, an http(s) URL or a path to a JSON file.
+	RatesSource string `default:"fx_rates.json" envconfig:"FX_RATES_SOURCE"`
+
+	// RefreshInterval is how often rates are fetched from RatesSource.
+	RefreshInterval time.Duration `default:"1m" envconfig:"FX_REFRESH_INTERVAL"`
+
+	// MaxRateAge is how old a rate can be before quotes are refused.
+	MaxRateAge time.Duration `default:"1h" envconfig:"FX_MAX_RATE_AGE"`
+
+	// QuoteTTL is how long a quote's rate is locked for.
+	QuoteTTL time.Duration `default:"30s" envconfig:"FX_QUOTE_TTL"`
+
+	// SpreadBps is taken off the mid-market rate, in basis points.
+	SpreadBps int64 `default:"50" envconfig:"FX_SPREAD_BPS"`
+
+	// FeeBps is charged on the source amount before it is converted, in basis points.
+	FeeBps int64 `default:"0" envconfig:"FX_FEE_BPS"`
+}
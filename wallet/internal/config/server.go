@@ -3,16 +3,37 @@ package config
 import (
 	"fmt"
 	"github.com/kelseyhightower/envconfig"
+	"time"
 )
 
 type ServerCfg struct {
 	// ListenPort is the port where the server listens for incoming requests.
 	ListenPort string `default:"8080" envconfig:"LISTEN_PORT"`
 
+	// MetricsPort is where counters are served on /debug/vars.
+	MetricsPort string `default:"9102" envconfig:"METRICS_PORT"`
+
 	JWTSecret string `default:"change-me-in-prod" envconfig:"JWT_SECRET"`
 
+	// ShutdownTimeout bounds draining on SIGTERM, keep it below the pod's termination grace period.
+	ShutdownTimeout time.Duration `default:"25s" envconfig:"SHUTDOWN_TIMEOUT"`
+
+	// HoldSweepInterval is how often expired holds are released.
+	HoldSweepInterval  time.Duration `default:"30s" envconfig:"HOLD_SWEEP_INTERVAL"`
+	HoldSweepBatchSize int           `default:"500" envconfig:"HOLD_SWEEP_BATCH_SIZE"`
+
+	// OutboxPollInterval is how often the outbox relay looks for unsent events,
+	// and the first wait before retrying while Kafka is failing.
+	OutboxPollInterval time.Duration `default:"500ms" envconfig:"OUTBOX_POLL_INTERVAL"`
+	OutboxBatchSize    int           `default:"100" envconfig:"OUTBOX_BATCH_SIZE"`
+	// OutboxRetention is how long sent events are kept in the outbox.
+	OutboxRetention time.Duration `default:"72h" envconfig:"OUTBOX_RETENTION"`
+
 	Postgres Postgres
 	Log      Log
+	TLS      TLS
+	FX       FX
+	DLQ      DLQ
 }
 
 func NewServerConfig() (*ServerCfg, error) {
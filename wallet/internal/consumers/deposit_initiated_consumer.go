@@ -4,21 +4,34 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"expvar"
+	"fmt"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgconn"
 	"log"
+	"strings"
 	"time"
+	"wallet/internal/dlq"
 	"wallet/internal/events"
+	"wallet/internal/ledger"
+	"wallet/internal/metrics"
+	"wallet/internal/money"
 	"wallet/internal/producers"
+	"wallet/internal/wallet"
 
 	"github.com/segmentio/kafka-go"
 )
 
 const (
-	NOTIFICATION_CHANNEL = "email"
-	EMAIL_TEMPLATE       = "deposit"
+	NOTIFICATION_CHANNEL          = "email"
+	EMAIL_TEMPLATE                = "deposit"
+	DEPOSIT_FAILED_EMAIL_TEMPLATE = "deposit_failed"
 )
 
+// INTEGRITY_CONSTRAINT_VIOLATION is the class of the SQLSTATE codes of check,
+// foreign key and unique violations.
+const INTEGRITY_CONSTRAINT_VIOLATION = "23"
+
 type Config struct {
 	Brokers        []string
 	Topic          string
@@ -29,15 +42,22 @@ type Config struct {
 	CommitInterval time.Duration
 }
 
+// TxBeginner starts the database transactions batches are applied in,
+// implemented by pgxpool.Pool.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
 type Consumer struct {
 	reader          *kafka.Reader
-	db              *pgxpool.Pool
-	depositProducer producers.DepositCompletedProducer
+	db              TxBeginner
+	outcomeProducer producers.DepositOutcomeProducer
 	notifyProducer  producers.NotificationProducer
+	deadLetters     *dlq.Handler
 	batchSize       int
 }
 
-func NewConsumer(db *pgxpool.Pool, cfg *Config, depositProducer producers.DepositCompletedProducer, notifyProducer producers.NotificationProducer) *Consumer {
+func NewConsumer(db TxBeginner, cfg *Config, outcomeProducer producers.DepositOutcomeProducer, notifyProducer producers.NotificationProducer, deadLetters *dlq.Handler) *Consumer {
 	return &Consumer{
 		reader: kafka.NewReader(kafka.ReaderConfig{
 			Brokers:        cfg.Brokers,
@@ -48,8 +68,9 @@ func NewConsumer(db *pgxpool.Pool, cfg *Config, depositProducer producers.Deposi
 			CommitInterval: cfg.CommitInterval,
 		}),
 		db:              db,
-		depositProducer: depositProducer,
+		outcomeProducer: outcomeProducer,
 		notifyProducer:  notifyProducer,
+		deadLetters:     deadLetters,
 		batchSize:       cfg.BatchSize,
 	}
 }
@@ -60,7 +81,7 @@ func (c *Consumer) Consume(ctx context.Context) {
 
 	for {
 		// Fetch a batch of messages
-		messages, err := c.fetchBatch(ctx)
+		messages, err := fetchBatch(ctx, c.reader, c.batchSize)
 		if err != nil {
 			if ctx.Err() != nil {
 				log.Printf("Consumer stopped: %v", ctx.Err())
@@ -70,22 +91,38 @@ func (c *Consumer) Consume(ctx context.Context) {
 			continue
 		}
 		if len(messages) == 0 {
+			if ctx.Err() != nil {
+				log.Printf("Consumer stopped: %v", ctx.Err())
+				return
+			}
 			continue
 		}
 
-		// Process the batch
-		depositEvents, notificationEvents := c.processBatch(ctx, messages)
-		if len(depositEvents) == 0 {
-			log.Printf("No valid events in batch of %d messages", len(messages))
-			continue
-		}
+		// A fetched batch is always finished, even on shutdown, so its offsets
+		// are committed before the reader is closed.
+		batchCtx := context.WithoutCancel(ctx)
 
-		// Publish to deposit-completed and notification topics
-		c.depositProducer.PublishDepositCompletedEvents(ctx, depositEvents)
-		c.notifyProducer.PublishNotificationEvents(ctx, notificationEvents)
+		// Process the batch, dead-lettering the events that keep failing. A
+		// batch that can be neither processed nor dead-lettered is retried until
+		// it is, fetching past it would let the next commit skip it. A batch of
+		// only refused or already applied events applies nothing, its offsets
+		// are still committed.
+		applied := 0
+		ok := c.deadLetters.HandleUntilDone(ctx, messages, func(ctx context.Context, messages []kafka.Message) error {
+			n, err := c.processBatch(ctx, messages)
+			applied += n
+			return err
+		})
+		if !ok {
+			log.Printf("Consumer stopped with a batch of %d messages uncommitted: %v", len(messages), ctx.Err())
+			return
+		}
+		if applied == 0 {
+			log.Printf("No new deposits in batch of %d messages", len(messages))
+		}
 
 		// Commit the batch
-		if err := c.reader.CommitMessages(ctx, messages...); err != nil {
+		if err := c.reader.CommitMessages(batchCtx, messages...); err != nil {
 			log.Printf("Failed to commit batch of %d messages: %v", len(messages), err)
 		} else {
 			log.Printf("Committed batch of %d messages", len(messages))
@@ -93,18 +130,20 @@ func (c *Consumer) Consume(ctx context.Context) {
 	}
 }
 
-func (c *Consumer) fetchBatch(ctx context.Context) ([]kafka.Message, error) {
-	messages := make([]kafka.Message, 0, c.batchSize)
+// fetchBatch fetches up to batchSize messages, returning what arrived within a
+// few seconds.
+func fetchBatch(ctx context.Context, reader *kafka.Reader, batchSize int) ([]kafka.Message, error) {
+	messages := make([]kafka.Message, 0, batchSize)
 
 	// Try to fetch up to batchSize messages with a reasonable timeout
 	fetchCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	for len(messages) < c.batchSize {
-		msg, err := c.reader.FetchMessage(fetchCtx)
+	for len(messages) < batchSize {
+		msg, err := reader.FetchMessage(fetchCtx)
 		if err != nil {
-			// If timeout and we have some messages, return them
-			if errors.Is(err, context.DeadlineExceeded) && len(messages) > 0 {
+			// If timeout or shutdown and we have some messages, return them
+			if (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) && len(messages) > 0 {
 				return messages, nil
 			}
 
@@ -123,57 +162,211 @@ func (c *Consumer) fetchBatch(ctx context.Context) ([]kafka.Message, error) {
 	return messages, nil
 }
 
-// processBatch handles a batch of Kafka messages in a single database transaction.
-func (c *Consumer) processBatch(ctx context.Context, messages []kafka.Message) ([]*events.Deposit, []*events.Notification) {
+// processBatch handles a batch of Kafka messages in a single database
+// transaction and returns how many deposits it applied. Each deposit is
+// recorded in processed_transactions along with its balance change or refusal,
+// so a batch redelivered after its offsets failed to commit skips the deposits
+// it already handled. The outcome and notification events are written to the
+// outbox in the same transaction. The batch is applied whole or not at all, an
+// event that can never be applied fails it with a poison error. The metrics
+// count the batch's events once it commits.
+func (c *Consumer) processBatch(ctx context.Context, messages []kafka.Message) (int, error) {
 	if len(messages) == 0 {
-		return nil, nil
+		return 0, nil
 	}
 
 	// Start a database transaction
 	tx, err := c.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		log.Printf("Failed to begin transaction: %v", err)
-		return nil, nil
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	depositEvents := make([]*events.Deposit, 0, len(messages))
-	notificationEvents := make([]*events.Notification, 0, len(messages))
-
+	var (
+		completed, failed              []*events.Deposit
+		appliedTopics, duplicateTopics []string
+	)
 	for _, msg := range messages {
 		var event events.Deposit
 		if err := json.Unmarshal(msg.Value, &event); err != nil {
-			log.Printf("Failed to unmarshal event: %v", err)
+			return 0, dlq.Poison(fmt.Errorf("failed to unmarshal event: %w", err))
+		}
+		if event.TransactionID == "" {
+			return 0, dlq.Poison(fmt.Errorf("deposit into wallet %s has no transaction ID", event.WalletID))
+		}
+
+		first, err := markProcessed(ctx, tx, event.TransactionID, ledger.KindDeposit)
+		if err != nil {
+			return 0, fmt.Errorf("failed to record transaction %s as processed: %w", event.TransactionID, err)
+		}
+		if !first {
+			log.Printf("Skipping deposit %s, it was applied already", event.TransactionID)
+			duplicateTopics = append(duplicateTopics, msg.Topic)
+			continue
+		}
+
+		amount, err := event.Money()
+		if err != nil || amount <= 0 {
+			log.Printf("Invalid amount for transaction %s: %v", event.TransactionID, err)
+			event.FailureReason = FAILURE_AMOUNT_INVALID
+			if event.UserID, err = walletOwner(ctx, tx, event.WalletID); err != nil {
+				return 0, fmt.Errorf("failed to find the owner of wallet %s: %w", event.WalletID, err)
+			}
+			failed = append(failed, &event)
 			continue
 		}
+		// Downstream consumers get both fields, whichever version they run.
+		event.SetMoney(amount)
 
-		// Update wallet balance
-		_, err = tx.Exec(ctx, "UPDATE wallets SET balance = balance + $1 WHERE id = $2", event.Amount, event.WalletID)
+		event.FailureReason, err = creditDeposit(ctx, tx, &event, amount)
 		if err != nil {
-			log.Printf("Failed to update balance for transaction %s: %v", event.TransactionID, err)
+			return 0, fmt.Errorf("failed to credit wallet for transaction %s: %w", event.TransactionID, err)
+		}
+		if event.FailureReason != "" {
+			log.Printf("Refused deposit %s into wallet %s: %s", event.TransactionID, event.WalletID, event.FailureReason)
+			failed = append(failed, &event)
 			continue
 		}
 
-		// Collect successful events
-		depositEvents = append(depositEvents, &event)
-		notificationEvents = append(notificationEvents, &events.Notification{
-			Channel: NOTIFICATION_CHANNEL,
-			Data: map[string]any{
-				"wallet_id":      event.WalletID,
-				"amount":         event.Amount,
-				"transaction_id": event.TransactionID,
-				"template":       EMAIL_TEMPLATE,
-			},
-		})
+		appliedTopics = append(appliedTopics, msg.Topic)
+		completed = append(completed, &event)
+	}
+
+	for _, event := range failed {
+		if err := markFailed(ctx, tx, event.TransactionID, event.FailureReason); err != nil {
+			return 0, fmt.Errorf("failed to record transaction %s as failed: %w", event.TransactionID, err)
+		}
+	}
+
+	if err := c.outcomeProducer.PublishDepositOutcomes(ctx, tx, completed, failed); err != nil {
+		return 0, fmt.Errorf("failed to publish outcome events: %w", err)
+	}
+	if err := c.notifyProducer.PublishNotificationEvents(ctx, tx, depositNotifications(completed, failed)); err != nil {
+		return 0, fmt.Errorf("failed to publish notification events: %w", err)
 	}
 
 	// Commit transaction
 	if err := tx.Commit(ctx); err != nil {
-		log.Printf("Failed to commit transaction: %v", err)
-		tx.Rollback(ctx)
-		return nil, nil
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	countEvents(metrics.AppliedEvents, appliedTopics)
+	countEvents(metrics.DuplicateEvents, duplicateTopics)
+
+	return len(completed), nil
+}
+
+// creditDeposit locks the wallet and posts the deposit, or returns why it was
+// refused. The posting runs in a savepoint, so a deposit the database refuses
+// with a constraint violation fails on its own instead of aborting the batch.
+func creditDeposit(ctx context.Context, tx pgx.Tx, event *events.Deposit, amount money.Amount) (string, error) {
+	var (
+		status   wallet.Status
+		currency money.Currency
+	)
+	err := tx.QueryRow(ctx, "SELECT user_id, status, currency FROM wallets WHERE id = $1 FOR UPDATE", event.WalletID).Scan(&event.UserID, &status, &currency)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return FAILURE_WALLET_NOT_FOUND, nil
+	case err != nil:
+		return "", err
+	case status == wallet.StatusClosed:
+		return FAILURE_WALLET_CLOSED, nil
+	}
+	if reason := currencyFailure(event.Currency, currency, amount); reason != "" {
+		return reason, nil
+	}
+	event.Currency = string(currency)
+	event.SetMoney(amount)
+
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer savepoint.Rollback(ctx)
+
+	_, err = ledger.Post(ctx, savepoint, ledger.Deposit(event.WalletID, event.TransactionID, amount, currency))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, INTEGRITY_CONSTRAINT_VIOLATION) {
+		log.Printf("Deposit %s violates %s: %s", event.TransactionID, pgErr.ConstraintName, pgErr.Message)
+		return FAILURE_CONSTRAINT_VIOLATION, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return "", savepoint.Commit(ctx)
+}
+
+// depositNotifications tells the owner of each wallet about its deposit,
+// applied or refused.
+func depositNotifications(completed, failed []*events.Deposit) []*events.Notification {
+	notifications := make([]*events.Notification, 0, len(completed)+len(failed))
+	for _, d := range completed {
+		notifications = append(notifications, depositNotification(d, EMAIL_TEMPLATE))
+	}
+	for _, d := range failed {
+		n := depositNotification(d, DEPOSIT_FAILED_EMAIL_TEMPLATE)
+		n.Data["failure_reason"] = d.FailureReason
+		notifications = append(notifications, n)
+	}
+	return notifications
+}
+
+func depositNotification(d *events.Deposit, template string) *events.Notification {
+	return &events.Notification{
+		Channel: NOTIFICATION_CHANNEL,
+		Data: map[string]any{
+			"wallet_id":      d.WalletID,
+			"amount":         d.Amount,
+			"minor_units":    d.MinorUnits,
+			"currency":       d.Currency,
+			"transaction_id": d.TransactionID,
+			"user_id":        d.UserID,
+			"template":       template,
+		},
+	}
+}
+
+// walletOwner returns the user owning the wallet, 0 when it doesn't exist, so
+// that events refused before the wallet is locked still reach its owner.
+func walletOwner(ctx context.Context, tx pgx.Tx, walletID string) (int, error) {
+	var userID int
+	err := tx.QueryRow(ctx, "SELECT user_id FROM wallets WHERE id = $1", walletID).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return userID, err
+}
+
+// markProcessed records in tx that the transaction's event is being applied.
+// It returns false when it was applied already, by an earlier delivery or
+// earlier in the same batch.
+func markProcessed(ctx context.Context, tx pgx.Tx, transactionID string, kind ledger.Kind) (bool, error) {
+	tag, err := tx.Exec(ctx,
+		"INSERT INTO processed_transactions (transaction_id, kind) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		transactionID, kind,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// countEvents adds one to m for the topic of each event, once the events are
+// committed, a batch that is rolled back and retried would count them again.
+func countEvents(m *expvar.Map, topics []string) {
+	for _, topic := range topics {
+		m.Add(topic, 1)
+	}
+}
 
-	return depositEvents, notificationEvents
+// markFailed records in tx why a processed transaction was refused, for the
+// transaction service to settle it if its outcome event is lost.
+func markFailed(ctx context.Context, tx pgx.Tx, transactionID string, reason string) error {
+	_, err := tx.Exec(ctx,
+		"UPDATE processed_transactions SET status = 'FAILED', failure_reason = $2 WHERE transaction_id = $1",
+		transactionID, reason,
+	)
+	return err
 }
 
 func (c *Consumer) Close() {
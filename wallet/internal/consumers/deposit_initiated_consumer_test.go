@@ -0,0 +1,246 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+	"wallet/internal/events"
+	"wallet/internal/ledger"
+	"wallet/internal/metrics"
+	"wallet/internal/money"
+	"wallet/internal/wallet"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingDepositOutcomes implements producers.DepositOutcomeProducer.
+type recordingDepositOutcomes struct {
+	completed, failed []*events.Deposit
+}
+
+func (p *recordingDepositOutcomes) PublishDepositOutcomes(ctx context.Context, tx pgx.Tx, completed, failed []*events.Deposit) error {
+	p.completed = append(p.completed, completed...)
+	p.failed = append(p.failed, failed...)
+	return nil
+}
+
+// recordingNotifications implements producers.NotificationProducer.
+type recordingNotifications struct {
+	notifications []*events.Notification
+}
+
+func (p *recordingNotifications) PublishNotificationEvents(ctx context.Context, tx pgx.Tx, events []*events.Notification) error {
+	p.notifications = append(p.notifications, events...)
+	return nil
+}
+
+// counted returns the count of topic in m.
+func counted(m *expvar.Map, topic string) int64 {
+	if v, ok := m.Get(topic).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestDepositProcessBatch(t *testing.T) {
+	t.Parallel()
+
+	deposit := events.Deposit{WalletID: "w1", MinorUnits: 2500, Currency: "EUR", TransactionID: "t1"}
+
+	testCases := []struct {
+		name               string
+		topic              string
+		batches            [][]events.Deposit
+		commitErr          error
+		expectedBalance    money.Amount
+		expectedCompleted  int
+		expectedApplied    int64
+		expectedDuplicates int64
+	}{
+		{
+			name:              "when a deposit is applied, it should credit the wallet and count it",
+			topic:             "deposit_initiated.applied",
+			batches:           [][]events.Deposit{{deposit}},
+			expectedBalance:   money.FromMinor(12500),
+			expectedCompleted: 1,
+			expectedApplied:   1,
+		},
+		{
+			name:               "when a deposit is redelivered, it should skip it and count the duplicate",
+			topic:              "deposit_initiated.redelivered",
+			batches:            [][]events.Deposit{{deposit}, {deposit}},
+			expectedBalance:    money.FromMinor(12500),
+			expectedCompleted:  1,
+			expectedApplied:    1,
+			expectedDuplicates: 1,
+		},
+		{
+			name:            "when the batch fails to commit, it should count nothing",
+			topic:           "deposit_initiated.rolled_back",
+			batches:         [][]events.Deposit{{deposit, deposit}},
+			commitErr:       errors.New("connection reset"),
+			expectedBalance: money.FromMinor(10000),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := newFakeDB(map[string]fakeWallet{
+				"w1": {userID: 1, status: wallet.StatusActive, balance: money.FromMinor(10000), currency: "EUR"},
+			})
+			db.commitErr = tc.commitErr
+			outcomes := &recordingDepositOutcomes{}
+			c := &Consumer{db: db, outcomeProducer: outcomes, notifyProducer: discardNotifications{}}
+
+			for _, batch := range tc.batches {
+				var messages []kafka.Message
+				for _, event := range batch {
+					messages = append(messages, eventMessage(t, tc.topic, event))
+				}
+				_, err := c.processBatch(context.Background(), messages)
+				assert.Equal(t, tc.commitErr != nil, err != nil)
+			}
+
+			assert.Equal(t, tc.expectedBalance, db.state.wallets["w1"].balance)
+			if tc.commitErr == nil {
+				assert.Len(t, outcomes.completed, tc.expectedCompleted)
+				assert.Len(t, db.journals(), tc.expectedCompleted)
+			}
+			assert.Equal(t, tc.expectedApplied, counted(metrics.AppliedEvents, tc.topic))
+			assert.Equal(t, tc.expectedDuplicates, counted(metrics.DuplicateEvents, tc.topic))
+		})
+	}
+}
+
+func TestDepositProcessBatchRefused(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		deposit        events.Deposit
+		expectedReason string
+		expectedUserID int
+	}{
+		{
+			name:           "when the wallet doesn't exist, it should fail the deposit",
+			deposit:        events.Deposit{WalletID: "missing", AmountMinor: 2500, TransactionID: "t2"},
+			expectedReason: FAILURE_WALLET_NOT_FOUND,
+		},
+		{
+			name:           "when the wallet is closed, it should fail the deposit",
+			deposit:        events.Deposit{WalletID: "closed", AmountMinor: 2500, TransactionID: "t2"},
+			expectedReason: FAILURE_WALLET_CLOSED,
+			expectedUserID: 1,
+		},
+		{
+			name:           "when the currency isn't the wallet's, it should fail the deposit",
+			deposit:        events.Deposit{WalletID: "w2", MinorUnits: 2500, Currency: "USD", TransactionID: "t2"},
+			expectedReason: FAILURE_CURRENCY_MISMATCH,
+			expectedUserID: 1,
+		},
+		{
+			name:           "when the amount isn't positive, it should fail the deposit",
+			deposit:        events.Deposit{WalletID: "w2", AmountMinor: -2500, TransactionID: "t2"},
+			expectedReason: FAILURE_AMOUNT_INVALID,
+			expectedUserID: 1,
+		},
+		{
+			name:           "when the database refuses the posting, it should fail the deposit alone",
+			deposit:        events.Deposit{WalletID: "violating", AmountMinor: 2500, TransactionID: "t2"},
+			expectedReason: FAILURE_CONSTRAINT_VIOLATION,
+			expectedUserID: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := newFakeDB(map[string]fakeWallet{
+				"w1":        {userID: 1, status: wallet.StatusActive, balance: money.FromMinor(10000), currency: "EUR"},
+				"w2":        {userID: 1, status: wallet.StatusActive, currency: "EUR"},
+				"closed":    {userID: 1, status: wallet.StatusClosed, currency: "EUR"},
+				"violating": {userID: 1, status: wallet.StatusActive, currency: "EUR", violates: true},
+			})
+			outcomes := &recordingDepositOutcomes{}
+			notifications := &recordingNotifications{}
+			c := &Consumer{db: db, outcomeProducer: outcomes, notifyProducer: notifications}
+			applied := events.Deposit{WalletID: "w1", AmountMinor: 2500, TransactionID: "t1"}
+
+			n, err := c.processBatch(context.Background(), []kafka.Message{
+				eventMessage(t, "deposit_initiated", applied),
+				eventMessage(t, "deposit_initiated", tc.deposit),
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, n)
+			assert.Equal(t, money.FromMinor(12500), db.state.wallets["w1"].balance)
+			assert.Equal(t, money.Amount(0), db.state.wallets[tc.deposit.WalletID].balance)
+			if assert.Len(t, outcomes.failed, 1) {
+				assert.Equal(t, tc.deposit.TransactionID, outcomes.failed[0].TransactionID)
+				assert.Equal(t, tc.expectedReason, outcomes.failed[0].FailureReason)
+				assert.Equal(t, tc.expectedUserID, outcomes.failed[0].UserID)
+			}
+			assert.Equal(t, fakeProcessed{kind: ledger.KindDeposit, status: "FAILED", failureReason: tc.expectedReason}, db.state.processed[tc.deposit.TransactionID])
+			assert.Equal(t, fakeProcessed{kind: ledger.KindDeposit, status: "COMPLETED"}, db.state.processed[applied.TransactionID])
+			if assert.Len(t, notifications.notifications, 2) {
+				assert.Equal(t, DEPOSIT_FAILED_EMAIL_TEMPLATE, notifications.notifications[1].Data["template"])
+				assert.Equal(t, tc.expectedReason, notifications.notifications[1].Data["failure_reason"])
+			}
+		})
+	}
+}
+
+func TestDepositProcessBatchMinorUnits(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		event string
+	}{
+		{
+			name:  "when a yen deposit is in minor units, it should credit whole yen",
+			event: `{"wallet_id":"jpy","amount":500,"minor_units":500,"currency":"JPY","transaction_id":"t1"}`,
+		},
+		{
+			name:  "when a yen deposit predates minor units, it should read hundredths",
+			event: `{"wallet_id":"jpy","amount":500,"amount_minor":50000,"currency":"JPY","transaction_id":"t1"}`,
+		},
+		{
+			name:  "when a yen deposit names no currency, it should publish it in the wallet's",
+			event: `{"wallet_id":"jpy","amount":500,"amount_minor":50000,"transaction_id":"t1"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := newFakeDB(map[string]fakeWallet{
+				"jpy": {userID: 1, status: wallet.StatusActive, currency: "JPY"},
+			})
+			outcomes := &recordingDepositOutcomes{}
+			notifications := &recordingNotifications{}
+			c := &Consumer{db: db, outcomeProducer: outcomes, notifyProducer: notifications}
+
+			n, err := c.processBatch(context.Background(), []kafka.Message{{Topic: "deposit_initiated", Value: []byte(tc.event)}})
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, n)
+			assert.Equal(t, money.FromMinorUnits(500, "JPY"), db.state.wallets["jpy"].balance)
+			if assert.Len(t, outcomes.completed, 1) {
+				assert.Equal(t, int64(500), outcomes.completed[0].MinorUnits)
+				assert.Equal(t, "JPY", outcomes.completed[0].Currency)
+				assert.Zero(t, outcomes.completed[0].AmountMinor)
+			}
+			if assert.Len(t, notifications.notifications, 1) {
+				assert.Equal(t, int64(500), notifications.notifications[0].Data["minor_units"])
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"wallet/internal/ledger"
+	"wallet/internal/money"
+	"wallet/internal/wallet"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeWallet is a row of the wallets table.
+type fakeWallet struct {
+	userID   int
+	status   wallet.Status
+	balance  money.Amount
+	held     money.Amount
+	currency money.Currency
+	// violates makes every posting to the wallet fail a check constraint.
+	violates bool
+}
+
+// fakeProcessed is a row of the processed_transactions table.
+type fakeProcessed struct {
+	kind          ledger.Kind
+	status        string
+	failureReason string
+}
+
+// fakeEntry is a row of the ledger_entries table.
+type fakeEntry struct {
+	journalID     string
+	kind          ledger.Kind
+	transactionID string
+	account       ledger.Account
+	walletID      *string
+	direction     ledger.Direction
+	amount        money.Amount
+}
+
+type fakeState struct {
+	wallets   map[string]fakeWallet
+	processed map[string]fakeProcessed
+	entries   []fakeEntry
+	journals  int
+}
+
+func (s fakeState) clone() fakeState {
+	c := fakeState{
+		wallets:   make(map[string]fakeWallet, len(s.wallets)),
+		processed: make(map[string]fakeProcessed, len(s.processed)),
+		entries:   append([]fakeEntry(nil), s.entries...),
+		journals:  s.journals,
+	}
+	for id, w := range s.wallets {
+		c.wallets[id] = w
+	}
+	for id, p := range s.processed {
+		c.processed[id] = p
+	}
+	return c
+}
+
+// fakeDB implements TxBeginner in memory, answering the statements the
+// consumers run. A transaction works on a copy of the state that replaces it on
+// commit.
+type fakeDB struct {
+	state   fakeState
+	commits int
+	// commitErr fails every commit, leaving the state as it was.
+	commitErr error
+}
+
+func newFakeDB(wallets map[string]fakeWallet) *fakeDB {
+	return &fakeDB{state: fakeState{wallets: wallets, processed: map[string]fakeProcessed{}}}
+}
+
+func (db *fakeDB) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	return &fakeTx{db: db, state: db.state.clone()}, nil
+}
+
+// journals returns the kinds of the journals posted, one per journal.
+func (db *fakeDB) journals() []ledger.Kind {
+	var kinds []ledger.Kind
+	seen := map[string]bool{}
+	for _, e := range db.state.entries {
+		if !seen[e.journalID] {
+			seen[e.journalID] = true
+			kinds = append(kinds, e.kind)
+		}
+	}
+	return kinds
+}
+
+// fakeTx is a transaction on a fakeDB, or a savepoint when parent is set. The
+// methods the consumers don't use are left to the embedded nil pgx.Tx.
+type fakeTx struct {
+	pgx.Tx
+	db     *fakeDB
+	parent *fakeTx
+	state  fakeState
+	done   bool
+}
+
+func (tx *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &fakeTx{db: tx.db, parent: tx, state: tx.state.clone()}, nil
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	if tx.done {
+		return pgx.ErrTxClosed
+	}
+	tx.done = true
+	if tx.parent != nil {
+		tx.parent.state = tx.state
+		return nil
+	}
+	if tx.db.commitErr != nil {
+		return tx.db.commitErr
+	}
+	tx.db.state = tx.state
+	tx.db.commits++
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	if tx.done {
+		return pgx.ErrTxClosed
+	}
+	tx.done = true
+	return nil
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	switch {
+	case strings.HasPrefix(sql, "INSERT INTO processed_transactions"):
+		id := args[0].(string)
+		if _, ok := tx.state.processed[id]; ok {
+			return pgconn.NewCommandTag("INSERT 0 0"), nil
+		}
+		tx.state.processed[id] = fakeProcessed{kind: args[1].(ledger.Kind), status: "COMPLETED"}
+		return pgconn.NewCommandTag("INSERT 0 1"), nil
+	case strings.HasPrefix(sql, "UPDATE processed_transactions SET status = 'FAILED'"):
+		id := args[0].(string)
+		p, ok := tx.state.processed[id]
+		if !ok {
+			return pgconn.NewCommandTag("UPDATE 0"), nil
+		}
+		p.status, p.failureReason = "FAILED", args[1].(string)
+		tx.state.processed[id] = p
+		return pgconn.NewCommandTag("UPDATE 1"), nil
+	case strings.HasPrefix(strings.TrimSpace(sql), "INSERT INTO ledger_entries"):
+		tx.state.entries = append(tx.state.entries, fakeEntry{
+			journalID:     args[0].(string),
+			kind:          args[1].(ledger.Kind),
+			transactionID: args[2].(string),
+			account:       args[3].(ledger.Account),
+			walletID:      args[4].(*string),
+			direction:     args[5].(ledger.Direction),
+			amount:        args[6].(money.Amount),
+		})
+		return pgconn.NewCommandTag("INSERT 0 1"), nil
+	}
+	return pgconn.CommandTag{}, fmt.Errorf("fakeTx: unexpected statement %q", sql)
+}
+
+func (tx *fakeTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	switch {
+	case strings.HasPrefix(sql, "SELECT gen_random_uuid()"):
+		tx.state.journals++
+		return fakeRow{values: []any{fmt.Sprintf("journal-%d", tx.state.journals)}}
+	case strings.HasPrefix(sql, "SELECT user_id, status, balance - held_balance, currency FROM wallets WHERE id = $1"):
+		w, ok := tx.state.wallets[args[0].(string)]
+		if !ok {
+			return fakeRow{err: pgx.ErrNoRows}
+		}
+		return fakeRow{values: []any{w.userID, w.status, w.balance - w.held, w.currency}}
+	case strings.HasPrefix(sql, "SELECT user_id FROM wallets WHERE id = $1"):
+		w, ok := tx.state.wallets[args[0].(string)]
+		if !ok {
+			return fakeRow{err: pgx.ErrNoRows}
+		}
+		return fakeRow{values: []any{w.userID}}
+	case strings.HasPrefix(sql, "SELECT user_id, status, currency FROM wallets WHERE id = $1"):
+		w, ok := tx.state.wallets[args[0].(string)]
+		if !ok {
+			return fakeRow{err: pgx.ErrNoRows}
+		}
+		return fakeRow{values: []any{w.userID, w.status, w.currency}}
+	case strings.HasPrefix(sql, "UPDATE wallets SET balance = balance + $1"):
+		id := args[1].(string)
+		w, ok := tx.state.wallets[id]
+		if !ok || w.currency != args[2].(money.Currency) {
+			return fakeRow{err: pgx.ErrNoRows}
+		}
+		w.balance += args[0].(money.Amount)
+		if w.balance < w.held || w.violates {
+			return fakeRow{err: &pgconn.PgError{Code: "23514", ConstraintName: "wallets_held_balance_check"}}
+		}
+		tx.state.wallets[id] = w
+		return fakeRow{values: []any{w.balance}}
+	}
+	return fakeRow{err: fmt.Errorf("fakeTx: unexpected query %q", sql)}
+}
+
+func (tx *fakeTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if !strings.HasPrefix(sql, "SELECT id, user_id, status, balance - held_balance, currency FROM wallets WHERE id = ANY($1)") {
+		return nil, fmt.Errorf("fakeTx: unexpected query %q", sql)
+	}
+	ids := append([]string(nil), args[0].([]string)...)
+	sort.Strings(ids)
+	rows := &fakeRows{}
+	for _, id := range ids {
+		if w, ok := tx.state.wallets[id]; ok {
+			rows.rows = append(rows.rows, []any{id, w.userID, w.status, w.balance - w.held, w.currency})
+		}
+	}
+	return rows, nil
+}
+
+type fakeRow struct {
+	values []any
+	err    error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	return scanValues(r.values, dest)
+}
+
+// fakeRows implements pgx.Rows over rows of values, the methods the consumers
+// don't use are left to the embedded nil pgx.Rows.
+type fakeRows struct {
+	pgx.Rows
+	rows [][]any
+	next int
+}
+
+func (r *fakeRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	return scanValues(r.rows[r.next-1], dest)
+}
+
+func (r *fakeRows) Err() error { return nil }
+
+func (r *fakeRows) Close() {}
+
+func scanValues(values []any, dest []any) error {
+	if len(values) != len(dest) {
+		return fmt.Errorf("fakeTx: scanning %d values into %d destinations", len(values), len(dest))
+	}
+	for i, v := range values {
+		d := reflect.ValueOf(dest[i]).Elem()
+		d.Set(reflect.ValueOf(v).Convert(d.Type()))
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"log"
+	"wallet/internal/dlq"
+	"wallet/internal/events"
+	"wallet/internal/ledger"
+	"wallet/internal/metrics"
+	"wallet/internal/money"
+	"wallet/internal/producers"
+	"wallet/internal/wallet"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type ReversalConsumer struct {
+	reader          *kafka.Reader
+	db              TxBeginner
+	outcomeProducer producers.ReversalOutcomeProducer
+	deadLetters     *dlq.Handler
+	batchSize       int
+}
+
+func NewReversalConsumer(db TxBeginner, cfg *Config, outcomeProducer producers.ReversalOutcomeProducer, deadLetters *dlq.Handler) *ReversalConsumer {
+	return &ReversalConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:        cfg.Brokers,
+			Topic:          cfg.Topic,
+			GroupID:        cfg.GroupID,
+			MinBytes:       cfg.MinBytes,
+			MaxBytes:       cfg.MaxBytes,
+			CommitInterval: cfg.CommitInterval,
+		}),
+		db:              db,
+		outcomeProducer: outcomeProducer,
+		deadLetters:     deadLetters,
+		batchSize:       cfg.BatchSize,
+	}
+}
+
+// Consume processes reversal_initiated messages, reversals and refunds of
+// deposits, debiting each wallet or refusing the debit, and publishes the
+// outcome of every one through the outbox.
+func (c *ReversalConsumer) Consume(ctx context.Context) {
+	log.Printf("Starting Kafka consumer for topic: %s with batch size: %d", c.reader.Config().Topic, c.batchSize)
+
+	for {
+		messages, err := fetchBatch(ctx, c.reader, c.batchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				log.Printf("Consumer stopped: %v", ctx.Err())
+				return
+			}
+			log.Printf("Error fetching batch: %v", err)
+			continue
+		}
+		if len(messages) == 0 {
+			if ctx.Err() != nil {
+				log.Printf("Consumer stopped: %v", ctx.Err())
+				return
+			}
+			continue
+		}
+
+		// See Consumer.Consume, a fetched batch is always finished.
+		batchCtx := context.WithoutCancel(ctx)
+
+		if !c.deadLetters.HandleUntilDone(ctx, messages, c.processBatch) {
+			log.Printf("Consumer stopped with a batch of %d messages uncommitted: %v", len(messages), ctx.Err())
+			return
+		}
+
+		if err := c.reader.CommitMessages(batchCtx, messages...); err != nil {
+			log.Printf("Failed to commit batch of %d messages: %v", len(messages), err)
+		} else {
+			log.Printf("Committed batch of %d messages", len(messages))
+		}
+	}
+}
+
+// processBatch applies a batch of reversals in a single database transaction.
+// Like withdrawals, available funds are checked on the locked wallet, and a
+// reversal the wallet can't cover fails with insufficient_funds, leaving the
+// deposit as it is. Like deposits, each one is recorded in
+// processed_transactions, so a redelivered reversal is never debited twice. The
+// metrics count the batch's events once it commits.
+func (c *ReversalConsumer) processBatch(ctx context.Context, messages []kafka.Message) error {
+	var (
+		completed, failed              []*events.Reversal
+		appliedTopics, duplicateTopics []string
+	)
+
+	tx, err := c.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, msg := range messages {
+		var event events.Reversal
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return dlq.Poison(fmt.Errorf("failed to unmarshal event: %w", err))
+		}
+		if event.TransactionID == "" {
+			return dlq.Poison(fmt.Errorf("reversal of transaction %s has no transaction ID", event.OriginalTransactionID))
+		}
+
+		first, err := markProcessed(ctx, tx, event.TransactionID, ledger.KindReversal)
+		if err != nil {
+			return fmt.Errorf("failed to record transaction %s as processed: %w", event.TransactionID, err)
+		}
+		if !first {
+			log.Printf("Skipping reversal %s, it was applied already", event.TransactionID)
+			duplicateTopics = append(duplicateTopics, msg.Topic)
+			continue
+		}
+
+		amount, err := event.Money()
+		if err != nil || amount <= 0 {
+			log.Printf("Invalid amount for transaction %s: %v", event.TransactionID, err)
+			event.FailureReason = FAILURE_AMOUNT_INVALID
+			if event.UserID, err = walletOwner(ctx, tx, event.WalletID); err != nil {
+				return fmt.Errorf("failed to find the owner of wallet %s: %w", event.WalletID, err)
+			}
+			failed = append(failed, &event)
+			continue
+		}
+		event.SetMoney(amount)
+
+		event.FailureReason, err = debitReversal(ctx, tx, &event, amount)
+		if err != nil {
+			return fmt.Errorf("failed to debit wallet for transaction %s: %w", event.TransactionID, err)
+		}
+		if event.FailureReason != "" {
+			log.Printf("Refused %s %s of transaction %s: %s", event.Type, event.TransactionID, event.OriginalTransactionID, event.FailureReason)
+			failed = append(failed, &event)
+			continue
+		}
+
+		appliedTopics = append(appliedTopics, msg.Topic)
+		completed = append(completed, &event)
+	}
+
+	for _, event := range failed {
+		if err := markFailed(ctx, tx, event.TransactionID, event.FailureReason); err != nil {
+			return fmt.Errorf("failed to record transaction %s as failed: %w", event.TransactionID, err)
+		}
+	}
+
+	if err := c.outcomeProducer.PublishReversalOutcomes(ctx, tx, completed, failed); err != nil {
+		return fmt.Errorf("failed to publish outcome events: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	countEvents(metrics.AppliedEvents, appliedTopics)
+	countEvents(metrics.DuplicateEvents, duplicateTopics)
+
+	return nil
+}
+
+// debitReversal locks the wallet and posts the reversal, or returns why it was
+// refused. Wallets only close empty, so a closed one has nothing left to take
+// back.
+func debitReversal(ctx context.Context, tx pgx.Tx, event *events.Reversal, amount money.Amount) (string, error) {
+	var (
+		status    wallet.Status
+		available money.Amount
+		currency  money.Currency
+	)
+	err := tx.QueryRow(ctx, "SELECT user_id, status, balance - held_balance, currency FROM wallets WHERE id = $1 FOR UPDATE", event.WalletID).Scan(&event.UserID, &status, &available, &currency)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return FAILURE_WALLET_NOT_FOUND, nil
+	case err != nil:
+		return "", err
+	case status == wallet.StatusClosed:
+		return FAILURE_WALLET_CLOSED, nil
+	}
+	if reason := currencyFailure(event.Currency, currency, amount); reason != "" {
+		return reason, nil
+	}
+	event.Currency = string(currency)
+	event.SetMoney(amount)
+	if available < amount {
+		return FAILURE_INSUFFICIENT_FUNDS, nil
+	}
+
+	_, err = ledger.Post(ctx, tx, ledger.Reversal(event.WalletID, event.TransactionID, amount, currency))
+	return "", err
+}
+
+func (c *ReversalConsumer) Close() {
+	if err := c.reader.Close(); err != nil {
+		log.Printf("Failed to close Kafka reader: %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"log"
+	"wallet/internal/dlq"
+	"wallet/internal/events"
+	"wallet/internal/ledger"
+	"wallet/internal/metrics"
+	"wallet/internal/money"
+	"wallet/internal/producers"
+	"wallet/internal/wallet"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	TRANSFER_SENT_EMAIL_TEMPLATE     = "transfer_sent"
+	TRANSFER_RECEIVED_EMAIL_TEMPLATE = "transfer_received"
+)
+
+// Reasons published with failed transfers, next to the withdrawal ones.
+const (
+	FAILURE_DESTINATION_NOT_FOUND = "destination_not_found"
+	FAILURE_DESTINATION_CLOSED    = "destination_closed"
+)
+
+type TransferConsumer struct {
+	reader          *kafka.Reader
+	db              TxBeginner
+	outcomeProducer producers.TransferOutcomeProducer
+	notifyProducer  producers.NotificationProducer
+	deadLetters     *dlq.Handler
+	batchSize       int
+}
+
+func NewTransferConsumer(db TxBeginner, cfg *Config, outcomeProducer producers.TransferOutcomeProducer, notifyProducer producers.NotificationProducer, deadLetters *dlq.Handler) *TransferConsumer {
+	return &TransferConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:        cfg.Brokers,
+			Topic:          cfg.Topic,
+			GroupID:        cfg.GroupID,
+			MinBytes:       cfg.MinBytes,
+			MaxBytes:       cfg.MaxBytes,
+			CommitInterval: cfg.CommitInterval,
+		}),
+		db:              db,
+		outcomeProducer: outcomeProducer,
+		notifyProducer:  notifyProducer,
+		deadLetters:     deadLetters,
+		batchSize:       cfg.BatchSize,
+	}
+}
+
+// Consume processes transfer_initiated messages. Both wallets live in this
+// database, so the debit and the credit of a transfer are applied in the same
+// database transaction and either both happen or neither does.
+func (c *TransferConsumer) Consume(ctx context.Context) {
+	log.Printf("Starting Kafka consumer for topic: %s with batch size: %d", c.reader.Config().Topic, c.batchSize)
+
+	for {
+		messages, err := fetchBatch(ctx, c.reader, c.batchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				log.Printf("Consumer stopped: %v", ctx.Err())
+				return
+			}
+			log.Printf("Error fetching batch: %v", err)
+			continue
+		}
+		if len(messages) == 0 {
+			if ctx.Err() != nil {
+				log.Printf("Consumer stopped: %v", ctx.Err())
+				return
+			}
+			continue
+		}
+
+		// See Consumer.Consume, a fetched batch is always finished.
+		batchCtx := context.WithoutCancel(ctx)
+
+		if !c.deadLetters.HandleUntilDone(ctx, messages, c.processBatch) {
+			log.Printf("Consumer stopped with a batch of %d messages uncommitted: %v", len(messages), ctx.Err())
+			return
+		}
+
+		if err := c.reader.CommitMessages(batchCtx, messages...); err != nil {
+			log.Printf("Failed to commit batch of %d messages: %v", len(messages), err)
+		} else {
+			log.Printf("Committed batch of %d messages", len(messages))
+		}
+	}
+}
+
+// processBatch applies a batch of transfers in a single database transaction,
+// along with their outcome and notification events in the outbox. Each transfer
+// is recorded in processed_transactions under its debit transaction, so a
+// redelivered one is skipped instead of moving the money twice.
+func (c *TransferConsumer) processBatch(ctx context.Context, messages []kafka.Message) error {
+	var (
+		completed, failed              []*events.Transfer
+		appliedTopics, duplicateTopics []string
+	)
+
+	tx, err := c.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, msg := range messages {
+		var event events.Transfer
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return dlq.Poison(fmt.Errorf("failed to unmarshal event: %w", err))
+		}
+		if event.DebitTransactionID == "" {
+			return dlq.Poison(fmt.Errorf("transfer %s has no debit transaction ID", event.TransferID))
+		}
+
+		first, err := markProcessed(ctx, tx, event.DebitTransactionID, ledger.KindTransfer)
+		if err != nil {
+			return fmt.Errorf("failed to record transaction %s as processed: %w", event.DebitTransactionID, err)
+		}
+		if !first {
+			log.Printf("Skipping transfer %s, it was applied already", event.TransferID)
+			duplicateTopics = append(duplicateTopics, msg.Topic)
+			continue
+		}
+
+		if err := applyTransfer(ctx, tx, &event); err != nil {
+			return fmt.Errorf("failed to apply transfer %s: %w", event.TransferID, err)
+		}
+
+		if event.FailureReason != "" {
+			log.Printf("Refused transfer %s: %s", event.TransferID, event.FailureReason)
+			failed = append(failed, &event)
+			continue
+		}
+		appliedTopics = append(appliedTopics, msg.Topic)
+		completed = append(completed, &event)
+	}
+
+	for _, event := range failed {
+		if err := markFailed(ctx, tx, event.DebitTransactionID, event.FailureReason); err != nil {
+			return fmt.Errorf("failed to record transaction %s as failed: %w", event.DebitTransactionID, err)
+		}
+	}
+
+	if err := c.outcomeProducer.PublishTransferOutcomes(ctx, tx, completed, failed); err != nil {
+		return fmt.Errorf("failed to publish outcome events: %w", err)
+	}
+	if err := c.notifyProducer.PublishNotificationEvents(ctx, tx, transferNotifications(completed)); err != nil {
+		return fmt.Errorf("failed to publish notification events: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	countEvents(metrics.AppliedEvents, appliedTopics)
+	countEvents(metrics.DuplicateEvents, duplicateTopics)
+
+	return nil
+}
+
+type transferWallet struct {
+	userID int
+	status wallet.Status
+	// available is the balance less its holds.
+	available money.Amount
+	currency  money.Currency
+}
+
+// applyTransfer moves the amount between the wallets, or sets FailureReason and
+// leaves them untouched. Both rows are locked in ID order first, so concurrent
+// transfers in opposite directions can't deadlock.
+func applyTransfer(ctx context.Context, tx pgx.Tx, event *events.Transfer) error {
+	amount, err := event.Money()
+	if err != nil || amount <= 0 {
+		event.FailureReason = FAILURE_AMOUNT_INVALID
+		if event.UserID, err = walletOwner(ctx, tx, event.SourceWalletID); err != nil {
+			return err
+		}
+		event.DestinationUserID, err = walletOwner(ctx, tx, event.DestinationWalletID)
+		return err
+	}
+	event.SetMoney(amount)
+
+	rows, err := tx.Query(ctx,
+		"SELECT id, user_id, status, balance - held_balance, currency FROM wallets WHERE id = ANY($1) ORDER BY id FOR UPDATE",
+		[]string{event.SourceWalletID, event.DestinationWalletID},
+	)
+	if err != nil {
+		return err
+	}
+	wallets := make(map[string]transferWallet, 2)
+	for rows.Next() {
+		var (
+			id string
+			w  transferWallet
+		)
+		if err := rows.Scan(&id, &w.userID, &w.status, &w.available, &w.currency); err != nil {
+			rows.Close()
+			return err
+		}
+		wallets[id] = w
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	source, sourceFound := wallets[event.SourceWalletID]
+	destination, destinationFound := wallets[event.DestinationWalletID]
+	event.UserID, event.DestinationUserID = source.userID, destination.userID
+
+	switch {
+	case !sourceFound:
+		event.FailureReason = FAILURE_WALLET_NOT_FOUND
+	case !destinationFound:
+		event.FailureReason = FAILURE_DESTINATION_NOT_FOUND
+	case source.status == wallet.StatusClosed:
+		event.FailureReason = FAILURE_WALLET_CLOSED
+	case destination.status == wallet.StatusClosed:
+		event.FailureReason = FAILURE_DESTINATION_CLOSED
+	case destination.currency != source.currency:
+		// Transfers never convert, both wallets hold the same currency.
+		event.FailureReason = FAILURE_CURRENCY_MISMATCH
+	default:
+		event.FailureReason = currencyFailure(event.Currency, source.currency, amount)
+	}
+	if event.FailureReason == "" && source.available < amount {
+		event.FailureReason = FAILURE_INSUFFICIENT_FUNDS
+	}
+	if event.FailureReason != "" {
+		return nil
+	}
+	event.Currency = string(source.currency)
+	event.SetMoney(amount)
+
+	_, err = ledger.Post(ctx, tx, ledger.Transfer(event.SourceWalletID, event.DebitTransactionID, event.DestinationWalletID, event.CreditTransactionID, amount, source.currency))
+	return err
+}
+
+// transferNotifications tells both parties of each completed transfer.
+func transferNotifications(transfers []*events.Transfer) []*events.Notification {
+	notifications := make([]*events.Notification, 0, 2*len(transfers))
+	for _, t := range transfers {
+		notifications = append(notifications,
+			transferNotification(t, TRANSFER_SENT_EMAIL_TEMPLATE, t.SourceWalletID, t.DebitTransactionID, t.UserID),
+			transferNotification(t, TRANSFER_RECEIVED_EMAIL_TEMPLATE, t.DestinationWalletID, t.CreditTransactionID, t.DestinationUserID),
+		)
+	}
+	return notifications
+}
+
+func transferNotification(t *events.Transfer, template, walletID, transactionID string, userID int) *events.Notification {
+	return &events.Notification{
+		Channel: NOTIFICATION_CHANNEL,
+		Data: map[string]any{
+			"wallet_id":      walletID,
+			"amount":         t.Amount,
+			"minor_units":    t.MinorUnits,
+			"currency":       t.Currency,
+			"transaction_id": transactionID,
+			"transfer_id":    t.TransferID,
+			"user_id":        userID,
+			"template":       template,
+		},
+	}
+}
+
+func (c *TransferConsumer) Close() {
+	if err := c.reader.Close(); err != nil {
+		log.Printf("Failed to close Kafka reader: %v", err)
+	}
+}
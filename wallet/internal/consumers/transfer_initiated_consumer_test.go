@@ -0,0 +1,117 @@
+package consumers
+
+import (
+	"context"
+	"testing"
+	"wallet/internal/events"
+	"wallet/internal/ledger"
+	"wallet/internal/metrics"
+	"wallet/internal/money"
+	"wallet/internal/wallet"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingTransferOutcomes implements producers.TransferOutcomeProducer.
+type recordingTransferOutcomes struct {
+	completed, failed []*events.Transfer
+}
+
+func (p *recordingTransferOutcomes) PublishTransferOutcomes(ctx context.Context, tx pgx.Tx, completed, failed []*events.Transfer) error {
+	p.completed = append(p.completed, completed...)
+	p.failed = append(p.failed, failed...)
+	return nil
+}
+
+func TestTransferProcessBatch(t *testing.T) {
+	t.Parallel()
+
+	transfer := events.Transfer{
+		TransferID:          "tr1",
+		SourceWalletID:      "w1",
+		DestinationWalletID: "w2",
+		MinorUnits:          2500,
+		Currency:            "EUR",
+		DebitTransactionID:  "t1",
+		CreditTransactionID: "t2",
+	}
+
+	testCases := []struct {
+		name                       string
+		topic                      string
+		batches                    [][]events.Transfer
+		expectedSourceBalance      money.Amount
+		expectedDestinationBalance money.Amount
+		expectedCompleted          int
+		expectedFailed             []string
+		expectedApplied            int64
+		expectedDuplicates         int64
+	}{
+		{
+			name:                       "when a transfer is applied, it should move the amount and count it",
+			topic:                      "transfer_initiated.applied",
+			batches:                    [][]events.Transfer{{transfer}},
+			expectedSourceBalance:      money.FromMinor(7500),
+			expectedDestinationBalance: money.FromMinor(2500),
+			expectedCompleted:          1,
+			expectedApplied:            1,
+		},
+		{
+			name:                       "when a transfer is redelivered, it should move the amount once and count the duplicate",
+			topic:                      "transfer_initiated.redelivered",
+			batches:                    [][]events.Transfer{{transfer}, {transfer}},
+			expectedSourceBalance:      money.FromMinor(7500),
+			expectedDestinationBalance: money.FromMinor(2500),
+			expectedCompleted:          1,
+			expectedApplied:            1,
+			expectedDuplicates:         1,
+		},
+		{
+			name:  "when the destination wallet doesn't exist, it should refuse the transfer without counting it as applied",
+			topic: "transfer_initiated.refused",
+			batches: [][]events.Transfer{{
+				{TransferID: "tr2", SourceWalletID: "w1", DestinationWalletID: "w3", AmountMinor: 2500, DebitTransactionID: "t3", CreditTransactionID: "t4"},
+			}},
+			expectedSourceBalance: money.FromMinor(10000),
+			expectedFailed:        []string{FAILURE_DESTINATION_NOT_FOUND},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newFakeDB(map[string]fakeWallet{
+				"w1": {userID: 1, status: wallet.StatusActive, balance: money.FromMinor(10000), currency: "EUR"},
+				"w2": {userID: 2, status: wallet.StatusActive, currency: "EUR"},
+			})
+			outcomes := &recordingTransferOutcomes{}
+			c := &TransferConsumer{db: db, outcomeProducer: outcomes, notifyProducer: discardNotifications{}}
+
+			for _, batch := range tc.batches {
+				var messages []kafka.Message
+				for _, event := range batch {
+					messages = append(messages, eventMessage(t, tc.topic, event))
+				}
+				assert.NoError(t, c.processBatch(context.Background(), messages))
+			}
+
+			assert.Equal(t, tc.expectedSourceBalance, db.state.wallets["w1"].balance)
+			assert.Equal(t, tc.expectedDestinationBalance, db.state.wallets["w2"].balance)
+			assert.Len(t, outcomes.completed, tc.expectedCompleted)
+			var failures []string
+			for _, event := range outcomes.failed {
+				failures = append(failures, event.FailureReason)
+				assert.Equal(t, fakeProcessed{kind: ledger.KindTransfer, status: "FAILED", failureReason: event.FailureReason}, db.state.processed[event.DebitTransactionID])
+			}
+			assert.Equal(t, tc.expectedFailed, failures)
+			if tc.expectedCompleted > 0 {
+				assert.Equal(t, []ledger.Kind{ledger.KindTransfer}, db.journals())
+			} else {
+				assert.Empty(t, db.journals())
+			}
+			assert.Equal(t, tc.expectedApplied, counted(metrics.AppliedEvents, tc.topic))
+			assert.Equal(t, tc.expectedDuplicates, counted(metrics.DuplicateEvents, tc.topic))
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"log"
+	"wallet/internal/dlq"
+	"wallet/internal/events"
+	"wallet/internal/ledger"
+	"wallet/internal/metrics"
+	"wallet/internal/money"
+	"wallet/internal/producers"
+	"wallet/internal/wallet"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	WITHDRAW_EMAIL_TEMPLATE        = "withdraw"
+	WITHDRAW_FAILED_EMAIL_TEMPLATE = "withdraw_failed"
+)
+
+// Reasons published with failed withdrawals and deposits.
+const (
+	FAILURE_AMOUNT_INVALID     = "amount_invalid"
+	FAILURE_WALLET_NOT_FOUND   = "wallet_not_found"
+	FAILURE_WALLET_CLOSED      = "wallet_closed"
+	FAILURE_INSUFFICIENT_FUNDS = "insufficient_funds"
+	FAILURE_CURRENCY_MISMATCH  = "currency_mismatch"
+	// FAILURE_CONSTRAINT_VIOLATION is a deposit the database refused.
+	FAILURE_CONSTRAINT_VIOLATION = "constraint_violation"
+)
+
+// currencyFailure checks an amount against the currency of the wallet it moves
+// and returns why it is refused, if it is. Events without a currency predate
+// currencies and are in the wallet's.
+func currencyFailure(currency string, walletCurrency money.Currency, amount money.Amount) string {
+	c := walletCurrency
+	if currency != "" {
+		var err error
+		if c, err = money.ParseCurrency(currency); err != nil {
+			return FAILURE_AMOUNT_INVALID
+		}
+	}
+	switch {
+	case c != walletCurrency:
+		return FAILURE_CURRENCY_MISMATCH
+	case walletCurrency.Check(amount) != nil:
+		return FAILURE_AMOUNT_INVALID
+	}
+	return ""
+}
+
+type WithdrawConsumer struct {
+	reader          *kafka.Reader
+	db              TxBeginner
+	outcomeProducer producers.WithdrawOutcomeProducer
+	notifyProducer  producers.NotificationProducer
+	deadLetters     *dlq.Handler
+	batchSize       int
+}
+
+func NewWithdrawConsumer(db TxBeginner, cfg *Config, outcomeProducer producers.WithdrawOutcomeProducer, notifyProducer producers.NotificationProducer, deadLetters *dlq.Handler) *WithdrawConsumer {
+	return &WithdrawConsumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:        cfg.Brokers,
+			Topic:          cfg.Topic,
+			GroupID:        cfg.GroupID,
+			MinBytes:       cfg.MinBytes,
+			MaxBytes:       cfg.MaxBytes,
+			CommitInterval: cfg.CommitInterval,
+		}),
+		db:              db,
+		outcomeProducer: outcomeProducer,
+		notifyProducer:  notifyProducer,
+		deadLetters:     deadLetters,
+		batchSize:       cfg.BatchSize,
+	}
+}
+
+// Consume processes withdraw_initiated messages, debiting each wallet or
+// refusing the withdrawal, and publishes the outcome of every one through the
+// outbox.
+func (c *WithdrawConsumer) Consume(ctx context.Context) {
+	log.Printf("Starting Kafka consumer for topic: %s with batch size: %d", c.reader.Config().Topic, c.batchSize)
+
+	for {
+		messages, err := fetchBatch(ctx, c.reader, c.batchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				log.Printf("Consumer stopped: %v", ctx.Err())
+				return
+			}
+			log.Printf("Error fetching batch: %v", err)
+			continue
+		}
+		if len(messages) == 0 {
+			if ctx.Err() != nil {
+				log.Printf("Consumer stopped: %v", ctx.Err())
+				return
+			}
+			continue
+		}
+
+		// See Consumer.Consume, a fetched batch is always finished.
+		batchCtx := context.WithoutCancel(ctx)
+
+		if !c.deadLetters.HandleUntilDone(ctx, messages, c.processBatch) {
+			log.Printf("Consumer stopped with a batch of %d messages uncommitted: %v", len(messages), ctx.Err())
+			return
+		}
+
+		if err := c.reader.CommitMessages(batchCtx, messages...); err != nil {
+			log.Printf("Failed to commit batch of %d messages: %v", len(messages), err)
+		} else {
+			log.Printf("Committed batch of %d messages", len(messages))
+		}
+	}
+}
+
+// processBatch applies a batch of withdrawals in a single database transaction.
+// Available funds, the balance less its holds, are checked on the locked wallet
+// before posting, so a refused withdrawal never violates the wallets checks or
+// aborts the batch. Like deposits, each withdrawal is recorded in
+// processed_transactions, so a redelivered one is skipped instead of debited
+// twice. The outcome of every withdrawal is written to the outbox in the same
+// transaction.
+func (c *WithdrawConsumer) processBatch(ctx context.Context, messages []kafka.Message) error {
+	var (
+		completed, failed              []*events.Withdrawal
+		appliedTopics, duplicateTopics []string
+	)
+
+	tx, err := c.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, msg := range messages {
+		var event events.Withdrawal
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			return dlq.Poison(fmt.Errorf("failed to unmarshal event: %w", err))
+		}
+		if event.TransactionID == "" {
+			return dlq.Poison(fmt.Errorf("withdrawal from wallet %s has no transaction ID", event.WalletID))
+		}
+
+		first, err := markProcessed(ctx, tx, event.TransactionID, ledger.KindWithdraw)
+		if err != nil {
+			return fmt.Errorf("failed to record transaction %s as processed: %w", event.TransactionID, err)
+		}
+		if !first {
+			log.Printf("Skipping withdrawal %s, it was applied already", event.TransactionID)
+			duplicateTopics = append(duplicateTopics, msg.Topic)
+			continue
+		}
+
+		amount, err := event.Money()
+		if err != nil || amount <= 0 {
+			log.Printf("Invalid amount for transaction %s: %v", event.TransactionID, err)
+			event.FailureReason = FAILURE_AMOUNT_INVALID
+			if event.UserID, err = walletOwner(ctx, tx, event.WalletID); err != nil {
+				return fmt.Errorf("failed to find the owner of wallet %s: %w", event.WalletID, err)
+			}
+			failed = append(failed, &event)
+			continue
+		}
+		event.SetMoney(amount)
+
+		event.FailureReason, err = debitWithdrawal(ctx, tx, &event, amount)
+		if err != nil {
+			return fmt.Errorf("failed to debit wallet for transaction %s: %w", event.TransactionID, err)
+		}
+
+		if event.FailureReason != "" {
+			log.Printf("Refused withdrawal %s: %s", event.TransactionID, event.FailureReason)
+			failed = append(failed, &event)
+			continue
+		}
+		appliedTopics = append(appliedTopics, msg.Topic)
+		completed = append(completed, &event)
+	}
+
+	for _, event := range failed {
+		if err := markFailed(ctx, tx, event.TransactionID, event.FailureReason); err != nil {
+			return fmt.Errorf("failed to record transaction %s as failed: %w", event.TransactionID, err)
+		}
+	}
+
+	if err := c.outcomeProducer.PublishWithdrawOutcomes(ctx, tx, completed, failed); err != nil {
+		return fmt.Errorf("failed to publish outcome events: %w", err)
+	}
+	if err := c.notifyProducer.PublishNotificationEvents(ctx, tx, withdrawNotifications(completed, failed)); err != nil {
+		return fmt.Errorf("failed to publish notification events: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	countEvents(metrics.AppliedEvents, appliedTopics)
+	countEvents(metrics.DuplicateEvents, duplicateTopics)
+
+	return nil
+}
+
+// debitWithdrawal locks the wallet and posts the withdrawal, or returns why it
+// was refused.
+func debitWithdrawal(ctx context.Context, tx pgx.Tx, event *events.Withdrawal, amount money.Amount) (string, error) {
+	var (
+		status    wallet.Status
+		available money.Amount
+		currency  money.Currency
+	)
+	err := tx.QueryRow(ctx, "SELECT user_id, status, balance - held_balance, currency FROM wallets WHERE id = $1 FOR UPDATE", event.WalletID).Scan(&event.UserID, &status, &available, &currency)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return FAILURE_WALLET_NOT_FOUND, nil
+	case err != nil:
+		return "", err
+	case status == wallet.StatusClosed:
+		return FAILURE_WALLET_CLOSED, nil
+	}
+	if reason := currencyFailure(event.Currency, currency, amount); reason != "" {
+		return reason, nil
+	}
+	event.Currency = string(currency)
+	event.SetMoney(amount)
+	if available < amount {
+		return FAILURE_INSUFFICIENT_FUNDS, nil
+	}
+
+	_, err = ledger.Post(ctx, tx, ledger.Withdrawal(event.WalletID, event.TransactionID, amount, currency))
+	return "", err
+}
+
+// withdrawNotifications tells the owner of each wallet about its withdrawal,
+// applied or refused.
+func withdrawNotifications(completed, failed []*events.Withdrawal) []*events.Notification {
+	notifications := make([]*events.Notification, 0, len(completed)+len(failed))
+	for _, w := range completed {
+		notifications = append(notifications, withdrawNotification(w, WITHDRAW_EMAIL_TEMPLATE))
+	}
+	for _, w := range failed {
+		n := withdrawNotification(w, WITHDRAW_FAILED_EMAIL_TEMPLATE)
+		n.Data["failure_reason"] = w.FailureReason
+		notifications = append(notifications, n)
+	}
+	return notifications
+}
+
+func withdrawNotification(w *events.Withdrawal, template string) *events.Notification {
+	return &events.Notification{
+		Channel: NOTIFICATION_CHANNEL,
+		Data: map[string]any{
+			"wallet_id":      w.WalletID,
+			"amount":         w.Amount,
+			"minor_units":    w.MinorUnits,
+			"currency":       w.Currency,
+			"transaction_id": w.TransactionID,
+			"user_id":        w.UserID,
+			"template":       template,
+		},
+	}
+}
+
+func (c *WithdrawConsumer) Close() {
+	if err := c.reader.Close(); err != nil {
+		log.Printf("Failed to close Kafka reader: %v", err)
+	}
+}
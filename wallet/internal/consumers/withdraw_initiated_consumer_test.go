@@ -0,0 +1,142 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"wallet/internal/events"
+	"wallet/internal/ledger"
+	"wallet/internal/metrics"
+	"wallet/internal/money"
+	"wallet/internal/wallet"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingWithdrawOutcomes implements producers.WithdrawOutcomeProducer.
+type recordingWithdrawOutcomes struct {
+	completed, failed []*events.Withdrawal
+}
+
+func (p *recordingWithdrawOutcomes) PublishWithdrawOutcomes(ctx context.Context, tx pgx.Tx, completed, failed []*events.Withdrawal) error {
+	p.completed = append(p.completed, completed...)
+	p.failed = append(p.failed, failed...)
+	return nil
+}
+
+// discardNotifications implements producers.NotificationProducer.
+type discardNotifications struct{}
+
+func (discardNotifications) PublishNotificationEvents(ctx context.Context, tx pgx.Tx, events []*events.Notification) error {
+	return nil
+}
+
+func eventMessage(t *testing.T, topic string, event any) kafka.Message {
+	value, err := json.Marshal(event)
+	assert.NoError(t, err)
+	return kafka.Message{Topic: topic, Value: value}
+}
+
+func TestWithdrawProcessBatch(t *testing.T) {
+	t.Parallel()
+
+	withdrawal := events.Withdrawal{WalletID: "w1", MinorUnits: 2500, Currency: "EUR", TransactionID: "t1"}
+
+	testCases := []struct {
+		name               string
+		topic              string
+		batches            [][]events.Withdrawal
+		expectedBalance    money.Amount
+		expectedCompleted  int
+		expectedFailed     []string
+		expectedTemplates  []any
+		expectedApplied    int64
+		expectedDuplicates int64
+	}{
+		{
+			name:              "when a withdrawal is applied, it should debit the wallet and count it",
+			topic:             "withdraw_initiated.applied",
+			batches:           [][]events.Withdrawal{{withdrawal}},
+			expectedBalance:   money.FromMinor(7500),
+			expectedCompleted: 1,
+			expectedTemplates: []any{WITHDRAW_EMAIL_TEMPLATE},
+			expectedApplied:   1,
+		},
+		{
+			name:               "when a withdrawal is redelivered, it should debit the wallet once and count the duplicate",
+			topic:              "withdraw_initiated.redelivered",
+			batches:            [][]events.Withdrawal{{withdrawal}, {withdrawal}},
+			expectedBalance:    money.FromMinor(7500),
+			expectedCompleted:  1,
+			expectedTemplates:  []any{WITHDRAW_EMAIL_TEMPLATE},
+			expectedApplied:    1,
+			expectedDuplicates: 1,
+		},
+		{
+			name:               "when a batch holds the same withdrawal twice, it should debit the wallet once",
+			topic:              "withdraw_initiated.twice",
+			batches:            [][]events.Withdrawal{{withdrawal, withdrawal}},
+			expectedBalance:    money.FromMinor(7500),
+			expectedCompleted:  1,
+			expectedTemplates:  []any{WITHDRAW_EMAIL_TEMPLATE},
+			expectedApplied:    1,
+			expectedDuplicates: 1,
+		},
+		{
+			name:  "when a withdrawal exceeds available funds, it should refuse it, notify its owner and not count it as applied",
+			topic: "withdraw_initiated.refused",
+			batches: [][]events.Withdrawal{{
+				{WalletID: "w1", MinorUnits: 20000, Currency: "EUR", TransactionID: "t2"},
+			}},
+			expectedBalance:   money.FromMinor(10000),
+			expectedFailed:    []string{FAILURE_INSUFFICIENT_FUNDS},
+			expectedTemplates: []any{WITHDRAW_FAILED_EMAIL_TEMPLATE},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newFakeDB(map[string]fakeWallet{
+				"w1": {userID: 1, status: wallet.StatusActive, balance: money.FromMinor(10000), currency: "EUR"},
+			})
+			outcomes := &recordingWithdrawOutcomes{}
+			notifications := &recordingNotifications{}
+			c := &WithdrawConsumer{db: db, outcomeProducer: outcomes, notifyProducer: notifications}
+
+			for _, batch := range tc.batches {
+				var messages []kafka.Message
+				for _, event := range batch {
+					messages = append(messages, eventMessage(t, tc.topic, event))
+				}
+				assert.NoError(t, c.processBatch(context.Background(), messages))
+			}
+
+			assert.Equal(t, tc.expectedBalance, db.state.wallets["w1"].balance)
+			assert.Len(t, outcomes.completed, tc.expectedCompleted)
+			var failures []string
+			for _, event := range outcomes.failed {
+				failures = append(failures, event.FailureReason)
+				assert.Equal(t, fakeProcessed{kind: ledger.KindWithdraw, status: "FAILED", failureReason: event.FailureReason}, db.state.processed[event.TransactionID])
+			}
+			assert.Equal(t, tc.expectedFailed, failures)
+			if tc.expectedCompleted > 0 {
+				assert.Equal(t, []ledger.Kind{ledger.KindWithdraw}, db.journals())
+			} else {
+				assert.Empty(t, db.journals())
+			}
+			var templates []any
+			for _, n := range notifications.notifications {
+				templates = append(templates, n.Data["template"])
+				assert.Equal(t, 1, n.Data["user_id"])
+				if n.Data["template"] == WITHDRAW_FAILED_EMAIL_TEMPLATE {
+					assert.Equal(t, tc.expectedFailed[0], n.Data["failure_reason"])
+				}
+			}
+			assert.Equal(t, tc.expectedTemplates, templates)
+			assert.Equal(t, tc.expectedApplied, counted(metrics.AppliedEvents, tc.topic))
+			assert.Equal(t, tc.expectedDuplicates, counted(metrics.DuplicateEvents, tc.topic))
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Reader fetches and commits messages, implemented by kafka.Reader.
+type Reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// NewReplayReader reads a dead-letter topic in its own group, so that a
+// message replayed once isn't replayed again.
+func NewReplayReader(brokers []string, topic, group string) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  brokers,
+		Topic:    topic,
+		GroupID:  group + "-dlq-replay",
+		MinBytes: 1,
+		MaxBytes: 10e6, // 10MB
+	})
+}
+
+// Replay re-publishes dead-lettered messages to the topics they failed on and
+// commits each once re-published, so their consumers process them again. It
+// stops after limit messages, 0 for no limit, or once none has arrived for
+// idle, and returns how many it replayed.
+func Replay(ctx context.Context, reader Reader, writer Writer, limit int, idle time.Duration) (int, error) {
+	replayed := 0
+	for limit == 0 || replayed < limit {
+		fetchCtx, cancel := context.WithTimeout(ctx, idle)
+		letter, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return replayed, nil
+		}
+		if err != nil {
+			return replayed, fmt.Errorf("failed to fetch dead-lettered message: %w", err)
+		}
+
+		msg := replayable(letter)
+		if err := writer.WriteMessages(ctx, msg); err != nil {
+			return replayed, fmt.Errorf("failed to replay message %s/%d@%d to %s: %w", letter.Topic, letter.Partition, letter.Offset, msg.Topic, err)
+		}
+		if err := reader.CommitMessages(ctx, letter); err != nil {
+			// Replayed but not committed, the next replay sends it again.
+			return replayed, fmt.Errorf("failed to commit replayed message %s/%d@%d: %w", letter.Topic, letter.Partition, letter.Offset, err)
+		}
+		replayed++
+	}
+	return replayed, nil
+}
@@ -0,0 +1,63 @@
+// Package errcodes attaches stable error codes to gRPC statuses. The broker reads
+// them from errdetails.ErrorInfo and returns them to clients, so codes must not
+// change once released.
+package errcodes
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+const domain = "wallet.wall-e"
+
+const (
+	InvalidArgument = "invalid_argument"
+	Unauthenticated = "auth.unauthenticated"
+	WalletNotFound  = "wallet.not_found"
+	WalletNameTaken = "wallet.name_taken"
+	WalletClosed    = "wallet.closed"
+	WalletNotEmpty  = "wallet.not_empty"
+	// InsufficientFunds and AmountInvalid are shared with the transaction flow,
+	// the broker maps them the same way whichever service sets them.
+	InsufficientFunds = "wallet.insufficient_funds"
+	AmountInvalid     = "transaction.amount_invalid"
+	HoldNotFound      = "hold.not_found"
+	HoldNotActive     = "hold.not_active"
+	HoldExpired       = "hold.expired"
+	CurrencyInvalid   = "currency.invalid"
+	CurrencyMismatch  = "wallet.currency_mismatch"
+	QuoteNotFound     = "fx.quote_not_found"
+	QuoteNotOpen      = "fx.quote_not_open"
+	QuoteExpired      = "fx.quote_expired"
+	RateUnavailable   = "fx.rate_unavailable"
+)
+
+// Error returns a status error with code and reason attached as ErrorInfo.
+func Error(code codes.Code, reason string, message string) error {
+	return withDetails(status.New(code, message), &errdetails.ErrorInfo{Reason: reason, Domain: domain})
+}
+
+// Invalid returns an InvalidArgument status error listing the invalid fields.
+func Invalid(reason string, message string, violations ...*errdetails.BadRequest_FieldViolation) error {
+	return withDetails(
+		status.New(codes.InvalidArgument, message),
+		&errdetails.ErrorInfo{Reason: reason, Domain: domain},
+		&errdetails.BadRequest{FieldViolations: violations},
+	)
+}
+
+// Violation describes a single invalid request field.
+func Violation(field string, description string) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{Field: field, Description: description}
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		// Details only fail to marshal on programming errors, keep the plain status.
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -1,12 +1,160 @@
 package events
 
+import (
+	"errors"
+	"wallet/internal/money"
+)
+
+// ErrNoCurrency is returned for an amount in minor units of no currency.
+var ErrNoCurrency = errors.New("minor units without a currency")
+
 type Notification struct {
 	Channel string
 	Data    map[string]any
 }
 
+// Deposit is read from deposit_initiated and published, once applied or
+// refused, to deposit_completed or deposit_failed.
 type Deposit struct {
+	WalletID string `json:"wallet_id"`
+	// Amount is the legacy float amount, MinorUnits the exact one in minor
+	// units of Currency, as in the Money message: 500 is 500 JPY but 5.00 EUR.
+	// Producers set both until every consumer reads MinorUnits.
+	Amount     float64 `json:"amount"`
+	MinorUnits int64   `json:"minor_units,omitempty"`
+	// AmountMinor is the exact amount in hundredths whatever the currency, read
+	// from events published before MinorUnits existed.
+	AmountMinor int64 `json:"amount_minor,omitempty"`
+	// Currency is the ISO 4217 code of the amount, the wallet's when empty.
+	Currency      string `json:"currency,omitempty"`
+	TransactionID string `json:"transaction_id"`
+	// UserID is the wallet owner, set once the wallet is found so downstream consumers can route by user.
+	UserID int `json:"user_id,omitempty"`
+	// FailureReason says why a deposit published to deposit_failed was refused, e.g. wallet_closed.
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// Money returns the exact deposit amount, converting the amounts of events
+// published before MinorUnits existed.
+func (d *Deposit) Money() (money.Amount, error) {
+	return eventMoney(d.MinorUnits, d.Currency, d.AmountMinor, d.Amount)
+}
+
+// SetMoney sets the amount fields that downstream consumers read, whichever
+// version they run.
+func (d *Deposit) SetMoney(amount money.Amount) {
+	d.Amount, d.MinorUnits, d.AmountMinor = amount.Float64(), minorUnits(amount, d.Currency), 0
+}
+
+// Withdrawal is read from withdraw_initiated and published, once applied or
+// refused, to withdraw_completed or withdraw_failed.
+type Withdrawal struct {
 	WalletID      string  `json:"wallet_id"`
 	Amount        float64 `json:"amount"`
+	MinorUnits    int64   `json:"minor_units,omitempty"`
+	AmountMinor   int64   `json:"amount_minor,omitempty"`
+	Currency      string  `json:"currency,omitempty"`
 	TransactionID string  `json:"transaction_id"`
+	UserID        int     `json:"user_id,omitempty"`
+	// FailureReason says why a failed withdrawal was refused, e.g. insufficient_funds.
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// Money returns the exact withdrawal amount, see Deposit.Money.
+func (w *Withdrawal) Money() (money.Amount, error) {
+	return eventMoney(w.MinorUnits, w.Currency, w.AmountMinor, w.Amount)
+}
+
+// SetMoney sets the amount fields, see Deposit.SetMoney.
+func (w *Withdrawal) SetMoney(amount money.Amount) {
+	w.Amount, w.MinorUnits, w.AmountMinor = amount.Float64(), minorUnits(amount, w.Currency), 0
+}
+
+// Transfer is read from transfer_initiated and published, once applied or
+// refused, to transfer_completed or transfer_failed.
+type Transfer struct {
+	TransferID          string  `json:"transfer_id"`
+	SourceWalletID      string  `json:"source_wallet_id"`
+	DestinationWalletID string  `json:"destination_wallet_id"`
+	Amount              float64 `json:"amount"`
+	MinorUnits          int64   `json:"minor_units,omitempty"`
+	AmountMinor         int64   `json:"amount_minor,omitempty"`
+	Currency            string  `json:"currency,omitempty"`
+	DebitTransactionID  string  `json:"debit_transaction_id"`
+	CreditTransactionID string  `json:"credit_transaction_id"`
+	// UserID owns the source wallet, DestinationUserID the destination one.
+	UserID            int    `json:"user_id,omitempty"`
+	DestinationUserID int    `json:"destination_user_id,omitempty"`
+	FailureReason     string `json:"failure_reason,omitempty"`
+}
+
+// Money returns the exact transfer amount, see Deposit.Money.
+func (t *Transfer) Money() (money.Amount, error) {
+	return eventMoney(t.MinorUnits, t.Currency, t.AmountMinor, t.Amount)
+}
+
+// SetMoney sets the amount fields, see Deposit.SetMoney.
+func (t *Transfer) SetMoney(amount money.Amount) {
+	t.Amount, t.MinorUnits, t.AmountMinor = amount.Float64(), minorUnits(amount, t.Currency), 0
+}
+
+// Reversal is read from reversal_initiated and published, once applied or
+// refused, to reversal_completed or reversal_failed. Refunds are reversals of
+// part of a deposit, Type tells them apart.
+type Reversal struct {
+	WalletID      string  `json:"wallet_id"`
+	Amount        float64 `json:"amount"`
+	MinorUnits    int64   `json:"minor_units,omitempty"`
+	AmountMinor   int64   `json:"amount_minor,omitempty"`
+	Currency      string  `json:"currency,omitempty"`
+	TransactionID string  `json:"transaction_id"`
+	// OriginalTransactionID is the deposit it takes back.
+	OriginalTransactionID string `json:"original_transaction_id"`
+	// Type is REVERSAL or REFUND.
+	Type          string `json:"type"`
+	UserID        int    `json:"user_id,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// Money returns the exact reversal amount, see Deposit.Money.
+func (r *Reversal) Money() (money.Amount, error) {
+	return eventMoney(r.MinorUnits, r.Currency, r.AmountMinor, r.Amount)
+}
+
+// SetMoney sets the amount fields, see Deposit.SetMoney.
+func (r *Reversal) SetMoney(amount money.Amount) {
+	r.Amount, r.MinorUnits, r.AmountMinor = amount.Float64(), minorUnits(amount, r.Currency), 0
+}
+
+// eventMoney reads minor units of the event's currency, then the hundredths and
+// the float of older events. Minor units are meaningless without a currency.
+func eventMoney(units int64, currency string, hundredths int64, legacy float64) (money.Amount, error) {
+	if units != 0 {
+		if currency == "" {
+			return 0, ErrNoCurrency
+		}
+		c, err := money.ParseCurrency(currency)
+		if err != nil {
+			return 0, err
+		}
+		return money.FromMinorUnits(units, c), nil
+	}
+	if hundredths != 0 {
+		return money.FromMinor(hundredths), nil
+	}
+	return money.FromFloat(legacy)
+}
+
+// minorUnits returns the amount in minor units of the event's currency, or 0,
+// leaving readers the float, when the event names none or the amount isn't a
+// whole number of them.
+func minorUnits(amount money.Amount, currency string) int64 {
+	if currency == "" {
+		return 0
+	}
+	c, err := money.ParseCurrency(currency)
+	if err != nil || c.Check(amount) != nil {
+		return 0
+	}
+	return amount.MinorUnits(c)
 }
@@ -0,0 +1,116 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"wallet/internal/money"
+)
+
+// Provider fetches the current exchange rates.
+type Provider interface {
+	Rates(ctx context.Context) ([]Rate, error)
+}
+
+// NewProvider returns an HTTPProvider for http(s) URLs and a FileProvider for
+// anything else.
+func NewProvider(source string, timeout time.Duration) Provider {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return NewHTTPProvider(source, timeout)
+	}
+	return NewFileProvider(source)
+}
+
+// ratesDocument is what both providers read, the price of one base unit in
+// each other currency:
+//
+//	{"base": "EUR", "as_of": "2025-03-01T16:00:00Z", "rates": {"USD": 1.0845, "GBP": "0.8571"}}
+type ratesDocument struct {
+	Base  string                 `json:"base"`
+	AsOf  time.Time              `json:"as_of"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+// parseRates reads a rates document. Currencies that aren't supported are
+// skipped, a document without as_of is taken to be current.
+func parseRates(r io.Reader, source string) ([]Rate, error) {
+	var doc ratesDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode rates from %s: %w", source, err)
+	}
+	base, err := money.ParseCurrency(doc.Base)
+	if err != nil || doc.Base == "" {
+		return nil, fmt.Errorf("rates from %s need a supported base currency, got %q", source, doc.Base)
+	}
+	if doc.AsOf.IsZero() {
+		doc.AsOf = time.Now()
+	}
+
+	rates := make([]Rate, 0, len(doc.Rates))
+	for code, value := range doc.Rates {
+		to, err := money.ParseCurrency(code)
+		if err != nil || to == base {
+			continue
+		}
+		mid, err := ParsePrice(value.String())
+		if err != nil {
+			return nil, fmt.Errorf("rate %s/%s from %s: %w", base, to, source, err)
+		}
+		rates = append(rates, Rate{From: base, To: to, Mid: mid, Source: source, AsOf: doc.AsOf})
+	}
+	return rates, nil
+}
+
+// FileProvider reads rates from a JSON file, a stand-in for a market data feed.
+type FileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+func (p *FileProvider) Rates(ctx context.Context) ([]Rate, error) {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rates file: %w", err)
+	}
+	defer f.Close()
+
+	return parseRates(f, "file:"+p.path)
+}
+
+// HTTPProvider fetches rates with a GET to url.
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPProvider(url string, timeout time.Duration) *HTTPProvider {
+	return &HTTPProvider{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *HTTPProvider) Rates(ctx context.Context) ([]Rate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rates request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch rates: %s", resp.Status)
+	}
+	return parseRates(resp.Body, p.url)
+}
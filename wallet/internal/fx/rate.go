@@ -0,0 +1,159 @@
+// Package fx prices currency conversions from exchange rates fed by a
+// pluggable provider.
+package fx
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"wallet/internal/money"
+)
+
+// Price is an exchange rate with PriceScale decimal places, 108450000 is
+// 1.0845.
+type Price int64
+
+// PriceScale is the number of decimal places of a price.
+const PriceScale = 8
+
+const priceUnit = 100_000_000
+
+var ErrInvalidPrice = errors.New("invalid exchange rate")
+
+// ParsePrice parses a positive decimal string such as "1.0845". Digits beyond
+// PriceScale are dropped.
+func ParsePrice(s string) (Price, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > PriceScale {
+		frac = frac[:PriceScale]
+	}
+	frac += strings.Repeat("0", PriceScale-len(frac))
+	if whole == "" || strings.ContainsAny(whole+frac, "+-") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPrice, s)
+	}
+	p, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil || p <= 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPrice, s)
+	}
+	return Price(p), nil
+}
+
+// String formats the price without trailing zeros, e.g. "1.0845".
+func (p Price) String() string {
+	s := fmt.Sprintf("%d.%08d", p/priceUnit, p%priceUnit)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// Inverse is the price of the other currency of the pair.
+func (p Price) Inverse() Price {
+	return Price(mulDiv(priceUnit, priceUnit, int64(p)))
+}
+
+// Times chains two prices, EUR/USD times USD/JPY is EUR/JPY.
+func (p Price) Times(q Price) Price {
+	return Price(mulDiv(int64(p), int64(q), priceUnit))
+}
+
+// Less takes bps basis points off the price.
+func (p Price) Less(bps int64) Price {
+	return Price(mulDiv(int64(p), 10_000-bps, 10_000))
+}
+
+// Convert buys the amount at price p, truncated to a hundredth of the target
+// currency.
+func Convert(a money.Amount, p Price) money.Amount {
+	return money.Amount(mulDiv(int64(a), int64(p), priceUnit))
+}
+
+// Fee is bps basis points of the amount, truncated.
+func Fee(a money.Amount, bps int64) money.Amount {
+	return money.Amount(mulDiv(int64(a), bps, 10_000))
+}
+
+// mulDiv returns a*b/c truncated, without overflowing on the product.
+func mulDiv(a, b, c int64) int64 {
+	product := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	return product.Quo(product, big.NewInt(c)).Int64()
+}
+
+// Scan reads a NUMERIC column.
+func (p *Price) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		price, err := ParsePrice(v)
+		*p = price
+		return err
+	case []byte:
+		price, err := ParsePrice(string(v))
+		*p = price
+		return err
+	default:
+		return fmt.Errorf("cannot scan %T into fx.Price", src)
+	}
+}
+
+// Value writes the price to a NUMERIC column as exact text.
+func (p Price) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// Rate is the mid-market price of one unit of From in To.
+type Rate struct {
+	From money.Currency
+	To   money.Currency
+	Mid  Price
+	// Source names the provider the rate came from.
+	Source string
+	AsOf   time.Time
+}
+
+type pair struct {
+	from, to money.Currency
+}
+
+// Table resolves rates between the currencies of a set of rates.
+type Table map[pair]Rate
+
+func NewTable(rates []Rate) Table {
+	t := make(Table, len(rates))
+	for _, r := range rates {
+		t[pair{r.From, r.To}] = r
+	}
+	return t
+}
+
+// Lookup returns the rate from one currency to another, quoted directly, from
+// the inverse pair, or across money.DefaultCurrency, which providers quote
+// every currency against. A crossed rate is as old as the older of its legs.
+func (t Table) Lookup(from, to money.Currency) (Rate, bool) {
+	if r, ok := t.direct(from, to); ok {
+		return r, true
+	}
+	first, ok := t.direct(from, money.DefaultCurrency)
+	if !ok {
+		return Rate{}, false
+	}
+	second, ok := t.direct(money.DefaultCurrency, to)
+	if !ok {
+		return Rate{}, false
+	}
+	crossed := Rate{From: from, To: to, Mid: first.Mid.Times(second.Mid), Source: first.Source, AsOf: first.AsOf}
+	if second.AsOf.Before(crossed.AsOf) {
+		crossed.AsOf = second.AsOf
+	}
+	return crossed, true
+}
+
+func (t Table) direct(from, to money.Currency) (Rate, bool) {
+	if r, ok := t[pair{from, to}]; ok {
+		return r, true
+	}
+	if r, ok := t[pair{to, from}]; ok {
+		return Rate{From: from, To: to, Mid: r.Mid.Inverse(), Source: r.Source, AsOf: r.AsOf}, true
+	}
+	return Rate{}, false
+}
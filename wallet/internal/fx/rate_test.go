@@ -0,0 +1,110 @@
+package fx
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"wallet/internal/money"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePrice(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		input       string
+		expected    Price
+		expectedErr error
+	}{
+		{name: "when the price has decimals, it should scale them", input: "1.0845", expected: 108450000},
+		{name: "when the price is whole, it should scale it", input: "161", expected: 16100000000},
+		{name: "when the price has more than eight decimals, it should drop the rest", input: "0.123456789", expected: 12345678},
+		{name: "when the price is zero, it should return an error", input: "0", expectedErr: ErrInvalidPrice},
+		{name: "when the price is negative, it should return an error", input: "-1.2", expectedErr: ErrInvalidPrice},
+		{name: "when the price isn't a number, it should return an error", input: "abc", expectedErr: ErrInvalidPrice},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			price, err := ParsePrice(tc.input)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedErr == nil {
+				assert.Equal(t, tc.expected, price)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	table := NewTable([]Rate{
+		{From: "EUR", To: "USD", Mid: 108450000, AsOf: now},
+		{From: "EUR", To: "GBP", Mid: 85710000, AsOf: now.Add(-time.Minute)},
+	})
+
+	testCases := []struct {
+		name          string
+		from, to      money.Currency
+		expectedMid   Price
+		expectedAsOf  time.Time
+		expectedFound bool
+	}{
+		{name: "when the pair is quoted, it should return its rate", from: "EUR", to: "USD", expectedMid: 108450000, expectedAsOf: now, expectedFound: true},
+		{name: "when the inverse pair is quoted, it should invert its rate", from: "USD", to: "EUR", expectedMid: 92208390, expectedAsOf: now, expectedFound: true},
+		{
+			name: "when neither currency is the default, it should cross through it as of the older leg",
+			from: "USD", to: "GBP", expectedMid: 79031811, expectedAsOf: now.Add(-time.Minute), expectedFound: true,
+		},
+		{name: "when a currency isn't quoted, it should not find a rate", from: "EUR", to: "JPY"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, ok := table.Lookup(tc.from, tc.to)
+			assert.Equal(t, tc.expectedFound, ok)
+			if ok {
+				assert.Equal(t, tc.expectedMid, rate.Mid)
+				assert.Equal(t, tc.expectedAsOf, rate.AsOf)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		amount   money.Amount
+		price    Price
+		expected money.Amount
+	}{
+		{name: "when the result has fractions of a cent, it should truncate them", amount: 9900, price: 107907750, expected: 10682},
+		{name: "when the amount is large, it should not overflow", amount: 900_000_000_000_000, price: 16142000000, expected: 145_278_000_000_000_000},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Convert(tc.amount, tc.price))
+		})
+	}
+}
+
+func TestParseRates(t *testing.T) {
+	t.Parallel()
+
+	doc := `{"base": "eur", "as_of": "2025-03-01T16:00:00Z", "rates": {"USD": 1.0845, "GBP": "0.8571", "XAU": "0.00035"}}`
+
+	rates, err := parseRates(strings.NewReader(doc), "test")
+
+	assert.NoError(t, err)
+	assert.Len(t, rates, 2)
+	table := NewTable(rates)
+	usd, _ := table.Lookup("EUR", "USD")
+	assert.Equal(t, Price(108450000), usd.Mid)
+	assert.Equal(t, time.Date(2025, 3, 1, 16, 0, 0, 0, time.UTC), usd.AsOf)
+}
@@ -0,0 +1,65 @@
+package fx
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Store keeps the latest rate of each currency pair.
+type Store interface {
+	SaveRates(ctx context.Context, rates []Rate) error
+}
+
+// Refresher copies the provider's rates to the store.
+type Refresher struct {
+	provider Provider
+	store    Store
+	interval time.Duration
+	log      *logrus.Logger
+}
+
+func NewRefresher(provider Provider, store Store, interval time.Duration, log *logrus.Logger) *Refresher {
+	return &Refresher{
+		provider: provider,
+		store:    store,
+		interval: interval,
+		log:      log,
+	}
+}
+
+// Run refreshes the rates right away and then every interval until ctx is
+// cancelled.
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.Refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh fetches and stores the rates once. Failures keep the previous rates,
+// which quotes refuse once they are too old.
+func (r *Refresher) Refresh(ctx context.Context) {
+	rates, err := r.provider.Rates(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.log.WithError(err).Error("failed to fetch exchange rates")
+		}
+		return
+	}
+	if err := r.store.SaveRates(ctx, rates); err != nil {
+		if ctx.Err() == nil {
+			r.log.WithError(err).Error("failed to save exchange rates")
+		}
+		return
+	}
+	r.log.Debugf("Refreshed %d exchange rates", len(rates))
+}
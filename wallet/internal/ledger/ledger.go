@@ -0,0 +1,175 @@
+// Package ledger records every balance movement as a balanced journal of debit
+// and credit postings. Wallet balances are a cache of their account's postings,
+// only ever changed by Post.
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"wallet/internal/money"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrUnbalanced = errors.New("journal debits and credits differ")
+
+// Deposit moves money from outside the platform into a wallet.
+func Deposit(walletID, transactionID string, amount money.Amount, currency money.Currency) Journal {
+	return Journal{Kind: KindDeposit, Currency: currency, Postings: []Posting{
+		{Account: AccountExternalCash, Direction: Debit, Amount: amount, TransactionID: transactionID},
+		{Account: AccountWallet, WalletID: walletID, Direction: Credit, Amount: amount, TransactionID: transactionID},
+	}}
+}
+
+// Withdrawal moves money from a wallet out of the platform.
+func Withdrawal(walletID, transactionID string, amount money.Amount, currency money.Currency) Journal {
+	return Journal{Kind: KindWithdraw, Currency: currency, Postings: []Posting{
+		{Account: AccountWallet, WalletID: walletID, Direction: Debit, Amount: amount, TransactionID: transactionID},
+		{Account: AccountExternalCash, Direction: Credit, Amount: amount, TransactionID: transactionID},
+	}}
+}
+
+// Reversal takes money a deposit brought in back out of its wallet, under the
+// reversal or refund transaction.
+func Reversal(walletID, transactionID string, amount money.Amount, currency money.Currency) Journal {
+	return Journal{Kind: KindReversal, Currency: currency, Postings: []Posting{
+		{Account: AccountWallet, WalletID: walletID, Direction: Debit, Amount: amount, TransactionID: transactionID},
+		{Account: AccountExternalCash, Direction: Credit, Amount: amount, TransactionID: transactionID},
+	}}
+}
+
+// Transfer moves money between two wallets. Each side posts under its own
+// transaction.
+func Transfer(sourceWalletID, debitTransactionID, destinationWalletID, creditTransactionID string, amount money.Amount, currency money.Currency) Journal {
+	return Journal{Kind: KindTransfer, Currency: currency, Postings: []Posting{
+		{Account: AccountWallet, WalletID: sourceWalletID, Direction: Debit, Amount: amount, TransactionID: debitTransactionID},
+		{Account: AccountWallet, WalletID: destinationWalletID, Direction: Credit, Amount: amount, TransactionID: creditTransactionID},
+	}}
+}
+
+// Capture moves the captured part of a hold out of the platform. Holds don't
+// post until they are captured, they only set part of the balance aside.
+func Capture(walletID, holdID string, amount money.Amount, currency money.Currency) Journal {
+	return Journal{Kind: KindCapture, Currency: currency, Postings: []Posting{
+		{Account: AccountWallet, WalletID: walletID, Direction: Debit, Amount: amount, TransactionID: holdID},
+		{Account: AccountExternalCash, Direction: Credit, Amount: amount, TransactionID: holdID},
+	}}
+}
+
+// Leg is one wallet's side of a conversion.
+type Leg struct {
+	WalletID string
+	Amount   money.Amount
+	Currency money.Currency
+}
+
+// Conversion exchanges sell for buy through the fx account, one journal per
+// currency so that each balances on its own. The fee, in the sell currency, is
+// part of sell and goes to the fees account instead of being converted. Both
+// journals post under the quote.
+func Conversion(quoteID string, sell, buy Leg, fee money.Amount) []Journal {
+	sold := Journal{Kind: KindConversion, Currency: sell.Currency, Postings: []Posting{
+		{Account: AccountWallet, WalletID: sell.WalletID, Direction: Debit, Amount: sell.Amount, TransactionID: quoteID},
+		{Account: AccountFX, Direction: Credit, Amount: sell.Amount - fee, TransactionID: quoteID},
+	}}
+	if fee > 0 {
+		sold.Postings = append(sold.Postings, Posting{Account: AccountFees, Direction: Credit, Amount: fee, TransactionID: quoteID})
+	}
+	bought := Journal{Kind: KindConversion, Currency: buy.Currency, Postings: []Posting{
+		{Account: AccountFX, Direction: Debit, Amount: buy.Amount, TransactionID: quoteID},
+		{Account: AccountWallet, WalletID: buy.WalletID, Direction: Credit, Amount: buy.Amount, TransactionID: quoteID},
+	}}
+	return []Journal{sold, bought}
+}
+
+// Validate checks that the journal has positive postings in whole minor units
+// of its currency on known accounts, and that its debits equal its credits.
+func (j Journal) Validate() error {
+	if len(j.Postings) < 2 {
+		return fmt.Errorf("journal needs at least two postings, got %d", len(j.Postings))
+	}
+	if _, err := money.ParseCurrency(string(j.Currency)); err != nil || j.Currency == "" {
+		return fmt.Errorf("journal needs a supported currency, got %q", j.Currency)
+	}
+
+	var sum money.Amount
+	for _, p := range j.Postings {
+		if p.Amount <= 0 {
+			return fmt.Errorf("posting amount must be positive, got %s", p.Amount)
+		}
+		if err := j.Currency.Check(p.Amount); err != nil {
+			return err
+		}
+		if (p.Account == AccountWallet) != (p.WalletID != "") {
+			return fmt.Errorf("only %s postings have a wallet ID", AccountWallet)
+		}
+		switch p.Direction {
+		case Debit:
+			sum += p.Amount
+		case Credit:
+			sum -= p.Amount
+		default:
+			return fmt.Errorf("unknown posting direction %q", p.Direction)
+		}
+	}
+	if sum != 0 {
+		return ErrUnbalanced
+	}
+	return nil
+}
+
+// delta is the change a posting makes to its wallet's balance.
+func (p Posting) delta() money.Amount {
+	if p.Direction == Credit {
+		return p.Amount
+	}
+	return -p.Amount
+}
+
+// Post writes the journal and applies its wallet postings to the cached
+// balances, within tx. A posting that would overdraw a wallet or spend its held
+// balance fails on the wallets checks, callers are expected to check available
+// funds first. The database
+// rejects unbalanced journals again when tx commits.
+func Post(ctx context.Context, tx pgx.Tx, j Journal) (string, error) {
+	if err := j.Validate(); err != nil {
+		return "", err
+	}
+
+	var journalID string
+	if err := tx.QueryRow(ctx, "SELECT gen_random_uuid()::text").Scan(&journalID); err != nil {
+		return "", fmt.Errorf("failed to generate journal ID: %w", err)
+	}
+
+	for _, p := range j.Postings {
+		var (
+			walletID     *string
+			balanceAfter *money.Amount
+		)
+		if p.Account == AccountWallet {
+			var balance money.Amount
+			err := tx.QueryRow(ctx,
+				"UPDATE wallets SET balance = balance + $1, updated_at = NOW() WHERE id = $2 AND currency = $3 RETURNING balance",
+				p.delta(), p.WalletID, j.Currency,
+			).Scan(&balance)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return "", fmt.Errorf("wallet %s doesn't exist or isn't in %s", p.WalletID, j.Currency)
+			}
+			if err != nil {
+				return "", fmt.Errorf("failed to apply posting to wallet %s: %w", p.WalletID, err)
+			}
+			walletID, balanceAfter = &p.WalletID, &balance
+		}
+
+		_, err := tx.Exec(ctx,
+			`INSERT INTO ledger_entries (journal_id, kind, transaction_id, account, wallet_id, direction, amount, currency, balance_after)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			journalID, j.Kind, p.TransactionID, p.Account, walletID, p.Direction, p.Amount, j.Currency, balanceAfter,
+		)
+		if err != nil {
+			return "", fmt.Errorf("failed to insert ledger entry: %w", err)
+		}
+	}
+	return journalID, nil
+}
@@ -0,0 +1,85 @@
+package ledger
+
+import (
+	"time"
+	"wallet/internal/money"
+)
+
+// Account is a ledger account. Wallet accounts are one per wallet, the others
+// are system accounts.
+type Account string
+
+const (
+	AccountWallet Account = "wallet"
+	// AccountExternalCash is the money outside the platform, deposits come from it
+	// and withdrawals go to it.
+	AccountExternalCash Account = "external_cash"
+	AccountFees         Account = "fees"
+	// AccountFX is the platform's currency position, conversions sell into it in
+	// one currency and buy out of it in another.
+	AccountFX Account = "fx"
+)
+
+type Direction string
+
+const (
+	Debit  Direction = "DEBIT"
+	Credit Direction = "CREDIT"
+)
+
+// Kind is the movement a journal records.
+type Kind string
+
+const (
+	KindDeposit  Kind = "DEPOSIT"
+	KindWithdraw Kind = "WITHDRAW"
+	KindTransfer Kind = "TRANSFER"
+	// KindCapture settles a hold, the money leaves the platform.
+	KindCapture Kind = "CAPTURE"
+	// KindConversion exchanges money between wallets in different currencies.
+	KindConversion Kind = "CONVERSION"
+	// KindReversal takes back all or part of a deposit, the money goes back out
+	// of the platform. Refunds post under it too.
+	KindReversal Kind = "REVERSAL"
+	// KindOpening carries the balances wallets had before the ledger existed.
+	KindOpening Kind = "OPENING"
+)
+
+// Posting is one side of a journal.
+type Posting struct {
+	Account Account
+	// WalletID is set on wallet account postings only.
+	WalletID  string
+	Direction Direction
+	Amount    money.Amount
+	// TransactionID is the transaction service record the posting belongs to, or
+	// the hold a capture settles.
+	TransactionID string
+}
+
+// Journal is a balanced set of postings applied together, all in the
+// currency of the wallets it moves money between.
+type Journal struct {
+	Kind     Kind
+	Currency money.Currency
+	Postings []Posting
+}
+
+// Entry is a posting as stored in ledger_entries.
+type Entry struct {
+	ID            int64
+	JournalID     string
+	Kind          Kind
+	TransactionID string
+	Account       Account
+	WalletID      string
+	Direction     Direction
+	Amount        money.Amount
+	Currency      money.Currency
+	// BalanceAfter is the wallet balance once the entry was applied.
+	BalanceAfter money.Amount
+	CreatedAt    time.Time
+	// CounterAccount and CounterWalletID are the other side of the journal.
+	CounterAccount  Account
+	CounterWalletID string
+}
@@ -0,0 +1,87 @@
+package ledger
+
+import (
+	"testing"
+	"wallet/internal/money"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		journal     Journal
+		expectError bool
+	}{
+		{
+			name:    "when a deposit is built, it should be balanced",
+			journal: Deposit("w1", "t1", 1000, money.DefaultCurrency),
+		},
+		{
+			name:    "when a reversal is built, it should be balanced",
+			journal: Reversal("w1", "t1", 1000, money.DefaultCurrency),
+		},
+		{
+			name:    "when a transfer is built, it should be balanced",
+			journal: Transfer("w1", "t1", "w2", "t2", 1000, money.DefaultCurrency),
+		},
+		{
+			name:    "when a conversion sells with a fee, it should be balanced in the sell currency",
+			journal: Conversion("q1", Leg{"w1", 10000, "EUR"}, Leg{"w2", 1079000, "JPY"}, 50)[0],
+		},
+		{
+			name:    "when a conversion buys, it should be balanced in the buy currency",
+			journal: Conversion("q1", Leg{"w1", 10000, "EUR"}, Leg{"w2", 1079000, "JPY"}, 50)[1],
+		},
+		{
+			name: "when debits and credits differ, it should return an error",
+			journal: Journal{Kind: KindDeposit, Currency: money.DefaultCurrency, Postings: []Posting{
+				{Account: AccountExternalCash, Direction: Debit, Amount: 1000},
+				{Account: AccountWallet, WalletID: "w1", Direction: Credit, Amount: 900},
+			}},
+			expectError: true,
+		},
+		{
+			name:        "when a posting isn't positive, it should return an error",
+			journal:     Deposit("w1", "t1", 0, money.DefaultCurrency),
+			expectError: true,
+		},
+		{
+			name: "when a wallet posting has no wallet ID, it should return an error",
+			journal: Journal{Kind: KindDeposit, Currency: money.DefaultCurrency, Postings: []Posting{
+				{Account: AccountExternalCash, Direction: Debit, Amount: 1000},
+				{Account: AccountWallet, Direction: Credit, Amount: 1000},
+			}},
+			expectError: true,
+		},
+		{
+			name:        "when a posting has fractions of a yen, it should return an error",
+			journal:     Deposit("w1", "t1", 1050, "JPY"),
+			expectError: true,
+		},
+		{
+			name:        "when the journal has no currency, it should return an error",
+			journal:     Deposit("w1", "t1", 1000, ""),
+			expectError: true,
+		},
+		{
+			name:        "when there is a single posting, it should return an error",
+			journal:     Journal{Kind: KindDeposit, Postings: Deposit("w1", "t1", 1000, money.DefaultCurrency).Postings[:1]},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.journal.Validate()
+
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
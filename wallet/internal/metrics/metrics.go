@@ -0,0 +1,43 @@
+// Package metrics holds the wallet service's counters. They are published with
+// expvar and served as JSON on /debug/vars of the metrics listener.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// AppliedEvents counts events applied to wallets, by topic.
+	AppliedEvents = expvar.NewMap("wallet_applied_events")
+	// DuplicateEvents counts redelivered events that were skipped because they
+	// had been applied already, by topic.
+	DuplicateEvents = expvar.NewMap("wallet_duplicate_events")
+	// DeadLetteredEvents counts events given up on and published to their
+	// dead-letter topic, by original topic.
+	DeadLetteredEvents = expvar.NewMap("wallet_dead_lettered_events")
+)
+
+// Serve serves /debug/vars on addr until ctx is cancelled.
+func Serve(ctx context.Context, addr string, log *logrus.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Infof("Metrics served on %s/debug/vars", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.WithError(err).Error("metrics listener stopped")
+	}
+}
@@ -0,0 +1,78 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCurrency(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		input       string
+		expected    Currency
+		expectedErr error
+	}{
+		{name: "when the code is known, it should return it", input: "GBP", expected: "GBP"},
+		{name: "when the code is lower case, it should upper case it", input: "eur", expected: "EUR"},
+		{name: "when the code is empty, it should return the default currency", input: "", expected: DefaultCurrency},
+		{name: "when the currency has three decimals, it should return an error", input: "BHD", expectedErr: ErrCurrency},
+		{name: "when the code isn't a currency, it should return an error", input: "EURO", expectedErr: ErrCurrency},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			currency, err := ParseCurrency(tc.input)
+			assert.ErrorIs(t, err, tc.expectedErr)
+			if tc.expectedErr == nil {
+				assert.Equal(t, tc.expected, currency)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		currency    Currency
+		amount      Amount
+		expectedErr error
+	}{
+		{name: "when the amount has cents in a two decimal currency, it should accept it", currency: "EUR", amount: 1050},
+		{name: "when the amount is whole yen, it should accept it", currency: "JPY", amount: 100000},
+		{name: "when the amount has fractions of a yen, it should return an error", currency: "JPY", amount: 1050, expectedErr: ErrCurrencyPrecision},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, tc.currency.Check(tc.amount), tc.expectedErr)
+		})
+	}
+}
+
+func TestMinorUnits(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		currency Currency
+		amount   Amount
+		expected int64
+	}{
+		{name: "when the currency has cents, it should count cents", currency: "EUR", amount: 1050, expected: 1050},
+		{name: "when the currency has no minor unit, it should count whole yen", currency: "JPY", amount: 50000, expected: 500},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			units := tc.amount.MinorUnits(tc.currency)
+			assert.Equal(t, tc.expected, units)
+			assert.Equal(t, tc.amount, FromMinorUnits(units, tc.currency))
+			assert.Equal(t, tc.amount, FromMinorUnits(tc.amount.ProtoIn(tc.currency).GetMinorUnits(), tc.currency))
+		})
+	}
+}
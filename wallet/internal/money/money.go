@@ -0,0 +1,176 @@
+// Package money represents amounts exactly, as an integer number of minor units
+// (cents). Floats are only accepted at the edges, from clients and messages that
+// predate it.
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is a number of minor units, 1050 is 10.50.
+type Amount int64
+
+// Scale is the number of decimal places of an amount.
+const Scale = 2
+
+const minorPerMajor = 100
+
+var (
+	ErrInvalid   = errors.New("invalid amount")
+	ErrPrecision = fmt.Errorf("amount has more than %d decimal places", Scale)
+)
+
+// FromMinor returns the amount of hundredths, whatever the currency. See
+// FromMinorUnits for the currency's own minor units.
+func FromMinor(minor int64) Amount {
+	return Amount(minor)
+}
+
+// FromFloat converts a legacy float amount, refusing values that aren't a whole
+// number of minor units once float noise is rounded away.
+func FromFloat(f float64) (Amount, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, ErrInvalid
+	}
+	minor := math.Round(f * minorPerMajor)
+	if minor > math.MaxInt64 || minor < math.MinInt64 {
+		return 0, ErrInvalid
+	}
+	if math.Abs(f*minorPerMajor-minor) > 1e-6 {
+		return 0, ErrPrecision
+	}
+	return Amount(minor), nil
+}
+
+// Parse parses a decimal string such as "10", "10.5" or "-0.05".
+func Parse(s string) (Amount, error) {
+	negative := strings.HasPrefix(s, "-")
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	if whole == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, ErrInvalid
+	}
+	if len(frac) > Scale {
+		// Trailing zeros, as in DECIMAL values with a larger scale, are exact.
+		if strings.Trim(frac[Scale:], "0") != "" {
+			return 0, ErrPrecision
+		}
+		frac = frac[:Scale]
+	}
+	frac += strings.Repeat("0", Scale-len(frac))
+
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || major > math.MaxInt64/minorPerMajor {
+		return 0, ErrInvalid
+	}
+	minor, _ := strconv.ParseInt(frac, 10, 64)
+
+	amount := Amount(major*minorPerMajor + minor)
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor returns the number of hundredths, whatever the currency. See
+// MinorUnits for the currency's own minor units.
+func (a Amount) Minor() int64 {
+	return int64(a)
+}
+
+// Float64 returns the amount for legacy float fields. It may not be exact.
+func (a Amount) Float64() float64 {
+	return float64(a) / minorPerMajor
+}
+
+// String formats the amount with exactly Scale decimal places.
+func (a Amount) String() string {
+	sign := ""
+	minor := int64(a)
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/minorPerMajor, Scale, minor%minorPerMajor)
+}
+
+// MarshalJSON encodes the amount as a decimal string so that JSON clients don't
+// parse it into a float.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON accepts a decimal string or, for older clients, a JSON number.
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	text := string(bytes.Trim(b, `"`))
+	if len(b) > 0 && b[0] != '"' {
+		// Numbers are parsed from their text, "10.10" stays exact.
+		if amount, err := Parse(text); err == nil {
+			*a = amount
+			return nil
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return ErrInvalid
+		}
+		amount, err := FromFloat(f)
+		if err != nil {
+			return err
+		}
+		*a = amount
+		return nil
+	}
+
+	amount, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Scan reads a DECIMAL column.
+func (a *Amount) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case string:
+		amount, err := Parse(v)
+		*a = amount
+		return err
+	case []byte:
+		amount, err := Parse(string(v))
+		*a = amount
+		return err
+	case int64:
+		*a = Amount(v * minorPerMajor)
+		return nil
+	case float64:
+		amount, err := FromFloat(v)
+		*a = amount
+		return err
+	default:
+		return fmt.Errorf("cannot scan %T into money.Amount", src)
+	}
+}
+
+// Value writes the amount to a DECIMAL column as exact text.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
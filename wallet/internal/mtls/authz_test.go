@@ -0,0 +1,83 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func peerContext(cert *x509.Certificate) context.Context {
+	state := tls.ConnectionState{}
+	if cert != nil {
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: state},
+	})
+}
+
+func TestAuthorize(t *testing.T) {
+	t.Parallel()
+
+	brokerURI, _ := url.Parse("spiffe://wall-e-go/broker")
+
+	testCases := []struct {
+		name         string
+		ctx          context.Context
+		allowed      []string
+		expectedCode codes.Code
+	}{
+		{
+			name:         "when no SANs are configured, it should allow any caller",
+			ctx:          context.Background(),
+			allowed:      nil,
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "when the client certificate has an allowed DNS SAN, it should allow the call",
+			ctx:          peerContext(&x509.Certificate{DNSNames: []string{"broker"}}),
+			allowed:      []string{"broker"},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "when the client certificate has an allowed URI SAN, it should allow the call",
+			ctx:          peerContext(&x509.Certificate{URIs: []*url.URL{brokerURI}}),
+			allowed:      []string{"spiffe://wall-e-go/broker"},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "when the client certificate has no allowed SAN, it should deny the call",
+			ctx:          peerContext(&x509.Certificate{DNSNames: []string{"notification"}}),
+			allowed:      []string{"broker"},
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			name:         "when the client presented no certificate, it should reject the call",
+			ctx:          peerContext(nil),
+			allowed:      []string{"broker"},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "when there is no peer information, it should reject the call",
+			ctx:          context.Background(),
+			allowed:      []string{"broker"},
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := authorize(tc.ctx, tc.allowed)
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"wallet/internal/config"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// ServerOptions returns the gRPC server options for cfg. When TLS is disabled the
+// server stays plaintext. With a CA bundle configured, client certificates are
+// required and, if AllowedSANs is set, checked against it on every call.
+func ServerOptions(ctx context.Context, cfg config.TLS, log *logrus.Logger) ([]grpc.ServerOption, error) {
+	if !cfg.Enabled {
+		log.Warn("TLS is disabled, serving plaintext gRPC")
+		return nil, nil
+	}
+
+	reloader, err := NewReloader(cfg.CertFile, cfg.KeyFile, cfg.CAFile, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificates: %w", err)
+	}
+	// Every handshake presents the current certificate, fail now rather than on
+	// the first one if there is none.
+	if reloader.Certificate() == nil {
+		return nil, errors.New("TLS is enabled but no server certificate and key are configured")
+	}
+	go reloader.Watch(ctx, cfg.ReloadInterval)
+
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			serverCfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*reloader.Certificate()},
+			}
+			if pool := reloader.CAPool(); pool != nil {
+				serverCfg.ClientCAs = pool
+				serverCfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return serverCfg, nil
+		},
+	}
+
+	return []grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(tlsCfg)),
+		grpc.ChainUnaryInterceptor(AuthorizeSANs(cfg.AllowedSANs)),
+		grpc.ChainStreamInterceptor(AuthorizeSANsStream(cfg.AllowedSANs)),
+	}, nil
+}
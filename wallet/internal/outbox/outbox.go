@@ -0,0 +1,43 @@
+// Package outbox publishes events with the database transaction that produced
+// them. Events are written to the outbox table in that transaction and a Relay
+// publishes them to Kafka afterwards, retrying until Kafka takes them, so an
+// event is published at least once exactly when its transaction commits.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Message is an event to publish to Topic, partitioned by Key.
+type Message struct {
+	Topic string
+	Key   string
+	// Payload is published as JSON.
+	Payload any
+}
+
+// Enqueue writes the messages to the outbox in tx. They are published in the
+// order they are enqueued once tx commits, and never if it rolls back.
+func Enqueue(ctx context.Context, tx pgx.Tx, messages ...Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	rows := make([][]any, 0, len(messages))
+	for _, m := range messages {
+		payload, err := json.Marshal(m.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s event %s: %w", m.Topic, m.Key, err)
+		}
+		rows = append(rows, []any{m.Topic, m.Key, payload})
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"outbox"}, []string{"topic", "key", "payload"}, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("failed to write %d events to the outbox: %w", len(messages), err)
+	}
+	return nil
+}
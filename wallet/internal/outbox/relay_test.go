@@ -0,0 +1,28 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		wait     time.Duration
+		expected time.Duration
+	}{
+		{name: "when publishing first fails, it should double the poll interval", wait: 500 * time.Millisecond, expected: time.Second},
+		{name: "when publishing keeps failing, it should keep doubling", wait: 8 * time.Second, expected: 16 * time.Second},
+		{name: "when the wait reaches the cap, it should stay there", wait: 40 * time.Second, expected: maxBackoff},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, backoff(tc.wait, 500*time.Millisecond))
+		})
+	}
+}
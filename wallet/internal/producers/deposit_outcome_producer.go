@@ -0,0 +1,41 @@
+package producers
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"wallet/internal/events"
+	"wallet/internal/outbox"
+)
+
+type DepositOutcomeProducer interface {
+	PublishDepositOutcomes(ctx context.Context, tx pgx.Tx, completed, failed []*events.Deposit) error
+}
+
+type DepositOutcomeProducerImpl struct {
+	completedTopic string
+	failedTopic    string
+}
+
+func NewDepositOutcomeProducer(completedTopic, failedTopic string) *DepositOutcomeProducerImpl {
+	return &DepositOutcomeProducerImpl{
+		completedTopic: completedTopic,
+		failedTopic:    failedTopic,
+	}
+}
+
+// PublishDepositOutcomes writes applied deposits for the completed topic and
+// refused ones for the failed topic to the outbox in tx, they are published
+// once it commits.
+func (p *DepositOutcomeProducerImpl) PublishDepositOutcomes(ctx context.Context, tx pgx.Tx, completed, failed []*events.Deposit) error {
+	messages := make([]outbox.Message, 0, len(completed)+len(failed))
+	messages = p.appendMessages(messages, p.completedTopic, completed)
+	messages = p.appendMessages(messages, p.failedTopic, failed)
+	return outbox.Enqueue(ctx, tx, messages...)
+}
+
+func (p *DepositOutcomeProducerImpl) appendMessages(messages []outbox.Message, topic string, events []*events.Deposit) []outbox.Message {
+	for _, e := range events {
+		messages = append(messages, outbox.Message{Topic: topic, Key: e.TransactionID, Payload: e})
+	}
+	return messages
+}
@@ -0,0 +1,40 @@
+package producers
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"wallet/internal/events"
+	"wallet/internal/outbox"
+)
+
+type ReversalOutcomeProducer interface {
+	PublishReversalOutcomes(ctx context.Context, tx pgx.Tx, completed, failed []*events.Reversal) error
+}
+
+type ReversalOutcomeProducerImpl struct {
+	completedTopic string
+	failedTopic    string
+}
+
+func NewReversalOutcomeProducer(completedTopic, failedTopic string) *ReversalOutcomeProducerImpl {
+	return &ReversalOutcomeProducerImpl{
+		completedTopic: completedTopic,
+		failedTopic:    failedTopic,
+	}
+}
+
+// PublishReversalOutcomes writes applied reversals and refunds for the
+// completed topic and refused ones for the failed topic to the outbox in tx.
+func (p *ReversalOutcomeProducerImpl) PublishReversalOutcomes(ctx context.Context, tx pgx.Tx, completed, failed []*events.Reversal) error {
+	messages := make([]outbox.Message, 0, len(completed)+len(failed))
+	messages = p.appendMessages(messages, p.completedTopic, completed)
+	messages = p.appendMessages(messages, p.failedTopic, failed)
+	return outbox.Enqueue(ctx, tx, messages...)
+}
+
+func (p *ReversalOutcomeProducerImpl) appendMessages(messages []outbox.Message, topic string, events []*events.Reversal) []outbox.Message {
+	for _, e := range events {
+		messages = append(messages, outbox.Message{Topic: topic, Key: e.TransactionID, Payload: e})
+	}
+	return messages
+}
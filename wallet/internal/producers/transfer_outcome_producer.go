@@ -0,0 +1,40 @@
+package producers
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"wallet/internal/events"
+	"wallet/internal/outbox"
+)
+
+type TransferOutcomeProducer interface {
+	PublishTransferOutcomes(ctx context.Context, tx pgx.Tx, completed, failed []*events.Transfer) error
+}
+
+type TransferOutcomeProducerImpl struct {
+	completedTopic string
+	failedTopic    string
+}
+
+func NewTransferOutcomeProducer(completedTopic, failedTopic string) *TransferOutcomeProducerImpl {
+	return &TransferOutcomeProducerImpl{
+		completedTopic: completedTopic,
+		failedTopic:    failedTopic,
+	}
+}
+
+// PublishTransferOutcomes writes applied transfers for the completed topic and
+// refused ones for the failed topic to the outbox in tx.
+func (p *TransferOutcomeProducerImpl) PublishTransferOutcomes(ctx context.Context, tx pgx.Tx, completed, failed []*events.Transfer) error {
+	messages := make([]outbox.Message, 0, len(completed)+len(failed))
+	messages = p.appendMessages(messages, p.completedTopic, completed)
+	messages = p.appendMessages(messages, p.failedTopic, failed)
+	return outbox.Enqueue(ctx, tx, messages...)
+}
+
+func (p *TransferOutcomeProducerImpl) appendMessages(messages []outbox.Message, topic string, events []*events.Transfer) []outbox.Message {
+	for _, e := range events {
+		messages = append(messages, outbox.Message{Topic: topic, Key: e.SourceWalletID, Payload: e})
+	}
+	return messages
+}
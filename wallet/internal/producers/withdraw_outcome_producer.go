@@ -0,0 +1,40 @@
+package producers
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"wallet/internal/events"
+	"wallet/internal/outbox"
+)
+
+type WithdrawOutcomeProducer interface {
+	PublishWithdrawOutcomes(ctx context.Context, tx pgx.Tx, completed, failed []*events.Withdrawal) error
+}
+
+type WithdrawOutcomeProducerImpl struct {
+	completedTopic string
+	failedTopic    string
+}
+
+func NewWithdrawOutcomeProducer(completedTopic, failedTopic string) *WithdrawOutcomeProducerImpl {
+	return &WithdrawOutcomeProducerImpl{
+		completedTopic: completedTopic,
+		failedTopic:    failedTopic,
+	}
+}
+
+// PublishWithdrawOutcomes writes applied withdrawals for the completed topic
+// and refused ones for the failed topic to the outbox in tx.
+func (p *WithdrawOutcomeProducerImpl) PublishWithdrawOutcomes(ctx context.Context, tx pgx.Tx, completed, failed []*events.Withdrawal) error {
+	messages := make([]outbox.Message, 0, len(completed)+len(failed))
+	messages = p.appendMessages(messages, p.completedTopic, completed)
+	messages = p.appendMessages(messages, p.failedTopic, failed)
+	return outbox.Enqueue(ctx, tx, messages...)
+}
+
+func (p *WithdrawOutcomeProducerImpl) appendMessages(messages []outbox.Message, topic string, events []*events.Withdrawal) []outbox.Message {
+	for _, e := range events {
+		messages = append(messages, outbox.Message{Topic: topic, Key: e.TransactionID, Payload: e})
+	}
+	return messages
+}
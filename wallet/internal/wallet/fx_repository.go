@@ -0,0 +1,200 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"wallet/internal/fx"
+	"wallet/internal/ledger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type FXRepository interface {
+	ListRates(ctx context.Context) ([]fx.Rate, error)
+	CreateQuote(ctx context.Context, quote *Quote, policy FXPolicy) (*Quote, error)
+	GetQuote(ctx context.Context, userID int, quoteID string) (*Quote, error)
+	ExecuteQuote(ctx context.Context, userID int, quoteID string) (*Quote, error)
+}
+
+const quoteColumns = `id, user_id, source_wallet_id, destination_wallet_id, source_amount, source_currency, fee,
+	target_amount, target_currency, mid_rate, rate, spread_bps, fee_bps, rate_source, rate_as_of,
+	case when status = 'OPEN' AND expires_at <= NOW() then 'EXPIRED' else status end,
+	expires_at, created_at, executed_at`
+
+func scanQuote(row pgx.Row) (*Quote, error) {
+	var quote Quote
+	err := row.Scan(
+		&quote.ID,
+		&quote.UserID,
+		&quote.SourceWalletID,
+		&quote.DestinationWalletID,
+		&quote.SourceAmount,
+		&quote.SourceCurrency,
+		&quote.Fee,
+		&quote.TargetAmount,
+		&quote.TargetCurrency,
+		&quote.MidRate,
+		&quote.Rate,
+		&quote.SpreadBps,
+		&quote.FeeBps,
+		&quote.RateSource,
+		&quote.RateAsOf,
+		&quote.Status,
+		&quote.ExpiresAt,
+		&quote.CreatedAt,
+		&quote.ExecutedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Quote{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+	return &quote, nil
+}
+
+type PostgresFXRepository struct {
+	db  *pgxpool.Pool
+	log *logrus.Logger
+}
+
+func NewPostgresFXRepository(db *pgxpool.Pool, log *logrus.Logger) *PostgresFXRepository {
+	return &PostgresFXRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// SaveRates replaces the stored rate of each pair, keeping the newer one when
+// the provider is behind what is stored
+func (r *PostgresFXRepository) SaveRates(ctx context.Context, rates []fx.Rate) error {
+	query := `insert into fx_rates (from_currency, to_currency, rate, source, as_of)
+		values ($1, $2, $3, $4, $5)
+		on conflict (from_currency, to_currency) do update
+		set rate = excluded.rate, source = excluded.source, as_of = excluded.as_of, updated_at = NOW()
+		where fx_rates.as_of <= excluded.as_of`
+
+	batch := &pgx.Batch{}
+	for _, rate := range rates {
+		batch.Queue(query, rate.From, rate.To, rate.Mid, rate.Source, rate.AsOf.UTC())
+	}
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to save rates: %w", err)
+	}
+	return nil
+}
+
+// ListRates returns the latest stored rate of every pair
+func (r *PostgresFXRepository) ListRates(ctx context.Context) ([]fx.Rate, error) {
+	rows, err := r.db.Query(ctx, `select from_currency, to_currency, rate, source, as_of from fx_rates`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []fx.Rate
+	for rows.Next() {
+		var rate fx.Rate
+		if err := rows.Scan(&rate.From, &rate.To, &rate.Mid, &rate.Source, &rate.AsOf); err != nil {
+			return nil, fmt.Errorf("failed to scan rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list rates: %w", err)
+	}
+	return rates, nil
+}
+
+// CreateQuote stores an open quote that expires after the policy's quote TTL
+func (r *PostgresFXRepository) CreateQuote(ctx context.Context, quote *Quote, policy FXPolicy) (*Quote, error) {
+	query := `insert into fx_quotes (user_id, source_wallet_id, destination_wallet_id, source_amount, source_currency,
+			fee, target_amount, target_currency, mid_rate, rate, spread_bps, fee_bps, rate_source, rate_as_of, expires_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW() + $15::int * interval '1 millisecond')
+		returning ` + quoteColumns
+
+	return scanQuote(r.db.QueryRow(ctx, query,
+		quote.UserID, quote.SourceWalletID, quote.DestinationWalletID, quote.SourceAmount, quote.SourceCurrency,
+		quote.Fee, quote.TargetAmount, quote.TargetCurrency, quote.MidRate, quote.Rate, quote.SpreadBps, quote.FeeBps,
+		quote.RateSource, quote.RateAsOf.UTC(), policy.QuoteTTL.Milliseconds(),
+	))
+}
+
+// GetQuote returns one quote of the user
+func (r *PostgresFXRepository) GetQuote(ctx context.Context, userID int, quoteID string) (*Quote, error) {
+	query := `select ` + quoteColumns + ` from fx_quotes where user_id = $1 AND id = $2`
+
+	return scanQuote(r.db.QueryRow(ctx, query, userID, quoteID))
+}
+
+// ExecuteQuote converts an open, unexpired quote of the user at its locked
+// rate: the source wallet is debited the source amount and the destination
+// wallet credited the target amount, through the ledger. It returns an empty
+// ID when the quote can't be executed or either wallet is no longer active,
+// in its currency, or, for the source, has the funds available.
+func (r *PostgresFXRepository) ExecuteQuote(ctx context.Context, userID int, quoteID string) (*Quote, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `update fx_quotes set status = 'EXECUTED', executed_at = NOW()
+		where user_id = $1 AND id = $2 AND status = 'OPEN' AND expires_at > NOW()
+		returning ` + quoteColumns
+
+	quote, err := scanQuote(tx.QueryRow(ctx, query, userID, quoteID))
+	if err != nil || quote.ID == "" {
+		return quote, err
+	}
+
+	// Locked in ID order so that opposite conversions between the same wallets
+	// can't deadlock.
+	rows, err := tx.Query(ctx,
+		`select `+walletColumns+` from wallets where id in ($1, $2) order by id for update`,
+		quote.SourceWalletID, quote.DestinationWalletID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock wallets of quote %s: %w", quote.ID, err)
+	}
+	wallets := make(map[string]*Wallet, 2)
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		wallets[wallet.ID] = wallet
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to lock wallets of quote %s: %w", quote.ID, err)
+	}
+
+	source, destination := wallets[quote.SourceWalletID], wallets[quote.DestinationWalletID]
+	if source == nil || destination == nil ||
+		source.UserID != userID || source.Status != StatusActive || source.Currency != quote.SourceCurrency ||
+		destination.Status != StatusActive || destination.Currency != quote.TargetCurrency ||
+		source.Available() < quote.SourceAmount {
+		return &Quote{}, nil
+	}
+
+	journals := ledger.Conversion(quote.ID,
+		ledger.Leg{WalletID: source.ID, Amount: quote.SourceAmount, Currency: quote.SourceCurrency},
+		ledger.Leg{WalletID: destination.ID, Amount: quote.TargetAmount, Currency: quote.TargetCurrency},
+		quote.Fee,
+	)
+	for _, journal := range journals {
+		if _, err := ledger.Post(ctx, tx, journal); err != nil {
+			return nil, fmt.Errorf("failed to post conversion of quote %s: %w", quote.ID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit conversion of quote %s: %w", quote.ID, err)
+	}
+	return quote, nil
+}
@@ -0,0 +1,235 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"wallet/internal/errcodes"
+	"wallet/internal/fx"
+	"wallet/internal/money"
+	"wallet/proto/gen"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *service) CreateQuote(ctx context.Context, req *gen.CreateQuoteRequest) (*gen.QuoteResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	amount, currency, err := validateCreateQuote(req)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.repo.GetByUserIdAndWalletID(ctx, userID, req.SourceWalletId)
+	if err != nil {
+		s.log.Errorf("error getting wallet: %v", err)
+		return nil, status.Error(codes.Internal, "error getting wallet")
+	}
+	switch {
+	case source.ID == "":
+		return nil, errcodes.Error(codes.NotFound, errcodes.WalletNotFound, "source wallet not found")
+	case source.Status == StatusClosed:
+		return nil, errcodes.Error(codes.FailedPrecondition, errcodes.WalletClosed, "source wallet is closed")
+	case currency != "" && currency != source.Currency:
+		return nil, currencyMismatchError(source.Currency, currency)
+	}
+	if err := source.Currency.Check(amount); err != nil {
+		return nil, errcodes.Invalid(errcodes.AmountInvalid, "invalid quote request", errcodes.Violation("amount", err.Error()))
+	}
+	if source.Available() < amount {
+		return nil, errcodes.Error(codes.FailedPrecondition, errcodes.InsufficientFunds,
+			fmt.Sprintf("wallet available balance is %s", source.Available()))
+	}
+
+	// The destination can be any user's wallet, as with transfers.
+	destination, err := s.repo.GetByID(ctx, req.DestinationWalletId)
+	if err != nil {
+		s.log.Errorf("error getting wallet: %v", err)
+		return nil, status.Error(codes.Internal, "error getting wallet")
+	}
+	switch {
+	case destination.ID == "":
+		return nil, errcodes.Error(codes.NotFound, errcodes.WalletNotFound, "destination wallet not found")
+	case destination.Status == StatusClosed:
+		return nil, errcodes.Error(codes.FailedPrecondition, errcodes.WalletClosed, "destination wallet is closed")
+	case destination.Currency == source.Currency:
+		return nil, errcodes.Invalid(errcodes.InvalidArgument, "both wallets are in "+string(source.Currency)+", use a transfer",
+			errcodes.Violation("destination_wallet_id", "must be in another currency than the source wallet"))
+	}
+
+	quote, err := s.priceQuote(ctx, source, destination, amount)
+	if err != nil {
+		return nil, err
+	}
+	quote.UserID = userID
+
+	quote, err = s.fx.CreateQuote(ctx, quote, s.policy)
+	if err != nil {
+		s.log.Errorf("error creating quote: %v", err)
+		return nil, status.Error(codes.Internal, "error creating quote")
+	}
+
+	return &gen.QuoteResponse{Quote: toProtoQuote(quote)}, nil
+}
+
+// priceQuote prices the conversion of amount from source into destination's
+// currency at the latest rate less the spread, after the fee.
+func (s *service) priceQuote(ctx context.Context, source, destination *Wallet, amount money.Amount) (*Quote, error) {
+	rates, err := s.fx.ListRates(ctx)
+	if err != nil {
+		s.log.Errorf("error listing exchange rates: %v", err)
+		return nil, status.Error(codes.Internal, "error getting exchange rate")
+	}
+	rate, ok := fx.NewTable(rates).Lookup(source.Currency, destination.Currency)
+	if !ok || time.Since(rate.AsOf) > s.policy.MaxRateAge {
+		return nil, errcodes.Error(codes.Unavailable, errcodes.RateUnavailable,
+			fmt.Sprintf("no current %s/%s exchange rate", source.Currency, destination.Currency))
+	}
+
+	fee := source.Currency.Truncate(fx.Fee(amount, s.policy.FeeBps))
+	price := rate.Mid.Less(s.policy.SpreadBps)
+	target := destination.Currency.Truncate(fx.Convert(amount-fee, price))
+	if target <= 0 {
+		return nil, errcodes.Invalid(errcodes.AmountInvalid, "amount is too small to convert",
+			errcodes.Violation("amount", fmt.Sprintf("converts to less than one minor unit of %s", destination.Currency)))
+	}
+
+	return &Quote{
+		SourceWalletID:      source.ID,
+		DestinationWalletID: destination.ID,
+		SourceAmount:        amount,
+		SourceCurrency:      source.Currency,
+		Fee:                 fee,
+		TargetAmount:        target,
+		TargetCurrency:      destination.Currency,
+		MidRate:             rate.Mid,
+		Rate:                price,
+		SpreadBps:           s.policy.SpreadBps,
+		FeeBps:              s.policy.FeeBps,
+		RateSource:          rate.Source,
+		RateAsOf:            rate.AsOf,
+	}, nil
+}
+
+func (s *service) ExecuteConversion(ctx context.Context, req *gen.ExecuteConversionRequest) (*gen.QuoteResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	if req.QuoteId == "" {
+		return nil, errcodes.Invalid(errcodes.InvalidArgument, "quote ID is required", errcodes.Violation("quote_id", "must not be empty"))
+	}
+
+	quote, err := s.fx.ExecuteQuote(ctx, userID, req.QuoteId)
+	if err != nil {
+		s.log.Errorf("error executing quote: %v", err)
+		return nil, status.Error(codes.Internal, "error executing conversion")
+	}
+	if quote.ID == "" {
+		return nil, s.unexecutableQuoteError(ctx, userID, req.QuoteId)
+	}
+
+	return &gen.QuoteResponse{Quote: toProtoQuote(quote)}, nil
+}
+
+// validateCreateQuote checks a quote request, collecting every invalid field,
+// and returns its amount and currency, empty when it names none.
+func validateCreateQuote(req *gen.CreateQuoteRequest) (money.Amount, money.Currency, error) {
+	reason := errcodes.InvalidArgument
+	var violations []*errdetails.BadRequest_FieldViolation
+	if req.SourceWalletId == "" {
+		violations = append(violations, errcodes.Violation("source_wallet_id", "must not be empty"))
+	}
+	if req.DestinationWalletId == "" {
+		violations = append(violations, errcodes.Violation("destination_wallet_id", "must not be empty"))
+	} else if req.DestinationWalletId == req.SourceWalletId {
+		violations = append(violations, errcodes.Violation("destination_wallet_id", "must differ from source_wallet_id"))
+	}
+	currency, err := amountCurrency(req.Amount)
+	if err != nil {
+		reason = errcodes.CurrencyInvalid
+		violations = append(violations, errcodes.Violation("amount.currency", err.Error()))
+	}
+	amount, _ := money.FromProto(req.Amount, 0)
+	if amount <= 0 && err == nil {
+		reason = errcodes.AmountInvalid
+		violations = append(violations, errcodes.Violation("amount", "must be greater than 0"))
+	}
+
+	if len(violations) > 0 {
+		return 0, "", errcodes.Invalid(reason, "invalid quote request", violations...)
+	}
+	return amount, currency, nil
+}
+
+// unexecutableQuoteError explains why a conversion matched no open quote or
+// its wallets couldn't take it.
+func (s *service) unexecutableQuoteError(ctx context.Context, userID int, quoteID string) error {
+	quote, err := s.fx.GetQuote(ctx, userID, quoteID)
+	if err != nil {
+		s.log.Errorf("error getting quote: %v", err)
+		return status.Error(codes.Internal, "error getting quote")
+	}
+
+	switch {
+	case quote.ID == "":
+		return errcodes.Error(codes.NotFound, errcodes.QuoteNotFound, "quote not found")
+	case quote.Status == QuoteExpired:
+		return errcodes.Error(codes.FailedPrecondition, errcodes.QuoteExpired, "quote has expired")
+	case quote.Status != QuoteOpen:
+		return errcodes.Error(codes.FailedPrecondition, errcodes.QuoteNotOpen, fmt.Sprintf("quote is %s", quote.Status))
+	}
+
+	source, err := s.repo.GetByUserIdAndWalletID(ctx, userID, quote.SourceWalletID)
+	if err != nil {
+		s.log.Errorf("error getting wallet: %v", err)
+		return status.Error(codes.Internal, "error getting wallet")
+	}
+	destination, err := s.repo.GetByID(ctx, quote.DestinationWalletID)
+	if err != nil {
+		s.log.Errorf("error getting wallet: %v", err)
+		return status.Error(codes.Internal, "error getting wallet")
+	}
+
+	switch {
+	case source.ID == "":
+		return errcodes.Error(codes.NotFound, errcodes.WalletNotFound, "source wallet not found")
+	case source.Status == StatusClosed:
+		return errcodes.Error(codes.FailedPrecondition, errcodes.WalletClosed, "source wallet is closed")
+	case destination.Status == StatusClosed:
+		return errcodes.Error(codes.FailedPrecondition, errcodes.WalletClosed, "destination wallet is closed")
+	default:
+		return errcodes.Error(codes.FailedPrecondition, errcodes.InsufficientFunds,
+			fmt.Sprintf("wallet available balance is %s", source.Available()))
+	}
+}
+
+func toProtoQuote(quote *Quote) *gen.Quote {
+	pb := &gen.Quote{
+		Id:                  quote.ID,
+		SourceWalletId:      quote.SourceWalletID,
+		DestinationWalletId: quote.DestinationWalletID,
+		SourceAmount:        quote.SourceAmount.ProtoIn(quote.SourceCurrency),
+		Fee:                 quote.Fee.ProtoIn(quote.SourceCurrency),
+		TargetAmount:        quote.TargetAmount.ProtoIn(quote.TargetCurrency),
+		MidRate:             quote.MidRate.String(),
+		Rate:                quote.Rate.String(),
+		SpreadBps:           quote.SpreadBps,
+		FeeBps:              quote.FeeBps,
+		Status:              string(quote.Status),
+		ExpiresAt:           quote.ExpiresAt.UTC().Format(time.RFC3339),
+		CreatedAt:           quote.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if quote.ExecutedAt != nil {
+		pb.ExecutedAt = quote.ExecutedAt.UTC().Format(time.RFC3339)
+	}
+	return pb
+}
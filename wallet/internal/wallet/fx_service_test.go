@@ -0,0 +1,268 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+	"wallet/internal/errcodes"
+	"wallet/internal/fx"
+	"wallet/internal/money"
+	"wallet/proto/gen"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// InMemoryFXRepository implements FXRepository for tests, on top of the
+// wallets of an InMemoryWalletRepository.
+type InMemoryFXRepository struct {
+	wallets *InMemoryWalletRepository
+	rates   []fx.Rate
+	quotes  []*Quote
+}
+
+func (r *InMemoryFXRepository) ListRates(ctx context.Context) ([]fx.Rate, error) {
+	return r.rates, nil
+}
+
+func (r *InMemoryFXRepository) CreateQuote(ctx context.Context, quote *Quote, policy FXPolicy) (*Quote, error) {
+	quote.ID = fmt.Sprintf("quote-%d", len(r.quotes)+1)
+	quote.Status = QuoteOpen
+	quote.ExpiresAt = time.Now().Add(policy.QuoteTTL)
+	r.quotes = append(r.quotes, quote)
+	return quote, nil
+}
+
+func (r *InMemoryFXRepository) GetQuote(ctx context.Context, userID int, quoteID string) (*Quote, error) {
+	for _, q := range r.quotes {
+		if q.UserID == userID && q.ID == quoteID {
+			if q.Status == QuoteOpen && !q.ExpiresAt.After(time.Now()) {
+				expired := *q
+				expired.Status = QuoteExpired
+				return &expired, nil
+			}
+			return q, nil
+		}
+	}
+	return &Quote{}, nil
+}
+
+func (r *InMemoryFXRepository) ExecuteQuote(ctx context.Context, userID int, quoteID string) (*Quote, error) {
+	q, _ := r.GetQuote(ctx, userID, quoteID)
+	if q.ID == "" || q.Status != QuoteOpen {
+		return &Quote{}, nil
+	}
+	source, _ := r.wallets.GetByUserIdAndWalletID(ctx, userID, q.SourceWalletID)
+	destination, _ := r.wallets.GetByID(ctx, q.DestinationWalletID)
+	if source.Status != StatusActive || destination.Status != StatusActive || source.Available() < q.SourceAmount {
+		return &Quote{}, nil
+	}
+	source.Balance -= q.SourceAmount
+	destination.Balance += q.TargetAmount
+
+	now := time.Now()
+	q.Status, q.ExecutedAt = QuoteExecuted, &now
+	return q, nil
+}
+
+func TestCreateQuote(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		request        *gen.CreateQuoteRequest
+		rateAge        time.Duration
+		expectedTarget *gen.Money
+		expectedFee    int64
+		expectedRate   string
+		expectedCode   codes.Code
+		expectedReason string
+	}{
+		{
+			name:           "when converting into another currency, it should take the fee and price at the mid rate less the spread",
+			request:        &gen.CreateQuoteRequest{SourceWalletId: "eur", DestinationWalletId: "usd", Amount: money.Amount(10000).Proto()},
+			expectedTarget: &gen.Money{MinorUnits: 10682, Currency: "USD"},
+			expectedFee:    100,
+			expectedRate:   "1.0790775",
+			expectedCode:   codes.OK,
+		},
+		{
+			name:           "when the target currency has no minor unit, it should truncate to whole units",
+			request:        &gen.CreateQuoteRequest{SourceWalletId: "eur", DestinationWalletId: "yen", Amount: money.Amount(10000).Proto()},
+			expectedTarget: &gen.Money{MinorUnits: 15900, Currency: "JPY"},
+			expectedFee:    100,
+			expectedRate:   "160.6129",
+			expectedCode:   codes.OK,
+		},
+		{
+			name:           "when both wallets are in the same currency, it should return invalid argument",
+			request:        &gen.CreateQuoteRequest{SourceWalletId: "eur", DestinationWalletId: "eur-2", Amount: money.Amount(10000).Proto()},
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: errcodes.InvalidArgument,
+		},
+		{
+			name:           "when the rate is too old, it should return rate unavailable",
+			request:        &gen.CreateQuoteRequest{SourceWalletId: "eur", DestinationWalletId: "usd", Amount: money.Amount(10000).Proto()},
+			rateAge:        2 * time.Hour,
+			expectedCode:   codes.Unavailable,
+			expectedReason: errcodes.RateUnavailable,
+		},
+		{
+			name:           "when no rate is known for the pair, it should return rate unavailable",
+			request:        &gen.CreateQuoteRequest{SourceWalletId: "eur", DestinationWalletId: "sek", Amount: money.Amount(10000).Proto()},
+			expectedCode:   codes.Unavailable,
+			expectedReason: errcodes.RateUnavailable,
+		},
+		{
+			name:           "when the amount exceeds the available balance, it should return insufficient funds",
+			request:        &gen.CreateQuoteRequest{SourceWalletId: "eur", DestinationWalletId: "usd", Amount: money.Amount(50001).Proto()},
+			expectedCode:   codes.FailedPrecondition,
+			expectedReason: errcodes.InsufficientFunds,
+		},
+		{
+			name:           "when the amount is in another currency than the source wallet, it should return currency mismatch",
+			request:        &gen.CreateQuoteRequest{SourceWalletId: "eur", DestinationWalletId: "usd", Amount: money.Amount(10000).ProtoIn("GBP")},
+			expectedCode:   codes.FailedPrecondition,
+			expectedReason: errcodes.CurrencyMismatch,
+		},
+		{
+			name:           "when the destination wallet is closed, it should return wallet closed",
+			request:        &gen.CreateQuoteRequest{SourceWalletId: "eur", DestinationWalletId: "closed", Amount: money.Amount(10000).Proto()},
+			expectedCode:   codes.FailedPrecondition,
+			expectedReason: errcodes.WalletClosed,
+		},
+		{
+			name:           "when the source wallet belongs to another user, it should return not found",
+			request:        &gen.CreateQuoteRequest{SourceWalletId: "usd", DestinationWalletId: "eur", Amount: money.Amount(10000).Proto()},
+			expectedCode:   codes.NotFound,
+			expectedReason: errcodes.WalletNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &InMemoryWalletRepository{
+				wallets: []*Wallet{
+					{ID: "eur", UserID: 1, Name: "main", Balance: 50000, Currency: "EUR", Status: StatusActive},
+					{ID: "eur-2", UserID: 1, Name: "savings", Currency: "EUR", Status: StatusActive},
+					{ID: "usd", UserID: 2, Name: "main", Currency: "USD", Status: StatusActive},
+					{ID: "yen", UserID: 1, Name: "yen", Currency: "JPY", Status: StatusActive},
+					{ID: "sek", UserID: 1, Name: "sek", Currency: "SEK", Status: StatusActive},
+					{ID: "closed", UserID: 1, Name: "closed", Currency: "GBP", Status: StatusClosed},
+				},
+			}
+			asOf := time.Now().Add(-tc.rateAge)
+			rates := &InMemoryFXRepository{wallets: repo, rates: []fx.Rate{
+				{From: "EUR", To: "USD", Mid: 108450000, Source: "test", AsOf: asOf},
+				{From: "EUR", To: "JPY", Mid: 16142000000, Source: "test", AsOf: asOf},
+			}}
+			policy := FXPolicy{SpreadBps: 50, FeeBps: 100, QuoteTTL: 30 * time.Second, MaxRateAge: time.Hour}
+			service := NewWalletService(repo, &InMemoryHoldRepository{wallets: repo}, rates, policy, logrus.New())
+
+			resp, err := service.CreateQuote(withUser("1"), tc.request)
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+				return
+			}
+			assert.Equal(t, string(QuoteOpen), resp.Quote.Status)
+			assert.Equal(t, tc.expectedTarget.MinorUnits, resp.Quote.TargetAmount.GetMinorUnits())
+			assert.Equal(t, tc.expectedTarget.Currency, resp.Quote.TargetAmount.GetCurrency())
+			assert.Equal(t, tc.expectedFee, resp.Quote.Fee.GetMinorUnits())
+			assert.Equal(t, tc.expectedRate, resp.Quote.Rate)
+			assert.Equal(t, int64(50), resp.Quote.SpreadBps)
+		})
+	}
+}
+
+func TestExecuteConversion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                       string
+		quoteID                    string
+		expectedSourceBalance      money.Amount
+		expectedDestinationBalance money.Amount
+		expectedCode               codes.Code
+		expectedReason             string
+	}{
+		{
+			name:                       "when the quote is open, it should debit the source and credit the target amount",
+			quoteID:                    "open",
+			expectedSourceBalance:      40000,
+			expectedDestinationBalance: 10682,
+			expectedCode:               codes.OK,
+		},
+		{
+			name:                  "when the quote was executed already, it should return not open",
+			quoteID:               "executed",
+			expectedSourceBalance: 50000,
+			expectedCode:          codes.FailedPrecondition,
+			expectedReason:        errcodes.QuoteNotOpen,
+		},
+		{
+			name:                  "when the quote has expired, it should return expired",
+			quoteID:               "expired",
+			expectedSourceBalance: 50000,
+			expectedCode:          codes.FailedPrecondition,
+			expectedReason:        errcodes.QuoteExpired,
+		},
+		{
+			name:                  "when the source no longer has the funds, it should return insufficient funds",
+			quoteID:               "too-large",
+			expectedSourceBalance: 50000,
+			expectedCode:          codes.FailedPrecondition,
+			expectedReason:        errcodes.InsufficientFunds,
+		},
+		{
+			name:                  "when the quote doesn't exist, it should return not found",
+			quoteID:               "missing",
+			expectedSourceBalance: 50000,
+			expectedCode:          codes.NotFound,
+			expectedReason:        errcodes.QuoteNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &InMemoryWalletRepository{
+				wallets: []*Wallet{
+					{ID: "eur", UserID: 1, Name: "main", Balance: 50000, Currency: "EUR", Status: StatusActive},
+					{ID: "usd", UserID: 2, Name: "main", Currency: "USD", Status: StatusActive},
+				},
+			}
+			quote := func(id string, amount money.Amount, status QuoteStatus, expiresIn time.Duration) *Quote {
+				return &Quote{
+					ID: id, UserID: 1, SourceWalletID: "eur", DestinationWalletID: "usd",
+					SourceAmount: amount, SourceCurrency: "EUR", TargetAmount: 10682, TargetCurrency: "USD",
+					Status: status, ExpiresAt: time.Now().Add(expiresIn),
+				}
+			}
+			quotes := &InMemoryFXRepository{wallets: repo, quotes: []*Quote{
+				quote("open", 10000, QuoteOpen, time.Minute),
+				quote("executed", 10000, QuoteExecuted, time.Minute),
+				quote("expired", 10000, QuoteOpen, -time.Second),
+				quote("too-large", 60000, QuoteOpen, time.Minute),
+			}}
+			service := NewWalletService(repo, &InMemoryHoldRepository{wallets: repo}, quotes, FXPolicy{}, logrus.New())
+
+			resp, err := service.ExecuteConversion(withUser("1"), &gen.ExecuteConversionRequest{QuoteId: tc.quoteID})
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			assert.Equal(t, tc.expectedSourceBalance, repo.wallets[0].Balance)
+			assert.Equal(t, tc.expectedDestinationBalance, repo.wallets[1].Balance)
+			if tc.expectedCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+				return
+			}
+			assert.Equal(t, string(QuoteExecuted), resp.Quote.Status)
+			assert.NotEmpty(t, resp.Quote.ExecutedAt)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package wallet
+
+import (
+	"time"
+	"wallet/internal/money"
+)
+
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "ACTIVE"
+	HoldCaptured HoldStatus = "CAPTURED"
+	HoldVoided   HoldStatus = "VOIDED"
+	HoldExpired  HoldStatus = "EXPIRED"
+)
+
+// Hold sets part of a wallet's balance aside until it is captured, voided or
+// expires.
+type Hold struct {
+	ID       string
+	WalletID string
+	Amount   money.Amount
+	// Currency is the currency of the wallet.
+	Currency money.Currency
+	// CapturedAmount is zero until the hold is captured, the rest of the hold is
+	// released.
+	CapturedAmount money.Amount
+	Status         HoldStatus
+	// Reference is the caller's own identifier for the payment, e.g. an order ID.
+	Reference string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,170 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"wallet/internal/ledger"
+	"wallet/internal/money"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type HoldRepository interface {
+	PlaceHold(ctx context.Context, userID int, hold *Hold, ttl time.Duration) (*Hold, error)
+	GetHold(ctx context.Context, userID int, holdID string) (*Hold, error)
+	CaptureHold(ctx context.Context, userID int, holdID string, amount money.Amount, currency money.Currency) (*Hold, error)
+	VoidHold(ctx context.Context, userID int, holdID string) (*Hold, error)
+	ExpireHolds(ctx context.Context, limit int) (int64, error)
+}
+
+const holdColumns = `h.id, h.wallet_id, h.amount, h.currency, coalesce(h.captured_amount, 0), h.status, h.reference,
+	h.expires_at, h.created_at, h.updated_at`
+
+func scanHold(row pgx.Row) (*Hold, error) {
+	var hold Hold
+	err := row.Scan(
+		&hold.ID,
+		&hold.WalletID,
+		&hold.Amount,
+		&hold.Currency,
+		&hold.CapturedAmount,
+		&hold.Status,
+		&hold.Reference,
+		&hold.ExpiresAt,
+		&hold.CreatedAt,
+		&hold.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Hold{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+	return &hold, nil
+}
+
+type PostgresHoldRepository struct {
+	db  *pgxpool.Pool
+	log *logrus.Logger
+}
+
+func NewPostgresHoldRepository(db *pgxpool.Pool, log *logrus.Logger) *PostgresHoldRepository {
+	return &PostgresHoldRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// PlaceHold sets the amount aside on an active wallet of the user in the hold's
+// currency, with an empty ID when there is no such wallet or its available
+// balance doesn't cover it
+func (r *PostgresHoldRepository) PlaceHold(ctx context.Context, userID int, hold *Hold, ttl time.Duration) (*Hold, error) {
+	query := `with held as (
+			update wallets set held_balance = held_balance + $3, updated_at = NOW()
+			where user_id = $1 AND id = $2 AND status = 'ACTIVE' AND currency = $6 AND balance - held_balance >= $3
+			returning id, currency
+		)
+		insert into holds as h (wallet_id, amount, currency, reference, expires_at)
+		select id, $3, currency, $4, NOW() + $5::int * interval '1 second' from held
+		returning ` + holdColumns
+
+	return scanHold(r.db.QueryRow(ctx, query,
+		userID, hold.WalletID, hold.Amount, hold.Reference, int64(ttl.Seconds()), hold.Currency,
+	))
+}
+
+// GetHold returns one hold on any of the user's wallets
+func (r *PostgresHoldRepository) GetHold(ctx context.Context, userID int, holdID string) (*Hold, error) {
+	query := `select ` + holdColumns + ` from holds h
+		join wallets w on w.id = h.wallet_id
+		where w.user_id = $1 AND h.id = $2`
+
+	return scanHold(r.db.QueryRow(ctx, query, userID, holdID))
+}
+
+// CaptureHold captures amount, the whole hold when zero, from an active and
+// unexpired hold of the user in currency, any when empty, and releases the rest. The captured
+// amount is debited through the ledger. It returns an empty ID when the hold
+// can't be captured.
+func (r *PostgresHoldRepository) CaptureHold(ctx context.Context, userID int, holdID string, amount money.Amount, currency money.Currency) (*Hold, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `update holds h set status = 'CAPTURED', captured_amount = coalesce(nullif($3::numeric, 0), h.amount), updated_at = NOW()
+		from wallets w
+		where w.id = h.wallet_id AND w.user_id = $1 AND h.id = $2
+			AND h.status = 'ACTIVE' AND h.expires_at > NOW() AND h.amount >= $3::numeric
+			AND ($4 = '' OR h.currency = $4)
+		returning ` + holdColumns
+
+	hold, err := scanHold(tx.QueryRow(ctx, query, userID, holdID, amount, currency))
+	if err != nil || hold.ID == "" {
+		return hold, err
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE wallets SET held_balance = held_balance - $1, updated_at = NOW() WHERE id = $2",
+		hold.Amount, hold.WalletID,
+	); err != nil {
+		return nil, fmt.Errorf("failed to release hold %s: %w", hold.ID, err)
+	}
+	if _, err := ledger.Post(ctx, tx, ledger.Capture(hold.WalletID, hold.ID, hold.CapturedAmount, hold.Currency)); err != nil {
+		return nil, fmt.Errorf("failed to post capture of hold %s: %w", hold.ID, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit capture of hold %s: %w", hold.ID, err)
+	}
+	return hold, nil
+}
+
+// VoidHold releases an active hold of the user, with an empty ID when there is
+// no such hold
+func (r *PostgresHoldRepository) VoidHold(ctx context.Context, userID int, holdID string) (*Hold, error) {
+	query := `with voided as (
+			update holds h set status = 'VOIDED', updated_at = NOW()
+			from wallets w
+			where w.id = h.wallet_id AND w.user_id = $1 AND h.id = $2 AND h.status = 'ACTIVE'
+			returning ` + holdColumns + `
+		), released as (
+			update wallets set held_balance = held_balance - voided.amount, updated_at = NOW()
+			from voided where wallets.id = voided.wallet_id
+		)
+		select * from voided`
+
+	return scanHold(r.db.QueryRow(ctx, query, userID, holdID))
+}
+
+// ExpireHolds releases up to limit active holds past their expiry and returns
+// how many it released. Holds being captured or voided are skipped.
+func (r *PostgresHoldRepository) ExpireHolds(ctx context.Context, limit int) (int64, error) {
+	query := `with expired as (
+			update holds set status = 'EXPIRED', updated_at = NOW()
+			where id in (
+				select id from holds
+				where status = 'ACTIVE' AND expires_at <= NOW()
+				order by expires_at
+				limit $1
+				for update skip locked
+			)
+			returning wallet_id, amount
+		), released as (
+			update wallets w set held_balance = w.held_balance - e.amount, updated_at = NOW()
+			from (select wallet_id, sum(amount) as amount from expired group by wallet_id) e
+			where w.id = e.wallet_id
+		)
+		select count(*) from expired`
+
+	var expired int64
+	if err := r.db.QueryRow(ctx, query, limit).Scan(&expired); err != nil {
+		return 0, fmt.Errorf("failed to expire holds: %w", err)
+	}
+	return expired, nil
+}
@@ -0,0 +1,254 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"wallet/internal/errcodes"
+	"wallet/internal/money"
+	"wallet/proto/gen"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultHoldTTL  = 7 * 24 * time.Hour
+	maxHoldTTL      = 30 * 24 * time.Hour
+	maxReferenceLen = 255
+)
+
+func (s *service) PlaceHold(ctx context.Context, req *gen.PlaceHoldRequest) (*gen.HoldResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	amount, currency, ttl, err := validatePlaceHold(req)
+	if err != nil {
+		return nil, err
+	}
+	if currency == "" {
+		// Amounts without a currency are in the wallet's. A missing wallet
+		// leaves it empty, which PlaceHold then matches no wallet for.
+		wallet, err := s.repo.GetByUserIdAndWalletID(ctx, userID, req.WalletId)
+		if err != nil {
+			s.log.Errorf("error getting wallet: %v", err)
+			return nil, status.Error(codes.Internal, "error getting wallet")
+		}
+		currency = wallet.Currency
+	}
+	if err := currency.Check(amount); err != nil {
+		return nil, errcodes.Invalid(errcodes.AmountInvalid, "invalid hold request", errcodes.Violation("amount", err.Error()))
+	}
+
+	hold, err := s.holds.PlaceHold(ctx, userID, &Hold{
+		WalletID:  req.WalletId,
+		Amount:    amount,
+		Currency:  currency,
+		Reference: req.Reference,
+	}, ttl)
+	if err != nil {
+		s.log.Errorf("error placing hold: %v", err)
+		return nil, status.Error(codes.Internal, "error placing hold")
+	}
+	if hold.ID == "" {
+		return nil, s.unholdableWalletError(ctx, userID, req.WalletId, currency)
+	}
+
+	return &gen.HoldResponse{Hold: toProtoHold(hold)}, nil
+}
+
+func (s *service) CaptureHold(ctx context.Context, req *gen.CaptureHoldRequest) (*gen.HoldResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	amount, currency, err := validateCaptureHold(req)
+	if err != nil {
+		return nil, err
+	}
+	if amount != 0 && currency == "" {
+		// Amounts without a currency are in the hold's.
+		hold, err := s.holds.GetHold(ctx, userID, req.HoldId)
+		if err != nil {
+			s.log.Errorf("error getting hold: %v", err)
+			return nil, status.Error(codes.Internal, "error getting hold")
+		}
+		currency = hold.Currency
+	}
+	if err := currency.Check(amount); err != nil {
+		return nil, errcodes.Invalid(errcodes.AmountInvalid, "invalid capture request", errcodes.Violation("amount", err.Error()))
+	}
+
+	hold, err := s.holds.CaptureHold(ctx, userID, req.HoldId, amount, currency)
+	if err != nil {
+		s.log.Errorf("error capturing hold: %v", err)
+		return nil, status.Error(codes.Internal, "error capturing hold")
+	}
+	if hold.ID == "" {
+		return nil, s.unusableHoldError(ctx, userID, req.HoldId, amount, currency)
+	}
+
+	return &gen.HoldResponse{Hold: toProtoHold(hold)}, nil
+}
+
+func (s *service) VoidHold(ctx context.Context, req *gen.HoldRequest) (*gen.HoldResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	if req.HoldId == "" {
+		return nil, errcodes.Invalid(errcodes.InvalidArgument, "hold ID is required", errcodes.Violation("hold_id", "must not be empty"))
+	}
+
+	hold, err := s.holds.VoidHold(ctx, userID, req.HoldId)
+	if err != nil {
+		s.log.Errorf("error voiding hold: %v", err)
+		return nil, status.Error(codes.Internal, "error voiding hold")
+	}
+	if hold.ID == "" {
+		return nil, s.unusableHoldError(ctx, userID, req.HoldId, 0, "")
+	}
+
+	return &gen.HoldResponse{Hold: toProtoHold(hold)}, nil
+}
+
+// validatePlaceHold checks a hold request, collecting every invalid field, and
+// returns its amount, currency, empty when it names none, and lifetime.
+func validatePlaceHold(req *gen.PlaceHoldRequest) (money.Amount, money.Currency, time.Duration, error) {
+	reason := errcodes.InvalidArgument
+	var violations []*errdetails.BadRequest_FieldViolation
+	if req.WalletId == "" {
+		violations = append(violations, errcodes.Violation("wallet_id", "must not be empty"))
+	}
+	currency, err := amountCurrency(req.Amount)
+	if err != nil {
+		reason = errcodes.CurrencyInvalid
+		violations = append(violations, errcodes.Violation("amount.currency", err.Error()))
+	}
+	amount, _ := money.FromProto(req.Amount, 0)
+	if amount <= 0 && err == nil {
+		reason = errcodes.AmountInvalid
+		violations = append(violations, errcodes.Violation("amount", "must be greater than 0"))
+	}
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	if ttl == 0 {
+		ttl = defaultHoldTTL
+	}
+	if ttl < 0 || ttl > maxHoldTTL {
+		violations = append(violations, errcodes.Violation("ttl_seconds", fmt.Sprintf("must be between 1 and %d", int64(maxHoldTTL.Seconds()))))
+	}
+	if len(req.Reference) > maxReferenceLen {
+		violations = append(violations, errcodes.Violation("reference", fmt.Sprintf("must be at most %d characters", maxReferenceLen)))
+	}
+
+	if len(violations) > 0 {
+		return 0, "", 0, errcodes.Invalid(reason, "invalid hold request", violations...)
+	}
+	return amount, currency, ttl, nil
+}
+
+// validateCaptureHold checks a capture request and returns the amount to
+// capture, zero for the whole hold, and its currency, empty when it names none.
+func validateCaptureHold(req *gen.CaptureHoldRequest) (money.Amount, money.Currency, error) {
+	reason := errcodes.InvalidArgument
+	var violations []*errdetails.BadRequest_FieldViolation
+	if req.HoldId == "" {
+		violations = append(violations, errcodes.Violation("hold_id", "must not be empty"))
+	}
+	var amount money.Amount
+	var currency money.Currency
+	if req.Amount != nil {
+		var err error
+		if currency, err = amountCurrency(req.Amount); err != nil {
+			reason = errcodes.CurrencyInvalid
+			violations = append(violations, errcodes.Violation("amount.currency", err.Error()))
+		} else if amount, _ = money.FromProto(req.Amount, 0); amount <= 0 {
+			reason = errcodes.AmountInvalid
+			violations = append(violations, errcodes.Violation("amount", "must be greater than 0"))
+		}
+	}
+
+	if len(violations) > 0 {
+		return 0, "", errcodes.Invalid(reason, "invalid capture request", violations...)
+	}
+	return amount, currency, nil
+}
+
+// amountCurrency returns the currency m names, if any. Money without one is in
+// hundredths of the currency of the wallet or hold it applies to.
+func amountCurrency(m *gen.Money) (money.Currency, error) {
+	if m.GetCurrency() == "" {
+		return "", nil
+	}
+	return money.CurrencyOf(m)
+}
+
+// unholdableWalletError explains why a hold matched no wallet to hold funds on.
+func (s *service) unholdableWalletError(ctx context.Context, userID int, walletID string, currency money.Currency) error {
+	wallet, err := s.repo.GetByUserIdAndWalletID(ctx, userID, walletID)
+	if err != nil {
+		s.log.Errorf("error getting wallet: %v", err)
+		return status.Error(codes.Internal, "error getting wallet")
+	}
+
+	switch {
+	case wallet.ID == "":
+		return errcodes.Error(codes.NotFound, errcodes.WalletNotFound, "wallet not found")
+	case wallet.Status == StatusClosed:
+		return errcodes.Error(codes.FailedPrecondition, errcodes.WalletClosed, "wallet is closed")
+	case wallet.Currency != currency:
+		return currencyMismatchError(wallet.Currency, currency)
+	default:
+		return errcodes.Error(codes.FailedPrecondition, errcodes.InsufficientFunds,
+			fmt.Sprintf("wallet available balance is %s", wallet.Available()))
+	}
+}
+
+// unusableHoldError explains why a capture or void matched no active hold.
+func (s *service) unusableHoldError(ctx context.Context, userID int, holdID string, amount money.Amount, currency money.Currency) error {
+	hold, err := s.holds.GetHold(ctx, userID, holdID)
+	if err != nil {
+		s.log.Errorf("error getting hold: %v", err)
+		return status.Error(codes.Internal, "error getting hold")
+	}
+
+	switch {
+	case hold.ID == "":
+		return errcodes.Error(codes.NotFound, errcodes.HoldNotFound, "hold not found")
+	case hold.Status != HoldActive:
+		return errcodes.Error(codes.FailedPrecondition, errcodes.HoldNotActive, fmt.Sprintf("hold is %s", hold.Status))
+	case currency != "" && hold.Currency != currency:
+		return currencyMismatchError(hold.Currency, currency)
+	case amount > hold.Amount:
+		return errcodes.Invalid(errcodes.AmountInvalid, "capture exceeds the hold",
+			errcodes.Violation("amount", fmt.Sprintf("must be at most %s", hold.Amount)))
+	default:
+		// Expired, but not released by the sweeper yet.
+		return errcodes.Error(codes.FailedPrecondition, errcodes.HoldExpired, "hold has expired")
+	}
+}
+
+func toProtoHold(hold *Hold) *gen.Hold {
+	pb := &gen.Hold{
+		Id:        hold.ID,
+		WalletId:  hold.WalletID,
+		Amount:    hold.Amount.ProtoIn(hold.Currency),
+		Status:    string(hold.Status),
+		Reference: hold.Reference,
+		ExpiresAt: hold.ExpiresAt.UTC().Format(time.RFC3339),
+		CreatedAt: hold.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: hold.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if hold.Status == HoldCaptured {
+		pb.CapturedAmount = hold.CapturedAmount.ProtoIn(hold.Currency)
+	}
+	return pb
+}
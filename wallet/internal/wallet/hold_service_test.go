@@ -0,0 +1,297 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+	"wallet/internal/errcodes"
+	"wallet/internal/money"
+	"wallet/proto/gen"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// InMemoryHoldRepository implements HoldRepository for tests, on top of the
+// wallets of an InMemoryWalletRepository.
+type InMemoryHoldRepository struct {
+	wallets *InMemoryWalletRepository
+	holds   []*Hold
+}
+
+func (r *InMemoryHoldRepository) PlaceHold(ctx context.Context, userID int, hold *Hold, ttl time.Duration) (*Hold, error) {
+	w, _ := r.wallets.GetByUserIdAndWalletID(ctx, userID, hold.WalletID)
+	if w.ID == "" || w.Status != StatusActive || w.Currency != hold.Currency || w.Available() < hold.Amount {
+		return &Hold{}, nil
+	}
+	w.Held += hold.Amount
+
+	hold.ID = fmt.Sprintf("hold-%d", len(r.holds)+1)
+	hold.Status = HoldActive
+	hold.ExpiresAt = time.Now().Add(ttl)
+	r.holds = append(r.holds, hold)
+	return hold, nil
+}
+
+func (r *InMemoryHoldRepository) GetHold(ctx context.Context, userID int, holdID string) (*Hold, error) {
+	for _, h := range r.holds {
+		if w, _ := r.wallets.GetByUserIdAndWalletID(ctx, userID, h.WalletID); h.ID == holdID && w.ID != "" {
+			return h, nil
+		}
+	}
+	return &Hold{}, nil
+}
+
+func (r *InMemoryHoldRepository) CaptureHold(ctx context.Context, userID int, holdID string, amount money.Amount, currency money.Currency) (*Hold, error) {
+	h, _ := r.GetHold(ctx, userID, holdID)
+	if h.ID == "" || h.Status != HoldActive || !h.ExpiresAt.After(time.Now()) || amount > h.Amount ||
+		(currency != "" && h.Currency != currency) {
+		return &Hold{}, nil
+	}
+	if amount == 0 {
+		amount = h.Amount
+	}
+	w, _ := r.wallets.GetByID(ctx, h.WalletID)
+	w.Held -= h.Amount
+	w.Balance -= amount
+
+	h.Status, h.CapturedAmount = HoldCaptured, amount
+	return h, nil
+}
+
+func (r *InMemoryHoldRepository) VoidHold(ctx context.Context, userID int, holdID string) (*Hold, error) {
+	h, _ := r.GetHold(ctx, userID, holdID)
+	if h.ID == "" || h.Status != HoldActive {
+		return &Hold{}, nil
+	}
+	w, _ := r.wallets.GetByID(ctx, h.WalletID)
+	w.Held -= h.Amount
+
+	h.Status = HoldVoided
+	return h, nil
+}
+
+func (r *InMemoryHoldRepository) ExpireHolds(ctx context.Context, limit int) (int64, error) {
+	var expired int64
+	for _, h := range r.holds {
+		if h.Status == HoldActive && !h.ExpiresAt.After(time.Now()) && expired < int64(limit) {
+			w, _ := r.wallets.GetByID(ctx, h.WalletID)
+			w.Held -= h.Amount
+			h.Status = HoldExpired
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func TestPlaceHold(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		request        *gen.PlaceHoldRequest
+		expectedHeld   money.Amount
+		expectedCode   codes.Code
+		expectedReason string
+	}{
+		{
+			name:         "when the available balance covers the amount, it should hold it",
+			request:      &gen.PlaceHoldRequest{WalletId: "w1", Amount: money.Amount(4000).Proto()},
+			expectedHeld: 5000,
+			expectedCode: codes.OK,
+		},
+		{
+			name:           "when funds are already held, it should only hold from what is available",
+			request:        &gen.PlaceHoldRequest{WalletId: "w1", Amount: money.Amount(9500).Proto()},
+			expectedHeld:   1000,
+			expectedCode:   codes.FailedPrecondition,
+			expectedReason: errcodes.InsufficientFunds,
+		},
+		{
+			name:           "when the amount is missing, it should return an error",
+			request:        &gen.PlaceHoldRequest{WalletId: "w1"},
+			expectedHeld:   1000,
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: errcodes.AmountInvalid,
+		},
+		{
+			name:           "when the ttl is longer than allowed, it should return an error",
+			request:        &gen.PlaceHoldRequest{WalletId: "w1", Amount: money.Amount(100).Proto(), TtlSeconds: int64((maxHoldTTL + time.Second).Seconds())},
+			expectedHeld:   1000,
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: errcodes.InvalidArgument,
+		},
+		{
+			name:           "when the amount is in another currency than the wallet, it should return currency mismatch",
+			request:        &gen.PlaceHoldRequest{WalletId: "w1", Amount: money.Amount(100).ProtoIn("GBP")},
+			expectedHeld:   1000,
+			expectedCode:   codes.FailedPrecondition,
+			expectedReason: errcodes.CurrencyMismatch,
+		},
+		{
+			name:           "when an amount without a currency is finer than the wallet's minor unit, it should return an error",
+			request:        &gen.PlaceHoldRequest{WalletId: "yen", Amount: money.Amount(1050).Proto()},
+			expectedHeld:   1000,
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: errcodes.AmountInvalid,
+		},
+		{
+			name:           "when the currency isn't supported, it should return an error",
+			request:        &gen.PlaceHoldRequest{WalletId: "w1", Amount: &gen.Money{MinorUnits: 100, Currency: "XYZ"}},
+			expectedHeld:   1000,
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: errcodes.CurrencyInvalid,
+		},
+		{
+			name:           "when the wallet is closed, it should return wallet closed",
+			request:        &gen.PlaceHoldRequest{WalletId: "closed", Amount: money.Amount(100).Proto()},
+			expectedHeld:   1000,
+			expectedCode:   codes.FailedPrecondition,
+			expectedReason: errcodes.WalletClosed,
+		},
+		{
+			name:           "when the wallet belongs to another user, it should return not found",
+			request:        &gen.PlaceHoldRequest{WalletId: "w2", Amount: money.Amount(100).Proto()},
+			expectedHeld:   1000,
+			expectedCode:   codes.NotFound,
+			expectedReason: errcodes.WalletNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &InMemoryWalletRepository{
+				wallets: []*Wallet{
+					{ID: "w1", UserID: 1, Name: "main", Balance: 10000, Held: 1000, Currency: "EUR", Status: StatusActive},
+					{ID: "closed", UserID: 1, Name: "closed", Currency: "EUR", Status: StatusClosed},
+					{ID: "w2", UserID: 2, Name: "main", Balance: 10000, Currency: "EUR", Status: StatusActive},
+					{ID: "yen", UserID: 1, Name: "yen", Balance: 100000, Currency: "JPY", Status: StatusActive},
+				},
+			}
+			service := NewWalletService(repo, &InMemoryHoldRepository{wallets: repo}, nil, FXPolicy{}, logrus.New())
+
+			resp, err := service.PlaceHold(withUser("1"), tc.request)
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			assert.Equal(t, tc.expectedHeld, repo.wallets[0].Held)
+			if tc.expectedCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+				return
+			}
+			assert.Equal(t, string(HoldActive), resp.Hold.Status)
+			assert.Equal(t, tc.request.Amount.GetMinorUnits(), resp.Hold.Amount.GetMinorUnits())
+			assert.Equal(t, "EUR", resp.Hold.Amount.GetCurrency())
+		})
+	}
+}
+
+func TestCaptureHold(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		request         *gen.CaptureHoldRequest
+		expectedBalance money.Amount
+		expectedCode    codes.Code
+		expectedReason  string
+	}{
+		{
+			name:            "when no amount is given, it should capture the whole hold",
+			request:         &gen.CaptureHoldRequest{HoldId: "hold-1"},
+			expectedBalance: 7000,
+			expectedCode:    codes.OK,
+		},
+		{
+			name:            "when part of the hold is captured, it should release the rest",
+			request:         &gen.CaptureHoldRequest{HoldId: "hold-1", Amount: money.Amount(1200).ProtoIn("GBP")},
+			expectedBalance: 8800,
+			expectedCode:    codes.OK,
+		},
+		{
+			name:            "when the amount exceeds the hold, it should return an error",
+			request:         &gen.CaptureHoldRequest{HoldId: "hold-1", Amount: money.Amount(3001).ProtoIn("GBP")},
+			expectedBalance: 10000,
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  errcodes.AmountInvalid,
+		},
+		{
+			name:            "when the amount is in another currency than the hold, it should return currency mismatch",
+			request:         &gen.CaptureHoldRequest{HoldId: "hold-1", Amount: money.Amount(1200).ProtoIn("EUR")},
+			expectedBalance: 10000,
+			expectedCode:    codes.FailedPrecondition,
+			expectedReason:  errcodes.CurrencyMismatch,
+		},
+		{
+			name:            "when the hold was voided, it should return not active",
+			request:         &gen.CaptureHoldRequest{HoldId: "hold-2"},
+			expectedBalance: 10000,
+			expectedCode:    codes.FailedPrecondition,
+			expectedReason:  errcodes.HoldNotActive,
+		},
+		{
+			name:            "when the hold expired before the sweeper released it, it should return expired",
+			request:         &gen.CaptureHoldRequest{HoldId: "hold-3"},
+			expectedBalance: 10000,
+			expectedCode:    codes.FailedPrecondition,
+			expectedReason:  errcodes.HoldExpired,
+		},
+		{
+			name:            "when the hold doesn't exist, it should return not found",
+			request:         &gen.CaptureHoldRequest{HoldId: "missing"},
+			expectedBalance: 10000,
+			expectedCode:    codes.NotFound,
+			expectedReason:  errcodes.HoldNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &InMemoryWalletRepository{
+				wallets: []*Wallet{{ID: "w1", UserID: 1, Name: "main", Balance: 10000, Held: 4000, Currency: "GBP", Status: StatusActive}},
+			}
+			holds := &InMemoryHoldRepository{wallets: repo, holds: []*Hold{
+				{ID: "hold-1", WalletID: "w1", Amount: 3000, Currency: "GBP", Status: HoldActive, ExpiresAt: time.Now().Add(time.Hour)},
+				{ID: "hold-2", WalletID: "w1", Amount: 500, Currency: "GBP", Status: HoldVoided, ExpiresAt: time.Now().Add(time.Hour)},
+				{ID: "hold-3", WalletID: "w1", Amount: 1000, Currency: "GBP", Status: HoldActive, ExpiresAt: time.Now().Add(-time.Minute)},
+			}}
+			service := NewWalletService(repo, holds, nil, FXPolicy{}, logrus.New())
+
+			resp, err := service.CaptureHold(withUser("1"), tc.request)
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			assert.Equal(t, tc.expectedBalance, repo.wallets[0].Balance)
+			if tc.expectedCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+				return
+			}
+			assert.Equal(t, string(HoldCaptured), resp.Hold.Status)
+			assert.Equal(t, money.Amount(1000), repo.wallets[0].Held)
+		})
+	}
+}
+
+func TestHoldSweeper(t *testing.T) {
+	t.Parallel()
+
+	repo := &InMemoryWalletRepository{
+		wallets: []*Wallet{{ID: "w1", UserID: 1, Name: "main", Balance: 10000, Held: 3500, Status: StatusActive}},
+	}
+	holds := &InMemoryHoldRepository{wallets: repo, holds: []*Hold{
+		{ID: "hold-1", WalletID: "w1", Amount: 1000, Status: HoldActive, ExpiresAt: time.Now().Add(-time.Hour)},
+		{ID: "hold-2", WalletID: "w1", Amount: 2000, Status: HoldActive, ExpiresAt: time.Now().Add(-time.Minute)},
+		{ID: "hold-3", WalletID: "w1", Amount: 500, Status: HoldActive, ExpiresAt: time.Now().Add(time.Hour)},
+	}}
+
+	// A batch smaller than the expired holds makes the sweep loop.
+	NewHoldSweeper(holds, time.Minute, 1, logrus.New()).Sweep(context.Background())
+
+	assert.Equal(t, HoldExpired, holds.holds[0].Status)
+	assert.Equal(t, HoldExpired, holds.holds[1].Status)
+	assert.Equal(t, HoldActive, holds.holds[2].Status)
+	assert.Equal(t, money.Amount(500), repo.wallets[0].Held)
+}
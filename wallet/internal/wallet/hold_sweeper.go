@@ -0,0 +1,59 @@
+package wallet
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HoldSweeper releases holds once they expire.
+type HoldSweeper struct {
+	holds     HoldRepository
+	interval  time.Duration
+	batchSize int
+	log       *logrus.Logger
+}
+
+func NewHoldSweeper(holds HoldRepository, interval time.Duration, batchSize int, log *logrus.Logger) *HoldSweeper {
+	return &HoldSweeper{
+		holds:     holds,
+		interval:  interval,
+		batchSize: batchSize,
+		log:       log,
+	}
+}
+
+// Run sweeps every interval until ctx is cancelled.
+func (s *HoldSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Sweep(ctx)
+		}
+	}
+}
+
+// Sweep releases expired holds a batch at a time until none are left.
+func (s *HoldSweeper) Sweep(ctx context.Context) {
+	for {
+		expired, err := s.holds.ExpireHolds(ctx, s.batchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.WithError(err).Error("failed to release expired holds")
+			}
+			return
+		}
+		if expired > 0 {
+			s.log.Infof("Released %d expired holds", expired)
+		}
+		if expired < int64(s.batchSize) {
+			return
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package wallet
+
+import (
+	"time"
+	"wallet/internal/ledger"
+)
+
+type ProcessedStatus string
+
+const (
+	ProcessedCompleted ProcessedStatus = "COMPLETED"
+	ProcessedFailed    ProcessedStatus = "FAILED"
+)
+
+// ProcessedTransaction is the outcome of a transaction event the wallet
+// consumers applied or refused.
+type ProcessedTransaction struct {
+	TransactionID string
+	Kind          ledger.Kind
+	Status        ProcessedStatus
+	// FailureReason says why a FAILED transaction was refused.
+	FailureReason string
+	ProcessedAt   time.Time
+}
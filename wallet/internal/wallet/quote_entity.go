@@ -0,0 +1,55 @@
+package wallet
+
+import (
+	"time"
+	"wallet/internal/fx"
+	"wallet/internal/money"
+)
+
+type QuoteStatus string
+
+const (
+	QuoteOpen     QuoteStatus = "OPEN"
+	QuoteExecuted QuoteStatus = "EXECUTED"
+	// QuoteExpired is never stored, open quotes read as expired once past
+	// ExpiresAt.
+	QuoteExpired QuoteStatus = "EXPIRED"
+)
+
+// Quote locks an exchange rate for converting between two wallets in
+// different currencies until it expires. Once executed it is the record of the
+// conversion, with the spread, fee and rate source it was priced with.
+type Quote struct {
+	ID                  string
+	UserID              int
+	SourceWalletID      string
+	DestinationWalletID string
+	// SourceAmount is debited from the source wallet, Fee is taken from it and
+	// the rest is converted into TargetAmount.
+	SourceAmount   money.Amount
+	SourceCurrency money.Currency
+	Fee            money.Amount
+	TargetAmount   money.Amount
+	TargetCurrency money.Currency
+	// MidRate is the provider's rate, Rate is what the conversion is priced at,
+	// MidRate less SpreadBps.
+	MidRate    fx.Price
+	Rate       fx.Price
+	SpreadBps  int64
+	FeeBps     int64
+	RateSource string
+	RateAsOf   time.Time
+	Status     QuoteStatus
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	ExecutedAt *time.Time
+}
+
+// FXPolicy is how conversions are priced.
+type FXPolicy struct {
+	SpreadBps int64
+	FeeBps    int64
+	QuoteTTL  time.Duration
+	// MaxRateAge is how old a rate can be and still be quoted.
+	MaxRateAge time.Duration
+}
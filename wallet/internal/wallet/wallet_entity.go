@@ -2,13 +2,44 @@ package wallet
 
 import (
 	"time"
+	"wallet/internal/money"
+)
+
+type Status string
+
+const (
+	StatusActive Status = "ACTIVE"
+	StatusClosed Status = "CLOSED"
 )
 
 type Wallet struct {
-	ID        string
-	UserID    int
-	Name      string
-	Balance   float64
+	ID      string
+	UserID  int
+	Name    string
+	Balance money.Amount
+	// Held is the part of Balance set aside by active holds.
+	Held      money.Amount
+	Currency  money.Currency
+	Status    Status
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	ClosedAt  *time.Time
+}
+
+// Available is the balance that can be spent, withdrawn or held.
+func (w *Wallet) Available() money.Amount {
+	return w.Balance - w.Held
+}
+
+// BalanceSummary is a wallet's balance next to the ledger postings it should
+// equal, for reconciling it with the transaction service.
+type BalanceSummary struct {
+	WalletID string
+	Currency money.Currency
+	Balance  money.Amount
+	// LedgerBalance is the net of all the wallet's postings.
+	LedgerBalance money.Amount
+	// Adjustments is the net of the postings made without a transaction of the
+	// transaction service: hold captures and conversions.
+	Adjustments money.Amount
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"wallet/internal/ledger"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
@@ -13,6 +15,38 @@ type Repository interface {
 	CreateWallet(ctx context.Context, wallet *Wallet) (string, error)
 	GetByUserIdAndWalletName(ctx context.Context, userID int, walletName string) (*Wallet, error)
 	GetByUserIdAndWalletID(ctx context.Context, userID int, walletID string) (*Wallet, error)
+	ListByUserID(ctx context.Context, userID int) ([]*Wallet, error)
+	Rename(ctx context.Context, userID int, walletID string, name string) (*Wallet, error)
+	Close(ctx context.Context, userID int, walletID string) (*Wallet, error)
+	GetByID(ctx context.Context, walletID string) (*Wallet, error)
+	ListLedgerEntries(ctx context.Context, walletID string, afterID int64, limit int) ([]*ledger.Entry, error)
+	GetProcessedTransaction(ctx context.Context, transactionID string) (*ProcessedTransaction, error)
+	EachBalanceSummary(ctx context.Context, fn func(*BalanceSummary) error) error
+}
+
+const walletColumns = `id, user_id, name, balance, held_balance, currency, status, created_at, updated_at, closed_at`
+
+func scanWallet(row pgx.Row) (*Wallet, error) {
+	var wallet Wallet
+	err := row.Scan(
+		&wallet.ID,
+		&wallet.UserID,
+		&wallet.Name,
+		&wallet.Balance,
+		&wallet.Held,
+		&wallet.Currency,
+		&wallet.Status,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+		&wallet.ClosedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Wallet{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	return &wallet, nil
 }
 
 type PostgresWalletRepository struct {
@@ -27,62 +61,194 @@ func NewPostgresWalletRepository(db *pgxpool.Pool, log *logrus.Logger) *Postgres
 	}
 }
 
-// GetByUserIdAndWalletName returns one wallet by wallet name and UserID
+// GetByUserIdAndWalletName returns one active wallet by wallet name and UserID
 func (r *PostgresWalletRepository) GetByUserIdAndWalletName(ctx context.Context, userID int, walletName string) (*Wallet, error) {
-	query := `select * from wallets where user_id = $1 AND name = $2`
+	query := `select ` + walletColumns + ` from wallets where user_id = $1 AND name = $2 AND status = 'ACTIVE'`
 
-	var wallet Wallet
+	return scanWallet(r.db.QueryRow(ctx, query, userID, walletName))
+}
 
-	row := r.db.QueryRow(ctx, query, userID, walletName)
+// GetByUserIdAndWalletID returns one wallet by wallet ID and UserID
+func (r *PostgresWalletRepository) GetByUserIdAndWalletID(ctx context.Context, userID int, walletID string) (*Wallet, error) {
+	query := `select ` + walletColumns + ` from wallets where user_id = $1 AND id = $2`
 
-	err := row.Scan(
-		&wallet.ID,
-		&wallet.UserID,
-		&wallet.Name,
-		&wallet.Balance,
-		&wallet.CreatedAt,
-		&wallet.UpdatedAt,
-	)
+	return scanWallet(r.db.QueryRow(ctx, query, userID, walletID))
+}
 
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return nil, fmt.Errorf("failed to get wallet: %w", err)
-	}
+// GetByID returns one wallet by ID, whoever owns it
+func (r *PostgresWalletRepository) GetByID(ctx context.Context, walletID string) (*Wallet, error) {
+	query := `select ` + walletColumns + ` from wallets where id = $1`
 
-	return &wallet, nil
+	return scanWallet(r.db.QueryRow(ctx, query, walletID))
 }
 
-// GetByUserIdAndWalletID returns one wallet by wallet ID and UserID
-func (r *PostgresWalletRepository) GetByUserIdAndWalletID(ctx context.Context, userID int, walletID string) (*Wallet, error) {
-	query := `select * from wallets where user_id = $1 AND id = $2`
+// ListLedgerEntries returns the wallet's ledger entries after afterID, oldest
+// first, each with the other side of its journal
+func (r *PostgresWalletRepository) ListLedgerEntries(ctx context.Context, walletID string, afterID int64, limit int) ([]*ledger.Entry, error) {
+	query := `select e.id, e.journal_id::text, e.kind, e.transaction_id, e.account, e.wallet_id::text, e.direction,
+			e.amount, e.currency, e.balance_after, e.created_at, c.account, coalesce(c.wallet_id::text, '')
+		from ledger_entries e
+		join lateral (
+			select account, wallet_id from ledger_entries
+			where journal_id = e.journal_id and direction <> e.direction
+			order by id limit 1
+		) c on true
+		where e.wallet_id = $1 and e.id > $2
+		order by e.id
+		limit $3`
 
-	var wallet Wallet
-	row := r.db.QueryRow(ctx, query, userID, walletID)
+	rows, err := r.db.Query(ctx, query, walletID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	defer rows.Close()
 
-	err := row.Scan(
-		&wallet.ID,
-		&wallet.UserID,
-		&wallet.Name,
-		&wallet.Balance,
-		&wallet.CreatedAt,
-		&wallet.UpdatedAt,
+	var entries []*ledger.Entry
+	for rows.Next() {
+		var entry ledger.Entry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.JournalID,
+			&entry.Kind,
+			&entry.TransactionID,
+			&entry.Account,
+			&entry.WalletID,
+			&entry.Direction,
+			&entry.Amount,
+			&entry.Currency,
+			&entry.BalanceAfter,
+			&entry.CreatedAt,
+			&entry.CounterAccount,
+			&entry.CounterWalletID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetProcessedTransaction returns the outcome of a processed transaction
+func (r *PostgresWalletRepository) GetProcessedTransaction(ctx context.Context, transactionID string) (*ProcessedTransaction, error) {
+	query := `select transaction_id, kind, status, coalesce(failure_reason, ''), processed_at
+		from processed_transactions where transaction_id = $1`
+
+	var processed ProcessedTransaction
+	err := r.db.QueryRow(ctx, query, transactionID).Scan(
+		&processed.TransactionID,
+		&processed.Kind,
+		&processed.Status,
+		&processed.FailureReason,
+		&processed.ProcessedAt,
 	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &ProcessedTransaction{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processed transaction: %w", err)
+	}
+	return &processed, nil
+}
 
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return nil, fmt.Errorf("failed to get wallet: %w", err)
+// EachBalanceSummary calls fn with the balance summary of every wallet, closed
+// ones included, ordered by wallet ID in byte order, as the rows are read
+func (r *PostgresWalletRepository) EachBalanceSummary(ctx context.Context, fn func(*BalanceSummary) error) error {
+	query := `select w.id::text, w.currency, w.balance,
+			coalesce(sum(case e.direction when 'CREDIT' then e.amount else -e.amount end), 0),
+			coalesce(sum(case when e.kind in ('CAPTURE', 'CONVERSION') then
+				case e.direction when 'CREDIT' then e.amount else -e.amount end end), 0)
+		from wallets w
+		left join ledger_entries e on e.wallet_id = w.id
+		group by w.id
+		order by w.id::text collate "C"`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to list balance summaries: %w", err)
 	}
+	defer rows.Close()
 
-	return &wallet, nil
+	for rows.Next() {
+		var summary BalanceSummary
+		err := rows.Scan(
+			&summary.WalletID,
+			&summary.Currency,
+			&summary.Balance,
+			&summary.LedgerBalance,
+			&summary.Adjustments,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan balance summary: %w", err)
+		}
+		if err := fn(&summary); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list balance summaries: %w", err)
+	}
+	return nil
+}
+
+// ListByUserID returns all wallets of a user, closed ones included
+func (r *PostgresWalletRepository) ListByUserID(ctx context.Context, userID int) ([]*Wallet, error) {
+	query := `select ` + walletColumns + ` from wallets where user_id = $1 order by created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallets: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*Wallet
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list wallets: %w", err)
+	}
+
+	return wallets, nil
+}
+
+// Rename renames an active wallet, with an empty ID when there is no such wallet
+func (r *PostgresWalletRepository) Rename(ctx context.Context, userID int, walletID string, name string) (*Wallet, error) {
+	query := `update wallets set name = $3, updated_at = NOW()
+		where user_id = $1 AND id = $2 AND status = 'ACTIVE'
+		returning ` + walletColumns
+
+	return scanWallet(r.db.QueryRow(ctx, query, userID, walletID, name))
+}
+
+// Close closes an active wallet with a zero balance, with an empty ID when
+// there is no such wallet
+func (r *PostgresWalletRepository) Close(ctx context.Context, userID int, walletID string) (*Wallet, error) {
+	query := `update wallets set status = 'CLOSED', closed_at = NOW(), updated_at = NOW()
+		where user_id = $1 AND id = $2 AND status = 'ACTIVE' AND balance = 0
+		returning ` + walletColumns
+
+	return scanWallet(r.db.QueryRow(ctx, query, userID, walletID))
 }
 
 // CreateWallet creates a new wallet in the database
 func (r *PostgresWalletRepository) CreateWallet(ctx context.Context, wallet *Wallet) (string, error) {
-	query := `insert into wallets (user_id, name, created_at, updated_at)
-		values ($1, $2, $3, $4) returning id`
+	query := `insert into wallets (user_id, name, currency, created_at, updated_at)
+		values ($1, $2, $3, $4, $5) returning id`
 
 	var newID string
 	err := r.db.QueryRow(ctx, query,
 		wallet.UserID,
 		wallet.Name,
+		wallet.Currency,
 		wallet.CreatedAt,
 		wallet.UpdatedAt,
 	).Scan(&newID)
@@ -2,11 +2,17 @@ package wallet
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/emptypb"
-	"log"
 	"strconv"
+	"time"
+	"wallet/internal/errcodes"
+	"wallet/internal/ledger"
+	"wallet/internal/money"
 	"wallet/proto/gen"
 
 	"google.golang.org/grpc/codes"
@@ -16,19 +22,43 @@ import (
 type Service interface {
 	CreateWallet(ctx context.Context, req *gen.CreateWalletRequest) (*gen.CreateWalletResponse, error)
 	ViewBalance(ctx context.Context, req *gen.ViewBalanceRequest) (*gen.ViewBalanceResponse, error)
+	IsWalletOwner(ctx context.Context, req *gen.IsOwnerRequest) (*gen.IsOwnerResponse, error)
+	ListWallets(ctx context.Context, req *emptypb.Empty) (*gen.ListWalletsResponse, error)
+	GetWallet(ctx context.Context, req *gen.WalletRequest) (*gen.WalletResponse, error)
+	RenameWallet(ctx context.Context, req *gen.RenameWalletRequest) (*gen.WalletResponse, error)
+	CloseWallet(ctx context.Context, req *gen.WalletRequest) (*gen.WalletResponse, error)
+	GetLedger(ctx context.Context, req *gen.GetLedgerRequest) (*gen.GetLedgerResponse, error)
+	GetProcessedTransaction(ctx context.Context, req *gen.ProcessedTransactionRequest) (*gen.ProcessedTransactionResponse, error)
+	StreamBalances(req *emptypb.Empty, stream gen.WalletService_StreamBalancesServer) error
+	PlaceHold(ctx context.Context, req *gen.PlaceHoldRequest) (*gen.HoldResponse, error)
+	CaptureHold(ctx context.Context, req *gen.CaptureHoldRequest) (*gen.HoldResponse, error)
+	VoidHold(ctx context.Context, req *gen.HoldRequest) (*gen.HoldResponse, error)
+	CreateQuote(ctx context.Context, req *gen.CreateQuoteRequest) (*gen.QuoteResponse, error)
+	ExecuteConversion(ctx context.Context, req *gen.ExecuteConversionRequest) (*gen.QuoteResponse, error)
 	HealthCheck(ctx context.Context, req *emptypb.Empty) (*emptypb.Empty, error)
 }
 
+const (
+	defaultLedgerLimit = 50
+	maxLedgerLimit     = 200
+)
+
 type service struct {
 	gen.UnimplementedWalletServiceServer
-	repo Repository
-	log  *logrus.Logger
+	repo   Repository
+	holds  HoldRepository
+	fx     FXRepository
+	policy FXPolicy
+	log    *logrus.Logger
 }
 
-func NewWalletService(repo Repository, log *logrus.Logger) *service {
+func NewWalletService(repo Repository, holds HoldRepository, fx FXRepository, policy FXPolicy, log *logrus.Logger) *service {
 	return &service{
-		repo: repo,
-		log:  log,
+		repo:   repo,
+		holds:  holds,
+		fx:     fx,
+		policy: policy,
+		log:    log,
 	}
 }
 
@@ -40,21 +70,18 @@ func (s *service) HealthCheck(ctx context.Context, req *emptypb.Empty) (*emptypb
 func (s *service) CreateWallet(ctx context.Context, req *gen.CreateWalletRequest) (*gen.CreateWalletResponse, error) {
 	var newWallet Wallet
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		s.log.Error("no metadata provided in context")
-		return nil, status.Error(codes.Unauthenticated, "no metadata provided")
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
 	}
 
-	userIDStr := md.Get("userID")
-	if len(userIDStr) == 0 {
-		s.log.Error("user ID not found in metadata")
-		return nil, status.Error(codes.Unauthenticated, "user ID not found in metadata")
+	if req.Name == "" {
+		return nil, errcodes.Invalid(errcodes.InvalidArgument, "wallet name is required", errcodes.Violation("name", "must not be empty"))
 	}
-
-	userID, err := strconv.Atoi(userIDStr[0])
+	currency, err := money.ParseCurrency(req.Currency)
 	if err != nil {
-		return nil, status.Error(codes.Internal, "invalid user ID")
+		return nil, errcodes.Invalid(errcodes.CurrencyInvalid, "unsupported wallet currency", errcodes.Violation("currency", err.Error()))
 	}
 
 	// Check if wallet with such name already exists for this user
@@ -64,11 +91,12 @@ func (s *service) CreateWallet(ctx context.Context, req *gen.CreateWalletRequest
 		return nil, status.Errorf(codes.Internal, "error handling create wallet request")
 	}
 	if existingWallet.ID != "" {
-		return nil, status.Errorf(codes.AlreadyExists, "wallet with name %v already exists for user with ID: %v", req.Name, userID)
+		return nil, errcodes.Error(codes.AlreadyExists, errcodes.WalletNameTaken, fmt.Sprintf("wallet with name %v already exists", req.Name))
 	}
 
 	newWallet.Name = req.Name
 	newWallet.UserID = userID
+	newWallet.Currency = currency
 
 	walletID, err := s.repo.CreateWallet(ctx, &newWallet)
 	if err != nil {
@@ -82,19 +110,340 @@ func (s *service) CreateWallet(ctx context.Context, req *gen.CreateWalletRequest
 }
 
 func (s *service) ViewBalance(ctx context.Context, req *gen.ViewBalanceRequest) (*gen.ViewBalanceResponse, error) {
-	log.Println("CreateWallet called with request: ", req, " and context: ", ctx)
-	userID, ok := ctx.Value("userID").(int)
-	if !ok {
-		return nil, status.Errorf(codes.Unauthenticated, "user ID not found in context")
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
 	}
 
 	wallet, err := s.repo.GetByUserIdAndWalletID(ctx, userID, req.WalletId)
 	if err != nil {
-		return nil, err
+		s.log.Errorf("error getting wallet: %v", err)
+		return nil, status.Error(codes.Internal, "error getting wallet")
+	}
+	if wallet.ID == "" {
+		return nil, errcodes.Error(codes.NotFound, errcodes.WalletNotFound, "wallet not found")
 	}
 
 	return &gen.ViewBalanceResponse{
-		Balance: wallet.Balance,
-		Name:    wallet.Name,
+		Balance:        wallet.Balance.Float64(),
+		BalanceMoney:   wallet.Balance.ProtoIn(wallet.Currency),
+		AvailableMoney: wallet.Available().ProtoIn(wallet.Currency),
+		HeldMoney:      wallet.Held.ProtoIn(wallet.Currency),
+		Name:           wallet.Name,
+		Currency:       string(wallet.Currency),
 	}, nil
 }
+
+func (s *service) IsWalletOwner(ctx context.Context, req *gen.IsOwnerRequest) (*gen.IsOwnerResponse, error) {
+	if req.WalletId == "" || req.UserId == 0 {
+		return nil, errcodes.Error(codes.InvalidArgument, errcodes.InvalidArgument, "wallet ID and user ID are required")
+	}
+
+	wallet, err := s.repo.GetByUserIdAndWalletID(ctx, int(req.UserId), req.WalletId)
+	if err != nil {
+		s.log.Errorf("error checking wallet ownership: %v", err)
+		return nil, status.Error(codes.Internal, "error checking wallet ownership")
+	}
+
+	return &gen.IsOwnerResponse{
+		Valid:  wallet.ID != "",
+		Closed: wallet.Status == StatusClosed,
+	}, nil
+}
+
+func (s *service) ListWallets(ctx context.Context, _ *emptypb.Empty) (*gen.ListWalletsResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	wallets, err := s.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		s.log.Errorf("error listing wallets: %v", err)
+		return nil, status.Error(codes.Internal, "error listing wallets")
+	}
+
+	resp := &gen.ListWalletsResponse{Wallets: make([]*gen.Wallet, 0, len(wallets))}
+	for _, wallet := range wallets {
+		resp.Wallets = append(resp.Wallets, toProtoWallet(wallet))
+	}
+	return resp, nil
+}
+
+func (s *service) GetWallet(ctx context.Context, req *gen.WalletRequest) (*gen.WalletResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	wallet, err := s.repo.GetByUserIdAndWalletID(ctx, userID, req.WalletId)
+	if err != nil {
+		s.log.Errorf("error getting wallet: %v", err)
+		return nil, status.Error(codes.Internal, "error getting wallet")
+	}
+	if wallet.ID == "" {
+		return nil, errcodes.Error(codes.NotFound, errcodes.WalletNotFound, "wallet not found")
+	}
+
+	return &gen.WalletResponse{Wallet: toProtoWallet(wallet)}, nil
+}
+
+// RenameWallet renames an active wallet, names are unique among a user's active wallets.
+func (s *service) RenameWallet(ctx context.Context, req *gen.RenameWalletRequest) (*gen.WalletResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	if req.Name == "" {
+		return nil, errcodes.Invalid(errcodes.InvalidArgument, "wallet name is required", errcodes.Violation("name", "must not be empty"))
+	}
+
+	existingWallet, err := s.repo.GetByUserIdAndWalletName(ctx, userID, req.Name)
+	if err != nil {
+		s.log.Errorf("error checking for duplicate wallet: %v", err)
+		return nil, status.Error(codes.Internal, "error renaming wallet")
+	}
+	if existingWallet.ID != "" && existingWallet.ID != req.WalletId {
+		return nil, errcodes.Error(codes.AlreadyExists, errcodes.WalletNameTaken, fmt.Sprintf("wallet with name %v already exists", req.Name))
+	}
+
+	wallet, err := s.repo.Rename(ctx, userID, req.WalletId, req.Name)
+	if err != nil {
+		s.log.Errorf("error renaming wallet: %v", err)
+		return nil, status.Error(codes.Internal, "error renaming wallet")
+	}
+	if wallet.ID == "" {
+		return nil, s.inactiveWalletError(ctx, userID, req.WalletId)
+	}
+
+	return &gen.WalletResponse{Wallet: toProtoWallet(wallet)}, nil
+}
+
+// CloseWallet closes an active wallet. It is refused while the balance is not
+// zero; the broker refuses it earlier while transactions are pending.
+func (s *service) CloseWallet(ctx context.Context, req *gen.WalletRequest) (*gen.WalletResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	wallet, err := s.repo.Close(ctx, userID, req.WalletId)
+	if err != nil {
+		s.log.Errorf("error closing wallet: %v", err)
+		return nil, status.Error(codes.Internal, "error closing wallet")
+	}
+	if wallet.ID == "" {
+		return nil, s.inactiveWalletError(ctx, userID, req.WalletId)
+	}
+
+	return &gen.WalletResponse{Wallet: toProtoWallet(wallet)}, nil
+}
+
+// GetLedger returns a page of a wallet's ledger entries, oldest first. The
+// broker checks that the wallet belongs to the caller.
+func (s *service) GetLedger(ctx context.Context, req *gen.GetLedgerRequest) (*gen.GetLedgerResponse, error) {
+	limit, afterID, err := ledgerPage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.repo.GetByID(ctx, req.WalletId)
+	if err != nil {
+		s.log.Errorf("error getting wallet: %v", err)
+		return nil, status.Error(codes.Internal, "error getting wallet")
+	}
+	if wallet.ID == "" {
+		return nil, errcodes.Error(codes.NotFound, errcodes.WalletNotFound, "wallet not found")
+	}
+
+	// One extra entry tells whether there is a next page.
+	entries, err := s.repo.ListLedgerEntries(ctx, req.WalletId, afterID, limit+1)
+	if err != nil {
+		s.log.Errorf("error listing ledger entries of wallet %s: %v", req.WalletId, err)
+		return nil, status.Error(codes.Internal, "error listing ledger entries")
+	}
+
+	resp := &gen.GetLedgerResponse{}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		resp.NextCursor = encodeLedgerCursor(entries[limit-1].ID)
+	}
+
+	resp.Entries = make([]*gen.LedgerEntry, 0, len(entries))
+	for _, entry := range entries {
+		resp.Entries = append(resp.Entries, toProtoLedgerEntry(entry))
+	}
+	return resp, nil
+}
+
+// GetProcessedTransaction returns the outcome of a transaction's event. It is
+// called by the transaction service, not on behalf of a user.
+func (s *service) GetProcessedTransaction(ctx context.Context, req *gen.ProcessedTransactionRequest) (*gen.ProcessedTransactionResponse, error) {
+	if req.TransactionId == "" {
+		return nil, errcodes.Invalid(errcodes.InvalidArgument, "invalid processed transaction request", errcodes.Violation("transaction_id", "must not be empty"))
+	}
+
+	processed, err := s.repo.GetProcessedTransaction(ctx, req.TransactionId)
+	if err != nil {
+		s.log.Errorf("error getting processed transaction %s: %v", req.TransactionId, err)
+		return nil, status.Error(codes.Internal, "error getting processed transaction")
+	}
+	if processed.TransactionID == "" {
+		return &gen.ProcessedTransactionResponse{}, nil
+	}
+
+	return &gen.ProcessedTransactionResponse{
+		Processed:     true,
+		Kind:          string(processed.Kind),
+		Status:        string(processed.Status),
+		FailureReason: processed.FailureReason,
+		ProcessedAt:   processed.ProcessedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// StreamBalances sends the balance summary of every wallet as it is read. It is
+// called by the transaction service's balance reconciliation, not on behalf of
+// a user.
+func (s *service) StreamBalances(req *emptypb.Empty, stream gen.WalletService_StreamBalancesServer) error {
+	err := s.repo.EachBalanceSummary(stream.Context(), func(summary *BalanceSummary) error {
+		return stream.Send(&gen.BalanceSummary{
+			WalletId:      summary.WalletID,
+			Balance:       summary.Balance.ProtoIn(summary.Currency),
+			LedgerBalance: summary.LedgerBalance.ProtoIn(summary.Currency),
+			Adjustments:   summary.Adjustments.ProtoIn(summary.Currency),
+		})
+	})
+	if err != nil {
+		s.log.Errorf("error streaming balance summaries: %v", err)
+		return status.Error(codes.Internal, "error streaming balance summaries")
+	}
+	return nil
+}
+
+// ledgerPage validates a ledger request, collecting every invalid field.
+func ledgerPage(req *gen.GetLedgerRequest) (int, int64, error) {
+	limit := int(req.Limit)
+	var afterID int64
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	if req.WalletId == "" {
+		violations = append(violations, errcodes.Violation("wallet_id", "must not be empty"))
+	}
+	if limit == 0 {
+		limit = defaultLedgerLimit
+	}
+	if limit < 0 || limit > maxLedgerLimit {
+		violations = append(violations, errcodes.Violation("limit", fmt.Sprintf("must be between 1 and %d", maxLedgerLimit)))
+	}
+	if req.Cursor != "" {
+		var err error
+		if afterID, err = decodeLedgerCursor(req.Cursor); err != nil {
+			violations = append(violations, errcodes.Violation("cursor", "is invalid"))
+		}
+	}
+
+	if len(violations) > 0 {
+		return 0, 0, errcodes.Invalid(errcodes.InvalidArgument, "invalid ledger request", violations...)
+	}
+	return limit, afterID, nil
+}
+
+// encodeLedgerCursor makes the ID of the last entry of a page opaque to clients.
+func encodeLedgerCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeLedgerCursor(raw string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+	return id, nil
+}
+
+func toProtoLedgerEntry(entry *ledger.Entry) *gen.LedgerEntry {
+	return &gen.LedgerEntry{
+		Id:              entry.ID,
+		JournalId:       entry.JournalID,
+		Kind:            string(entry.Kind),
+		TransactionId:   entry.TransactionID,
+		Direction:       string(entry.Direction),
+		Amount:          entry.Amount.ProtoIn(entry.Currency),
+		BalanceAfter:    entry.BalanceAfter.ProtoIn(entry.Currency),
+		CreatedAt:       entry.CreatedAt.UTC().Format(time.RFC3339),
+		CounterAccount:  string(entry.CounterAccount),
+		CounterWalletId: entry.CounterWalletID,
+	}
+}
+
+// inactiveWalletError explains why an update matched no active wallet.
+func (s *service) inactiveWalletError(ctx context.Context, userID int, walletID string) error {
+	wallet, err := s.repo.GetByUserIdAndWalletID(ctx, userID, walletID)
+	if err != nil {
+		s.log.Errorf("error getting wallet: %v", err)
+		return status.Error(codes.Internal, "error getting wallet")
+	}
+
+	switch {
+	case wallet.ID == "":
+		return errcodes.Error(codes.NotFound, errcodes.WalletNotFound, "wallet not found")
+	case wallet.Status == StatusClosed:
+		return errcodes.Error(codes.FailedPrecondition, errcodes.WalletClosed, "wallet is closed")
+	default:
+		return errcodes.Error(codes.FailedPrecondition, errcodes.WalletNotEmpty,
+			fmt.Sprintf("wallet balance is %s, it must be zero to close the wallet", wallet.Balance))
+	}
+}
+
+// currencyMismatchError refuses an amount in another currency than the wallet's.
+func currencyMismatchError(want, got money.Currency) error {
+	return errcodes.Error(codes.FailedPrecondition, errcodes.CurrencyMismatch,
+		fmt.Sprintf("wallet is in %s, not %s", want, got))
+}
+
+func toProtoWallet(wallet *Wallet) *gen.Wallet {
+	pb := &gen.Wallet{
+		Id:           wallet.ID,
+		Name:         wallet.Name,
+		Balance:      wallet.Balance.Float64(),
+		BalanceMoney: wallet.Balance.ProtoIn(wallet.Currency),
+		Currency:     string(wallet.Currency),
+		Status:       string(wallet.Status),
+		CreatedAt:    wallet.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:    wallet.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if wallet.ClosedAt != nil {
+		pb.ClosedAt = wallet.ClosedAt.UTC().Format(time.RFC3339)
+	}
+	return pb
+}
+
+// userIDFromContext reads the ID of the authenticated user that the broker
+// forwards in the "userID" metadata key.
+func userIDFromContext(ctx context.Context) (int, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0, errcodes.Error(codes.Unauthenticated, errcodes.Unauthenticated, "no metadata provided")
+	}
+
+	userIDStr := md.Get("userID")
+	if len(userIDStr) == 0 {
+		return 0, errcodes.Error(codes.Unauthenticated, errcodes.Unauthenticated, "user ID not found in metadata")
+	}
+
+	userID, err := strconv.Atoi(userIDStr[0])
+	if err != nil {
+		return 0, errcodes.Error(codes.Unauthenticated, errcodes.Unauthenticated, "invalid user ID")
+	}
+	return userID, nil
+}
@@ -0,0 +1,525 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+	"wallet/internal/errcodes"
+	"wallet/internal/ledger"
+	"wallet/internal/money"
+	"wallet/proto/gen"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// InMemoryWalletRepository implements Repository for tests.
+type InMemoryWalletRepository struct {
+	wallets   []*Wallet
+	entries   []*ledger.Entry
+	processed []*ProcessedTransaction
+	summaries []*BalanceSummary
+}
+
+func (r *InMemoryWalletRepository) CreateWallet(ctx context.Context, wallet *Wallet) (string, error) {
+	wallet.ID = "new-wallet"
+	r.wallets = append(r.wallets, wallet)
+	return wallet.ID, nil
+}
+
+func (r *InMemoryWalletRepository) GetByUserIdAndWalletName(ctx context.Context, userID int, walletName string) (*Wallet, error) {
+	for _, w := range r.wallets {
+		if w.UserID == userID && w.Name == walletName && w.Status == StatusActive {
+			return w, nil
+		}
+	}
+	return &Wallet{}, nil
+}
+
+func (r *InMemoryWalletRepository) GetByUserIdAndWalletID(ctx context.Context, userID int, walletID string) (*Wallet, error) {
+	for _, w := range r.wallets {
+		if w.UserID == userID && w.ID == walletID {
+			return w, nil
+		}
+	}
+	return &Wallet{}, nil
+}
+
+func (r *InMemoryWalletRepository) ListByUserID(ctx context.Context, userID int) ([]*Wallet, error) {
+	var wallets []*Wallet
+	for _, w := range r.wallets {
+		if w.UserID == userID {
+			wallets = append(wallets, w)
+		}
+	}
+	return wallets, nil
+}
+
+func (r *InMemoryWalletRepository) Rename(ctx context.Context, userID int, walletID string, name string) (*Wallet, error) {
+	w, _ := r.GetByUserIdAndWalletID(ctx, userID, walletID)
+	if w.ID == "" || w.Status != StatusActive {
+		return &Wallet{}, nil
+	}
+	w.Name = name
+	return w, nil
+}
+
+func (r *InMemoryWalletRepository) Close(ctx context.Context, userID int, walletID string) (*Wallet, error) {
+	w, _ := r.GetByUserIdAndWalletID(ctx, userID, walletID)
+	if w.ID == "" || w.Status != StatusActive || w.Balance != 0 {
+		return &Wallet{}, nil
+	}
+	w.Status = StatusClosed
+	return w, nil
+}
+
+func (r *InMemoryWalletRepository) GetByID(ctx context.Context, walletID string) (*Wallet, error) {
+	for _, w := range r.wallets {
+		if w.ID == walletID {
+			return w, nil
+		}
+	}
+	return &Wallet{}, nil
+}
+
+func (r *InMemoryWalletRepository) ListLedgerEntries(ctx context.Context, walletID string, afterID int64, limit int) ([]*ledger.Entry, error) {
+	var entries []*ledger.Entry
+	for _, e := range r.entries {
+		if e.WalletID == walletID && e.ID > afterID && len(entries) < limit {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (r *InMemoryWalletRepository) GetProcessedTransaction(ctx context.Context, transactionID string) (*ProcessedTransaction, error) {
+	for _, p := range r.processed {
+		if p.TransactionID == transactionID {
+			return p, nil
+		}
+	}
+	return &ProcessedTransaction{}, nil
+}
+
+func (r *InMemoryWalletRepository) EachBalanceSummary(ctx context.Context, fn func(*BalanceSummary) error) error {
+	for _, summary := range r.summaries {
+		if err := fn(summary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func withUser(userID string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("userID", userID))
+}
+
+func reasonOf(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+	return ""
+}
+
+func TestViewBalance(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		ctx              context.Context
+		walletID         string
+		expectedBalance  money.Amount
+		expectedHeld     money.Amount
+		expectedMinor    int64
+		expectedCurrency string
+		expectedCode     codes.Code
+		expectedReason   string
+	}{
+		{
+			name:             "when the wallet belongs to the user, it should return its balance and what is held",
+			ctx:              withUser("1"),
+			walletID:         "w1",
+			expectedBalance:  5000,
+			expectedHeld:     1500,
+			expectedMinor:    5000,
+			expectedCurrency: "GBP",
+			expectedCode:     codes.OK,
+		},
+		{
+			name:             "when the currency has no minor unit, it should return whole units",
+			ctx:              withUser("1"),
+			walletID:         "yen",
+			expectedBalance:  120000,
+			expectedMinor:    1200,
+			expectedCurrency: "JPY",
+			expectedCode:     codes.OK,
+		},
+		{
+			name:           "when the wallet belongs to another user, it should return not found",
+			ctx:            withUser("2"),
+			walletID:       "w1",
+			expectedCode:   codes.NotFound,
+			expectedReason: errcodes.WalletNotFound,
+		},
+		{
+			name:           "when no user is forwarded, it should return unauthenticated",
+			ctx:            context.Background(),
+			walletID:       "w1",
+			expectedCode:   codes.Unauthenticated,
+			expectedReason: errcodes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &InMemoryWalletRepository{
+				wallets: []*Wallet{
+					{ID: "w1", UserID: 1, Name: "main", Balance: 5000, Held: 1500, Currency: "GBP", Status: StatusActive},
+					{ID: "yen", UserID: 1, Name: "yen", Balance: 120000, Currency: "JPY", Status: StatusActive},
+				},
+			}
+			service := NewWalletService(repo, &InMemoryHoldRepository{wallets: repo}, nil, FXPolicy{}, logrus.New())
+
+			resp, err := service.ViewBalance(tc.ctx, &gen.ViewBalanceRequest{WalletId: tc.walletID})
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+				return
+			}
+			assert.Equal(t, tc.expectedMinor, resp.BalanceMoney.GetMinorUnits())
+			assert.Equal(t, tc.expectedBalance.Float64(), resp.Balance)
+			assert.Equal(t, tc.expectedCurrency, resp.Currency)
+			assert.Equal(t, resp.Currency, resp.HeldMoney.GetCurrency())
+			held, _ := money.FromProto(resp.HeldMoney, 0)
+			available, _ := money.FromProto(resp.AvailableMoney, 0)
+			assert.Equal(t, tc.expectedHeld, held)
+			assert.Equal(t, tc.expectedBalance-tc.expectedHeld, available)
+		})
+	}
+}
+
+func TestCreateWallet(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		request          *gen.CreateWalletRequest
+		expectedCurrency money.Currency
+		expectedCode     codes.Code
+		expectedReason   string
+	}{
+		{
+			name:             "when no currency is given, it should create a wallet in the default currency",
+			request:          &gen.CreateWalletRequest{Name: "savings"},
+			expectedCurrency: money.DefaultCurrency,
+			expectedCode:     codes.OK,
+		},
+		{
+			name:             "when a currency is given, it should create the wallet in it",
+			request:          &gen.CreateWalletRequest{Name: "savings", Currency: "gbp"},
+			expectedCurrency: "GBP",
+			expectedCode:     codes.OK,
+		},
+		{
+			name:           "when the currency isn't supported, it should return an error",
+			request:        &gen.CreateWalletRequest{Name: "savings", Currency: "XYZ"},
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: errcodes.CurrencyInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &InMemoryWalletRepository{}
+			service := NewWalletService(repo, &InMemoryHoldRepository{wallets: repo}, nil, FXPolicy{}, logrus.New())
+
+			resp, err := service.CreateWallet(withUser("1"), tc.request)
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+				assert.Empty(t, repo.wallets)
+				return
+			}
+			assert.Equal(t, tc.expectedCurrency, repo.wallets[0].Currency)
+		})
+	}
+}
+
+func TestCloseWallet(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		walletID       string
+		expectedCode   codes.Code
+		expectedReason string
+	}{
+		{
+			name:         "when the wallet is empty, it should close it",
+			walletID:     "empty",
+			expectedCode: codes.OK,
+		},
+		{
+			name:           "when the wallet has a balance, it should refuse to close it",
+			walletID:       "funded",
+			expectedCode:   codes.FailedPrecondition,
+			expectedReason: errcodes.WalletNotEmpty,
+		},
+		{
+			name:           "when the wallet is already closed, it should return wallet closed",
+			walletID:       "closed",
+			expectedCode:   codes.FailedPrecondition,
+			expectedReason: errcodes.WalletClosed,
+		},
+		{
+			name:           "when the wallet doesn't exist, it should return not found",
+			walletID:       "missing",
+			expectedCode:   codes.NotFound,
+			expectedReason: errcodes.WalletNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &InMemoryWalletRepository{
+				wallets: []*Wallet{
+					{ID: "empty", UserID: 1, Name: "empty", Status: StatusActive},
+					{ID: "funded", UserID: 1, Name: "funded", Balance: 1000, Status: StatusActive},
+					{ID: "closed", UserID: 1, Name: "closed", Status: StatusClosed},
+				},
+			}
+			service := NewWalletService(repo, &InMemoryHoldRepository{wallets: repo}, nil, FXPolicy{}, logrus.New())
+
+			resp, err := service.CloseWallet(withUser("1"), &gen.WalletRequest{WalletId: tc.walletID})
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+				return
+			}
+			assert.Equal(t, string(StatusClosed), resp.Wallet.Status)
+		})
+	}
+}
+
+func TestGetLedger(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		request        *gen.GetLedgerRequest
+		expectedIDs    []int64
+		expectNextPage bool
+		expectedCode   codes.Code
+		expectedReason string
+	}{
+		{
+			name:        "when the ledger fits in a page, it should return every entry oldest first",
+			request:     &gen.GetLedgerRequest{WalletId: "w1"},
+			expectedIDs: []int64{1, 3, 4},
+		},
+		{
+			name:           "when the ledger is longer than the limit, it should return a cursor to the next page",
+			request:        &gen.GetLedgerRequest{WalletId: "w1", Limit: 2},
+			expectedIDs:    []int64{1, 3},
+			expectNextPage: true,
+		},
+		{
+			name:        "when a cursor is given, it should continue after it",
+			request:     &gen.GetLedgerRequest{WalletId: "w1", Cursor: encodeLedgerCursor(3)},
+			expectedIDs: []int64{4},
+		},
+		{
+			name:           "when the cursor is tampered with, it should return an error",
+			request:        &gen.GetLedgerRequest{WalletId: "w1", Cursor: "not-a-cursor"},
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: errcodes.InvalidArgument,
+		},
+		{
+			name:           "when the limit is too large, it should return an error",
+			request:        &gen.GetLedgerRequest{WalletId: "w1", Limit: maxLedgerLimit + 1},
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: errcodes.InvalidArgument,
+		},
+		{
+			name:           "when the wallet doesn't exist, it should return not found",
+			request:        &gen.GetLedgerRequest{WalletId: "missing"},
+			expectedCode:   codes.NotFound,
+			expectedReason: errcodes.WalletNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &InMemoryWalletRepository{
+				wallets: []*Wallet{
+					{ID: "w1", UserID: 1, Name: "main", Balance: 2500, Status: StatusActive},
+					{ID: "w2", UserID: 2, Name: "main", Balance: 500, Status: StatusActive},
+				},
+				entries: []*ledger.Entry{
+					{ID: 1, Kind: ledger.KindDeposit, WalletID: "w1", Direction: ledger.Credit, Amount: 3000, BalanceAfter: 3000, CounterAccount: ledger.AccountExternalCash},
+					{ID: 2, Kind: ledger.KindTransfer, WalletID: "w2", Direction: ledger.Credit, Amount: 500, BalanceAfter: 500, CounterAccount: ledger.AccountWallet, CounterWalletID: "w1"},
+					{ID: 3, Kind: ledger.KindTransfer, WalletID: "w1", Direction: ledger.Debit, Amount: 500, BalanceAfter: 2500, CounterAccount: ledger.AccountWallet, CounterWalletID: "w2"},
+					{ID: 4, Kind: ledger.KindWithdraw, WalletID: "w1", Direction: ledger.Debit, Amount: 100, BalanceAfter: 2400, CounterAccount: ledger.AccountExternalCash},
+				},
+			}
+			service := NewWalletService(repo, &InMemoryHoldRepository{wallets: repo}, nil, FXPolicy{}, logrus.New())
+
+			resp, err := service.GetLedger(context.Background(), tc.request)
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode != codes.OK {
+				assert.Nil(t, resp)
+				assert.Equal(t, tc.expectedReason, reasonOf(err))
+				return
+			}
+			var ids []int64
+			for _, entry := range resp.Entries {
+				ids = append(ids, entry.Id)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+			assert.Equal(t, tc.expectNextPage, resp.NextCursor != "")
+		})
+	}
+}
+
+func TestGetProcessedTransaction(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name              string
+		transactionID     string
+		expectedProcessed bool
+		expectedStatus    string
+		expectedReason    string
+		expectedCode      codes.Code
+	}{
+		{
+			name:              "when the deposit was applied, it should return it completed",
+			transactionID:     "tx-1",
+			expectedProcessed: true,
+			expectedStatus:    "COMPLETED",
+		},
+		{
+			name:              "when the deposit was refused, it should return it failed with the reason",
+			transactionID:     "tx-2",
+			expectedProcessed: true,
+			expectedStatus:    "FAILED",
+			expectedReason:    "wallet_closed",
+		},
+		{
+			name:          "when the event never arrived, it should return it unprocessed",
+			transactionID: "tx-3",
+		},
+		{
+			name:         "when the transaction ID is empty, it should return an error",
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &InMemoryWalletRepository{
+				processed: []*ProcessedTransaction{
+					{TransactionID: "tx-1", Kind: ledger.KindDeposit, Status: ProcessedCompleted},
+					{TransactionID: "tx-2", Kind: ledger.KindDeposit, Status: ProcessedFailed, FailureReason: "wallet_closed"},
+				},
+			}
+			service := NewWalletService(repo, &InMemoryHoldRepository{wallets: repo}, nil, FXPolicy{}, logrus.New())
+
+			resp, err := service.GetProcessedTransaction(context.Background(), &gen.ProcessedTransactionRequest{TransactionId: tc.transactionID})
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode != codes.OK {
+				assert.Nil(t, resp)
+				return
+			}
+			assert.Equal(t, tc.expectedProcessed, resp.Processed)
+			assert.Equal(t, tc.expectedStatus, resp.Status)
+			assert.Equal(t, tc.expectedReason, resp.FailureReason)
+		})
+	}
+}
+
+// fakeBalanceStream collects what StreamBalances sends.
+type fakeBalanceStream struct {
+	grpc.ServerStream
+	sent []*gen.BalanceSummary
+}
+
+func (s *fakeBalanceStream) Context() context.Context { return context.Background() }
+
+func (s *fakeBalanceStream) Send(summary *gen.BalanceSummary) error {
+	s.sent = append(s.sent, summary)
+	return nil
+}
+
+func TestStreamBalances(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		summaries []*BalanceSummary
+		expected  []*gen.BalanceSummary
+	}{
+		{
+			name: "when there are wallets, it should send each in minor units of its currency",
+			summaries: []*BalanceSummary{
+				{WalletID: "w1", Currency: "EUR", Balance: 2500, LedgerBalance: 2500, Adjustments: -500},
+				{WalletID: "w2", Currency: "JPY", Balance: 120000, LedgerBalance: 120000},
+			},
+			expected: []*gen.BalanceSummary{
+				{
+					WalletId:      "w1",
+					Balance:       &gen.Money{MinorUnits: 2500, Currency: "EUR"},
+					LedgerBalance: &gen.Money{MinorUnits: 2500, Currency: "EUR"},
+					Adjustments:   &gen.Money{MinorUnits: -500, Currency: "EUR"},
+				},
+				{
+					WalletId:      "w2",
+					Balance:       &gen.Money{MinorUnits: 1200, Currency: "JPY"},
+					LedgerBalance: &gen.Money{MinorUnits: 1200, Currency: "JPY"},
+					Adjustments:   &gen.Money{MinorUnits: 0, Currency: "JPY"},
+				},
+			},
+		},
+		{
+			name: "when there are no wallets, it should send nothing",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := &InMemoryWalletRepository{summaries: tc.summaries}
+			service := NewWalletService(repo, &InMemoryHoldRepository{wallets: repo}, nil, FXPolicy{}, logrus.New())
+			stream := &fakeBalanceStream{}
+
+			err := service.StreamBalances(&emptypb.Empty{}, stream)
+
+			assert.NoError(t, err)
+			assert.Equal(t, len(tc.expected), len(stream.sent))
+			for i, summary := range stream.sent {
+				assert.Equal(t, tc.expected[i].WalletId, summary.WalletId)
+				assert.Equal(t, tc.expected[i].Balance.MinorUnits, summary.Balance.MinorUnits)
+				assert.Equal(t, tc.expected[i].Balance.Currency, summary.Balance.Currency)
+				assert.Equal(t, tc.expected[i].Adjustments.MinorUnits, summary.Adjustments.MinorUnits)
+			}
+		})
+	}
+}
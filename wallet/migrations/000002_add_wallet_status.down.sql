@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS wallets_user_id_idx;
+
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS closed_at,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,5 @@
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'CLOSED')),
+    ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS wallets_user_id_idx ON wallets (user_id);
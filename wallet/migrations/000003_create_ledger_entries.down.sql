@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS ledger_entries;
+
+DROP FUNCTION IF EXISTS ledger_entries_append_only();
+DROP FUNCTION IF EXISTS ledger_entries_check_balanced();
@@ -0,0 +1,59 @@
+-- Every balance movement is a journal of postings whose debits and credits are
+-- equal. Wallet accounts are liabilities: a CREDIT raises the balance and a
+-- DEBIT lowers it. external_cash and fees are system accounts without a wallet.
+CREATE TABLE IF NOT EXISTS ledger_entries(
+id BIGSERIAL PRIMARY KEY,
+journal_id uuid NOT NULL,
+kind VARCHAR(20) NOT NULL,
+transaction_id VARCHAR(255) NOT NULL,
+account VARCHAR(20) NOT NULL CHECK (account IN ('wallet', 'external_cash', 'fees')),
+wallet_id uuid REFERENCES wallets(id),
+direction VARCHAR(6) NOT NULL CHECK (direction IN ('DEBIT', 'CREDIT')),
+amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+-- The wallet balance once the posting is applied, wallet accounts only.
+balance_after DECIMAL(15,2),
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+CHECK ((account = 'wallet') = (wallet_id IS NOT NULL)),
+CHECK ((account = 'wallet') = (balance_after IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_wallet_id_idx ON ledger_entries (wallet_id, id) WHERE wallet_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS ledger_entries_journal_id_idx ON ledger_entries (journal_id);
+
+-- Checked at commit, once all postings of the journal are in.
+CREATE OR REPLACE FUNCTION ledger_entries_check_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT SUM(CASE direction WHEN 'DEBIT' THEN amount ELSE -amount END)
+        FROM ledger_entries WHERE journal_id = NEW.journal_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger journal % is not balanced', NEW.journal_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_entries_balanced
+    AFTER INSERT ON ledger_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_entries_check_balanced();
+
+CREATE OR REPLACE FUNCTION ledger_entries_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_entries_append_only();
+
+-- Open the ledger of existing wallets with their current balance, so that
+-- every balance equals the sum of its wallet's postings from here on.
+WITH openings AS (
+    SELECT id AS wallet_id, balance, gen_random_uuid() AS journal_id
+    FROM wallets WHERE balance > 0
+)
+INSERT INTO ledger_entries (journal_id, kind, transaction_id, account, wallet_id, direction, amount, balance_after)
+SELECT journal_id, 'OPENING', 'opening-' || wallet_id, 'external_cash', NULL, 'DEBIT', balance, NULL FROM openings
+UNION ALL
+SELECT journal_id, 'OPENING', 'opening-' || wallet_id, 'wallet', wallet_id, 'CREDIT', balance, balance FROM openings;
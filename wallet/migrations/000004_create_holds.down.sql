@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS holds;
+
+ALTER TABLE wallets
+    DROP CONSTRAINT IF EXISTS wallets_held_balance_check,
+    DROP COLUMN IF EXISTS held_balance;
@@ -0,0 +1,25 @@
+-- Holds reserve part of a wallet's balance for a later capture. held_balance
+-- is the sum of the wallet's ACTIVE holds, the available balance is what is
+-- left of balance once it is set aside.
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS held_balance DECIMAL(15,2) NOT NULL DEFAULT 0,
+    ADD CONSTRAINT wallets_held_balance_check CHECK (held_balance >= 0 AND held_balance <= balance);
+
+CREATE TABLE IF NOT EXISTS holds(
+id uuid DEFAULT gen_random_uuid(),
+wallet_id uuid NOT NULL REFERENCES wallets(id),
+amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+-- Set once the hold is captured, at most the held amount.
+captured_amount DECIMAL(15,2) CHECK (captured_amount > 0 AND captured_amount <= amount),
+status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'CAPTURED', 'VOIDED', 'EXPIRED')),
+reference VARCHAR(255) NOT NULL DEFAULT '',
+expires_at TIMESTAMP NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+PRIMARY KEY(id)
+);
+
+CREATE INDEX IF NOT EXISTS holds_wallet_id_idx ON holds (wallet_id);
+-- The sweeper's scan for expired holds.
+CREATE INDEX IF NOT EXISTS holds_active_expires_at_idx ON holds (expires_at) WHERE status = 'ACTIVE';
@@ -0,0 +1,13 @@
+CREATE OR REPLACE FUNCTION ledger_entries_check_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT SUM(CASE direction WHEN 'DEBIT' THEN amount ELSE -amount END)
+        FROM ledger_entries WHERE journal_id = NEW.journal_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger journal % is not balanced', NEW.journal_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS currency;
+ALTER TABLE holds DROP COLUMN IF EXISTS currency;
+ALTER TABLE wallets DROP COLUMN IF EXISTS currency;
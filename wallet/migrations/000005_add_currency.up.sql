@@ -0,0 +1,22 @@
+-- Amounts so far were all in the default currency, EUR. Holds and ledger
+-- entries take the currency of their wallet, which never changes.
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'EUR';
+ALTER TABLE wallets ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE holds ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'EUR';
+ALTER TABLE holds ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'EUR';
+ALTER TABLE ledger_entries ALTER COLUMN currency DROP DEFAULT;
+
+-- System accounts are kept per currency, so journals balance per currency.
+CREATE OR REPLACE FUNCTION ledger_entries_check_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM ledger_entries WHERE journal_id = NEW.journal_id
+        GROUP BY currency
+        HAVING SUM(CASE direction WHEN 'DEBIT' THEN amount ELSE -amount END) <> 0) THEN
+        RAISE EXCEPTION 'ledger journal % is not balanced', NEW.journal_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,6 @@
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS ledger_entries_account_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_account_check
+    CHECK (account IN ('wallet', 'external_cash', 'fees')) NOT VALID;
+
+DROP TABLE IF EXISTS fx_quotes;
+DROP TABLE IF EXISTS fx_rates;
@@ -0,0 +1,43 @@
+-- The latest mid-market rate of each currency pair, kept up to date from the
+-- configured provider.
+CREATE TABLE IF NOT EXISTS fx_rates(
+from_currency CHAR(3) NOT NULL,
+to_currency CHAR(3) NOT NULL,
+rate NUMERIC(20,8) NOT NULL CHECK (rate > 0),
+source VARCHAR(255) NOT NULL,
+as_of TIMESTAMP NOT NULL,
+updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+PRIMARY KEY (from_currency, to_currency)
+);
+
+-- A quote locks a rate for a conversion until it expires. The spread, fee and
+-- rate source are kept for reporting.
+CREATE TABLE IF NOT EXISTS fx_quotes(
+id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+user_id INT NOT NULL,
+source_wallet_id uuid NOT NULL REFERENCES wallets(id),
+destination_wallet_id uuid NOT NULL REFERENCES wallets(id),
+source_amount DECIMAL(15,2) NOT NULL CHECK (source_amount > 0),
+source_currency CHAR(3) NOT NULL,
+fee DECIMAL(15,2) NOT NULL CHECK (fee >= 0),
+target_amount DECIMAL(15,2) NOT NULL CHECK (target_amount > 0),
+target_currency CHAR(3) NOT NULL,
+mid_rate NUMERIC(20,8) NOT NULL,
+rate NUMERIC(20,8) NOT NULL,
+spread_bps INT NOT NULL,
+fee_bps INT NOT NULL,
+rate_source VARCHAR(255) NOT NULL,
+rate_as_of TIMESTAMP NOT NULL,
+status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'EXECUTED')),
+expires_at TIMESTAMP NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+executed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS fx_quotes_user_id_idx ON fx_quotes (user_id, created_at);
+
+-- Conversions go through the platform's currency position.
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS ledger_entries_account_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_account_check
+    CHECK (account IN ('wallet', 'external_cash', 'fees', 'fx'));
@@ -0,0 +1,13 @@
+-- Transactions whose event has been applied, written in the same database
+-- transaction as the balance change, so that a redelivered event is skipped
+-- instead of applied twice.
+CREATE TABLE IF NOT EXISTS processed_transactions(
+transaction_id VARCHAR(255) PRIMARY KEY,
+kind VARCHAR(20) NOT NULL,
+processed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Deposits applied before the table existed are in the ledger.
+INSERT INTO processed_transactions (transaction_id, kind)
+SELECT DISTINCT transaction_id, kind FROM ledger_entries WHERE kind = 'DEPOSIT'
+ON CONFLICT DO NOTHING;
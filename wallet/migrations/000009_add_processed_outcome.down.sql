@@ -0,0 +1,3 @@
+ALTER TABLE processed_transactions
+DROP COLUMN IF EXISTS failure_reason,
+DROP COLUMN IF EXISTS status;
@@ -0,0 +1,11 @@
+-- The outcome of each processed transaction, so that the transaction service
+-- can settle one whose outcome event it never received.
+ALTER TABLE processed_transactions
+ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'COMPLETED',
+ADD COLUMN IF NOT EXISTS failure_reason VARCHAR(64);
+
+-- Deposits refused before the columns existed have no ledger entry.
+UPDATE processed_transactions p SET status = 'FAILED'
+WHERE NOT EXISTS (
+    SELECT 1 FROM ledger_entries e WHERE e.transaction_id = p.transaction_id AND e.kind = p.kind
+);
@@ -0,0 +1,5 @@
+-- Withdrawals are recorded in processed_transactions too, those applied before
+-- are in the ledger.
+INSERT INTO processed_transactions (transaction_id, kind)
+SELECT DISTINCT transaction_id, kind FROM ledger_entries WHERE kind = 'WITHDRAW'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1 @@
+DELETE FROM processed_transactions WHERE kind = 'TRANSFER';
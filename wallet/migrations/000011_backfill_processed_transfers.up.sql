@@ -0,0 +1,5 @@
+-- Transfers are recorded in processed_transactions under their debit
+-- transaction, those applied before are in the ledger.
+INSERT INTO processed_transactions (transaction_id, kind)
+SELECT DISTINCT transaction_id, kind FROM ledger_entries WHERE kind = 'TRANSFER' AND direction = 'DEBIT'
+ON CONFLICT DO NOTHING;
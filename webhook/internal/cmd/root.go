@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// NewRootCmd creates and returns the root command
+func NewRootCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "webhook-service",
+		Short: "A gRPC webhook service",
+		Long:  `A gRPC webhook service that manages partner callbacks and delivers domain events to them`,
+	}
+
+	// Add commands
+	rootCmd.AddCommand(NewServeCmd())
+
+	return rootCmd
+}
+
+// Execute runs the root command
+func Execute() {
+	rootCmd := NewRootCmd()
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/joho/godotenv"
+	"net"
+	"os/signal"
+	"syscall"
+	"webhook/internal/config"
+	"webhook/internal/delivery"
+	"webhook/internal/mtls"
+	"webhook/internal/webhook"
+	pb "webhook/proto/gen"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+// NewServeCmd creates and returns the serve command
+func NewServeCmd() *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Start the webhook service",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Cancelled on SIGTERM so that Kubernetes rolling deploys drain the pod.
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			// Load .env file
+			if err := godotenv.Load(); err != nil {
+				return fmt.Errorf("failed to load .env file: %w", err)
+			}
+
+			cfg, err := config.NewServerConfig()
+			if err != nil {
+				return fmt.Errorf("failed to create runtime config: %w", err)
+			}
+
+			log := newLogger(cfg.Log)
+
+			pgPool, err := newPostgresPool(ctx, cfg.Postgres)
+			if err != nil {
+				return fmt.Errorf("failed to create postgres pool: %w", err)
+			}
+			defer pgPool.Close()
+
+			log.Info("Successfully connected to postgres")
+
+			// Create dependencies
+			webhookRepo := webhook.NewPostgresWebhookRepository(pgPool, log)
+			webhookSvc := webhook.NewWebhookService(webhookRepo, cfg.Delivery, log)
+
+			consumer := delivery.NewConsumer(cfg.Delivery, webhookRepo, log)
+			defer consumer.Close()
+
+			consumerDone := make(chan struct{})
+			go func() {
+				defer close(consumerDone)
+				consumer.Consume(ctx)
+			}()
+
+			dispatcher := delivery.NewDispatcher(cfg.Delivery, webhookRepo, log)
+
+			dispatcherDone := make(chan struct{})
+			go func() {
+				defer close(dispatcherDone)
+				dispatcher.Run(ctx)
+			}()
+
+			// Set up gRPC server
+			lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.ListenPort))
+			if err != nil {
+				return fmt.Errorf("failed to listen: %w", err)
+			}
+
+			serverOpts, err := mtls.ServerOptions(ctx, cfg.TLS, log)
+			if err != nil {
+				return fmt.Errorf("failed to configure TLS: %w", err)
+			}
+
+			s := grpc.NewServer(serverOpts...)
+			pb.RegisterWebhookServiceServer(s, webhookSvc)
+
+			serveErr := make(chan error, 1)
+			go func() {
+				log.Printf("Webhook service running on :%s", cfg.ListenPort)
+				serveErr <- s.Serve(lis)
+			}()
+
+			select {
+			case err := <-serveErr:
+				return fmt.Errorf("failed to serve: %w", err)
+			case <-ctx.Done():
+			}
+
+			log.Infof("Shutting down, draining for up to %s", cfg.ShutdownTimeout)
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+			defer cancel()
+
+			gracefulStop(shutdownCtx, s)
+
+			for name, done := range map[string]chan struct{}{"consumer": consumerDone, "dispatcher": dispatcherDone} {
+				select {
+				case <-done:
+				case <-shutdownCtx.Done():
+					log.Warnf("Timed out waiting for the webhook %s", name)
+				}
+			}
+
+			log.Info("Webhook service stopped")
+			return nil
+		},
+	}
+
+	return serveCmd
+}
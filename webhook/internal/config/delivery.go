@@ -0,0 +1,39 @@
+package config
+
+import "time"
+
+type Delivery struct {
+	// KafkaBrokers are the Kafka bootstrap servers the domain events are read from.
+	KafkaBrokers []string `default:"localhost:9092" envconfig:"DELIVERY_KAFKA_BROKERS"`
+
+	// Topics are the domain topics delivered to endpoints, the topic name is the event type.
+	Topics []string `default:"deposit_completed,withdraw_completed,withdraw_failed,transfer_completed,transfer_failed" envconfig:"DELIVERY_TOPICS"`
+
+	// GroupID is the consumer group shared by all webhook instances.
+	GroupID string `default:"webhook-group" envconfig:"DELIVERY_GROUP_ID"`
+
+	// Workers is the number of deliveries sent concurrently.
+	Workers int `default:"10" envconfig:"DELIVERY_WORKERS"`
+
+	// PollInterval is how often due deliveries are picked up.
+	PollInterval time.Duration `default:"1s" envconfig:"DELIVERY_POLL_INTERVAL"`
+
+	// RequestTimeout bounds a single POST to an endpoint.
+	RequestTimeout time.Duration `default:"10s" envconfig:"DELIVERY_REQUEST_TIMEOUT"`
+
+	// MaxAttempts is how many times a delivery is tried before it is marked FAILED.
+	MaxAttempts int `default:"8" envconfig:"DELIVERY_MAX_ATTEMPTS"`
+
+	// BackoffBase and BackoffMax bound the exponential delay between attempts.
+	BackoffBase time.Duration `default:"30s" envconfig:"DELIVERY_BACKOFF_BASE"`
+	BackoffMax  time.Duration `default:"6h" envconfig:"DELIVERY_BACKOFF_MAX"`
+
+	// DisableAfter is the number of consecutive FAILED deliveries after which an endpoint is disabled.
+	DisableAfter int `default:"5" envconfig:"DELIVERY_DISABLE_AFTER"`
+
+	// SecretGracePeriod is how long payloads are also signed with the previous secret after a rotation.
+	SecretGracePeriod time.Duration `default:"24h" envconfig:"DELIVERY_SECRET_GRACE_PERIOD"`
+
+	// AllowInsecure allows plain http:// endpoints and private network addresses, for local development only.
+	AllowInsecure bool `default:"false" envconfig:"DELIVERY_ALLOW_INSECURE"`
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"time"
+)
+
+type ServerCfg struct {
+	// ListenPort is the port where the server listens for incoming requests.
+	ListenPort string `default:"50054" envconfig:"LISTEN_PORT"`
+
+	// ShutdownTimeout bounds draining on SIGTERM, keep it below the pod's termination grace period.
+	ShutdownTimeout time.Duration `default:"25s" envconfig:"SHUTDOWN_TIMEOUT"`
+
+	Postgres Postgres
+	Log      Log
+	TLS      TLS
+	Delivery Delivery
+}
+
+func NewServerConfig() (*ServerCfg, error) {
+	var cfg ServerCfg
+
+	err := envconfig.Process("", &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process env variables: %w", err)
+	}
+
+	return &cfg, nil
+}
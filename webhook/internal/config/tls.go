@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+type TLS struct {
+	// Enabled is a toggle whether gRPC traffic is served over TLS or plaintext.
+	Enabled bool `default:"false" envconfig:"TLS_ENABLED"`
+
+	// CertFile and KeyFile are the PEM encoded certificate and private key presented to peers.
+	CertFile string `envconfig:"TLS_CERT_FILE"`
+	KeyFile  string `envconfig:"TLS_KEY_FILE"`
+
+	// CAFile is the PEM encoded CA bundle used to verify peers. When set, clients must present a certificate (mTLS).
+	CAFile string `envconfig:"TLS_CA_FILE"`
+
+	// AllowedSANs is a list of DNS/URI SANs that are allowed to call the server. Empty allows any verified client.
+	AllowedSANs []string `envconfig:"TLS_ALLOWED_SANS"`
+
+	// ReloadInterval is how often the certificate files are checked for changes.
+	ReloadInterval time.Duration `default:"30s" envconfig:"TLS_RELOAD_INTERVAL"`
+}
@@ -0,0 +1,27 @@
+package delivery
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// backoff returns the delay before the attempt after the given one: base doubled
+// for every previous attempt, capped at max.
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return min(delay, max)
+}
+
+// jitter spreads retries of endpoints that failed together by up to a fifth of delay.
+func jitter(delay time.Duration) time.Duration {
+	if delay < 5 {
+		return delay
+	}
+	return delay + rand.N(delay/5)
+}
@@ -0,0 +1,149 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+	"time"
+	"webhook/internal/config"
+	"webhook/internal/webhook"
+)
+
+// Event is the JSON body POSTed to endpoints. Data is the domain event as published.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Store is the part of the webhook repository the delivery worker needs.
+type Store interface {
+	ListSubscribedEndpoints(ctx context.Context, userID int, eventType string) ([]*webhook.Endpoint, error)
+	CreateDeliveries(ctx context.Context, deliveries []*webhook.Delivery) error
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*webhook.DueDelivery, error)
+	MarkSucceeded(ctx context.Context, delivery *webhook.DueDelivery, attempt webhook.Attempt) error
+	MarkRetry(ctx context.Context, delivery *webhook.DueDelivery, attempt webhook.Attempt, delay time.Duration) error
+	MarkFailed(ctx context.Context, delivery *webhook.DueDelivery, attempt webhook.Attempt, disableAfter int) (bool, error)
+}
+
+// Consumer turns domain events into pending deliveries, one per subscribed endpoint.
+type Consumer struct {
+	reader *kafka.Reader
+	store  Store
+	log    *logrus.Logger
+}
+
+func NewConsumer(cfg config.Delivery, store Store, log *logrus.Logger) *Consumer {
+	return &Consumer{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     cfg.KafkaBrokers,
+			GroupTopics: cfg.Topics,
+			GroupID:     cfg.GroupID,
+			MinBytes:    10e3, // 10KB
+			MaxBytes:    10e6, // 10MB
+		}),
+		store: store,
+		log:   log,
+	}
+}
+
+// Consume runs until ctx is cancelled. A message is committed only once its
+// deliveries are stored, so a crash re-reads it and the unique event ID per
+// endpoint keeps it from being queued twice.
+func (c *Consumer) Consume(ctx context.Context) {
+	c.log.Info("Starting webhook consumer")
+
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				c.log.Info("Webhook consumer stopped")
+				return
+			}
+			c.log.WithError(err).Error("failed to fetch message")
+			time.Sleep(time.Second)
+			continue
+		}
+
+		// The message is already fetched, finish it even if shutdown starts now.
+		workCtx := context.WithoutCancel(ctx)
+		for {
+			err := c.enqueue(workCtx, msg)
+			if err == nil {
+				break
+			}
+			c.log.WithError(err).WithField("offset", msg.Offset).Error("failed to enqueue deliveries, retrying")
+
+			select {
+			case <-ctx.Done():
+				// Left uncommitted, the next instance picks it up.
+				return
+			case <-time.After(time.Second):
+			}
+		}
+
+		if err := c.reader.CommitMessages(workCtx, msg); err != nil {
+			c.log.WithError(err).Error("failed to commit message")
+		}
+	}
+}
+
+func (c *Consumer) enqueue(ctx context.Context, msg kafka.Message) error {
+	var owner struct {
+		UserID int `json:"user_id"`
+		// DestinationUserID is set on transfers, their recipient receives them too.
+		DestinationUserID int `json:"destination_user_id"`
+	}
+	if err := json.Unmarshal(msg.Value, &owner); err != nil || owner.UserID == 0 {
+		c.log.WithField("topic", msg.Topic).WithField("offset", msg.Offset).Warn("skipping event without user_id")
+		return nil
+	}
+
+	userIDs := []int{owner.UserID}
+	if owner.DestinationUserID != 0 && owner.DestinationUserID != owner.UserID {
+		userIDs = append(userIDs, owner.DestinationUserID)
+	}
+
+	var endpoints []*webhook.Endpoint
+	for _, userID := range userIDs {
+		subscribed, err := c.store.ListSubscribedEndpoints(ctx, userID, msg.Topic)
+		if err != nil {
+			return err
+		}
+		endpoints = append(endpoints, subscribed...)
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	event := Event{
+		ID:        fmt.Sprintf("%s-%d-%d", msg.Topic, msg.Partition, msg.Offset),
+		Type:      msg.Topic,
+		CreatedAt: msg.Time.UTC(),
+		Data:      msg.Value,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	deliveries := make([]*webhook.Delivery, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		deliveries = append(deliveries, &webhook.Delivery{
+			EndpointID: endpoint.ID,
+			EventID:    event.ID,
+			EventType:  event.Type,
+			Payload:    payload,
+		})
+	}
+	return c.store.CreateDeliveries(ctx, deliveries)
+}
+
+func (c *Consumer) Close() {
+	if err := c.reader.Close(); err != nil {
+		c.log.WithError(err).Error("failed to close Kafka reader")
+	}
+}
@@ -0,0 +1,205 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+	"webhook/internal/config"
+	"webhook/internal/webhook"
+)
+
+const (
+	userAgent = "Wall-E-Webhooks/1.0"
+
+	// maxErrorBody is how much of a failed response body is kept in the delivery log.
+	maxErrorBody = 512
+)
+
+var errForbiddenAddress = errors.New("endpoint resolves to a non-public address")
+
+// Dispatcher sends due deliveries and records every attempt.
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	cfg    config.Delivery
+	log    *logrus.Logger
+	now    func() time.Time
+}
+
+func NewDispatcher(cfg config.Delivery, store Store, log *logrus.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: newHTTPClient(cfg.RequestTimeout, cfg.AllowInsecure),
+		cfg:    cfg,
+		log:    log,
+		now:    time.Now,
+	}
+}
+
+// newHTTPClient returns a client that doesn't follow redirects and, unless
+// allowInsecure, refuses to connect to loopback, private and link-local
+// addresses. The check runs on the resolved address so DNS can't bypass it.
+func newHTTPClient(timeout time.Duration, allowInsecure bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			if allowInsecure {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+				return errForbiddenAddress
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Run polls for due deliveries until ctx is cancelled. Deliveries already
+// claimed are sent and recorded before it returns.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.log.Infof("Starting webhook dispatcher with %d workers", d.cfg.Workers)
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going without waiting for the ticker while there is a backlog.
+		for d.dispatch(ctx) == d.cfg.Workers && ctx.Err() == nil {
+		}
+
+		select {
+		case <-ctx.Done():
+			d.log.Info("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch claims up to one delivery per worker, sends them concurrently and
+// returns how many were claimed.
+func (d *Dispatcher) dispatch(ctx context.Context) int {
+	// Leased for longer than a send can take, a worker that dies mid-send
+	// leaves the delivery to be retried once the lease runs out.
+	due, err := d.store.ClaimDue(ctx, d.cfg.Workers, 2*d.cfg.RequestTimeout)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.log.WithError(err).Error("failed to claim due deliveries")
+		}
+		return 0
+	}
+
+	workCtx := context.WithoutCancel(ctx)
+
+	var wg sync.WaitGroup
+	for _, delivery := range due {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(workCtx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(due)
+}
+
+// deliver sends one delivery and records the outcome: success, a retry after
+// backoff, or failure once MaxAttempts is reached.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *webhook.DueDelivery) {
+	attempt := d.send(ctx, delivery)
+	log := d.log.WithField("delivery_id", delivery.ID).WithField("endpoint_id", delivery.EndpointID).
+		WithField("attempt", attempt.Attempt)
+
+	if attempt.Error == "" {
+		if err := d.store.MarkSucceeded(ctx, delivery, attempt); err != nil {
+			log.WithError(err).Error("failed to record delivery")
+		}
+		return
+	}
+
+	if attempt.Attempt < d.cfg.MaxAttempts {
+		delay := jitter(backoff(attempt.Attempt, d.cfg.BackoffBase, d.cfg.BackoffMax))
+		log.WithField("retry_in", delay).Warnf("delivery failed: %s", attempt.Error)
+		if err := d.store.MarkRetry(ctx, delivery, attempt, delay); err != nil {
+			log.WithError(err).Error("failed to record delivery")
+		}
+		return
+	}
+
+	disabled, err := d.store.MarkFailed(ctx, delivery, attempt, d.cfg.DisableAfter)
+	if err != nil {
+		log.WithError(err).Error("failed to record delivery")
+		return
+	}
+	log.Warnf("delivery failed after %d attempts: %s", attempt.Attempt, attempt.Error)
+	if disabled {
+		log.Warnf("endpoint disabled after %d failed deliveries in a row", d.cfg.DisableAfter)
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *webhook.DueDelivery) webhook.Attempt {
+	attempt := webhook.Attempt{Attempt: delivery.Attempts + 1}
+	started := d.now()
+	defer func() { attempt.Duration = d.now().Sub(started) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, SignatureHeaderValue(delivery.Endpoint.Secrets(), started.Unix(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		// Drain so the connection is reused.
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return attempt
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	attempt.Error = "unexpected status " + strconv.Itoa(resp.StatusCode)
+	if len(body) > 0 {
+		attempt.Error = fmt.Sprintf("%s: %s", attempt.Error, bytes.ReplaceAll(bytes.ToValidUTF8(body, nil), []byte{0}, nil))
+	}
+	return attempt
+}
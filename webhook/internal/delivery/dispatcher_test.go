@@ -0,0 +1,165 @@
+package delivery
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"webhook/internal/config"
+	"webhook/internal/webhook"
+)
+
+// recordingStore keeps the outcome the dispatcher records for each delivery
+type recordingStore struct {
+	mu       sync.Mutex
+	due      []*webhook.DueDelivery
+	outcome  string
+	attempt  webhook.Attempt
+	disabled bool
+}
+
+func (s *recordingStore) ListSubscribedEndpoints(context.Context, int, string) ([]*webhook.Endpoint, error) {
+	return nil, nil
+}
+
+func (s *recordingStore) CreateDeliveries(context.Context, []*webhook.Delivery) error {
+	return nil
+}
+
+func (s *recordingStore) ClaimDue(_ context.Context, limit int, _ time.Duration) ([]*webhook.DueDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := min(limit, len(s.due))
+	due := s.due[:n]
+	s.due = s.due[n:]
+	return due, nil
+}
+
+func (s *recordingStore) MarkSucceeded(_ context.Context, _ *webhook.DueDelivery, attempt webhook.Attempt) error {
+	s.record("succeeded", attempt)
+	return nil
+}
+
+func (s *recordingStore) MarkRetry(_ context.Context, _ *webhook.DueDelivery, attempt webhook.Attempt, _ time.Duration) error {
+	s.record("retry", attempt)
+	return nil
+}
+
+func (s *recordingStore) MarkFailed(_ context.Context, _ *webhook.DueDelivery, attempt webhook.Attempt, disableAfter int) (bool, error) {
+	s.record("failed", attempt)
+	s.disabled = disableAfter == 1
+	return s.disabled, nil
+}
+
+func (s *recordingStore) record(outcome string, attempt webhook.Attempt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outcome = outcome
+	s.attempt = attempt
+}
+
+func TestDispatch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		status             int
+		previousAttempts   int
+		expectedOutcome    string
+		expectedStatusCode int
+		expectedError      string
+	}{
+		{
+			name:               "when the endpoint answers 2xx, it should mark the delivery succeeded",
+			status:             http.StatusNoContent,
+			expectedOutcome:    "succeeded",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:               "when the endpoint fails, it should schedule a retry with the response logged",
+			status:             http.StatusServiceUnavailable,
+			expectedOutcome:    "retry",
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedError:      "unexpected status 503: try later",
+		},
+		{
+			name:               "when the last attempt fails, it should mark the delivery failed",
+			status:             http.StatusInternalServerError,
+			previousAttempts:   2,
+			expectedOutcome:    "failed",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedError:      "unexpected status 500: try later",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var signature, event string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				signature = r.Header.Get(SignatureHeader)
+				event = r.Header.Get(EventHeader)
+				assert.NoError(t, Verify(signature, "whsec_test", body, time.Minute, time.Now()))
+
+				w.WriteHeader(tc.status)
+				if tc.status >= 300 {
+					_, _ = w.Write([]byte("try later"))
+				}
+			}))
+			defer server.Close()
+
+			store := &recordingStore{due: []*webhook.DueDelivery{{
+				Delivery: webhook.Delivery{
+					ID:        "delivery-1",
+					EventType: "deposit_completed",
+					Payload:   []byte(`{"id":"deposit_completed-0-1"}`),
+					Attempts:  tc.previousAttempts,
+				},
+				Endpoint: webhook.Endpoint{URL: server.URL, Secret: "whsec_test"},
+			}}}
+
+			cfg := config.Delivery{
+				Workers:        1,
+				RequestTimeout: time.Second,
+				MaxAttempts:    3,
+				BackoffBase:    time.Second,
+				BackoffMax:     time.Minute,
+				DisableAfter:   5,
+				AllowInsecure:  true,
+			}
+			dispatcher := NewDispatcher(cfg, store, logrus.New())
+
+			assert.Equal(t, 1, dispatcher.dispatch(context.Background()))
+			assert.Equal(t, "deposit_completed", event)
+			assert.Equal(t, tc.expectedOutcome, store.outcome)
+			assert.Equal(t, tc.previousAttempts+1, store.attempt.Attempt)
+			assert.Equal(t, tc.expectedStatusCode, store.attempt.StatusCode)
+			assert.Equal(t, tc.expectedError, store.attempt.Error)
+		})
+	}
+}
+
+func TestDispatchRejectsPrivateAddresses(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a loopback endpoint should not be called")
+	}))
+	defer server.Close()
+
+	store := &recordingStore{due: []*webhook.DueDelivery{{
+		Delivery: webhook.Delivery{ID: "delivery-1", Payload: []byte(`{}`)},
+		Endpoint: webhook.Endpoint{URL: server.URL, Secret: "whsec_test"},
+	}}}
+
+	cfg := config.Delivery{Workers: 1, RequestTimeout: time.Second, MaxAttempts: 3, BackoffBase: time.Second, BackoffMax: time.Minute}
+	NewDispatcher(cfg, store, logrus.New()).dispatch(context.Background())
+
+	assert.Equal(t, "retry", store.outcome)
+	assert.Contains(t, store.attempt.Error, errForbiddenAddress.Error())
+}
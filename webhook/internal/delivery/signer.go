@@ -0,0 +1,86 @@
+package delivery
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery.
+const (
+	SignatureHeader = "Walle-Signature"
+	EventHeader     = "Walle-Event"
+	DeliveryHeader  = "Walle-Delivery"
+)
+
+var (
+	ErrInvalidSignatureHeader = errors.New("invalid signature header")
+	ErrTimestampOutOfRange    = errors.New("signature timestamp outside tolerance")
+	ErrSignatureMismatch      = errors.New("no matching signature")
+)
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" under secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureHeaderValue builds the signature header, "t=<unix>,v1=<sig>[,v1=<sig>]",
+// with one v1 entry per secret so that receivers keep verifying during a rotation.
+func SignatureHeaderValue(secrets []string, timestamp int64, body []byte) string {
+	var b strings.Builder
+	b.WriteString("t=")
+	b.WriteString(strconv.FormatInt(timestamp, 10))
+	for _, secret := range secrets {
+		b.WriteString(",v1=")
+		b.WriteString(Sign(secret, timestamp, body))
+	}
+	return b.String()
+}
+
+// Verify checks a signature header the way receivers are documented to: the
+// timestamp must be within tolerance of now and one v1 entry must match secret.
+func Verify(header, secret string, body []byte, tolerance time.Duration, now time.Time) error {
+	var (
+		timestamp  int64
+		signatures []string
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return ErrInvalidSignatureHeader
+		}
+		switch key {
+		case "t":
+			ts, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrInvalidSignatureHeader
+			}
+			timestamp = ts
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrInvalidSignatureHeader
+	}
+
+	if age := now.Sub(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return ErrTimestampOutOfRange
+	}
+
+	expected := Sign(secret, timestamp, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
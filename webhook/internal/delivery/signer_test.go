@@ -0,0 +1,89 @@
+package delivery
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"id":"deposit_completed-0-1"}`)
+	now := time.Unix(1700000000, 0)
+
+	testCases := []struct {
+		name        string
+		header      string
+		secret      string
+		expectedErr error
+	}{
+		{
+			name:   "when the payload is signed with the secret, it should verify",
+			header: SignatureHeaderValue([]string{"whsec_new"}, now.Unix(), body),
+			secret: "whsec_new",
+		},
+		{
+			name:   "when signed with the new and previous secret, it should verify with the previous one",
+			header: SignatureHeaderValue([]string{"whsec_new", "whsec_old"}, now.Unix(), body),
+			secret: "whsec_old",
+		},
+		{
+			name:        "when signed with another secret, it should return a mismatch",
+			header:      SignatureHeaderValue([]string{"whsec_other"}, now.Unix(), body),
+			secret:      "whsec_new",
+			expectedErr: ErrSignatureMismatch,
+		},
+		{
+			name:        "when the timestamp is too old, it should reject a replay",
+			header:      SignatureHeaderValue([]string{"whsec_new"}, now.Add(-10*time.Minute).Unix(), body),
+			secret:      "whsec_new",
+			expectedErr: ErrTimestampOutOfRange,
+		},
+		{
+			name:        "when the header has no signature, it should return an invalid header error",
+			header:      "t=1700000000",
+			secret:      "whsec_new",
+			expectedErr: ErrInvalidSignatureHeader,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.header, tc.secret, body, 5*time.Minute, now)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		attempt  int
+		expected time.Duration
+	}{
+		{
+			name:     "when the first attempt failed, it should wait the base delay",
+			attempt:  1,
+			expected: 30 * time.Second,
+		},
+		{
+			name:     "when the fourth attempt failed, it should double the delay three times",
+			attempt:  4,
+			expected: 4 * time.Minute,
+		},
+		{
+			name:     "when many attempts failed, it should cap the delay",
+			attempt:  20,
+			expected: time.Hour,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, backoff(tc.attempt, 30*time.Second, time.Hour))
+		})
+	}
+}
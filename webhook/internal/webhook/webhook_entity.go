@@ -0,0 +1,66 @@
+package webhook
+
+import "time"
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING"
+	DeliverySucceeded DeliveryStatus = "SUCCEEDED"
+	DeliveryFailed    DeliveryStatus = "FAILED"
+)
+
+// Endpoint is a partner URL that receives the events of one user.
+type Endpoint struct {
+	ID     string
+	UserID int
+	URL    string
+	Secret string
+	// PreviousSecret is still used to sign payloads during the grace period after a rotation.
+	PreviousSecret      string
+	EventTypes          []string
+	Enabled             bool
+	ConsecutiveFailures int
+	DisabledAt          *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// Secrets returns the secrets payloads are currently signed with, newest first.
+func (e *Endpoint) Secrets() []string {
+	if e.PreviousSecret == "" {
+		return []string{e.Secret}
+	}
+	return []string{e.Secret, e.PreviousSecret}
+}
+
+// Delivery is one event queued for one endpoint.
+type Delivery struct {
+	ID             string
+	EndpointID     string
+	EventID        string
+	EventType      string
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	AttemptLog     []Attempt
+}
+
+// DueDelivery is a delivery claimed for sending together with its endpoint.
+type DueDelivery struct {
+	Delivery
+	Endpoint Endpoint
+}
+
+// Attempt is one entry of the delivery log.
+type Attempt struct {
+	Attempt    int
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+	CreatedAt  time.Time
+}
@@ -0,0 +1,396 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+type Repository interface {
+	CreateEndpoint(ctx context.Context, endpoint *Endpoint) error
+	ListEndpoints(ctx context.Context, userID int) ([]*Endpoint, error)
+	GetEndpoint(ctx context.Context, userID int, endpointID string) (*Endpoint, error)
+	DeleteEndpoint(ctx context.Context, userID int, endpointID string) (bool, error)
+	RotateSecret(ctx context.Context, userID int, endpointID, secret string, gracePeriod time.Duration) (*Endpoint, error)
+	EnableEndpoint(ctx context.Context, userID int, endpointID string) (*Endpoint, error)
+	ListDeliveries(ctx context.Context, endpointID string, limit int) ([]*Delivery, error)
+	Redeliver(ctx context.Context, endpointID, deliveryID string) (*Delivery, error)
+}
+
+type PostgresWebhookRepository struct {
+	db  *pgxpool.Pool
+	log *logrus.Logger
+}
+
+func NewPostgresWebhookRepository(db *pgxpool.Pool, log *logrus.Logger) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// The previous secret is only returned while its grace period lasts.
+const endpointColumns = `id, user_id, url, secret,
+	CASE WHEN previous_secret_expires_at > NOW() THEN COALESCE(previous_secret, '') ELSE '' END,
+	event_types, enabled, consecutive_failures, disabled_at, created_at, updated_at`
+
+func scanEndpoint(row pgx.Row) (*Endpoint, error) {
+	var endpoint Endpoint
+	err := row.Scan(
+		&endpoint.ID,
+		&endpoint.UserID,
+		&endpoint.URL,
+		&endpoint.Secret,
+		&endpoint.PreviousSecret,
+		&endpoint.EventTypes,
+		&endpoint.Enabled,
+		&endpoint.ConsecutiveFailures,
+		&endpoint.DisabledAt,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+	)
+	return &endpoint, err
+}
+
+// CreateEndpoint inserts the endpoint and sets its ID and timestamps
+func (r *PostgresWebhookRepository) CreateEndpoint(ctx context.Context, endpoint *Endpoint) error {
+	query := `insert into webhook_endpoints (user_id, url, secret, event_types)
+		values ($1, $2, $3, $4) returning id, enabled, created_at, updated_at`
+
+	err := r.db.QueryRow(ctx, query, endpoint.UserID, endpoint.URL, endpoint.Secret, endpoint.EventTypes).
+		Scan(&endpoint.ID, &endpoint.Enabled, &endpoint.CreatedAt, &endpoint.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create endpoint: %w", err)
+	}
+	return nil
+}
+
+// ListEndpoints returns the endpoints of a user
+func (r *PostgresWebhookRepository) ListEndpoints(ctx context.Context, userID int) ([]*Endpoint, error) {
+	query := `select ` + endpointColumns + ` from webhook_endpoints where user_id = $1 order by created_at`
+
+	return r.queryEndpoints(ctx, query, userID)
+}
+
+// ListSubscribedEndpoints returns the enabled endpoints of a user that receive eventType
+func (r *PostgresWebhookRepository) ListSubscribedEndpoints(ctx context.Context, userID int, eventType string) ([]*Endpoint, error) {
+	query := `select ` + endpointColumns + ` from webhook_endpoints
+		where user_id = $1 and enabled and (event_types = '{}' or $2 = any(event_types))`
+
+	return r.queryEndpoints(ctx, query, userID, eventType)
+}
+
+func (r *PostgresWebhookRepository) queryEndpoints(ctx context.Context, query string, args ...any) ([]*Endpoint, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []*Endpoint
+	for rows.Next() {
+		endpoint, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan endpoint: %w", err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// GetEndpoint returns one endpoint of a user, with an empty ID when it doesn't exist
+func (r *PostgresWebhookRepository) GetEndpoint(ctx context.Context, userID int, endpointID string) (*Endpoint, error) {
+	query := `select ` + endpointColumns + ` from webhook_endpoints where user_id = $1 and id = $2`
+
+	return r.queryEndpoint(ctx, query, userID, endpointID)
+}
+
+func (r *PostgresWebhookRepository) queryEndpoint(ctx context.Context, query string, args ...any) (*Endpoint, error) {
+	endpoint, err := scanEndpoint(r.db.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Endpoint{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// DeleteEndpoint deletes an endpoint of a user together with its delivery log
+func (r *PostgresWebhookRepository) DeleteEndpoint(ctx context.Context, userID int, endpointID string) (bool, error) {
+	query := `delete from webhook_endpoints where user_id = $1 and id = $2`
+
+	tag, err := r.db.Exec(ctx, query, userID, endpointID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete endpoint: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RotateSecret replaces the secret and keeps the current one valid for gracePeriod
+func (r *PostgresWebhookRepository) RotateSecret(ctx context.Context, userID int, endpointID, secret string, gracePeriod time.Duration) (*Endpoint, error) {
+	query := `update webhook_endpoints
+		set previous_secret = secret, previous_secret_expires_at = NOW() + $4::interval, secret = $3, updated_at = NOW()
+		where user_id = $1 and id = $2
+		returning ` + endpointColumns
+
+	return r.queryEndpoint(ctx, query, userID, endpointID, secret, gracePeriod)
+}
+
+// EnableEndpoint re-enables a disabled endpoint and resets its failure count
+func (r *PostgresWebhookRepository) EnableEndpoint(ctx context.Context, userID int, endpointID string) (*Endpoint, error) {
+	query := `update webhook_endpoints
+		set enabled = true, consecutive_failures = 0, disabled_at = null, updated_at = NOW()
+		where user_id = $1 and id = $2
+		returning ` + endpointColumns
+
+	return r.queryEndpoint(ctx, query, userID, endpointID)
+}
+
+const deliveryColumns = `id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	COALESCE(last_status_code, 0), COALESCE(last_error, ''), created_at`
+
+func scanDelivery(row pgx.Row) (*Delivery, error) {
+	var delivery Delivery
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.EndpointID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+	)
+	return &delivery, err
+}
+
+// ListDeliveries returns the latest deliveries of an endpoint with their attempt log
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, endpointID string, limit int) ([]*Delivery, error) {
+	query := `select ` + deliveryColumns + ` from webhook_deliveries
+		where endpoint_id = $1 order by created_at desc limit $2`
+
+	rows, err := r.db.Query(ctx, query, endpointID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*Delivery
+	byID := make(map[string]*Delivery)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+		byID[delivery.ID] = delivery
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+
+	ids := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.ID)
+	}
+
+	attemptsQuery := `select delivery_id, attempt, COALESCE(status_code, 0), COALESCE(error, ''), duration_ms, created_at
+		from webhook_delivery_attempts where delivery_id = any($1::uuid[]) order by attempt`
+
+	attemptRows, err := r.db.Query(ctx, attemptsQuery, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list delivery attempts: %w", err)
+	}
+	defer attemptRows.Close()
+
+	for attemptRows.Next() {
+		var (
+			deliveryID string
+			attempt    Attempt
+			durationMs int64
+		)
+		if err := attemptRows.Scan(&deliveryID, &attempt.Attempt, &attempt.StatusCode, &attempt.Error, &durationMs, &attempt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan delivery attempt: %w", err)
+		}
+		attempt.Duration = time.Duration(durationMs) * time.Millisecond
+		byID[deliveryID].AttemptLog = append(byID[deliveryID].AttemptLog, attempt)
+	}
+	if err := attemptRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list delivery attempts: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver queues a delivery again with a fresh retry budget, with an empty ID when it doesn't exist
+func (r *PostgresWebhookRepository) Redeliver(ctx context.Context, endpointID, deliveryID string) (*Delivery, error) {
+	query := `update webhook_deliveries
+		set status = 'PENDING', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+		where endpoint_id = $1 and id = $2
+		returning ` + deliveryColumns
+
+	delivery, err := scanDelivery(r.db.QueryRow(ctx, query, endpointID, deliveryID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Delivery{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeliver: %w", err)
+	}
+	return delivery, nil
+}
+
+// CreateDeliveries queues deliveries, skipping events an endpoint already has
+func (r *PostgresWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*Delivery) error {
+	batch := &pgx.Batch{}
+	for _, delivery := range deliveries {
+		batch.Queue(`insert into webhook_deliveries (endpoint_id, event_id, event_type, payload)
+			values ($1, $2, $3, $4) on conflict (endpoint_id, event_id) do nothing`,
+			delivery.EndpointID, delivery.EventID, delivery.EventType, delivery.Payload)
+	}
+
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to create deliveries: %w", err)
+	}
+	return nil
+}
+
+// ClaimDue leases up to limit due deliveries of enabled endpoints. A delivery that
+// is not recorded within lease, e.g. because the worker died, becomes due again.
+func (r *PostgresWebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*DueDelivery, error) {
+	query := `with due as (
+			select d.id from webhook_deliveries d
+			join webhook_endpoints e on e.id = d.endpoint_id
+			where d.status = 'PENDING' and d.next_attempt_at <= NOW() and e.enabled
+			order by d.next_attempt_at
+			limit $1
+			for update of d skip locked
+		)
+		update webhook_deliveries d
+		set next_attempt_at = NOW() + $2::interval, updated_at = NOW()
+		from due, webhook_endpoints e
+		where d.id = due.id and e.id = d.endpoint_id
+		returning d.id, d.endpoint_id, d.event_id, d.event_type, d.payload, d.attempts, e.url, e.secret,
+			CASE WHEN e.previous_secret_expires_at > NOW() THEN COALESCE(e.previous_secret, '') ELSE '' END`
+
+	rows, err := r.db.Query(ctx, query, limit, lease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var due []*DueDelivery
+	for rows.Next() {
+		var d DueDelivery
+		err := rows.Scan(
+			&d.ID,
+			&d.EndpointID,
+			&d.EventID,
+			&d.EventType,
+			&d.Payload,
+			&d.Attempts,
+			&d.Endpoint.URL,
+			&d.Endpoint.Secret,
+			&d.Endpoint.PreviousSecret,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan claimed delivery: %w", err)
+		}
+		d.Endpoint.ID = d.EndpointID
+		due = append(due, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+	return due, nil
+}
+
+// MarkSucceeded logs the attempt, completes the delivery and resets the endpoint's failure count
+func (r *PostgresWebhookRepository) MarkSucceeded(ctx context.Context, delivery *DueDelivery, attempt Attempt) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := recordAttempt(ctx, tx, delivery, attempt, DeliverySucceeded, 0); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, `update webhook_endpoints set consecutive_failures = 0 where id = $1`, delivery.EndpointID)
+		if err != nil {
+			return fmt.Errorf("failed to reset endpoint failures: %w", err)
+		}
+		return nil
+	})
+}
+
+// MarkRetry logs the attempt and schedules the next one after delay
+func (r *PostgresWebhookRepository) MarkRetry(ctx context.Context, delivery *DueDelivery, attempt Attempt, delay time.Duration) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return recordAttempt(ctx, tx, delivery, attempt, DeliveryPending, delay)
+	})
+}
+
+// MarkFailed logs the last attempt and fails the delivery. The endpoint is disabled
+// once disableAfter deliveries in a row have failed, and its pending deliveries are
+// failed with it. It reports whether the endpoint was disabled.
+func (r *PostgresWebhookRepository) MarkFailed(ctx context.Context, delivery *DueDelivery, attempt Attempt, disableAfter int) (bool, error) {
+	var disabled bool
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := recordAttempt(ctx, tx, delivery, attempt, DeliveryFailed, 0); err != nil {
+			return err
+		}
+
+		err := tx.QueryRow(ctx, `update webhook_endpoints
+			set consecutive_failures = consecutive_failures + 1,
+				enabled = enabled and consecutive_failures + 1 < $2,
+				disabled_at = CASE WHEN enabled and consecutive_failures + 1 >= $2 THEN NOW() ELSE disabled_at END,
+				updated_at = NOW()
+			where id = $1
+			returning not enabled and disabled_at = NOW()`, delivery.EndpointID, disableAfter).Scan(&disabled)
+		if err != nil {
+			return fmt.Errorf("failed to update endpoint failures: %w", err)
+		}
+		if !disabled {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx, `update webhook_deliveries
+			set status = 'FAILED', last_error = 'endpoint disabled', updated_at = NOW()
+			where endpoint_id = $1 and status = 'PENDING'`, delivery.EndpointID)
+		if err != nil {
+			return fmt.Errorf("failed to fail pending deliveries: %w", err)
+		}
+		return nil
+	})
+	return disabled, err
+}
+
+func recordAttempt(ctx context.Context, tx pgx.Tx, delivery *DueDelivery, attempt Attempt, status DeliveryStatus, delay time.Duration) error {
+	_, err := tx.Exec(ctx, `insert into webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms)
+		values ($1, $2, NULLIF($3, 0), NULLIF($4, ''), $5)`,
+		delivery.ID, attempt.Attempt, attempt.StatusCode, attempt.Error, attempt.Duration.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to log delivery attempt: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `update webhook_deliveries
+		set status = $2, attempts = $3, last_status_code = NULLIF($4, 0), last_error = NULLIF($5, ''),
+			next_attempt_at = NOW() + $6::interval, updated_at = NOW()
+		where id = $1`,
+		delivery.ID, status, attempt.Attempt, attempt.StatusCode, attempt.Error, delay)
+	if err != nil {
+		return fmt.Errorf("failed to update delivery: %w", err)
+	}
+	return nil
+}
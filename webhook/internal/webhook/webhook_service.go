@@ -0,0 +1,339 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+	"webhook/internal/config"
+	"webhook/internal/errcodes"
+	"webhook/proto/gen"
+)
+
+const (
+	secretPrefix = "whsec_"
+
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 100
+)
+
+type service struct {
+	gen.UnimplementedWebhookServiceServer
+	repo Repository
+	cfg  config.Delivery
+	log  *logrus.Logger
+}
+
+func NewWebhookService(repo Repository, cfg config.Delivery, log *logrus.Logger) *service {
+	return &service{
+		repo: repo,
+		cfg:  cfg,
+		log:  log,
+	}
+}
+
+func (s *service) HealthCheck(ctx context.Context, req *emptypb.Empty) (*emptypb.Empty, error) {
+	s.log.Info("Health check called")
+	return req, nil
+}
+
+// CreateEndpoint registers an endpoint. The response is the only one apart from
+// RotateSecret that includes the signing secret.
+func (s *service) CreateEndpoint(ctx context.Context, req *gen.CreateEndpointRequest) (*gen.EndpointResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	if err := s.validateEndpoint(req.Url, req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		s.log.Errorf("error generating secret: %v", err)
+		return nil, status.Error(codes.Internal, "error creating endpoint")
+	}
+
+	endpoint := &Endpoint{
+		UserID:     userID,
+		URL:        req.Url,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+	}
+	if endpoint.EventTypes == nil {
+		endpoint.EventTypes = []string{}
+	}
+
+	if err := s.repo.CreateEndpoint(ctx, endpoint); err != nil {
+		s.log.Errorf("error creating endpoint: %v", err)
+		return nil, status.Error(codes.Internal, "error creating endpoint")
+	}
+
+	return &gen.EndpointResponse{Endpoint: toProtoEndpoint(endpoint, true)}, nil
+}
+
+func (s *service) ListEndpoints(ctx context.Context, _ *emptypb.Empty) (*gen.ListEndpointsResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	endpoints, err := s.repo.ListEndpoints(ctx, userID)
+	if err != nil {
+		s.log.Errorf("error listing endpoints: %v", err)
+		return nil, status.Error(codes.Internal, "error listing endpoints")
+	}
+
+	resp := &gen.ListEndpointsResponse{Endpoints: make([]*gen.Endpoint, 0, len(endpoints))}
+	for _, endpoint := range endpoints {
+		resp.Endpoints = append(resp.Endpoints, toProtoEndpoint(endpoint, false))
+	}
+	return resp, nil
+}
+
+func (s *service) DeleteEndpoint(ctx context.Context, req *gen.EndpointRequest) (*emptypb.Empty, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	deleted, err := s.repo.DeleteEndpoint(ctx, userID, req.EndpointId)
+	if err != nil {
+		s.log.Errorf("error deleting endpoint: %v", err)
+		return nil, status.Error(codes.Internal, "error deleting endpoint")
+	}
+	if !deleted {
+		return nil, errcodes.Error(codes.NotFound, errcodes.EndpointNotFound, "webhook endpoint not found")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// RotateSecret issues a new signing secret. Payloads are signed with both the new
+// and the old secret for the configured grace period so partners can roll over.
+func (s *service) RotateSecret(ctx context.Context, req *gen.EndpointRequest) (*gen.EndpointResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		s.log.Errorf("error generating secret: %v", err)
+		return nil, status.Error(codes.Internal, "error rotating secret")
+	}
+
+	endpoint, err := s.repo.RotateSecret(ctx, userID, req.EndpointId, secret, s.cfg.SecretGracePeriod)
+	if err != nil {
+		s.log.Errorf("error rotating secret: %v", err)
+		return nil, status.Error(codes.Internal, "error rotating secret")
+	}
+	if endpoint.ID == "" {
+		return nil, errcodes.Error(codes.NotFound, errcodes.EndpointNotFound, "webhook endpoint not found")
+	}
+
+	return &gen.EndpointResponse{Endpoint: toProtoEndpoint(endpoint, true)}, nil
+}
+
+// EnableEndpoint re-enables an endpoint that was disabled after repeated failures.
+func (s *service) EnableEndpoint(ctx context.Context, req *gen.EndpointRequest) (*gen.EndpointResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return nil, err
+	}
+
+	endpoint, err := s.repo.EnableEndpoint(ctx, userID, req.EndpointId)
+	if err != nil {
+		s.log.Errorf("error enabling endpoint: %v", err)
+		return nil, status.Error(codes.Internal, "error enabling endpoint")
+	}
+	if endpoint.ID == "" {
+		return nil, errcodes.Error(codes.NotFound, errcodes.EndpointNotFound, "webhook endpoint not found")
+	}
+
+	return &gen.EndpointResponse{Endpoint: toProtoEndpoint(endpoint, false)}, nil
+}
+
+// ListDeliveries returns the latest deliveries of an endpoint, newest first.
+func (s *service) ListDeliveries(ctx context.Context, req *gen.ListDeliveriesRequest) (*gen.ListDeliveriesResponse, error) {
+	if err := s.ownEndpoint(ctx, req.EndpointId); err != nil {
+		return nil, err
+	}
+
+	limit := int(req.Limit)
+	if limit < 0 || limit > maxDeliveriesLimit {
+		return nil, errcodes.Invalid(errcodes.InvalidArgument, "invalid limit",
+			errcodes.Violation("limit", fmt.Sprintf("must be between 1 and %d", maxDeliveriesLimit)))
+	}
+	if limit == 0 {
+		limit = defaultDeliveriesLimit
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, req.EndpointId, limit)
+	if err != nil {
+		s.log.Errorf("error listing deliveries: %v", err)
+		return nil, status.Error(codes.Internal, "error listing deliveries")
+	}
+
+	resp := &gen.ListDeliveriesResponse{Deliveries: make([]*gen.Delivery, 0, len(deliveries))}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, toProtoDelivery(delivery))
+	}
+	return resp, nil
+}
+
+// Redeliver queues a delivery to be sent again, whatever its current status.
+func (s *service) Redeliver(ctx context.Context, req *gen.RedeliverRequest) (*gen.DeliveryResponse, error) {
+	if err := s.ownEndpoint(ctx, req.EndpointId); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.repo.Redeliver(ctx, req.EndpointId, req.DeliveryId)
+	if err != nil {
+		s.log.Errorf("error redelivering: %v", err)
+		return nil, status.Error(codes.Internal, "error redelivering")
+	}
+	if delivery.ID == "" {
+		return nil, errcodes.Error(codes.NotFound, errcodes.DeliveryNotFound, "webhook delivery not found")
+	}
+
+	return &gen.DeliveryResponse{Delivery: toProtoDelivery(delivery)}, nil
+}
+
+// ownEndpoint returns NotFound unless the endpoint belongs to the authenticated user.
+func (s *service) ownEndpoint(ctx context.Context, endpointID string) error {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		s.log.WithError(err).Error("failed to resolve user")
+		return err
+	}
+
+	endpoint, err := s.repo.GetEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		s.log.Errorf("error getting endpoint: %v", err)
+		return status.Error(codes.Internal, "error getting endpoint")
+	}
+	if endpoint.ID == "" {
+		return errcodes.Error(codes.NotFound, errcodes.EndpointNotFound, "webhook endpoint not found")
+	}
+	return nil
+}
+
+// validateEndpoint checks the URL and that every event type is one we deliver.
+// Private addresses are rejected when the worker dials, after DNS resolution.
+func (s *service) validateEndpoint(rawURL string, eventTypes []string) error {
+	var violations []*errdetails.BadRequest_FieldViolation
+
+	u, err := url.Parse(rawURL)
+	switch {
+	case rawURL == "":
+		violations = append(violations, errcodes.Violation("url", "must not be empty"))
+	case err != nil || u.Host == "":
+		violations = append(violations, errcodes.Violation("url", "must be an absolute URL"))
+	case u.Scheme != "https" && !(s.cfg.AllowInsecure && u.Scheme == "http"):
+		violations = append(violations, errcodes.Violation("url", "must use https"))
+	case u.User != nil:
+		violations = append(violations, errcodes.Violation("url", "must not contain credentials"))
+	}
+
+	for i, eventType := range eventTypes {
+		if !slices.Contains(s.cfg.Topics, eventType) {
+			violations = append(violations, errcodes.Violation(fmt.Sprintf("event_types[%d]", i),
+				fmt.Sprintf("unknown event type %q", eventType)))
+		}
+	}
+
+	if len(violations) > 0 {
+		return errcodes.Invalid(errcodes.URLInvalid, "invalid webhook endpoint", violations...)
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
+
+func toProtoEndpoint(endpoint *Endpoint, withSecret bool) *gen.Endpoint {
+	pb := &gen.Endpoint{
+		Id:                  endpoint.ID,
+		Url:                 endpoint.URL,
+		EventTypes:          endpoint.EventTypes,
+		Enabled:             endpoint.Enabled,
+		ConsecutiveFailures: int32(endpoint.ConsecutiveFailures),
+		CreatedAt:           endpoint.CreatedAt.Format(time.RFC3339),
+	}
+	if withSecret {
+		pb.Secret = endpoint.Secret
+	}
+	if endpoint.DisabledAt != nil {
+		pb.DisabledAt = endpoint.DisabledAt.Format(time.RFC3339)
+	}
+	return pb
+}
+
+func toProtoDelivery(delivery *Delivery) *gen.Delivery {
+	pb := &gen.Delivery{
+		Id:             delivery.ID,
+		EndpointId:     delivery.EndpointID,
+		EventId:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         string(delivery.Status),
+		Attempts:       int32(delivery.Attempts),
+		LastStatusCode: int32(delivery.LastStatusCode),
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt.Format(time.RFC3339),
+	}
+	if delivery.Status == DeliveryPending {
+		pb.NextAttemptAt = delivery.NextAttemptAt.Format(time.RFC3339)
+	}
+	for _, attempt := range delivery.AttemptLog {
+		pb.AttemptLog = append(pb.AttemptLog, &gen.DeliveryAttempt{
+			Attempt:    int32(attempt.Attempt),
+			StatusCode: int32(attempt.StatusCode),
+			Error:      attempt.Error,
+			DurationMs: attempt.Duration.Milliseconds(),
+			CreatedAt:  attempt.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return pb
+}
+
+// userIDFromContext reads the ID of the authenticated user that the broker
+// forwards in the "userID" metadata key.
+func userIDFromContext(ctx context.Context) (int, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0, errcodes.Error(codes.Unauthenticated, errcodes.Unauthenticated, "no metadata provided")
+	}
+
+	userIDStr := md.Get("userID")
+	if len(userIDStr) == 0 {
+		return 0, errcodes.Error(codes.Unauthenticated, errcodes.Unauthenticated, "user ID not found in metadata")
+	}
+
+	userID, err := strconv.Atoi(userIDStr[0])
+	if err != nil {
+		return 0, errcodes.Error(codes.Unauthenticated, errcodes.Unauthenticated, "invalid user ID")
+	}
+	return userID, nil
+}
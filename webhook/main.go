@@ -0,0 +1,9 @@
+package main
+
+import (
+	"webhook/internal/cmd"
+)
+
+func main() {
+	cmd.Execute()
+}
@@ -0,0 +1,52 @@
+CREATE TABLE IF NOT EXISTS webhook_endpoints(
+id uuid DEFAULT gen_random_uuid(),
+user_id INT NOT NULL,
+url TEXT NOT NULL,
+secret VARCHAR(255) NOT NULL,
+previous_secret VARCHAR(255),
+previous_secret_expires_at TIMESTAMP,
+event_types TEXT[] NOT NULL DEFAULT '{}',
+enabled BOOLEAN NOT NULL DEFAULT TRUE,
+consecutive_failures INT NOT NULL DEFAULT 0,
+disabled_at TIMESTAMP,
+created_at TIMESTAMP DEFAULT NOW(),
+updated_at TIMESTAMP DEFAULT NOW(),
+
+PRIMARY KEY(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_user_id ON webhook_endpoints(user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+id uuid DEFAULT gen_random_uuid(),
+endpoint_id uuid NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+event_id VARCHAR(255) NOT NULL,
+event_type VARCHAR(255) NOT NULL,
+payload JSONB NOT NULL,
+status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+attempts INT NOT NULL DEFAULT 0,
+next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+last_status_code INT,
+last_error TEXT,
+created_at TIMESTAMP DEFAULT NOW(),
+updated_at TIMESTAMP DEFAULT NOW(),
+
+PRIMARY KEY(id),
+UNIQUE(endpoint_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'PENDING';
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts(
+id BIGSERIAL,
+delivery_id uuid NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+attempt INT NOT NULL,
+status_code INT,
+error TEXT,
+duration_ms BIGINT NOT NULL,
+created_at TIMESTAMP DEFAULT NOW(),
+
+PRIMARY KEY(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts(delivery_id);